@@ -145,6 +145,10 @@ func (c *Connection) Reject() error {
 	}
 	c.state = CONNECTION_STATE_CLOSED
 	c.stateCond.Broadcast()
+	// A rejected connection never receives a close packet, so it is released here.
+	_ = c.bufferWriter.Close()
+	_ = c.bufferReader.Close()
+	c.ctx.waitGroup.Done()
 	packet := Packet{
 		Type:         PACKET_ERROR,
 		ConnectionId: c.id,
@@ -120,6 +120,20 @@ func (p PayloadChannelRequestX11) Equals(other Payload) bool {
 		p.Screen == p2.Screen
 }
 
+// PayloadChannelRequestAuthAgent is a payload signaling the request to forward the SSH agent of the client.
+type PayloadChannelRequestAuthAgent struct {
+	RequestID uint64 `json:"requestId" yaml:"requestId"`
+}
+
+// Equals compares two PayloadChannelRequestAuthAgent payloads.
+func (p PayloadChannelRequestAuthAgent) Equals(other Payload) bool {
+	p2, ok := other.(PayloadChannelRequestAuthAgent)
+	if !ok {
+		return false
+	}
+	return p.RequestID == p2.RequestID
+}
+
 // PayloadChannelRequestShell is a payload signaling a request for a shell.
 type PayloadChannelRequestShell struct {
 	RequestID uint64 `json:"requestId" yaml:"requestId"`
@@ -147,6 +161,22 @@ func (p PayloadChannelRequestSignal) Equals(other Payload) bool {
 	return p.RequestID == p2.RequestID && p.Signal == p2.Signal
 }
 
+// PayloadChannelRequestBreak is a payload signaling the request to send a break to the terminal.
+type PayloadChannelRequestBreak struct {
+	RequestID uint64 `json:"requestId" yaml:"requestId"`
+
+	Length uint32 `json:"length" yaml:"length"`
+}
+
+// Equals compares two PayloadChannelRequestBreak payloads.
+func (p PayloadChannelRequestBreak) Equals(other Payload) bool {
+	p2, ok := other.(PayloadChannelRequestBreak)
+	if !ok {
+		return false
+	}
+	return p.RequestID == p2.RequestID && p.Length == p2.Length
+}
+
 // PayloadChannelRequestSubsystem is a payload requesting a well-known subsystem (e.g. sftp)
 type PayloadChannelRequestSubsystem struct {
 	RequestID uint64 `json:"requestId" yaml:"requestId"`
@@ -14,3 +14,17 @@ func (p PayloadConnect) Equals(other Payload) bool {
 	}
 	return p.RemoteAddr == p2.RemoteAddr
 }
+
+// PayloadTerminate contains the reason the server terminated the connection.
+type PayloadTerminate struct {
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// Equals compares two PayloadTerminate payloads.
+func (p PayloadTerminate) Equals(other Payload) bool {
+	p2, ok := other.(PayloadTerminate)
+	if !ok {
+		return false
+	}
+	return p.Reason == p2.Reason
+}
@@ -10,6 +10,7 @@ type Type int32
 const (
 	TypeConnect                  Type = 0   // TypeConnect describes a message that is sent when the user connects on a TCP level.
 	TypeDisconnect               Type = 1   // TypeDisconnect describes a message that is sent when the user disconnects on a TCP level.
+	TypeTerminate                Type = 2   // TypeTerminate indicates that the server closed the connection because of a session policy, such as an idle timeout. The payload contains the reason.
 	TypeAuthPassword             Type = 100 // TypeAuthPassword describes a message that is sent when the user submits a username and password.
 	TypeAuthPasswordSuccessful   Type = 101 // TypeAuthPasswordSuccessful describes a message that is sent when the submitted username and password were valid.
 	TypeAuthPasswordFailed       Type = 102 // TypeAuthPasswordFailed describes a message that is sent when the submitted username and password were invalid.
@@ -41,6 +42,7 @@ const (
 	TypeNewReverseX11ForwardChannel  Type = 305 // TypeNewReverseX11ForwardChannel describes a message when the server opens a new channel due to an incoming connection on the forwarded X11 port
 	TypeNewForwardStreamLocalChannel Type = 306 // TypeDirectStreamLocalChannel describes a message when the client requests to open a new channel due to an incoming connection towards a forwarded port
 	TypeNewReverseStreamLocalChannel Type = 307 // TypeNewReverseStreamLocalChannel describes a message when the server opens a new channel due to an incoming connection on a forwarded unix socket
+	TypeNewReverseAuthAgentChannel   Type = 308 // TypeNewReverseAuthAgentChannel describes a message when the server opens a new channel to the SSH agent of the client due to an incoming connection on the forwarded agent socket
 
 	TypeChannelRequestUnknownType  Type = 400 // TypeChannelRequestUnknownType describes an in-channel request from the user that is not supported.
 	TypeChannelRequestDecodeFailed Type = 401 // TypeChannelRequestDecodeFailed describes an in-channel request from the user that is supported but the payload could not be decoded.
@@ -54,7 +56,9 @@ const (
 	TypeChannelRequestSubsystem Type = 407 // TypeChannelRequestSubsystem describes an in-channel request to start a well-known subsystem (e.g. SFTP).
 	TypeChannelRequestWindow    Type = 408 // TypeChannelRequestWindow describes an in-channel request to resize the current interactive terminal.
 
-	TypeChannelRequestX11 Type = 409 // TypeChannelRequestX11 describes an in-channel request to start forwarding remote X11 connections to the client
+	TypeChannelRequestX11       Type = 409 // TypeChannelRequestX11 describes an in-channel request to start forwarding remote X11 connections to the client
+	TypeChannelRequestAuthAgent Type = 410 // TypeChannelRequestAuthAgent describes an in-channel request to forward the SSH agent of the client
+	TypeChannelRequestBreak     Type = 411 // TypeChannelRequestBreak describes an in-channel request to send a break to the terminal of the running program (RFC 4335).
 
 	TypeWriteClose Type = 496 // TypeWriteClose indicates that the channel was closed for writing from the server side.
 	TypeClose      Type = 497 // TypeClose indicates that the channel was closed.
@@ -68,6 +72,7 @@ const (
 var typeToID = map[Type]string{
 	TypeConnect:    "connect",
 	TypeDisconnect: "disconnect",
+	TypeTerminate:  "terminate",
 
 	TypeAuthPassword:             "auth_password",
 	TypeAuthPasswordSuccessful:   "auth_password_successful",
@@ -98,6 +103,7 @@ var typeToID = map[Type]string{
 	TypeNewReverseX11ForwardChannel:  "new_channel_x11",
 	TypeNewForwardStreamLocalChannel: "new_channel_direct_streamlocal",
 	TypeNewReverseStreamLocalChannel: "new_channel_forwarded_streamlocal",
+	TypeNewReverseAuthAgentChannel:   "new_channel_auth_agent",
 
 	TypeChannelRequestUnknownType:  "channel_request_unknown",
 	TypeChannelRequestDecodeFailed: "channel_request_decode_failed",
@@ -109,6 +115,8 @@ var typeToID = map[Type]string{
 	TypeChannelRequestSubsystem:    "subsystem",
 	TypeChannelRequestWindow:       "window",
 	TypeChannelRequestX11:          "x11-req",
+	TypeChannelRequestAuthAgent:    "auth-agent-req",
+	TypeChannelRequestBreak:        "break",
 	TypeWriteClose:                 "close_write",
 	TypeClose:                      "close",
 	TypeExit:                       "exit",
@@ -121,6 +129,7 @@ var typeToID = map[Type]string{
 var typeToName = map[Type]string{
 	TypeConnect:    "Connect",
 	TypeDisconnect: "Disconnect",
+	TypeTerminate:  "Terminate connection",
 
 	TypeAuthPassword:             "Password authentication",
 	TypeAuthPasswordSuccessful:   "Password authentication successful",
@@ -151,6 +160,7 @@ var typeToName = map[Type]string{
 	TypeNewReverseX11ForwardChannel:  "New server-to-client X11 forwarding channel",
 	TypeNewForwardStreamLocalChannel: "New client-to-server unix socket forwarding channel",
 	TypeNewReverseStreamLocalChannel: "New server-to-client unix socket forwarding channel",
+	TypeNewReverseAuthAgentChannel:   "New server-to-client SSH agent channel",
 
 	TypeChannelRequestUnknownType:  "Unknown channel request",
 	TypeChannelRequestDecodeFailed: "Failed to decode channel request",
@@ -162,6 +172,8 @@ var typeToName = map[Type]string{
 	TypeChannelRequestSubsystem:    "Request subsystem",
 	TypeChannelRequestWindow:       "Change window size",
 	TypeChannelRequestX11:          "Request X11 forwarding",
+	TypeChannelRequestAuthAgent:    "Request SSH agent forwarding",
+	TypeChannelRequestBreak:        "Send break to terminal",
 	TypeWriteClose:                 "Close channel for writing",
 	TypeClose:                      "Close channel",
 	TypeExit:                       "Program exited",
@@ -174,6 +186,7 @@ var typeToName = map[Type]string{
 var messageTypeToPayload = map[Type]Payload{
 	TypeConnect:    PayloadConnect{},
 	TypeDisconnect: nil,
+	TypeTerminate:  PayloadTerminate{},
 
 	TypeAuthPassword:                        PayloadAuthPassword{},
 	TypeAuthPasswordSuccessful:              PayloadAuthPassword{},
@@ -205,6 +218,7 @@ var messageTypeToPayload = map[Type]Payload{
 	TypeNewReverseX11ForwardChannel:  PayloadNewReverseX11ForwardChannel{},
 	TypeNewForwardStreamLocalChannel: PayloadRequestStreamLocal{},
 	TypeNewReverseStreamLocalChannel: PayloadRequestStreamLocal{},
+	TypeNewReverseAuthAgentChannel:   nil,
 
 	TypeChannelRequestUnknownType:  PayloadChannelRequestUnknownType{},
 	TypeChannelRequestDecodeFailed: PayloadChannelRequestDecodeFailed{},
@@ -216,6 +230,8 @@ var messageTypeToPayload = map[Type]Payload{
 	TypeChannelRequestSubsystem:    PayloadChannelRequestSubsystem{},
 	TypeChannelRequestWindow:       PayloadChannelRequestWindow{},
 	TypeChannelRequestX11:          PayloadChannelRequestX11{},
+	TypeChannelRequestAuthAgent:    PayloadChannelRequestAuthAgent{},
+	TypeChannelRequestBreak:        PayloadChannelRequestBreak{},
 	TypeIO:                         PayloadIO{},
 	TypeRequestFailed:              PayloadRequestFailed{},
 	TypeExit:                       PayloadExit{},
@@ -35,6 +35,34 @@ type AuthorizationRequest struct {
 	metadata.ConnectionAuthenticatedMetadata `json:",inline"`
 }
 
+// BannerRequest is the request for the banner sent to the client before authentication.
+//
+// swagger:model BannerRequest
+type BannerRequest struct {
+	metadata.ConnectionAuthPendingMetadata `json:",inline"`
+}
+
+// BannerResponseBody is the response to banner requests.
+//
+// swagger:model BannerResponseBody
+type BannerResponseBody struct {
+	// Banner is the text sent to the client before authentication. If it is empty, no banner is sent.
+	//
+	// required: false
+	// in: body
+	Banner string `json:"banner"`
+}
+
+// BannerResponse is the full HTTP banner response.
+//
+// swagger:response BannerResponse
+type BannerResponse struct {
+	// The response body
+	//
+	// in: body
+	BannerResponseBody
+}
+
 // ResponseBody is a response to authentication requests.
 //
 // swagger:model AuthResponseBody
@@ -46,6 +74,14 @@ type ResponseBody struct {
 	// required: true
 	// in: body
 	Success bool `json:"success"`
+
+	// CacheTTL is the number of seconds ContainerSSH may cache this public key or authorization decision for if
+	// caching is enabled. 0 disables caching this decision. If not set, the configured TTL is used. Password
+	// authentication responses are never cached.
+	//
+	// required: false
+	// in: body
+	CacheTTL *int `json:"cacheTTL,omitempty"`
 }
 
 // Response is the full HTTP authentication response.
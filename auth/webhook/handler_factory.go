@@ -3,6 +3,9 @@ package webhook
 import (
 	"net/http"
 
+	"go.containerssh.io/containerssh/config"
+	http2 "go.containerssh.io/containerssh/http"
+
 	"go.containerssh.io/containerssh/internal/auth"
 	"go.containerssh.io/containerssh/log"
 )
@@ -11,3 +14,14 @@ import (
 func NewHandler(h AuthRequestHandler, logger log.Logger) http.Handler {
 	return auth.NewHandler(h, logger)
 }
+
+// NewSignatureVerifier wraps an HTTP handler, such as the one returned by NewHandler, so that it rejects requests
+// without a valid HMAC-SHA256 signature from one of the configured keys with a 401 status code. Use it to verify the
+// requests of ContainerSSH instances that have signing enabled if you run the handler in your own HTTP server.
+func NewSignatureVerifier(
+	signature config.HTTPServerSignatureConfig,
+	handler http.Handler,
+	logger log.Logger,
+) (http.Handler, error) {
+	return http2.NewSignatureVerifier(signature, handler, logger)
+}
@@ -19,3 +19,19 @@ func NewServer(
 		logger,
 	)
 }
+
+// NewSignedServer returns a complete HTTP server that responds to the authentication requests. Requests without a
+// valid HMAC-SHA256 signature from one of the keys in signature are rejected.
+func NewSignedServer(
+	cfg config.HTTPServerConfiguration,
+	signature config.HTTPServerSignatureConfig,
+	h AuthRequestHandler,
+	logger log.Logger,
+) (http.Server, error) {
+	return auth.NewSignedServer(
+		cfg,
+		signature,
+		h,
+		logger,
+	)
+}
@@ -36,6 +36,9 @@ type AppConfig struct {
 	// Security contains the security restrictions on what can be executed. This option can be changed from the config
 	// server.
 	Security SecurityConfig `json:"security" yaml:"security"`
+	// Throttle contains the bandwidth limits for connections and channels. This option can be changed from the config
+	// server.
+	Throttle ThrottleConfig `json:"throttle" yaml:"throttle"`
 	// Backend defines which backend to use. This option can be changed from the config server.
 	Backend Backend `json:"backend" yaml:"backend" default:"docker"`
 	// Docker contains the configuration for the docker backend. This option can be changed from the config server.
@@ -104,6 +107,7 @@ func (cfg *AppConfig) Validate(dynamic bool) error {
 		return queue.Validate()
 	}
 	queue.add("security", &cfg.Security)
+	queue.add("throttle", &cfg.Throttle)
 	queue.add("backend", &cfg.Backend)
 	switch cfg.Backend {
 	case BackendDocker:
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/crypto/ssh"
 )
 
 // AuthConfig is the configuration of the authentication client.
@@ -33,6 +40,21 @@ type AuthConfig struct {
 	// do not verify the provided SSH username.
 	Authz AuthzConfig `json:"authz" yaml:"authz"`
 
+	// Lockout configures banning usernames and source addresses after repeated authentication failures. Banned
+	// clients are rejected without contacting the authentication backends.
+	Lockout AuthLockoutConfig `json:"lockout" yaml:"lockout"`
+
+	// Banner configures fetching the banner from the authentication server before authentication, for example to show
+	// legal notices depending on the region of the client. If not set, the banner from the SSH configuration is used.
+	Banner AuthBannerConfig `json:"banner" yaml:"banner"`
+
+	// Required lists the combinations of SSH authentication methods users must pass, similar to the
+	// AuthenticationMethods option of OpenSSH. Each entry is a list of methods that must succeed in the given order,
+	// and the user is logged in once any one entry is complete. For example, [["publickey", "keyboard-interactive"],
+	// ["gssapi-with-mic"]] requires public key authentication followed by keyboard-interactive, or GSSAPI alone. If
+	// empty, any single configured method logs the user in.
+	Required AuthRequiredMethods `json:"required" yaml:"required"`
+
 	// AuthTimeout is the timeout for the overall authentication call (e.g. verifying a password). If the server
 	// responds with a non-200 response the call will be retried until this timeout is reached. This timeout
 	// should be increased to ~180s for OAuth2 login.
@@ -56,6 +78,9 @@ type legacyAuthConfig struct {
 	KeyboardInteractiveAuth KeyboardInteractiveAuthConfig `json:"keyboardInteractive" yaml:"keyboardInteractive"`
 	GSSAPIAuth              GSSAPIAuthConfig              `json:"gssapi" yaml:"gssapi"`
 	Authz                   AuthzConfig                   `json:"authz" yaml:"authz"`
+	Lockout                 AuthLockoutConfig             `json:"lockout" yaml:"lockout"`
+	Banner                  AuthBannerConfig              `json:"banner" yaml:"banner"`
+	Required                AuthRequiredMethods           `json:"required" yaml:"required"`
 
 	HTTPClientConfiguration `json:",inline" yaml:",inline"`
 	AuthTimeout             time.Duration `json:"authTimeout" yaml:"authTimeout" default:"60s"`
@@ -69,6 +94,9 @@ func (l *legacyAuthConfig) Set(c *AuthConfig) {
 	c.KeyboardInteractiveAuth = l.KeyboardInteractiveAuth
 	c.GSSAPIAuth = l.GSSAPIAuth
 	c.Authz = l.Authz
+	c.Lockout = l.Lockout
+	c.Banner = l.Banner
+	c.Required = l.Required
 	c.HTTPClientConfiguration = l.HTTPClientConfiguration
 	c.Password = l.Password
 	c.PubKey = l.PubKey
@@ -80,6 +108,9 @@ type newAuthConfig struct {
 	KeyboardInteractiveAuth KeyboardInteractiveAuthConfig `json:"keyboardInteractive" yaml:"keyboardInteractive"`
 	GSSAPIAuth              GSSAPIAuthConfig              `json:"gssapi" yaml:"gssapi"`
 	Authz                   AuthzConfig                   `json:"authz" yaml:"authz"`
+	Lockout                 AuthLockoutConfig             `json:"lockout" yaml:"lockout"`
+	Banner                  AuthBannerConfig              `json:"banner" yaml:"banner"`
+	Required                AuthRequiredMethods           `json:"required" yaml:"required"`
 
 	HTTPClientConfiguration `json:",inline" yaml:",inline"`
 	AuthTimeout             time.Duration `json:"authTimeout" yaml:"authTimeout"`
@@ -93,6 +124,9 @@ func (n *newAuthConfig) Set(c *AuthConfig) {
 	c.KeyboardInteractiveAuth = n.KeyboardInteractiveAuth
 	c.GSSAPIAuth = n.GSSAPIAuth
 	c.Authz = n.Authz
+	c.Lockout = n.Lockout
+	c.Banner = n.Banner
+	c.Required = n.Required
 	c.HTTPClientConfiguration = n.HTTPClientConfiguration
 	c.Password = n.Password
 	c.PubKey = n.PubKey
@@ -164,6 +198,16 @@ func (c *AuthConfig) Validate() error {
 		if err := c.KeyboardInteractiveAuth.Validate(); err != nil {
 			return wrap(err, "keyboardInteractive")
 		}
+		//goland:noinspection GoDeprecation
+		if c.KeyboardInteractiveAuth.Method == KeyboardInteractiveAuthMethodTOTP &&
+			c.PasswordAuth.Method == PasswordAuthMethodDisabled &&
+			c.PublicKeyAuth.Method == PubKeyAuthMethodDisabled &&
+			c.URL == "" {
+			return newError(
+				"keyboardInteractive",
+				"the totp method is a second factor and requires password or public key authentication",
+			)
+		}
 	}
 	if c.GSSAPIAuth.Method != GSSAPIAuthMethodDisabled {
 		if err := c.GSSAPIAuth.Validate(); err != nil {
@@ -175,6 +219,28 @@ func (c *AuthConfig) Validate() error {
 			return wrap(err, "authz")
 		}
 	}
+	if err := c.Lockout.Validate(); err != nil {
+		return wrap(err, "lockout")
+	}
+	if c.Banner.Method != AuthBannerMethodDisabled {
+		if err := c.Banner.Validate(); err != nil {
+			return wrap(err, "banner")
+		}
+	}
+	if err := c.Required.Validate(); err != nil {
+		return wrap(err, "required")
+	}
+	for _, methods := range c.Required {
+		for _, method := range methods {
+			if !c.methodEnabled(method) {
+				return newError(
+					"required",
+					"the %s method is required, but it is not configured",
+					method,
+				)
+			}
+		}
+	}
 	//goland:noinspection GoDeprecation
 	if ((c.Password != nil && *c.Password) || (c.PubKey != nil && *c.PubKey)) && c.URL != "" {
 		//goland:noinspection GoDeprecation
@@ -184,6 +250,77 @@ func (c *AuthConfig) Validate() error {
 	return nil
 }
 
+// methodEnabled returns true if the SSH authentication method is configured.
+func (c *AuthConfig) methodEnabled(method SSHAuthMethod) bool {
+	switch method {
+	case SSHAuthMethodPassword:
+		//goland:noinspection GoDeprecation
+		return c.PasswordAuth.Method != PasswordAuthMethodDisabled ||
+			(c.Password != nil && *c.Password && c.URL != "")
+	case SSHAuthMethodPublicKey:
+		//goland:noinspection GoDeprecation
+		return c.PublicKeyAuth.Method != PubKeyAuthMethodDisabled || (c.PubKey != nil && *c.PubKey && c.URL != "")
+	case SSHAuthMethodKeyboardInteractive:
+		return c.KeyboardInteractiveAuth.Method != KeyboardInteractiveAuthMethodDisabled
+	case SSHAuthMethodGSSAPI:
+		return c.GSSAPIAuth.Method != GSSAPIAuthMethodDisabled
+	default:
+		return false
+	}
+}
+
+// region Required
+
+// AuthRequiredMethods lists alternative sequences of SSH authentication methods. A user is authenticated once they
+// passed all methods of one sequence in order.
+type AuthRequiredMethods [][]SSHAuthMethod
+
+// Validate checks if the sequences are not empty and only contain valid methods.
+func (r AuthRequiredMethods) Validate() error {
+	for i, methods := range r {
+		if len(methods) == 0 {
+			return newError(fmt.Sprintf("%d", i), "the list of required methods cannot be empty")
+		}
+		seen := map[SSHAuthMethod]bool{}
+		for _, method := range methods {
+			if err := method.Validate(); err != nil {
+				return wrap(err, fmt.Sprintf("%d", i))
+			}
+			if seen[method] {
+				return newError(fmt.Sprintf("%d", i), "the %s method is listed more than once", method)
+			}
+			seen[method] = true
+		}
+	}
+	return nil
+}
+
+// SSHAuthMethod is the name of an authentication method in the SSH protocol.
+type SSHAuthMethod string
+
+const (
+	// SSHAuthMethodPassword is password authentication.
+	SSHAuthMethodPassword SSHAuthMethod = "password"
+	// SSHAuthMethodPublicKey is public key authentication.
+	SSHAuthMethodPublicKey SSHAuthMethod = "publickey"
+	// SSHAuthMethodKeyboardInteractive is keyboard-interactive authentication.
+	SSHAuthMethodKeyboardInteractive SSHAuthMethod = "keyboard-interactive"
+	// SSHAuthMethodGSSAPI is GSSAPI authentication.
+	SSHAuthMethodGSSAPI SSHAuthMethod = "gssapi-with-mic"
+)
+
+// Validate checks if the method is a supported SSH authentication method.
+func (m SSHAuthMethod) Validate() error {
+	switch m {
+	case SSHAuthMethodPassword, SSHAuthMethodPublicKey, SSHAuthMethodKeyboardInteractive, SSHAuthMethodGSSAPI:
+		return nil
+	default:
+		return fmt.Errorf("unsupported SSH authentication method: %s", m)
+	}
+}
+
+// endregion
+
 // region AuthMethod
 
 // AuthMethod is a listing of all authentication methods. These methods are not guaranteed to support any particular
@@ -192,7 +329,7 @@ type AuthMethod string
 
 // Validate checks if the provided method is valid or not.
 func (m AuthMethod) Validate() error {
-	if m == "webhook" || m == "oauth2" || m == "kerberos" {
+	if m == "webhook" || m == "oauth2" || m == "kerberos" || m == "certificate" {
 		return nil
 	}
 	return fmt.Errorf("invalid value for method: %s", m)
@@ -210,6 +347,25 @@ const AuthMethodOAuth2 AuthMethod = "oauth2"
 // AuthMethodKerberos authenticates using the Kerberos method.
 const AuthMethodKerberos AuthMethod = "kerberos"
 
+// AuthMethodCertificate authenticates OpenSSH certificates against a set of trusted CA keys.
+const AuthMethodCertificate AuthMethod = "certificate"
+
+// AuthMethodAuthorizedKeys authenticates public keys against local OpenSSH authorized_keys files.
+const AuthMethodAuthorizedKeys AuthMethod = "authorizedkeys"
+
+// AuthMethodFile authenticates passwords against a local htpasswd-style file.
+const AuthMethodFile AuthMethod = "file"
+
+// AuthMethodLDAP authenticates against an LDAP directory, such as OpenLDAP or Active Directory.
+const AuthMethodLDAP AuthMethod = "ldap"
+
+// AuthMethodTOTP asks for a time-based one-time password as a second factor.
+const AuthMethodTOTP AuthMethod = "totp"
+
+// AuthMethodJWT accepts JSON Web Tokens signed by a trusted issuer, such as the OIDC tokens of CI systems, as
+// passwords.
+const AuthMethodJWT AuthMethod = "jwt"
+
 // endregion
 
 // region PasswordAuth
@@ -224,6 +380,15 @@ type PasswordAuthConfig struct {
 
 	// Kerberos configures the Kerberos authenticator for password authentication.
 	Kerberos AuthKerberosClientConfig `json:"kerberos" yaml:"kerberos"`
+
+	// File configures the local password file authenticator for password authentication.
+	File AuthPasswordFileConfig `json:"file" yaml:"file"`
+
+	// LDAP configures the LDAP authenticator for password authentication.
+	LDAP AuthLDAPConfig `json:"ldap" yaml:"ldap"`
+
+	// JWT configures accepting JSON Web Tokens as passwords.
+	JWT AuthJWTConfig `json:"jwt" yaml:"jwt"`
 }
 
 // Validate checks the password configuration structure for misconfiguration.
@@ -238,6 +403,12 @@ func (c PasswordAuthConfig) Validate() error {
 		return c.Webhook.Validate()
 	case PasswordAuthMethodKerberos:
 		return c.Kerberos.Validate()
+	case PasswordAuthMethodFile:
+		return wrap(c.File.Validate(), "file")
+	case PasswordAuthMethodLDAP:
+		return wrap(c.LDAP.Validate(), "ldap")
+	case PasswordAuthMethodJWT:
+		return wrap(c.JWT.Validate(), "jwt")
 	default:
 		return fmt.Errorf("BUG: unsupported password authenticator: %s", c.Method)
 	}
@@ -248,7 +419,9 @@ type PasswordAuthMethod string
 
 // Validate checks if the provided method is valid or not.
 func (m PasswordAuthMethod) Validate() error {
-	if m == PasswordAuthMethodDisabled || m == PasswordAuthMethodWebhook || m == PasswordAuthMethodKerberos {
+	switch m {
+	case PasswordAuthMethodDisabled, PasswordAuthMethodWebhook, PasswordAuthMethodKerberos, PasswordAuthMethodFile,
+		PasswordAuthMethodLDAP, PasswordAuthMethodJWT:
 		return nil
 	}
 	return fmt.Errorf("invalid value for method: %s", m)
@@ -263,6 +436,68 @@ const PasswordAuthMethodWebhook PasswordAuthMethod = PasswordAuthMethod(AuthMeth
 // PasswordAuthMethodKerberos authenticates passwords using Kerberos.
 const PasswordAuthMethodKerberos PasswordAuthMethod = PasswordAuthMethod(AuthMethodKerberos)
 
+// PasswordAuthMethodFile authenticates passwords against a local htpasswd-style file.
+const PasswordAuthMethodFile PasswordAuthMethod = PasswordAuthMethod(AuthMethodFile)
+
+// PasswordAuthMethodLDAP authenticates passwords by binding to an LDAP directory as the user.
+const PasswordAuthMethodLDAP PasswordAuthMethod = PasswordAuthMethod(AuthMethodLDAP)
+
+// PasswordAuthMethodJWT validates the password as a JSON Web Token signed by a trusted issuer.
+const PasswordAuthMethodJWT PasswordAuthMethod = PasswordAuthMethod(AuthMethodJWT)
+
+// endregion
+
+// region PasswordFile
+
+// AuthPasswordFileConfig is the configuration for authenticating passwords against a local file, without contacting
+// a webhook.
+type AuthPasswordFileConfig struct {
+	// Path is the password file. Each line has the htpasswd-style username:hash format, optionally followed by a
+	// metadata and an environment column in URL query format, e.g. alice:$2y$10$...:GROUP=admins:LANG=en_US.UTF-8.
+	// Supported hashes are bcrypt, argon2id and sha512-crypt. The file is re-read when it changes.
+	Path string `json:"path" yaml:"path"`
+
+	// DefaultAlgorithm is the hash algorithm used by the users in the password file. Logins with unknown usernames
+	// are checked against a dummy hash of this algorithm so the response time does not reveal which users exist.
+	DefaultAlgorithm PasswordHashAlgorithm `json:"defaultAlgorithm" yaml:"defaultAlgorithm" default:"bcrypt"`
+}
+
+// Validate checks if the password file authentication configuration is valid.
+func (c *AuthPasswordFileConfig) Validate() error {
+	if c.Path == "" {
+		return newError("path", "the path of the password file is required")
+	}
+	if _, err := os.Stat(c.Path); err != nil {
+		return wrapWithMessage(err, "path", "password file %s does not exist or is inaccessible", c.Path)
+	}
+	if err := c.DefaultAlgorithm.Validate(); err != nil {
+		return wrap(err, "defaultAlgorithm")
+	}
+	return nil
+}
+
+// PasswordHashAlgorithm is a password hash algorithm supported in password files.
+type PasswordHashAlgorithm string
+
+const (
+	// PasswordHashAlgorithmBcrypt is the bcrypt algorithm. This is the default if no algorithm is set.
+	PasswordHashAlgorithmBcrypt PasswordHashAlgorithm = "bcrypt"
+	// PasswordHashAlgorithmArgon2id is the argon2id algorithm.
+	PasswordHashAlgorithmArgon2id PasswordHashAlgorithm = "argon2id"
+	// PasswordHashAlgorithmSHA512Crypt is the SHA-512 based crypt algorithm.
+	PasswordHashAlgorithmSHA512Crypt PasswordHashAlgorithm = "sha512-crypt"
+)
+
+// Validate checks if the password hash algorithm is supported.
+func (a PasswordHashAlgorithm) Validate() error {
+	switch a {
+	case "", PasswordHashAlgorithmBcrypt, PasswordHashAlgorithmArgon2id, PasswordHashAlgorithmSHA512Crypt:
+		return nil
+	default:
+		return fmt.Errorf("unsupported password hash algorithm: %s", a)
+	}
+}
+
 // endregion
 
 // region PubKeyAuth
@@ -274,6 +509,15 @@ type PublicKeyAuthConfig struct {
 
 	// Webhook configures the webhook authenticator for public key authentication.
 	Webhook AuthWebhookClientConfig `json:"webhook" yaml:"webhook"`
+
+	// Certificate configures the authenticator for OpenSSH user certificates signed by trusted CA keys.
+	Certificate AuthCertificateConfig `json:"certificate" yaml:"certificate"`
+
+	// AuthorizedKeys configures the authenticator for local OpenSSH authorized_keys files.
+	AuthorizedKeys AuthAuthorizedKeysConfig `json:"authorizedKeys" yaml:"authorizedKeys"`
+
+	// LDAP configures the authenticator looking up the public keys of users in an LDAP directory.
+	LDAP AuthLDAPConfig `json:"ldap" yaml:"ldap"`
 }
 
 func (c PublicKeyAuthConfig) Validate() error {
@@ -285,6 +529,12 @@ func (c PublicKeyAuthConfig) Validate() error {
 		return nil
 	case PubKeyAuthMethodWebhook:
 		return c.Webhook.Validate()
+	case PubKeyAuthMethodCertificate:
+		return wrap(c.Certificate.Validate(), "certificate")
+	case PubKeyAuthMethodAuthorizedKeys:
+		return wrap(c.AuthorizedKeys.Validate(), "authorizedKeys")
+	case PubKeyAuthMethodLDAP:
+		return wrap(c.LDAP.Validate(), "ldap")
 	default:
 		return fmt.Errorf("BUG: unsupported public key authenticator: %s", c.Method)
 	}
@@ -295,7 +545,9 @@ type PublicKeyAuthMethod string
 
 // Validate checks if the provided method is valid or not.
 func (m PublicKeyAuthMethod) Validate() error {
-	if m == PubKeyAuthMethodDisabled || m == PubKeyAuthMethodWebhook {
+	switch m {
+	case PubKeyAuthMethodDisabled, PubKeyAuthMethodWebhook, PubKeyAuthMethodCertificate, PubKeyAuthMethodAuthorizedKeys,
+		PubKeyAuthMethodLDAP:
 		return nil
 	}
 	return fmt.Errorf("invalid value for method: %s", m)
@@ -307,6 +559,360 @@ const PubKeyAuthMethodDisabled PublicKeyAuthMethod = PublicKeyAuthMethod(AuthMet
 // PubKeyAuthMethodWebhook authenticates using an HTTP webhook.
 const PubKeyAuthMethodWebhook PublicKeyAuthMethod = PublicKeyAuthMethod(AuthMethodWebhook)
 
+// PubKeyAuthMethodCertificate authenticates OpenSSH user certificates against the configured CA keys.
+const PubKeyAuthMethodCertificate PublicKeyAuthMethod = PublicKeyAuthMethod(AuthMethodCertificate)
+
+// PubKeyAuthMethodAuthorizedKeys authenticates public keys against local OpenSSH authorized_keys files.
+const PubKeyAuthMethodAuthorizedKeys PublicKeyAuthMethod = PublicKeyAuthMethod(AuthMethodAuthorizedKeys)
+
+// PubKeyAuthMethodLDAP authenticates public keys against the keys stored in the LDAP entry of the user.
+const PubKeyAuthMethodLDAP PublicKeyAuthMethod = PublicKeyAuthMethod(AuthMethodLDAP)
+
+// endregion
+
+// region AuthorizedKeys
+
+// AuthAuthorizedKeysConfig is the configuration for authenticating public keys against OpenSSH authorized_keys files
+// stored locally, without contacting a webhook.
+type AuthAuthorizedKeysConfig struct {
+	// Path is a Go template for the path of the authorized_keys file of a user, for example
+	// /etc/containerssh/keys/{{ .Username }}. The template can use the Username field. Usernames containing path
+	// separators are rejected. The files are re-read when they change.
+	Path string `json:"path" yaml:"path"`
+}
+
+// Validate checks if the authorized_keys authentication configuration is valid.
+func (c *AuthAuthorizedKeysConfig) Validate() error {
+	if c.Path == "" {
+		return newError("path", "the path of the authorized_keys files is required")
+	}
+	if err := validateTemplate(c.Path); err != nil {
+		return wrap(err, "path")
+	}
+	return nil
+}
+
+// endregion
+
+// region LDAP
+
+// AuthLDAPConfig is the configuration for authenticating users against an LDAP directory. The user entry is looked
+// up with the bind DN, then password authentication binds as the user, while public key authentication compares the
+// keys stored in the user entry.
+type AuthLDAPConfig struct {
+	// URL is the address of the LDAP server, for example ldap://ldap.example.com:389 or
+	// ldaps://ldap.example.com:636.
+	URL string `json:"url" yaml:"url" comment:"LDAP server URL"`
+	// StartTLS upgrades ldap:// connections to TLS before binding.
+	StartTLS bool `json:"startTLS" yaml:"startTLS" comment:"Use StartTLS on ldap:// connections"`
+	// Timeout is the timeout for connecting and for each LDAP request.
+	Timeout time.Duration `json:"timeout" yaml:"timeout" comment:"Timeout for LDAP requests" default:"10s"`
+
+	// CACert is the PEM-encoded CA certificate, or file containing a PEM-encoded CA certificate used to verify the
+	// LDAP server certificate. If empty, the system certificate pool is used.
+	CACert string `json:"cacert" yaml:"cacert" comment:"CA certificate in PEM format to use for host verification."`
+	// ClientCert is a PEM containing an x509 certificate to present to the server or a file name containing the PEM.
+	ClientCert string `json:"cert" yaml:"cert" comment:"Client certificate file in PEM format."`
+	// ClientKey is a PEM containing a private key to use to connect the server or a file name containing the PEM.
+	ClientKey string `json:"key" yaml:"key" comment:"Client key file in PEM format."`
+	// TLSVersion is the minimum TLS version to use.
+	TLSVersion TLSVersion `json:"tlsVersion" yaml:"tlsVersion" default:"1.2"`
+
+	// BindDN is the DN used to search for users and groups. If empty, the searches are performed anonymously.
+	BindDN string `json:"bindDN" yaml:"bindDN" comment:"DN to bind as for searches"`
+	// BindPassword is the password for BindDN.
+	BindPassword string `json:"bindPassword" yaml:"bindPassword" comment:"Password of the bind DN"`
+
+	// BaseDN is the DN under which users are searched.
+	BaseDN string `json:"baseDN" yaml:"baseDN" comment:"Base DN to search users in"`
+	// UserFilter is a Go template for the LDAP filter finding the user entry. The Username field contains the
+	// escaped SSH username. The filter must match exactly one entry.
+	UserFilter string `json:"userFilter" yaml:"userFilter" comment:"Filter template to find the user" default:"(&(objectClass=person)(uid={{ .Username }}))"`
+
+	// GroupBaseDN is the DN under which the groups of the user are searched. If empty, groups are not looked up.
+	GroupBaseDN string `json:"groupBaseDN" yaml:"groupBaseDN" comment:"Base DN to search groups in"`
+	// GroupFilter is a Go template for the LDAP filter finding the groups of the user. The DN and Username fields
+	// contain the escaped DN of the user entry and the escaped SSH username.
+	GroupFilter string `json:"groupFilter" yaml:"groupFilter" comment:"Filter template to find the groups of the user" default:"(member={{ .DN }})"`
+	// GroupNameAttribute is the attribute of the group entries containing the group name.
+	GroupNameAttribute string `json:"groupNameAttribute" yaml:"groupNameAttribute" default:"cn"`
+	// RequiredGroups rejects users who are not a member of at least one of the listed groups.
+	RequiredGroups []string `json:"requiredGroups" yaml:"requiredGroups" comment:"Groups of which the user must be a member of at least one"`
+
+	// PublicKeyAttribute is the attribute of the user entry containing the public keys in the authorized_keys format.
+	PublicKeyAttribute string `json:"publicKeyAttribute" yaml:"publicKeyAttribute" default:"sshPublicKey"`
+
+	// Metadata maps the attributes of the user entry to connection metadata keys.
+	Metadata map[string]string `json:"metadata" yaml:"metadata" comment:"Map of LDAP attributes to metadata keys"`
+	// Environment maps the attributes of the user entry to environment variables.
+	Environment map[string]string `json:"environment" yaml:"environment" comment:"Map of LDAP attributes to environment variables"`
+}
+
+// Validate checks if the LDAP configuration is valid.
+func (c *AuthLDAPConfig) Validate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return wrap(err, "url")
+	}
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		if c.StartTLS {
+			return newError("startTLS", "startTLS cannot be used with ldaps:// URLs")
+		}
+	default:
+		return newError("url", "invalid LDAP URL, must start with ldap:// or ldaps://: %s", c.URL)
+	}
+	if c.Timeout < 100*time.Millisecond {
+		return newError("timeout", "timeout value %s is too low, must be at least 100ms", c.Timeout.String())
+	}
+	if err := c.TLSVersion.Validate(); err != nil {
+		return wrap(err, "tlsVersion")
+	}
+	if _, err := c.TLSConfig(); err != nil {
+		return err
+	}
+	if c.BaseDN == "" {
+		return newError("baseDN", "the base DN to search users in is required")
+	}
+	if err := validateTemplate(c.UserFilter); err != nil {
+		return wrap(err, "userFilter")
+	}
+	if c.GroupBaseDN != "" {
+		if err := validateTemplate(c.GroupFilter); err != nil {
+			return wrap(err, "groupFilter")
+		}
+		if c.GroupNameAttribute == "" {
+			return newError("groupNameAttribute", "the group name attribute is required when groups are looked up")
+		}
+	} else if len(c.RequiredGroups) > 0 {
+		return newError("requiredGroups", "requiredGroups can only be used if groupBaseDN is set")
+	}
+	return nil
+}
+
+// TLSConfig returns the TLS configuration for ldaps:// and StartTLS connections with the configured certificates
+// loaded.
+func (c *AuthLDAPConfig) TLSConfig() (*tls.Config, error) {
+	// We let users configure the minimum TLS version, so we don't need gosec here.
+	tlsConfig := &tls.Config{ //nolint:gosec
+		MinVersion: c.TLSVersion.GetTLSVersion(),
+	}
+	if u, err := url.Parse(c.URL); err == nil {
+		tlsConfig.ServerName = u.Hostname()
+	}
+	if strings.TrimSpace(c.CACert) != "" {
+		caCert, err := loadPEM(c.CACert)
+		if err != nil {
+			return nil, wrapWithMessage(err, "cacert", "failed to load CA certificate")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, newError("cacert", "invalid CA certificate provided")
+		}
+	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return nil, newError("cert", "client certificate and client key must be provided together")
+	}
+	if c.ClientCert != "" {
+		clientCert, err := loadPEM(c.ClientCert)
+		if err != nil {
+			return nil, wrapWithMessage(err, "cert", "failed to load client certificate")
+		}
+		clientKey, err := loadPEM(c.ClientKey)
+		if err != nil {
+			return nil, wrapWithMessage(err, "key", "failed to load client key")
+		}
+		cert, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, wrapWithMessage(err, "cert", "failed to load certificate or key")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// endregion
+
+// region JWT
+
+// AuthJWTConfig is the configuration for accepting JSON Web Tokens, such as the OIDC tokens issued to CI jobs, as
+// passwords. The token signature is verified against the JSON Web Key Set of the issuer, which is either loaded from
+// a file or fetched over HTTP. If neither jwksFile nor jwksUrl is set, the JWKS URL is discovered from the OpenID
+// configuration of the issuer.
+type AuthJWTConfig struct {
+	// HTTPClientConfiguration configures the HTTP client fetching the OpenID configuration and the JWKS. If the URL
+	// is empty, the issuer is used.
+	HTTPClientConfiguration `json:",inline" yaml:",inline"`
+
+	// Issuer is the required value of the iss claim.
+	Issuer string `json:"issuer" yaml:"issuer" comment:"Required value of the iss claim"`
+	// Audience lists the accepted values of the aud claim. The token must contain at least one of them.
+	Audience []string `json:"audience" yaml:"audience" comment:"Accepted values of the aud claim"`
+	// Algorithms lists the accepted signature algorithms.
+	Algorithms []string `json:"algorithms" yaml:"algorithms" default:"[\"RS256\",\"RS384\",\"RS512\",\"PS256\",\"PS384\",\"PS512\",\"ES256\",\"ES384\",\"ES512\",\"EdDSA\"]"`
+	// Leeway is the allowed clock difference when checking the exp, nbf and iat claims.
+	Leeway time.Duration `json:"leeway" yaml:"leeway" comment:"Allowed clock skew for time-based claims" default:"1m"`
+
+	// JWKSFile is a file containing the JSON Web Key Set. The file is re-read when it changes.
+	JWKSFile string `json:"jwksFile" yaml:"jwksFile" comment:"File containing the JSON Web Key Set"`
+	// JWKSURL is the URL of the JSON Web Key Set. If empty, it is discovered from the issuer.
+	JWKSURL string `json:"jwksUrl" yaml:"jwksUrl" comment:"URL of the JSON Web Key Set"`
+	// JWKSRefresh is the interval after which the JWKS is fetched again. The JWKS is also fetched again if a token is
+	// signed with an unknown key, at most once a minute.
+	JWKSRefresh time.Duration `json:"jwksRefresh" yaml:"jwksRefresh" comment:"Interval to refresh the JWKS" default:"1h"`
+
+	// UsernameClaim is the claim containing the authenticated username.
+	UsernameClaim string `json:"usernameClaim" yaml:"usernameClaim" comment:"Claim containing the username" default:"sub"`
+	// MatchUsername rejects tokens where the username claim is not equal to the SSH username. If disabled, users may
+	// log in with any SSH username and the authenticated username is taken from the claim.
+	MatchUsername bool `json:"matchUsername" yaml:"matchUsername" comment:"Require the SSH username to match the username claim"`
+	// Claims lists the required claims. Each claim must have one of the listed values. For array claims, one of the
+	// array elements must match.
+	Claims map[string][]string `json:"claims" yaml:"claims" comment:"Map of claims to accepted values"`
+
+	// Metadata maps claims to connection metadata keys.
+	Metadata map[string]string `json:"metadata" yaml:"metadata" comment:"Map of claims to metadata keys"`
+	// Environment maps claims to environment variables.
+	Environment map[string]string `json:"environment" yaml:"environment" comment:"Map of claims to environment variables"`
+}
+
+// Validate checks if the JWT configuration is valid.
+func (c *AuthJWTConfig) Validate() error {
+	if c.Issuer == "" {
+		return newError("issuer", "the issuer is required")
+	}
+	if len(c.Audience) == 0 {
+		return newError("audience", "at least one audience is required")
+	}
+	if len(c.Algorithms) == 0 {
+		return newError("algorithms", "at least one algorithm is required")
+	}
+	for _, algorithm := range c.Algorithms {
+		switch algorithm {
+		case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA":
+		default:
+			return newError("algorithms", "unsupported algorithm: %s", algorithm)
+		}
+	}
+	if c.Leeway < 0 {
+		return newError("leeway", "cannot be negative")
+	}
+	if c.UsernameClaim == "" {
+		return newError("usernameClaim", "the username claim is required")
+	}
+	if c.JWKSFile != "" {
+		if c.JWKSURL != "" {
+			return newError("jwksUrl", "jwksUrl and jwksFile cannot be used together")
+		}
+		if _, err := os.Stat(c.JWKSFile); err != nil {
+			return wrapWithMessage(err, "jwksFile", "JWKS file %s does not exist or is inaccessible", c.JWKSFile)
+		}
+		return nil
+	}
+	if c.JWKSRefresh < time.Minute {
+		return newError("jwksRefresh", "the JWKS refresh interval must be at least 1m")
+	}
+	if c.JWKSURL != "" {
+		if _, err := url.ParseRequestURI(c.JWKSURL); err != nil {
+			return newError("jwksUrl", "invalid URL: %s", c.JWKSURL)
+		}
+	}
+	clientConfig := c.ClientConfig()
+	return clientConfig.Validate()
+}
+
+// ClientConfig returns the HTTP client configuration to fetch the OpenID configuration and JWKS with. The URL is set
+// to the issuer if empty and always ends in a slash.
+func (c *AuthJWTConfig) ClientConfig() HTTPClientConfiguration {
+	clientConfig := c.HTTPClientConfiguration
+	if clientConfig.URL == "" {
+		clientConfig.URL = c.Issuer
+	}
+	if !strings.HasSuffix(clientConfig.URL, "/") {
+		clientConfig.URL += "/"
+	}
+	return clientConfig
+}
+
+// endregion
+
+// region Certificate
+
+// AuthCertificateConfig is the configuration for authenticating OpenSSH user certificates
+// (e.g. ssh-ed25519-cert-v01@openssh.com) locally, without contacting a webhook.
+type AuthCertificateConfig struct {
+	// CAKeys is a list of trusted certificate authority public keys in the authorized_keys format, or files
+	// containing one or more such keys. Only certificates signed by one of these keys are accepted.
+	CAKeys []string `json:"caKeys" yaml:"caKeys"`
+
+	// RevocationList is a file listing revoked keys, key IDs and serials. Both binary key revocation lists (KRLs)
+	// created with ssh-keygen -k and the text KRL specification format ssh-keygen -k accepts as input are supported.
+	// In the text format, plain public keys and "key:", "hash:", "id:" and "serial:" entries are supported. KRL
+	// signatures are not verified. The file is re-read when it changes.
+	RevocationList string `json:"revocationList" yaml:"revocationList"`
+
+	// EnforcePrincipal requires the SSH username to be listed among the principals of the certificate. If set to
+	// false any principal is accepted and the authorization server must take care of checking the
+	// SSH_CERT_PRINCIPALS metadata.
+	EnforcePrincipal bool `json:"enforcePrincipal" yaml:"enforcePrincipal" default:"true"`
+}
+
+// Validate checks if the certificate authentication configuration is valid.
+func (c *AuthCertificateConfig) Validate() error {
+	if len(c.CAKeys) == 0 {
+		return newError("caKeys", "at least one CA key is required for certificate authentication")
+	}
+	if _, err := c.LoadCAKeys(); err != nil {
+		return wrap(err, "caKeys")
+	}
+	if c.RevocationList != "" {
+		if _, err := os.Stat(c.RevocationList); err != nil {
+			return wrapWithMessage(err, "revocationList", "revocation list %s does not exist or is inaccessible", c.RevocationList)
+		}
+	}
+	return nil
+}
+
+// LoadCAKeys parses the configured CA keys and returns them as SSH public keys.
+func (c *AuthCertificateConfig) LoadCAKeys() ([]ssh.PublicKey, error) {
+	var result []ssh.PublicKey
+	for _, caKey := range c.CAKeys {
+		keyData := []byte(caKey)
+		if _, _, _, _, err := ssh.ParseAuthorizedKey(keyData); err != nil {
+			// We are deliberately loading a dynamic file here.
+			keyData, err = os.ReadFile(caKey) //nolint:gosec
+			if err != nil {
+				return nil, fmt.Errorf("failed to load CA key %s (%w)", caKey, err)
+			}
+		}
+		keys, err := ParseAuthorizedKeys(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA key %s (%w)", caKey, err)
+		}
+		if len(keys) == 0 {
+			return nil, fmt.Errorf("no CA keys found in %s", caKey)
+		}
+		result = append(result, keys...)
+	}
+	return result, nil
+}
+
+// ParseAuthorizedKeys parses all keys from a block of text in the authorized_keys format. Empty lines and comments
+// are skipped.
+func ParseAuthorizedKeys(data []byte) ([]ssh.PublicKey, error) {
+	var result []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+		data = rest
+	}
+	return result, nil
+}
+
 // endregion
 
 // region Keyboard-interactive
@@ -318,6 +924,9 @@ type KeyboardInteractiveAuthConfig struct {
 
 	// Webhook configures the oAuth2 authenticator for keyboard-interactive authentication.
 	OAuth2 AuthOAuth2ClientConfig `json:"oauth2" yaml:"oauth2"`
+
+	// TOTP configures the time-based one-time password second factor.
+	TOTP AuthTOTPConfig `json:"totp" yaml:"totp"`
 }
 
 func (c KeyboardInteractiveAuthConfig) Validate() error {
@@ -329,6 +938,14 @@ func (c KeyboardInteractiveAuthConfig) Validate() error {
 		return nil
 	case KeyboardInteractiveAuthMethodOAuth2:
 		return wrap(c.OAuth2.Validate(), "oauth2")
+	case KeyboardInteractiveAuthMethodTOTP:
+		if c.OAuth2.Provider != "" || c.OAuth2.ClientID != "" {
+			return newError(
+				"oauth2",
+				"the totp method replaces oauth2 for keyboard-interactive authentication and cannot be combined with it",
+			)
+		}
+		return wrap(c.TOTP.Validate(), "totp")
 	default:
 		return newError("method", "BUG: unsupported keyboard-interactive authentication method: %s", c.Method)
 	}
@@ -339,10 +956,12 @@ type KeyboardInteractiveAuthMethod string
 
 // Validate checks if the provided method is valid or not.
 func (m KeyboardInteractiveAuthMethod) Validate() error {
-	if m == KeyboardInteractiveAuthMethodDisabled || m == KeyboardInteractiveAuthMethodOAuth2 {
+	switch m {
+	case KeyboardInteractiveAuthMethodDisabled, KeyboardInteractiveAuthMethodOAuth2, KeyboardInteractiveAuthMethodTOTP:
 		return nil
+	default:
+		return fmt.Errorf("invalid value for method for keyboard-interactive authentication: %s", m)
 	}
-	return fmt.Errorf("invalid value for method for keyboard-interactive authentication: %s", m)
 }
 
 // KeyboardInteractiveAuthMethodDisabled disables keyboard-interactive authentication.
@@ -351,6 +970,93 @@ const KeyboardInteractiveAuthMethodDisabled KeyboardInteractiveAuthMethod = Keyb
 // KeyboardInteractiveAuthMethodOAuth2 authenticates using oAuth2/OIDC.
 const KeyboardInteractiveAuthMethodOAuth2 KeyboardInteractiveAuthMethod = KeyboardInteractiveAuthMethod(AuthMethodOAuth2)
 
+// KeyboardInteractiveAuthMethodTOTP asks for a time-based one-time password after a successful password or public key
+// authentication.
+const KeyboardInteractiveAuthMethodTOTP KeyboardInteractiveAuthMethod = KeyboardInteractiveAuthMethod(AuthMethodTOTP)
+
+// endregion
+
+// region TOTP
+
+// AuthTOTPConfig configures the time-based one-time password (RFC 6238) second factor. Users who passed password or
+// public key authentication are asked for a code using keyboard-interactive authentication before they are logged in.
+type AuthTOTPConfig struct {
+	// SecretsFile is a file with username:secret lines, where the secret is base32-encoded as in authenticator apps.
+	// The file is re-read when it changes. It can be left empty if the secrets are returned by the first
+	// authentication method in the metadata.
+	SecretsFile string `json:"secretsFile" yaml:"secretsFile" comment:"File with username:base32secret lines"`
+	// SecretMetadata is the metadata key in which the first authentication method, for example the authentication
+	// webhook, can return the base32-encoded secret of the user. It takes precedence over the secrets file. The key is
+	// removed from the metadata once the code has been checked.
+	SecretMetadata string `json:"secretMetadata" yaml:"secretMetadata" comment:"Metadata key containing the secret of the user" default:"TOTP_SECRET"`
+	// Optional lets users without a secret log in with the first authentication method alone. It has no effect if
+	// the required methods are configured explicitly.
+	Optional bool `json:"optional" yaml:"optional" comment:"Let users without a secret log in without a code"`
+
+	// Algorithm is the HMAC algorithm used to generate the codes.
+	Algorithm TOTPAlgorithm `json:"algorithm" yaml:"algorithm" comment:"HMAC algorithm: SHA1, SHA256 or SHA512" default:"SHA1"`
+	// Digits is the number of digits in a code.
+	Digits int `json:"digits" yaml:"digits" comment:"Number of digits in a code" default:"6"`
+	// Period is the time step after which a new code is generated.
+	Period time.Duration `json:"period" yaml:"period" comment:"Time step of the codes" default:"30s"`
+	// Skew is the number of time steps before and after the current one that are also accepted to allow for clock
+	// drift.
+	Skew uint `json:"skew" yaml:"skew" comment:"Number of time steps accepted before and after the current one" default:"1"`
+
+	// Prompt is the question sent to the user.
+	Prompt string `json:"prompt" yaml:"prompt" comment:"Question sent to the user" default:"Verification code: "`
+}
+
+// Validate checks if the TOTP configuration is valid.
+func (c *AuthTOTPConfig) Validate() error {
+	if c.SecretsFile == "" && c.SecretMetadata == "" {
+		return newError("secretsFile", "either the secrets file or the secret metadata key must be set")
+	}
+	if c.SecretsFile != "" {
+		if _, err := os.Stat(c.SecretsFile); err != nil {
+			return wrapWithMessage(err, "secretsFile", "TOTP secrets file %s does not exist or is inaccessible", c.SecretsFile)
+		}
+	}
+	if err := c.Algorithm.Validate(); err != nil {
+		return wrap(err, "algorithm")
+	}
+	if c.Digits < 6 || c.Digits > 8 {
+		return newError("digits", "the number of digits must be between 6 and 8, %d given", c.Digits)
+	}
+	if c.Period < time.Second || c.Period%time.Second != 0 {
+		return newError("period", "the period must be a whole number of seconds, %s given", c.Period)
+	}
+	if c.Skew > 10 {
+		return newError("skew", "the skew must be at most 10 time steps, %d given", c.Skew)
+	}
+	if c.Prompt == "" {
+		return newError("prompt", "the prompt cannot be empty")
+	}
+	return nil
+}
+
+// TOTPAlgorithm is the HMAC algorithm used for generating TOTP codes.
+type TOTPAlgorithm string
+
+const (
+	// TOTPAlgorithmSHA1 is the default algorithm supported by all authenticator apps.
+	TOTPAlgorithmSHA1 TOTPAlgorithm = "SHA1"
+	// TOTPAlgorithmSHA256 uses HMAC-SHA-256.
+	TOTPAlgorithmSHA256 TOTPAlgorithm = "SHA256"
+	// TOTPAlgorithmSHA512 uses HMAC-SHA-512.
+	TOTPAlgorithmSHA512 TOTPAlgorithm = "SHA512"
+)
+
+// Validate checks if the algorithm is supported.
+func (a TOTPAlgorithm) Validate() error {
+	switch a {
+	case TOTPAlgorithmSHA1, TOTPAlgorithmSHA256, TOTPAlgorithmSHA512:
+		return nil
+	default:
+		return fmt.Errorf("unsupported TOTP algorithm: %s", a)
+	}
+}
+
 // endregion
 
 // region GSSAPI
@@ -405,6 +1111,17 @@ type AuthWebhookClientConfig struct {
 	// AuthTimeout is the timeout for the overall authentication call (e.g. verifying a password). If the server
 	// responds with a non-200 response the call will be retried until this timeout is reached.
 	AuthTimeout time.Duration `json:"authTimeout" yaml:"authTimeout" default:"60s"`
+
+	// FallbackURLs are the base URLs of further servers implementing the same webhook. They are tried in order if
+	// the request to the main URL fails or its circuit breaker is open. All other HTTP settings are shared with the
+	// main URL.
+	FallbackURLs []string `json:"fallbackUrls" yaml:"fallbackUrls" comment:"Base URLs to try in order if the main URL fails."`
+
+	// Cache configures caching the public key and authorization decisions of the webhook.
+	Cache AuthWebhookCacheConfig `json:"cache" yaml:"cache"`
+
+	// CircuitBreaker configures skipping servers that keep failing.
+	CircuitBreaker AuthWebhookCircuitBreakerConfig `json:"circuitBreaker" yaml:"circuitBreaker"`
 }
 
 // Validate validates the authentication client configuration.
@@ -415,6 +1132,77 @@ func (c *AuthWebhookClientConfig) Validate() error {
 	if err := c.HTTPClientConfiguration.Validate(); err != nil {
 		return err
 	}
+	for i, fallbackURL := range c.FallbackURLs {
+		fallbackConfig := c.HTTPClientConfiguration
+		fallbackConfig.URL = fallbackURL
+		if err := fallbackConfig.Validate(); err != nil {
+			return wrap(err, fmt.Sprintf("fallbackUrls[%d]", i))
+		}
+	}
+	if err := c.Cache.Validate(); err != nil {
+		return wrap(err, "cache")
+	}
+	if err := c.CircuitBreaker.Validate(); err != nil {
+		return wrap(err, "circuitBreaker")
+	}
+	return nil
+}
+
+// AuthWebhookCacheConfig configures caching of public key and authorization decisions. Decisions are cached per
+// remote IP address, username and key fingerprint, or per remote IP address, username, authenticated username and
+// authentication context (methods, metadata, environment and files) for authorization. Password authentications are
+// never cached.
+type AuthWebhookCacheConfig struct {
+	// Enable enables caching the decisions of the webhook.
+	Enable bool `json:"enable" yaml:"enable" comment:"Cache public key and authorization decisions." default:"false"`
+	// TTL is the time a successful decision is cached for if the webhook response does not contain a cacheTTL.
+	TTL time.Duration `json:"ttl" yaml:"ttl" comment:"Time to cache successful decisions for." default:"5m"`
+	// NegativeTTL is the time a failed decision is cached for if the webhook response does not contain a cacheTTL.
+	// 0 disables caching failed decisions. Backend errors are never cached.
+	NegativeTTL time.Duration `json:"negativeTtl" yaml:"negativeTtl" comment:"Time to cache failed decisions for." default:"30s"`
+	// MaxEntries is the maximum number of cached decisions. Once reached, the entries expiring first are evicted.
+	MaxEntries int `json:"maxEntries" yaml:"maxEntries" comment:"Maximum number of cached decisions." default:"10000"`
+}
+
+// Validate checks the cache configuration.
+func (c AuthWebhookCacheConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.TTL < 0 {
+		return newError("ttl", "cannot be negative")
+	}
+	if c.NegativeTTL < 0 {
+		return newError("negativeTtl", "cannot be negative")
+	}
+	if c.MaxEntries <= 0 {
+		return newError("maxEntries", "must be positive")
+	}
+	return nil
+}
+
+// AuthWebhookCircuitBreakerConfig configures the circuit breaker of the webhook client. After a number of consecutive
+// failed requests to a server, the server is skipped until the reset timeout passes. After that, a single request
+// is let through to check if the server has recovered.
+type AuthWebhookCircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed requests after which a server is skipped. 0 disables the
+	// circuit breaker.
+	FailureThreshold int `json:"failureThreshold" yaml:"failureThreshold" comment:"Consecutive failures before skipping a server, 0 to disable." default:"0"`
+	// ResetTimeout is the time a server is skipped for before it is tried again.
+	ResetTimeout time.Duration `json:"resetTimeout" yaml:"resetTimeout" comment:"Time to skip a failing server for." default:"30s"`
+}
+
+// Validate checks the circuit breaker configuration.
+func (c AuthWebhookCircuitBreakerConfig) Validate() error {
+	if c.FailureThreshold < 0 {
+		return newError("failureThreshold", "cannot be negative")
+	}
+	if c.FailureThreshold == 0 {
+		return nil
+	}
+	if c.ResetTimeout <= 0 {
+		return newError("resetTimeout", "must be positive")
+	}
 	return nil
 }
 
@@ -444,6 +1232,12 @@ type AuthOAuth2ClientConfig struct {
 	// Generic is a generic OAuth2 authentication without OIDC userinfo. This method cannot verify the username.
 	Generic AuthGenericConfig `json:"generic" yaml:"generic"`
 
+	// GitLab is the configuration for the GitLab provider.
+	GitLab AuthGitLabConfig `json:"gitlab" yaml:"gitlab"`
+
+	// Gitea is the configuration for the Gitea provider.
+	Gitea AuthGiteaConfig `json:"gitea" yaml:"gitea"`
+
 	// QRCodeClients contains a list of strings that are used to identify SSH clients that can display an ASCII QR Code
 	// for the device authorization flow. Each string is compiled as regular expressions and are used to match against
 	// the client version string.
@@ -491,6 +1285,14 @@ func (o *AuthOAuth2ClientConfig) Validate() error {
 		if err := o.Generic.Validate(); err != nil {
 			return wrap(err, "generic")
 		}
+	case AuthOAuth2GitLabProvider:
+		if err := o.GitLab.Validate(); err != nil {
+			return wrap(err, "gitlab")
+		}
+	case AuthOAuth2GiteaProvider:
+		if err := o.Gitea.Validate(); err != nil {
+			return wrap(err, "gitea")
+		}
 	}
 
 	return nil
@@ -506,6 +1308,10 @@ const (
 	AuthOAuth2OIDCProvider OAuth2ProviderName = "oidc"
 	// AuthOAuth2GenericProvider authenticates against a generic OAuth2 server.
 	AuthOAuth2GenericProvider OAuth2ProviderName = "generic"
+	// AuthOAuth2GitLabProvider authenticates against GitLab.com or a self-hosted GitLab instance.
+	AuthOAuth2GitLabProvider OAuth2ProviderName = "gitlab"
+	// AuthOAuth2GiteaProvider authenticates against a Gitea instance.
+	AuthOAuth2GiteaProvider OAuth2ProviderName = "gitea"
 )
 
 func (o OAuth2ProviderName) Validate() error {
@@ -516,6 +1322,10 @@ func (o OAuth2ProviderName) Validate() error {
 		return nil
 	case AuthOAuth2GenericProvider:
 		return nil
+	case AuthOAuth2GitLabProvider:
+		return nil
+	case AuthOAuth2GiteaProvider:
+		return nil
 	default:
 		return fmt.Errorf("invalid oAuth2 provider")
 	}
@@ -623,7 +1433,7 @@ type AuthOIDCConfig struct {
 	// AuthorizationCodeFlow enables or disables the OIDC authorization code flow.
 	AuthorizationCodeFlow bool `json:"authorizationCodeFlow" yaml:"authorizationCodeFlow"`
 
-	// UsernameField indicates the userinfo field that should be taken as the username.
+	// UsernameField indicates the claim that should be taken as the username. Nested claims are addressed with dots.
 	UsernameField string `json:"usernameField" yaml:"usernameField" default:"sub"`
 
 	// RedirectURI is the URI the client is returned to. This URL should be configured to the redirect server endpoint.
@@ -634,6 +1444,15 @@ type AuthOIDCConfig struct {
 	ExtraScopes []string `json:"extraScopes" yaml:"extraScopes"`
 	// EnforceScopes rejects the user authentication if the user fails to grant the scopes requested in extraScopes.
 	EnforceScopes bool `json:"enforceScopes" yaml:"enforceScopes"`
+
+	// Metadata maps claims of the ID token and the userinfo response to connection metadata keys. Nested claims are
+	// addressed with dots, for example realm_access.roles. Arrays are joined with commas.
+	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+	// Environment maps claims to environment variables in the same way as Metadata.
+	Environment map[string]string `json:"environment" yaml:"environment"`
+	// RequiredClaims lists expressions that must all be true for the authentication to succeed, for example
+	// "groups contains ssh-users" or "email_verified == true".
+	RequiredClaims []OIDCClaimExpression `json:"requiredClaims" yaml:"requiredClaims"`
 }
 
 func (o *AuthOIDCConfig) Validate() error {
@@ -643,9 +1462,96 @@ func (o *AuthOIDCConfig) Validate() error {
 	if o.AuthorizationCodeFlow && o.RedirectURI == "" {
 		return wrap(fmt.Errorf("redirectURI is required if the authorization code flow is enabled"), "redirectURI")
 	}
+	if o.UsernameField == "" {
+		return newError("usernameField", "the username field is required")
+	}
+	for claim, key := range o.Metadata {
+		if claim == "" || key == "" {
+			return newError("metadata", "empty claim or metadata key")
+		}
+	}
+	for claim, name := range o.Environment {
+		if claim == "" || name == "" {
+			return newError("environment", "empty claim or environment variable name")
+		}
+	}
+	for i, expression := range o.RequiredClaims {
+		if err := expression.Validate(); err != nil {
+			return wrap(err, fmt.Sprintf("requiredClaims[%d]", i))
+		}
+	}
 	return o.HTTPClientConfiguration.Validate()
 }
 
+// OIDCClaimOperator is the comparison in a required claim expression.
+type OIDCClaimOperator string
+
+const (
+	// OIDCClaimOperatorPresent requires the claim to be present and not false, null, an empty string or an empty
+	// array. It is used when the expression only contains the claim.
+	OIDCClaimOperatorPresent OIDCClaimOperator = ""
+	// OIDCClaimOperatorEquals requires a scalar claim to be equal to the value.
+	OIDCClaimOperatorEquals OIDCClaimOperator = "=="
+	// OIDCClaimOperatorNotEquals requires the claim to be missing or not equal to the value.
+	OIDCClaimOperatorNotEquals OIDCClaimOperator = "!="
+	// OIDCClaimOperatorContains requires an array claim to have an element equal to the value. Strings are treated
+	// as space-separated lists, like the scope claim.
+	OIDCClaimOperatorContains OIDCClaimOperator = "contains"
+)
+
+// OIDCClaimExpression is a condition on a claim in the form of "claim", "claim == value", "claim != value" or
+// "claim contains value". Nested claims are addressed with dots. Values containing spaces can be written as quoted
+// strings.
+type OIDCClaimExpression string
+
+// OIDCClaimCondition is a parsed OIDCClaimExpression.
+type OIDCClaimCondition struct {
+	Claim    string
+	Operator OIDCClaimOperator
+	Value    string
+}
+
+// Parse splits the expression into the claim, the operator and the value.
+func (e OIDCClaimExpression) Parse() (OIDCClaimCondition, error) {
+	expression := strings.TrimSpace(string(e))
+	fields := strings.Fields(expression)
+	if len(fields) == 0 {
+		return OIDCClaimCondition{}, fmt.Errorf("empty expression")
+	}
+	condition := OIDCClaimCondition{
+		Claim: fields[0],
+	}
+	if len(fields) == 1 {
+		return condition, nil
+	}
+	condition.Operator = OIDCClaimOperator(fields[1])
+	switch condition.Operator {
+	case OIDCClaimOperatorEquals, OIDCClaimOperatorNotEquals, OIDCClaimOperatorContains:
+	default:
+		return OIDCClaimCondition{}, fmt.Errorf("invalid operator in expression %q: %s", expression, fields[1])
+	}
+	if len(fields) == 2 {
+		return OIDCClaimCondition{}, fmt.Errorf("missing value in expression %q", expression)
+	}
+	value := strings.TrimSpace(strings.TrimPrefix(expression, fields[0]))
+	value = strings.TrimSpace(strings.TrimPrefix(value, fields[1]))
+	if strings.HasPrefix(value, "\"") {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return OIDCClaimCondition{}, fmt.Errorf("invalid quoted value in expression %q", expression)
+		}
+		value = unquoted
+	}
+	condition.Value = value
+	return condition, nil
+}
+
+// Validate checks if the expression can be parsed.
+func (e OIDCClaimExpression) Validate() error {
+	_, err := e.Parse()
+	return err
+}
+
 type AuthGenericConfig struct {
 	// AuthorizeEndpointURL is the endpoint configuration for the OAuth2 authorization
 	AuthorizeEndpointURL string `json:"authorizeEndpointURL" yaml:"authorizeEndpointURL"`
@@ -670,6 +1576,75 @@ func (o *AuthGenericConfig) Validate() error {
 	return nil
 }
 
+// AuthGitLabConfig is the configuration for authenticating against GitLab.com or a self-hosted GitLab instance.
+type AuthGitLabConfig struct {
+	// HTTPClientConfiguration configures the connection to GitLab. The URL is the base URL of the GitLab instance,
+	// for example https://gitlab.com.
+	HTTPClientConfiguration `json:",inline" yaml:",inline"`
+
+	// DeviceFlow enables the device authorization flow. This requires GitLab 17.2 or newer.
+	DeviceFlow bool `json:"deviceFlow" yaml:"deviceFlow"`
+	// AuthorizationCodeFlow enables the authorization code flow.
+	AuthorizationCodeFlow bool `json:"authorizationCodeFlow" yaml:"authorizationCodeFlow" default:"true"`
+
+	// RedirectURI is the URI the client is returned to. This URL should be configured to the redirect server endpoint.
+	RedirectURI string `json:"redirectURI" yaml:"redirectURI"`
+
+	// EnforceUsername requires that the GitLab username and the entered SSH username match. If this is set to false
+	// the configuration server has to handle the GITLAB_LOGIN connection parameter in order to obtain the correct
+	// username as the SSH username cannot be trusted.
+	EnforceUsername bool `json:"enforceUsername" yaml:"enforceUsername" default:"true"`
+	// RequireGroupMembership lists groups by their full path, such as example/infra. The user must be a member of at
+	// least one of them, directly or through a parent group.
+	RequireGroupMembership []string `json:"requireGroupMembership" yaml:"requireGroupMembership"`
+
+	// ExtraScopes asks the user to grant extra scopes to ContainerSSH in addition to the openid and read_user scopes.
+	ExtraScopes []string `json:"extraScopes" yaml:"extraScopes"`
+}
+
+// Validate checks the GitLab configuration.
+func (o *AuthGitLabConfig) Validate() error {
+	if !o.DeviceFlow && !o.AuthorizationCodeFlow {
+		return fmt.Errorf("at least one of deviceFlow or authorizationCodeFlow must be enabled")
+	}
+	if o.AuthorizationCodeFlow {
+		if _, err := url.ParseRequestURI(o.RedirectURI); err != nil {
+			return newError("redirectURI", "invalid URL: %s", o.RedirectURI)
+		}
+	}
+	return o.HTTPClientConfiguration.Validate()
+}
+
+// AuthGiteaConfig is the configuration for authenticating against a Gitea instance. Gitea does not support the device
+// flow, so users are always sent through the authorization code flow.
+type AuthGiteaConfig struct {
+	// HTTPClientConfiguration configures the connection to Gitea. The URL is the base URL of the Gitea instance, for
+	// example https://gitea.example.com.
+	HTTPClientConfiguration `json:",inline" yaml:",inline"`
+
+	// RedirectURI is the URI the client is returned to. This URL should be configured to the redirect server endpoint.
+	RedirectURI string `json:"redirectURI" yaml:"redirectURI"`
+
+	// EnforceUsername requires that the Gitea username and the entered SSH username match. If this is set to false
+	// the configuration server has to handle the GITEA_LOGIN connection parameter in order to obtain the correct
+	// username as the SSH username cannot be trusted.
+	EnforceUsername bool `json:"enforceUsername" yaml:"enforceUsername" default:"true"`
+	// RequireOrgMembership lists organizations. The user must be a member of at least one of them.
+	RequireOrgMembership []string `json:"requireOrgMembership" yaml:"requireOrgMembership"`
+
+	// ExtraScopes asks the user to grant extra scopes to ContainerSSH in addition to the read:user and
+	// read:organization scopes.
+	ExtraScopes []string `json:"extraScopes" yaml:"extraScopes"`
+}
+
+// Validate checks the Gitea configuration.
+func (o *AuthGiteaConfig) Validate() error {
+	if _, err := url.ParseRequestURI(o.RedirectURI); err != nil {
+		return newError("redirectURI", "invalid URL: %s", o.RedirectURI)
+	}
+	return o.HTTPClientConfiguration.Validate()
+}
+
 // endregion
 
 // region Authz
@@ -715,6 +1690,49 @@ const AuthzMethodWebhook AuthzMethod = AuthzMethod(AuthMethodWebhook)
 
 // endregion
 
+// region Banner
+
+// AuthBannerConfig is the configuration for fetching the banner from the authentication server.
+type AuthBannerConfig struct {
+	Method AuthBannerMethod `json:"method" yaml:"method" default:""`
+
+	Webhook AuthWebhookClientConfig `json:"webhook" yaml:"webhook"`
+}
+
+// Validate validates the banner configuration.
+func (k *AuthBannerConfig) Validate() error {
+	if err := k.Method.Validate(); err != nil {
+		return wrap(err, "method")
+	}
+	switch k.Method {
+	case AuthBannerMethodDisabled:
+		return nil
+	case AuthBannerMethodWebhook:
+		return wrap(k.Webhook.Validate(), "webhook")
+	default:
+		return newError("method", "BUG: invalid value for method for banner: %s", k.Method)
+	}
+}
+
+// AuthBannerMethod provides the methods usable for fetching the banner.
+type AuthBannerMethod string
+
+// Validate checks if the provided method is valid or not.
+func (m AuthBannerMethod) Validate() error {
+	if m == AuthBannerMethodDisabled || m == AuthBannerMethodWebhook {
+		return nil
+	}
+	return fmt.Errorf("invalid value for method for banner: %s", m)
+}
+
+// AuthBannerMethodDisabled disables fetching the banner. The banner from the SSH configuration is used.
+const AuthBannerMethodDisabled AuthBannerMethod = AuthBannerMethod(AuthMethodDisabled)
+
+// AuthBannerMethodWebhook fetches the banner from the authentication server using HTTP webhooks.
+const AuthBannerMethodWebhook AuthBannerMethod = AuthBannerMethod(AuthMethodWebhook)
+
+// endregion
+
 // region Kerberos
 
 // AuthKerberosClientConfig is the configuration for the Kerberos authentication method.
@@ -759,3 +1777,88 @@ func (k *AuthKerberosClientConfig) Validate() error {
 }
 
 // endregion
+
+// region Lockout
+
+// AuthLockoutConfig configures the tracking of failed authentication attempts. Each rule counts the failures of a
+// different key and bans the key once the number of failures within the window reaches the threshold.
+type AuthLockoutConfig struct {
+	// Username bans a username regardless of the address the failed attempts came from.
+	Username AuthLockoutRule `json:"username" yaml:"username"`
+	// SourceIP bans a client address regardless of the usernames it tried.
+	SourceIP AuthLockoutRule `json:"sourceIP" yaml:"sourceIP"`
+	// UsernameSourceIP bans a username only when logging in from the address the failed attempts came from.
+	UsernameSourceIP AuthLockoutRule `json:"usernameSourceIP" yaml:"usernameSourceIP"`
+	// Server configures the HTTP server listing the current bans.
+	Server AuthLockoutServerConfig `json:"server" yaml:"server"`
+}
+
+// Enabled returns true if at least one lockout rule is enabled.
+func (c AuthLockoutConfig) Enabled() bool {
+	return c.Username.MaxFailures > 0 || c.SourceIP.MaxFailures > 0 || c.UsernameSourceIP.MaxFailures > 0
+}
+
+// Validate checks the lockout configuration.
+func (c AuthLockoutConfig) Validate() error {
+	if err := c.Username.Validate(); err != nil {
+		return wrap(err, "username")
+	}
+	if err := c.SourceIP.Validate(); err != nil {
+		return wrap(err, "sourceIP")
+	}
+	if err := c.UsernameSourceIP.Validate(); err != nil {
+		return wrap(err, "usernameSourceIP")
+	}
+	if c.Server.Enable && !c.Enabled() {
+		return newError("server", "the lockout server is enabled, but no lockout rule is configured")
+	}
+	if err := c.Server.Validate(); err != nil {
+		return wrap(err, "server")
+	}
+	return nil
+}
+
+// AuthLockoutRule describes when a key is banned.
+type AuthLockoutRule struct {
+	// MaxFailures is the number of failed attempts within the window after which the key is banned. 0 disables the
+	// rule.
+	MaxFailures int `json:"maxFailures" yaml:"maxFailures" comment:"Failed attempts before banning, 0 to disable"`
+	// Window is the time after which a failed attempt is no longer counted.
+	Window time.Duration `json:"window" yaml:"window" default:"10m" comment:"Time after which failed attempts expire"`
+	// BanDuration is the time a key stays banned after reaching the threshold.
+	BanDuration time.Duration `json:"banDuration" yaml:"banDuration" default:"15m" comment:"Time a ban lasts"`
+}
+
+// Validate checks the lockout rule.
+func (r AuthLockoutRule) Validate() error {
+	if r.MaxFailures < 0 {
+		return newError("maxFailures", "cannot be negative")
+	}
+	if r.MaxFailures == 0 {
+		return nil
+	}
+	if r.Window <= 0 {
+		return newError("window", "must be positive")
+	}
+	if r.BanDuration <= 0 {
+		return newError("banDuration", "must be positive")
+	}
+	return nil
+}
+
+// AuthLockoutServerConfig configures the HTTP server that lists the current bans as JSON.
+type AuthLockoutServerConfig struct {
+	HTTPServerConfiguration `json:",inline" yaml:",inline" default:"{\"listen\":\"127.0.0.1:9102\"}"`
+
+	Enable bool `json:"enable" yaml:"enable" comment:"Enable the ban list server." default:"false"`
+}
+
+// Validate validates the lockout server configuration.
+func (c AuthLockoutServerConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	return c.HTTPServerConfiguration.Validate()
+}
+
+// endregion
@@ -5,6 +5,11 @@ type HealthConfig struct {
 	Enable                  bool `json:"enable" yaml:"enable"`
 	HTTPServerConfiguration `json:",inline" yaml:",inline" default:"{\"listen\":\"0.0.0.0:7000\"}"`
 	Client                  HTTPClientConfiguration `json:"client" yaml:"client" default:"{\"url\":\"http://127.0.0.1:7000/\"}"`
+	// Drain enables starting drain mode with a POST request to the /drain path of the health check endpoint. Since
+	// the request shuts down the server, drain requires the health check endpoint to use TLS with client certificate
+	// authentication (cert, key and clientcacert). The health check client and load balancer must then present a
+	// client certificate too.
+	Drain bool `json:"drain" yaml:"drain" comment:"Allow starting drain mode with a POST request to /drain, requires clientcacert"`
 }
 
 func (c HealthConfig) Validate() error {
@@ -17,5 +22,11 @@ func (c HealthConfig) Validate() error {
 	if err := c.Client.Validate(); err != nil {
 		return wrap(err, "client")
 	}
+	if c.Drain && (c.Cert == "" || c.ClientCACert == "") {
+		return newError(
+			"drain",
+			"drain requires the health check endpoint to authenticate clients with certificates, please set cert, key and clientcacert",
+		)
+	}
 	return nil
 }
@@ -314,6 +314,77 @@ type HTTPClientConfiguration struct {
 
 	// RequestEncoding is the means by which the request body is encoded. It defaults to JSON encoding.
 	RequestEncoding RequestEncoding `json:"-" yaml:"-"`
+
+	// Signature configures signing the request bodies with HMAC-SHA256 so the server can verify them even if TLS is
+	// terminated before it.
+	Signature HTTPClientSignatureConfig `json:"signature" yaml:"signature"`
+}
+
+// HTTPClientSignatureConfig configures signing HTTP request bodies with HMAC-SHA256. The signature also covers the
+// path and query of the request URL, so the server must verify it against the same path. If a reverse proxy rewrites
+// the path, configure the removed prefix in the pathPrefix option of the server.
+type HTTPClientSignatureConfig struct {
+	// KeyID is sent along with the signature so the server can pick the matching secret. This allows the server to
+	// accept several keys while they are rotated.
+	KeyID string `json:"keyId" yaml:"keyId" comment:"Identifier of the signing key sent to the server."`
+	// Secret is the shared secret used to sign the requests. Signing is disabled if empty.
+	Secret string `json:"secret" yaml:"secret" comment:"Shared secret to sign requests with, empty to disable signing."`
+}
+
+// Validate checks the signing configuration.
+func (c HTTPClientSignatureConfig) Validate() error {
+	if c.Secret == "" {
+		if c.KeyID != "" {
+			return newError("secret", "a key ID is set, but no secret")
+		}
+		return nil
+	}
+	return validateSignatureSecret(c.Secret)
+}
+
+// HTTPServerSignatureConfig configures verifying the HMAC-SHA256 signatures of webhook requests.
+type HTTPServerSignatureConfig struct {
+	// Keys maps key IDs to the shared secrets accepted by the server. Listing several keys allows rotating them
+	// without downtime: add the new key here, switch the clients to it, then remove the old key.
+	Keys map[string]string `json:"keys" yaml:"keys" comment:"Map of key IDs to shared secrets accepted by the server."`
+	// MaxSkew is the maximum difference between the request timestamp and the server clock. Requests outside this
+	// window are rejected, and nonces are remembered for this long to reject replayed requests. Nonces are kept in
+	// the memory of each server process, replicas do not share them.
+	MaxSkew time.Duration `json:"maxSkew" yaml:"maxSkew" comment:"Maximum accepted age of a signed request." default:"5m"`
+	// PathPrefix is the path prefix a reverse proxy in front of the server removes before forwarding requests, for
+	// example /webhook if the clients call https://example.com/webhook/ and the server receives the request on /.
+	// The signature covers the path the client sent the request to, so the prefix is added back to the request path
+	// before verifying it. Without it, every request whose path was rewritten is rejected.
+	PathPrefix string `json:"pathPrefix" yaml:"pathPrefix" comment:"Path prefix removed by a reverse proxy in front of the server."`
+}
+
+// Validate checks the signature verification configuration.
+func (c HTTPServerSignatureConfig) Validate() error {
+	if len(c.Keys) == 0 {
+		return newError("keys", "at least one key is required")
+	}
+	for keyID, secret := range c.Keys {
+		if keyID == "" {
+			return newError("keys", "key IDs cannot be empty")
+		}
+		if err := validateSignatureSecret(secret); err != nil {
+			return wrap(wrap(err, keyID), "keys")
+		}
+	}
+	if c.MaxSkew <= 0 {
+		return newError("maxSkew", "must be positive")
+	}
+	if c.PathPrefix != "" && (!strings.HasPrefix(c.PathPrefix, "/") || strings.HasSuffix(c.PathPrefix, "/")) {
+		return newError("pathPrefix", "must start with a / and must not end with a /")
+	}
+	return nil
+}
+
+func validateSignatureSecret(secret string) error {
+	if len(secret) < 32 {
+		return newError("secret", "the secret must be at least 32 characters long")
+	}
+	return nil
 }
 
 // HTTPClientCerts is a structure that holds the client certificates after successfully calling ValidateWithCerts
@@ -350,6 +421,10 @@ func (c *HTTPClientConfiguration) ValidateWithCerts() (*HTTPClientCerts, error)
 		return nil, err
 	}
 
+	if err := c.Signature.Validate(); err != nil {
+		return nil, wrap(err, "signature")
+	}
+
 	if strings.HasPrefix(c.URL, "https://") {
 		if err := c.TLSVersion.Validate(); err != nil {
 			return nil, wrap(err, "tlsVersion")
@@ -436,6 +511,9 @@ type HTTPServerConfiguration struct {
 
 	// CipherSuites is a list of supported cipher suites.
 	CipherSuites CipherSuiteList `json:"cipher" yaml:"cipher" default:"[\"TLS_AES_128_GCM_SHA256\",\"TLS_AES_256_GCM_SHA384\",\"TLS_CHACHA20_POLY1305_SHA256\",\"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256\",\"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256\"]"`
+
+	// ProxyProtocol configures the PROXY protocol for servers running behind a load balancer.
+	ProxyProtocol ProxyProtocolConfig `json:"proxyProtocol" yaml:"proxyProtocol"`
 }
 
 func (config *HTTPServerConfiguration) Validate() error {
@@ -466,6 +544,10 @@ func (config *HTTPServerConfiguration) ValidateWithCerts() (*HTTPServerCerts, er
 		return nil, fmt.Errorf("key provided without certificate")
 	}
 
+	if err := config.ProxyProtocol.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol configuration (%w)", err)
+	}
+
 	result := &HTTPServerCerts{}
 
 	if config.Cert != "" && config.Key != "" {
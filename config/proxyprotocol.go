@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ProxyProtocolConfig configures the PROXY protocol (v1 and v2) on a listener. When enabled, connections from
+// trusted proxies must start with a PROXY protocol header and the address in the header is used as the remote
+// address of the connection. Connections from other addresses are treated as direct connections.
+type ProxyProtocolConfig struct {
+	// Enable enables parsing the PROXY protocol header on connections from trusted proxies.
+	Enable bool `json:"enable" yaml:"enable" comment:"Enable the PROXY protocol for connections from trusted proxies."`
+	// TrustedProxies is a list of IP addresses or CIDR ranges of load balancers allowed to send a PROXY protocol
+	// header.
+	TrustedProxies []string `json:"trustedProxies" yaml:"trustedProxies" comment:"IP addresses or CIDR ranges of trusted proxies."`
+	// HeaderTimeout is the maximum time to wait for the PROXY protocol header after the connection is accepted.
+	// A value of 0 disables the timeout.
+	HeaderTimeout time.Duration `json:"headerTimeout" yaml:"headerTimeout" default:"5s" comment:"Time to wait for the PROXY protocol header."`
+}
+
+// Validate validates the PROXY protocol configuration.
+func (c ProxyProtocolConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if len(c.TrustedProxies) == 0 {
+		return newError("trustedProxies", "at least one trusted proxy is required when the PROXY protocol is enabled")
+	}
+	if _, err := c.ParseTrustedProxies(); err != nil {
+		return wrap(err, "trustedProxies")
+	}
+	if c.HeaderTimeout < 0 {
+		return newError("headerTimeout", "headerTimeout must not be negative")
+	}
+	return nil
+}
+
+// ParseTrustedProxies returns the trusted proxies as a list of networks. Single IP addresses are returned as a
+// network containing only that address.
+func (c ProxyProtocolConfig) ParseTrustedProxies() ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, len(c.TrustedProxies))
+	for i, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", proxy)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			result[i] = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy range: %s (%w)", proxy, err)
+		}
+		result[i] = ipNet
+	}
+	return result, nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 // SecurityConfig is the configuration structure for security settings.
@@ -35,6 +36,12 @@ type SecurityConfig struct {
 	// Signal configures how to handle signal requests to running programs.
 	Signal SecuritySignalConfig `json:"signal" yaml:"signal"`
 
+	// Break configures how to handle break requests (RFC 4335) to the terminal of running programs.
+	Break SecurityBreakConfig `json:"break" yaml:"break"`
+
+	// Session configures the idle timeout and the maximum duration of connections.
+	Session SecuritySessionConfig `json:"session" yaml:"session"`
+
 	// MaxSessions drives how many session channels can be open at the same time for a single network connection.
 	// -1 means unlimited. It is strongly recommended to configure this to a sane value, e.g. 10.
 	MaxSessions int `json:"maxSessions" yaml:"maxSessions" default:"-1"`
@@ -63,6 +70,12 @@ func (c SecurityConfig) Validate() error {
 	if err := c.Signal.Validate(); err != nil {
 		return wrap(err, "signal")
 	}
+	if err := c.Break.Validate(); err != nil {
+		return wrap(err, "break")
+	}
+	if err := c.Session.Validate(); err != nil {
+		return wrap(err, "session")
+	}
 	if c.MaxSessions < -1 {
 		return newError("maxSessions", "invalid maxSessions setting: %d", c.MaxSessions)
 	}
@@ -154,6 +167,10 @@ type ForwardingConfig struct {
 
 	// X11forwardingMode configures how to treat X11 forwarding requests from the container to the client
 	X11ForwardingMode SecurityExecutionPolicy `json:"x11ForwardingMode" yaml:"x11ForwardingMode" default:"disable"`
+
+	// AgentForwardingMode configures how to treat SSH agent forwarding requests from the client. When enabled, the
+	// agent of the client is made available in the container via SSH_AUTH_SOCK.
+	AgentForwardingMode SecurityExecutionPolicy `json:"agentForwardingMode" yaml:"agentForwardingMode" default:"disable"`
 }
 
 func (f ForwardingConfig) Validate() error {
@@ -172,6 +189,9 @@ func (f ForwardingConfig) Validate() error {
 	if err := f.X11ForwardingMode.Validate(); err != nil {
 		return fmt.Errorf("invalid mode (%w)", err)
 	}
+	if err := f.AgentForwardingMode.Validate(); err != nil {
+		return fmt.Errorf("invalid mode (%w)", err)
+	}
 	return nil
 }
 
@@ -239,3 +259,55 @@ func (e SecurityExecutionPolicy) Validate() error {
 	}
 	return nil
 }
+
+// SecurityBreakConfig configures how break requests are treated.
+type SecurityBreakConfig struct {
+	// Mode configures how to treat break requests to the terminal of running programs. Filtering is not supported for
+	// break requests and rejects them.
+	Mode SecurityExecutionPolicy `json:"mode" yaml:"mode" default:""`
+}
+
+// Validate validates the break configuration.
+func (b SecurityBreakConfig) Validate() error {
+	if err := b.Mode.Validate(); err != nil {
+		return wrap(err, "mode")
+	}
+	return nil
+}
+
+// SecuritySessionRemainingPlaceholder is replaced with the time remaining until the connection is closed in the
+// session warning messages.
+const SecuritySessionRemainingPlaceholder = "{remaining}"
+
+// SecuritySessionConfig configures when connections are closed regardless of what the user is doing. Only the input
+// and output of session channels counts as activity, forwarded connections do not.
+type SecuritySessionConfig struct {
+	// IdleTimeout closes the connection when there was no input or output on any session for this time. 0 disables
+	// the idle timeout.
+	IdleTimeout time.Duration `json:"idleTimeout" yaml:"idleTimeout" comment:"Close the connection after no activity for this time"`
+	// MaxDuration closes the connection this time after the user logged in. 0 disables the limit.
+	MaxDuration time.Duration `json:"maxDuration" yaml:"maxDuration" comment:"Close the connection after this time"`
+	// WarningBefore is the time before closing the connection when the warning is written to the interactive
+	// sessions. 0 disables the warning.
+	WarningBefore time.Duration `json:"warningBefore" yaml:"warningBefore" default:"1m" comment:"Warn the user this time before closing the connection"`
+	// IdleWarning is written to the terminal before the idle timeout is reached. {remaining} is replaced with the
+	// time left.
+	IdleWarning string `json:"idleWarning" yaml:"idleWarning" default:"This session has been idle and will be closed in {remaining}."`
+	// MaxDurationWarning is written to the terminal before the maximum session duration is reached. {remaining} is
+	// replaced with the time left.
+	MaxDurationWarning string `json:"maxDurationWarning" yaml:"maxDurationWarning" default:"This session reached the maximum duration and will be closed in {remaining}. Please save your work."`
+}
+
+// Validate validates the session configuration.
+func (s SecuritySessionConfig) Validate() error {
+	if s.IdleTimeout < 0 {
+		return newError("idleTimeout", "cannot be negative")
+	}
+	if s.MaxDuration < 0 {
+		return newError("maxDuration", "cannot be negative")
+	}
+	if s.WarningBefore < 0 {
+		return newError("warningBefore", "cannot be negative")
+	}
+	return nil
+}
@@ -8,9 +8,12 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -18,8 +21,12 @@ import (
 
 // SSHConfig is the base configuration structure of the SSH server.
 type SSHConfig struct {
-	// Listen is the listen address for the SSH server
+	// Listen is the listen address for the SSH server. This listener is named "default". It can be set to an empty
+	// string if only the listeners in Listeners should be used.
 	Listen string `json:"listen" yaml:"listen" default:"0.0.0.0:2222"`
+	// Listeners are additional listeners, for example on different addresses, Unix sockets or sockets passed by
+	// systemd. Each listener can override the host keys and banner.
+	Listeners []SSHListenerConfig `json:"listeners" yaml:"listeners" comment:"Additional listeners"`
 	// ServerVersion is the version sent to the client.
 	//               Must be in the format of "SSH-protoversion-softwareversion SPACE comments".
 	//               See https://tools.ietf.org/html/rfc4253#page-4 section 4.2. Protocol Version Exchange
@@ -31,10 +38,26 @@ type SSHConfig struct {
 	KexAlgorithms SSHKexList `json:"kex" yaml:"kex" default:"[\"curve25519-sha256@libssh.org\",\"ecdh-sha2-nistp521\",\"ecdh-sha2-nistp384\",\"ecdh-sha2-nistp256\"]" comment:"Key exchange algorithms to use"`
 	// MACs are the SSHMAC algorithms offered to the client.
 	MACs SSHMACList `json:"macs" yaml:"macs" default:"[\"hmac-sha2-256-etm@openssh.com\",\"hmac-sha2-256\"]" comment:"SSHMAC algorithms to use"`
-	// Banner is the banner sent to the client on connecting.
+	// Banner is the banner sent to the client on connecting. It is sent as is unless BannerTemplate is enabled.
 	Banner string `json:"banner" yaml:"banner" comment:"Host banner to show after the username" default:""`
+	// BannerTemplate renders the banner and the listener banners as Go templates that can use the fields of
+	// sshserver.BannerData, for example {{ .RemoteAddress }} or {{ .Country }}. It is off by default so existing
+	// banners containing {{ are not changed.
+	BannerTemplate bool `json:"bannerTemplate" yaml:"bannerTemplate" comment:"Render the banners as templates" default:"false"`
+	// MOTD is the message of the day written to the standard error when a shell starts. It is a Go template that can
+	// use the fields of backend.MOTDData, for example {{ .Username }}. This option can be changed from the config
+	// server.
+	MOTD string `json:"motd" yaml:"motd" comment:"Message of the day template to show when a shell starts" default:""`
 	// HostKeys are the host keys either in PEM format, or filenames to load.
 	HostKeys []string `json:"hostkeys" yaml:"hostkeys" comment:"Host keys in PEM format or files to load PEM host keys from."`
+	// HostCertificates are OpenSSH host certificates for the host keys, either inline in the authorized_keys format
+	// or filenames to load. Each certificate is matched to the host key it was issued for and is presented in
+	// addition to the plain host key.
+	HostCertificates []string `json:"hostcertificates" yaml:"hostcertificates" comment:"Host certificates in OpenSSH format or files to load host certificates from."`
+	// StagedHostKeys are the next host keys in PEM format, or filenames to load. They are not used for the handshake,
+	// but are advertised to OpenSSH clients after authentication so clients with UpdateHostKeys enabled learn them
+	// before they replace the current host keys.
+	StagedHostKeys []string `json:"stagedhostkeys" yaml:"stagedhostkeys" comment:"Next host keys to advertise to clients for key rotation."`
 	// ClientAliveInterval is the duration between keep alive messages that
 	// ContainerSSH will send to each client. If the duration is 0 or unset
 	// it disables the feature.
@@ -43,6 +66,13 @@ type SSHConfig struct {
 	// allowed to be sent without a response being received. If this number
 	// is exceeded the connection is considered dead
 	ClientAliveCountMax int `json:"clientAliveCountMax" yaml:"clientAliveCountMax" default:"3" comment:"Maximum number of failed keepalives"`
+	// Limits configures the connection limits applied to incoming connections before the SSH handshake.
+	Limits SSHLimitsConfig `json:"limits" yaml:"limits" comment:"Connection limits"`
+	// ProxyProtocol configures the PROXY protocol for running the SSH server behind a load balancer.
+	ProxyProtocol ProxyProtocolConfig `json:"proxyProtocol" yaml:"proxyProtocol" comment:"PROXY protocol configuration"`
+	// Drain configures how long connections may continue and how users are warned when the server is drained before
+	// a shutdown.
+	Drain SSHDrainConfig `json:"drain" yaml:"drain" comment:"Drain mode configuration"`
 }
 
 // GenerateHostKey generates a random host key and adds it to SSHConfig
@@ -67,9 +97,20 @@ func (cfg *SSHConfig) GenerateHostKey() error {
 	return nil
 }
 
+// LoadHostKeys loads the configured host keys. If host certificates are configured, a certificate signer is returned
+// for each certificate after the plain host keys.
 func (cfg *SSHConfig) LoadHostKeys() ([]ssh.Signer, error) {
+	return loadHostKeys(cfg.HostKeys, cfg.HostCertificates)
+}
+
+// LoadStagedHostKeys loads the staged host keys that are advertised to clients, but not used for the handshake.
+func (cfg *SSHConfig) LoadStagedHostKeys() ([]ssh.Signer, error) {
+	return loadHostKeys(cfg.StagedHostKeys, nil)
+}
+
+func loadHostKeys(hostKeyList []string, hostCertificates []string) ([]ssh.Signer, error) {
 	var hostKeys []ssh.Signer
-	for index, hostKey := range cfg.HostKeys {
+	for index, hostKey := range hostKeyList {
 		if strings.TrimSpace(hostKey)[:5] != "-----" {
 			// We are deliberalely loading a dynamic file here.
 			fh, err := os.Open(hostKey) //nolint:gosec
@@ -97,7 +138,64 @@ func (cfg *SSHConfig) LoadHostKeys() ([]ssh.Signer, error) {
 		}
 		hostKeys = append(hostKeys, private)
 	}
-	return hostKeys, nil
+	certSigners, err := loadHostCertificates(hostCertificates, hostKeys)
+	if err != nil {
+		return nil, err
+	}
+	return append(hostKeys, certSigners...), nil
+}
+
+func loadHostCertificates(hostCertificates []string, hostKeys []ssh.Signer) ([]ssh.Signer, error) {
+	var certSigners []ssh.Signer
+	for index, hostCert := range hostCertificates {
+		certData := []byte(hostCert)
+		if _, _, _, _, err := ssh.ParseAuthorizedKey(certData); err != nil {
+			// We are deliberately loading a dynamic file here.
+			certData, err = os.ReadFile(hostCert) //nolint:gosec
+			if err != nil {
+				return nil, fmt.Errorf("failed to load host certificate %s (%w)", hostCert, err)
+			}
+		}
+		pubKey, _, _, _, err := ssh.ParseAuthorizedKey(certData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse host certificate %d (%w)", index, err)
+		}
+		cert, ok := pubKey.(*ssh.Certificate)
+		if !ok {
+			return nil, fmt.Errorf("host certificate %d is a plain public key, not a certificate", index)
+		}
+		if cert.CertType != ssh.HostCert {
+			return nil, fmt.Errorf("host certificate %d (key ID %s) is not a host certificate", index, cert.KeyId)
+		}
+		if cert.ValidBefore != ssh.CertTimeInfinity && time.Now().Unix() >= int64(cert.ValidBefore) { //nolint:gosec
+			return nil, fmt.Errorf(
+				"host certificate %d (key ID %s) expired at %s",
+				index,
+				cert.KeyId,
+				time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.RFC3339), //nolint:gosec
+			)
+		}
+		var hostKey ssh.Signer
+		for _, key := range hostKeys {
+			if bytes.Equal(key.PublicKey().Marshal(), cert.Key.Marshal()) {
+				hostKey = key
+				break
+			}
+		}
+		if hostKey == nil {
+			return nil, fmt.Errorf(
+				"host certificate %d (key ID %s) does not match any of the configured host keys",
+				index,
+				cert.KeyId,
+			)
+		}
+		certSigner, err := ssh.NewCertSigner(cert, hostKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to use host certificate %d (%w)", index, err)
+		}
+		certSigners = append(certSigners, certSigner)
+	}
+	return certSigners, nil
 }
 
 // Validate validates the configuration and returns an error if invalid.
@@ -114,12 +212,60 @@ func (cfg SSHConfig) Validate() error {
 	if err := cfg.MACs.Validate(); err != nil {
 		return wrap(err, "macs")
 	}
+	if cfg.BannerTemplate {
+		if err := validateTemplate(cfg.Banner); err != nil {
+			return wrap(err, "banner")
+		}
+	}
+	if err := validateTemplate(cfg.MOTD); err != nil {
+		return wrap(err, "motd")
+	}
 	if cfg.ClientAliveInterval != 0 && cfg.ClientAliveInterval < 1*time.Second {
 		return newError("clientAliveInterval", "clientAliveInterval should be at least 1 second long")
 	}
 	if cfg.ClientAliveCountMax <= 0 {
 		return newError("clientAliveCountMax", "clientAliveCountMax should be at least 1")
 	}
+	if err := cfg.Limits.Validate(); err != nil {
+		return wrap(err, "limits")
+	}
+	if err := cfg.ProxyProtocol.Validate(); err != nil {
+		return wrap(err, "proxyProtocol")
+	}
+	if err := cfg.Drain.Validate(); err != nil {
+		return wrap(err, "drain")
+	}
+	if cfg.Listen == "" && len(cfg.Listeners) == 0 {
+		return newError("listen", "no listen address or listeners configured")
+	}
+	listenerNames := map[string]struct{}{}
+	if cfg.Listen != "" {
+		listenerNames[SSHDefaultListenerName] = struct{}{}
+	}
+	for i, listener := range cfg.Listeners {
+		if err := listener.Validate(); err != nil {
+			return wrap(err, fmt.Sprintf("listeners[%d]", i))
+		}
+		if cfg.BannerTemplate {
+			if err := validateTemplate(listener.Banner); err != nil {
+				return wrap(err, fmt.Sprintf("listeners[%d].banner", i))
+			}
+		}
+		if _, ok := listenerNames[listener.Name]; ok {
+			return newError(fmt.Sprintf("listeners[%d].name", i), "duplicate listener name: %s", listener.Name)
+		}
+		listenerNames[listener.Name] = struct{}{}
+	}
+	if len(cfg.HostCertificates) > 0 {
+		if _, err := cfg.LoadHostKeys(); err != nil {
+			return wrap(err, "hostcertificates")
+		}
+	}
+	if len(cfg.StagedHostKeys) > 0 {
+		if _, err := cfg.LoadStagedHostKeys(); err != nil {
+			return wrap(err, "stagedhostkeys")
+		}
+	}
 	return nil
 }
 
@@ -417,3 +563,272 @@ func (s SSHServerVersion) Validate() error {
 func (s SSHServerVersion) String() string {
 	return string(s)
 }
+
+// SSHLimitsConfig configures limits on incoming connections. A value of 0 disables the respective limit.
+type SSHLimitsConfig struct {
+	// MaxConnections is the maximum number of concurrent connections to the SSH server.
+	MaxConnections int `json:"maxConnections" yaml:"maxConnections" comment:"Maximum number of concurrent connections"`
+	// MaxConnectionsPerSource is the maximum number of concurrent connections from a single source network. The
+	// source network is determined by IPv4PrefixLength and IPv6PrefixLength.
+	MaxConnectionsPerSource int `json:"maxConnectionsPerSource" yaml:"maxConnectionsPerSource" comment:"Maximum number of concurrent connections per source network"`
+	// ConnectionRate is the number of new connections per second allowed from a single source network. Connections
+	// are counted using a token bucket that holds ConnectionBurst tokens.
+	ConnectionRate float64 `json:"connectionRate" yaml:"connectionRate" comment:"New connections per second per source network"`
+	// ConnectionBurst is the number of connections a single source network can open at once before ConnectionRate
+	// applies. Defaults to the rounded up ConnectionRate if not set.
+	ConnectionBurst int `json:"connectionBurst" yaml:"connectionBurst" comment:"Burst of new connections per source network"`
+	// MaxStartups limits the number of concurrent unauthenticated connections, similar to the OpenSSH option of the
+	// same name. It can be a single number, or "start:rate:full", in which case connections are dropped with a
+	// probability of rate percent once there are start unauthenticated connections, increasing linearly until all
+	// connections are dropped at full.
+	MaxStartups SSHMaxStartups `json:"maxStartups" yaml:"maxStartups" comment:"Maximum number of unauthenticated connections"`
+	// IPv4PrefixLength is the prefix length used to group IPv4 addresses into source networks.
+	IPv4PrefixLength int `json:"ipv4PrefixLength" yaml:"ipv4PrefixLength" default:"32" comment:"Prefix length to group IPv4 sources by"`
+	// IPv6PrefixLength is the prefix length used to group IPv6 addresses into source networks.
+	IPv6PrefixLength int `json:"ipv6PrefixLength" yaml:"ipv6PrefixLength" default:"64" comment:"Prefix length to group IPv6 sources by"`
+	// MaxConnectionsPerUser is the maximum number of concurrent authenticated connections per username.
+	MaxConnectionsPerUser int `json:"maxConnectionsPerUser" yaml:"maxConnectionsPerUser" comment:"Maximum number of concurrent connections per user"`
+	// MaxContainersPerUser is the maximum number of concurrent backend containers per username. In connection
+	// execution mode every connection runs one container, in session execution mode every session does.
+	MaxContainersPerUser int `json:"maxContainersPerUser" yaml:"maxContainersPerUser" comment:"Maximum number of concurrent containers per user"`
+	// GroupMetadataKey is the metadata key returned by the authentication server that users are grouped by for
+	// MaxConnectionsPerGroup and MaxContainersPerGroup, for example "team". Users without this metadata key are not
+	// subject to group limits.
+	GroupMetadataKey string `json:"groupMetadataKey" yaml:"groupMetadataKey" comment:"Metadata key to group users by"`
+	// MaxConnectionsPerGroup is the maximum number of concurrent authenticated connections per group.
+	MaxConnectionsPerGroup int `json:"maxConnectionsPerGroup" yaml:"maxConnectionsPerGroup" comment:"Maximum number of concurrent connections per group"`
+	// MaxContainersPerGroup is the maximum number of concurrent backend containers per group.
+	MaxContainersPerGroup int `json:"maxContainersPerGroup" yaml:"maxContainersPerGroup" comment:"Maximum number of concurrent containers per group"`
+}
+
+// Validate validates the connection limits configuration.
+func (l SSHLimitsConfig) Validate() error {
+	if l.MaxConnections < 0 {
+		return newError("maxConnections", "maxConnections must not be negative")
+	}
+	if l.MaxConnectionsPerSource < 0 {
+		return newError("maxConnectionsPerSource", "maxConnectionsPerSource must not be negative")
+	}
+	if l.ConnectionRate < 0 {
+		return newError("connectionRate", "connectionRate must not be negative")
+	}
+	if l.ConnectionBurst < 0 {
+		return newError("connectionBurst", "connectionBurst must not be negative")
+	}
+	if err := l.MaxStartups.Validate(); err != nil {
+		return wrap(err, "maxStartups")
+	}
+	if l.IPv4PrefixLength < 0 || l.IPv4PrefixLength > 32 {
+		return newError("ipv4PrefixLength", "ipv4PrefixLength must be between 0 and 32")
+	}
+	if l.IPv6PrefixLength < 0 || l.IPv6PrefixLength > 128 {
+		return newError("ipv6PrefixLength", "ipv6PrefixLength must be between 0 and 128")
+	}
+	if l.MaxConnectionsPerUser < 0 {
+		return newError("maxConnectionsPerUser", "maxConnectionsPerUser must not be negative")
+	}
+	if l.MaxContainersPerUser < 0 {
+		return newError("maxContainersPerUser", "maxContainersPerUser must not be negative")
+	}
+	if l.MaxConnectionsPerGroup < 0 {
+		return newError("maxConnectionsPerGroup", "maxConnectionsPerGroup must not be negative")
+	}
+	if l.MaxContainersPerGroup < 0 {
+		return newError("maxContainersPerGroup", "maxContainersPerGroup must not be negative")
+	}
+	if (l.MaxConnectionsPerGroup > 0 || l.MaxContainersPerGroup > 0) && l.GroupMetadataKey == "" {
+		return newError(
+			"groupMetadataKey",
+			"groupMetadataKey must be set if maxConnectionsPerGroup or maxContainersPerGroup is set",
+		)
+	}
+	return nil
+}
+
+// validateTemplate checks if a banner or message of the day template can be parsed.
+func validateTemplate(tpl string) error {
+	if _, err := template.New("").Parse(tpl); err != nil {
+		return fmt.Errorf("invalid template (%w)", err)
+	}
+	return nil
+}
+
+// SSHMaxStartups is the specification for the maximum number of unauthenticated connections in the OpenSSH
+// "start:rate:full" or "full" format.
+type SSHMaxStartups string
+
+// Parse returns the start, rate and full values. If no limit is set, all values are 0.
+func (m SSHMaxStartups) Parse() (start int, rate int, full int, err error) {
+	if m == "" {
+		return 0, 0, 0, nil
+	}
+	parts := strings.Split(string(m), ":")
+	switch len(parts) {
+	case 1:
+		full, err = strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid maxStartups value: %s (%w)", m, err)
+		}
+		start = full
+		rate = 100
+	case 3:
+		values := make([]int, 3)
+		for i, part := range parts {
+			values[i], err = strconv.Atoi(part)
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("invalid maxStartups value: %s (%w)", m, err)
+			}
+		}
+		start, rate, full = values[0], values[1], values[2]
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid maxStartups value: %s, expected start:rate:full", m)
+	}
+	if start <= 0 || full < start || rate < 0 || rate > 100 {
+		return 0, 0, 0, fmt.Errorf(
+			"invalid maxStartups value: %s, start must be positive, full must not be lower than start and rate must be between 0 and 100",
+			m,
+		)
+	}
+	return start, rate, full, nil
+}
+
+// Validate checks if the maxStartups specification is valid.
+func (m SSHMaxStartups) Validate() error {
+	_, _, _, err := m.Parse()
+	return err
+}
+
+// SSHDefaultListenerName is the name of the listener configured in the listen option.
+const SSHDefaultListenerName = "default"
+
+// SSHListenerType is the type of socket an SSH listener uses.
+type SSHListenerType string
+
+const (
+	// SSHListenerTypeTCP listens on a TCP host:port address.
+	SSHListenerTypeTCP SSHListenerType = "tcp"
+	// SSHListenerTypeUnix listens on a Unix domain socket. An existing socket file at the path is replaced. Clients
+	// connecting through it have the remote address "unix" without an IP, so per-source limits and lockouts do not
+	// apply to them and IP restrictions such as from= or source-address never match.
+	SSHListenerTypeUnix SSHListenerType = "unix"
+	// SSHListenerTypeSystemd uses sockets passed by systemd socket activation (LISTEN_FDS). The listen option
+	// selects the sockets by their FileDescriptorName, or all passed sockets if empty.
+	SSHListenerTypeSystemd SSHListenerType = "systemd"
+)
+
+// Validate checks if the listener type is valid.
+func (t SSHListenerType) Validate() error {
+	switch t {
+	case "", SSHListenerTypeTCP, SSHListenerTypeUnix, SSHListenerTypeSystemd:
+		return nil
+	default:
+		return fmt.Errorf("invalid listener type: %s", t)
+	}
+}
+
+// SSHListenerConfig configures an additional listener for the SSH server.
+type SSHListenerConfig struct {
+	// Name identifies the listener. It is passed to the authentication and configuration servers in the connection
+	// metadata.
+	Name string `json:"name" yaml:"name" comment:"Name of the listener, passed in the connection metadata."`
+	// Type is the socket type of the listener. Defaults to tcp.
+	Type SSHListenerType `json:"type" yaml:"type" default:"tcp" comment:"Listener type: tcp, unix, or systemd."`
+	// Listen is the host:port address for TCP listeners, the socket path for Unix listeners, and the
+	// FileDescriptorName for systemd listeners.
+	Listen string `json:"listen" yaml:"listen" comment:"Address, socket path, or systemd socket name to listen on."`
+	// HostKeys overrides the host keys of the server for this listener. Keys are in PEM format or filenames to load.
+	HostKeys []string `json:"hostkeys" yaml:"hostkeys" comment:"Host keys for this listener, if different from the global host keys."`
+	// HostCertificates overrides the host certificates of the server for this listener.
+	HostCertificates []string `json:"hostcertificates" yaml:"hostcertificates" comment:"Host certificates for this listener."`
+	// StagedHostKeys overrides the staged host keys of the server for this listener. They are only used if the
+	// listener has its own host keys.
+	StagedHostKeys []string `json:"stagedhostkeys" yaml:"stagedhostkeys" comment:"Next host keys to advertise for this listener."`
+	// Banner overrides the banner of the server for this listener. It is rendered as a template if BannerTemplate is
+	// enabled on the server.
+	Banner string `json:"banner" yaml:"banner" comment:"Banner for this listener, if different from the global banner."`
+}
+
+// Validate validates the listener configuration.
+func (l SSHListenerConfig) Validate() error {
+	if l.Name == "" {
+		return newError("name", "listener name is required")
+	}
+	if err := l.Type.Validate(); err != nil {
+		return wrap(err, "type")
+	}
+	switch l.Type {
+	case "", SSHListenerTypeTCP:
+		if _, _, err := net.SplitHostPort(l.Listen); err != nil {
+			return wrapWithMessage(err, "listen", "invalid listen address: %s", l.Listen)
+		}
+	case SSHListenerTypeUnix:
+		if l.Listen == "" {
+			return newError("listen", "socket path is required for unix listeners")
+		}
+	}
+	if len(l.HostKeys) == 0 && len(l.HostCertificates) > 0 {
+		return newError("hostcertificates", "host certificates can only be set together with host keys")
+	}
+	if len(l.HostKeys) > 0 {
+		if _, err := l.LoadHostKeys(nil, nil); err != nil {
+			return wrap(err, "hostkeys")
+		}
+	}
+	if len(l.HostKeys) == 0 && len(l.StagedHostKeys) > 0 {
+		return newError("stagedhostkeys", "staged host keys can only be set together with host keys")
+	}
+	if len(l.StagedHostKeys) > 0 {
+		if _, err := l.LoadStagedHostKeys(nil); err != nil {
+			return wrap(err, "stagedhostkeys")
+		}
+	}
+	return nil
+}
+
+// LoadHostKeys loads the host keys for this listener. If the listener has no host keys of its own, the default host
+// keys and certificates passed are used.
+func (l SSHListenerConfig) LoadHostKeys(defaultHostKeys []string, defaultHostCertificates []string) (
+	[]ssh.Signer,
+	error,
+) {
+	if len(l.HostKeys) == 0 {
+		return loadHostKeys(defaultHostKeys, defaultHostCertificates)
+	}
+	return loadHostKeys(l.HostKeys, l.HostCertificates)
+}
+
+// LoadStagedHostKeys loads the staged host keys for this listener. If the listener has no host keys of its own, the
+// default staged host keys passed are used.
+func (l SSHListenerConfig) LoadStagedHostKeys(defaultStagedHostKeys []string) ([]ssh.Signer, error) {
+	if len(l.HostKeys) == 0 {
+		return loadHostKeys(defaultStagedHostKeys, nil)
+	}
+	return loadHostKeys(l.StagedHostKeys, nil)
+}
+
+// SSHDrainRemainingPlaceholder is replaced with the time remaining until shutdown in the drain message.
+const SSHDrainRemainingPlaceholder = "{remaining}"
+
+// SSHDrainConfig configures drain mode. When draining, the SSH server stops accepting new connections and warns the
+// users of interactive sessions before the remaining connections are closed.
+type SSHDrainConfig struct {
+	// Timeout is the time existing connections can continue after draining started. The server shuts down when the
+	// timeout expires or when all connections are closed, whichever comes first.
+	Timeout time.Duration `json:"timeout" yaml:"timeout" default:"10m" comment:"Time to wait for connections to close before shutting down"`
+	// WarningInterval is the interval at which the message is repeated. If it is 0 the message is only sent once.
+	WarningInterval time.Duration `json:"warningInterval" yaml:"warningInterval" default:"1m" comment:"Interval to repeat the drain message at"`
+	// Message is written to the stderr of interactive sessions. The text {remaining} is replaced with the time
+	// remaining until shutdown. If it is empty no message is sent.
+	Message string `json:"message" yaml:"message" default:"This server is shutting down in {remaining}. Please save your work and log out." comment:"Message to write to interactive sessions"`
+}
+
+// Validate validates the drain configuration.
+func (d SSHDrainConfig) Validate() error {
+	if d.Timeout < 0 {
+		return newError("timeout", "timeout must not be negative")
+	}
+	if d.WarningInterval != 0 && d.WarningInterval < time.Second {
+		return newError("warningInterval", "warningInterval should be at least 1 second long")
+	}
+	return nil
+}
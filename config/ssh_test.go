@@ -0,0 +1,149 @@
+package config_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/structutils"
+	"golang.org/x/crypto/ssh"
+)
+
+func newHostCertificate(t *testing.T, hostKey ssh.PublicKey, certType uint32, validBefore time.Time) string {
+	_, caPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ssh.NewSignerFromKey(caPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:             hostKey,
+		CertType:        certType,
+		KeyId:           "host.example.com",
+		ValidPrincipals: []string{"host.example.com"},
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return string(ssh.MarshalAuthorizedKey(cert))
+}
+
+func newHostKeyConfig(t *testing.T) (config.SSHConfig, ssh.PublicKey) {
+	cfg := config.SSHConfig{}
+	structutils.Defaults(&cfg)
+	if err := cfg.GenerateHostKey(); err != nil {
+		t.Fatal(err)
+	}
+	hostKeys, err := cfg.LoadHostKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, hostKeys[0].PublicKey()
+}
+
+func TestHostCertificate(t *testing.T) {
+	cfg, hostKey := newHostKeyConfig(t)
+	cfg.HostCertificates = []string{
+		newHostCertificate(t, hostKey, ssh.HostCert, time.Now().Add(time.Hour)),
+	}
+	assert.NoError(t, cfg.Validate())
+
+	hostKeys, err := cfg.LoadHostKeys()
+	assert.NoError(t, err)
+	assert.Len(t, hostKeys, 2)
+	assert.Equal(t, ssh.CertAlgoRSAv01, hostKeys[1].PublicKey().Type())
+}
+
+func TestHostCertificateInvalid(t *testing.T) {
+	cfg, hostKey := newHostKeyConfig(t)
+	_, otherKey := newHostKeyConfig(t)
+
+	for name, cert := range map[string]string{
+		"expired":      newHostCertificate(t, hostKey, ssh.HostCert, time.Now().Add(-time.Hour)),
+		"user cert":    newHostCertificate(t, hostKey, ssh.UserCert, time.Now().Add(time.Hour)),
+		"key mismatch": newHostCertificate(t, otherKey, ssh.HostCert, time.Now().Add(time.Hour)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.HostCertificates = []string{cert}
+			assert.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestStagedHostKeys(t *testing.T) {
+	cfg, _ := newHostKeyConfig(t)
+	staged, stagedKey := newHostKeyConfig(t)
+	cfg.StagedHostKeys = staged.HostKeys
+	assert.NoError(t, cfg.Validate())
+
+	stagedHostKeys, err := cfg.LoadStagedHostKeys()
+	assert.NoError(t, err)
+	assert.Len(t, stagedHostKeys, 1)
+	assert.Equal(t, stagedKey.Marshal(), stagedHostKeys[0].PublicKey().Marshal())
+
+	cfg.StagedHostKeys = []string{"not a key"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.StagedHostKeys = nil
+	cfg.Listeners = []config.SSHListenerConfig{
+		{Name: "internal", Listen: "127.0.0.1:2223", StagedHostKeys: staged.HostKeys},
+	}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestListeners(t *testing.T) {
+	cfg, _ := newHostKeyConfig(t)
+	cfg.Listeners = []config.SSHListenerConfig{
+		{Name: "internal", Listen: "127.0.0.1:2223"},
+		{Name: "local", Type: config.SSHListenerTypeUnix, Listen: "/run/containerssh.sock"},
+		{Name: "activated", Type: config.SSHListenerTypeSystemd},
+	}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Listen = ""
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Listeners = nil
+	assert.Error(t, cfg.Validate())
+}
+
+func TestListenersInvalid(t *testing.T) {
+	cfg, _ := newHostKeyConfig(t)
+	for name, listener := range map[string]config.SSHListenerConfig{
+		"no name":       {Listen: "127.0.0.1:2223"},
+		"reserved name": {Name: config.SSHDefaultListenerName, Listen: "127.0.0.1:2223"},
+		"invalid type":  {Name: "foo", Type: "udp", Listen: "127.0.0.1:2223"},
+		"invalid tcp":   {Name: "foo", Listen: "127.0.0.1"},
+		"no unix path":  {Name: "foo", Type: config.SSHListenerTypeUnix},
+		"cert only":     {Name: "foo", Listen: "127.0.0.1:2223", HostCertificates: []string{"foo"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg.Listeners = []config.SSHListenerConfig{listener}
+			assert.Error(t, cfg.Validate())
+		})
+	}
+}
+
+func TestBannerTemplate(t *testing.T) {
+	cfg, _ := newHostKeyConfig(t)
+	cfg.Banner = "{{ legacy banner }}"
+	cfg.Listeners = []config.SSHListenerConfig{
+		{Name: "local", Type: config.SSHListenerTypeUnix, Listen: "/run/containerssh.sock", Banner: "{{ local }}"},
+	}
+	assert.NoError(t, cfg.Validate(), "banners are not parsed as templates unless enabled")
+
+	cfg.BannerTemplate = true
+	assert.Error(t, cfg.Validate())
+
+	cfg.Banner = "Welcome {{ .Username }}"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Listeners[0].Banner = "Welcome to {{ .Listener }}"
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,45 @@
+package config
+
+// ThrottleConfig configures bandwidth limits. Upload is the data sent by the client, download is the data sent to the
+// client. Rates are in bytes per second, a value of 0 disables the respective limit.
+type ThrottleConfig struct {
+	// Session limits the bandwidth of each session channel, including SFTP and other subsystems.
+	Session ThrottleRateConfig `json:"session" yaml:"session" comment:"Bandwidth limits per session channel"`
+	// Forward limits the bandwidth of each port, socket, X11 or agent forwarding channel.
+	Forward ThrottleRateConfig `json:"forward" yaml:"forward" comment:"Bandwidth limits per forwarding channel"`
+	// Connection limits the combined bandwidth of all channels of a connection.
+	Connection ThrottleRateConfig `json:"connection" yaml:"connection" comment:"Bandwidth limits per connection"`
+}
+
+// Validate validates the bandwidth limits.
+func (c ThrottleConfig) Validate() error {
+	if err := c.Session.Validate(); err != nil {
+		return wrap(err, "session")
+	}
+	if err := c.Forward.Validate(); err != nil {
+		return wrap(err, "forward")
+	}
+	if err := c.Connection.Validate(); err != nil {
+		return wrap(err, "connection")
+	}
+	return nil
+}
+
+// ThrottleRateConfig holds the upload and download rates in bytes per second.
+type ThrottleRateConfig struct {
+	// Upload is the maximum rate of data sent by the client in bytes per second.
+	Upload int64 `json:"upload" yaml:"upload" comment:"Maximum upload rate in bytes per second"`
+	// Download is the maximum rate of data sent to the client in bytes per second.
+	Download int64 `json:"download" yaml:"download" comment:"Maximum download rate in bytes per second"`
+}
+
+// Validate validates the rates.
+func (c ThrottleRateConfig) Validate() error {
+	if c.Upload < 0 {
+		return newError("upload", "upload must not be negative")
+	}
+	if c.Download < 0 {
+		return newError("download", "download must not be negative")
+	}
+	return nil
+}
@@ -20,3 +20,19 @@ func NewServer(
 		logger,
 	)
 }
+
+// NewSignedServer returns a complete HTTP server that responds to the configuration requests. Requests without a
+// valid HMAC-SHA256 signature from one of the keys in signature are rejected.
+func NewSignedServer(
+	cfg configuration.HTTPServerConfiguration,
+	signature configuration.HTTPServerSignatureConfig,
+	h ConfigRequestHandler,
+	logger log.Logger,
+) (service.Service, error) {
+	return config.NewSignedServer(
+		cfg,
+		signature,
+		h,
+		logger,
+	)
+}
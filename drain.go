@@ -0,0 +1,36 @@
+package containerssh
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/internal/health"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/service"
+)
+
+// drainShutdownTimeout is the time the services have to shut down after draining finished.
+const drainShutdownTimeout = 20 * time.Second
+
+// drainer marks the service as not ready, stops the SSH server from accepting new connections and stops ContainerSSH
+// once the existing connections are closed or the drain timeout expires.
+type drainer struct {
+	once          *sync.Once
+	sshServer     sshserver.Server
+	healthService health.Service
+	lifecycle     service.Lifecycle
+}
+
+func (d *drainer) drain() {
+	d.once.Do(func() {
+		d.healthService.ChangeStatus(false)
+		drained := d.sshServer.Drain()
+		go func() {
+			<-drained
+			shutdownContext, cancelFunc := context.WithTimeout(context.Background(), drainShutdownTimeout)
+			defer cancelFunc()
+			d.lifecycle.Stop(shutdownContext)
+		}()
+	})
+}
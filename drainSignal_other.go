@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package containerssh
+
+import (
+	"os"
+)
+
+// drainSignals are the signals that start drain mode. This platform has no suitable signal, drain mode can only be
+// started through the health check endpoint.
+var drainSignals []os.Signal
@@ -0,0 +1,12 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package containerssh
+
+import (
+	"os"
+	"syscall"
+)
+
+// drainSignals are the signals that start drain mode.
+var drainSignals = []os.Signal{syscall.SIGUSR1}
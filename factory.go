@@ -2,6 +2,7 @@ package containerssh
 
 import (
 	"context"
+	"sync"
 
 	"go.containerssh.io/containerssh/config"
 	"go.containerssh.io/containerssh/internal/auditlogintegration"
@@ -10,6 +11,7 @@ import (
 	"go.containerssh.io/containerssh/internal/geoip"
 	"go.containerssh.io/containerssh/internal/geoip/geoipprovider"
 	"go.containerssh.io/containerssh/internal/health"
+	"go.containerssh.io/containerssh/internal/limits"
 	"go.containerssh.io/containerssh/internal/metrics"
 	"go.containerssh.io/containerssh/internal/metricsintegration"
 	"go.containerssh.io/containerssh/internal/sshserver"
@@ -71,23 +73,54 @@ func New(cfg config.AppConfig, factory log.LoggerFactory) (Service, service.Life
 		return nil, nil, err
 	}
 
-	if err := createSSHServer(cfg, logger, metricsHandler, pool); err != nil {
+	limitsHandler, err := createLimitsHandler(cfg, logger, metricsHandler, metricsCollector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sshServer, err := createSSHServer(cfg, logger, limitsHandler, geoIPLookupProvider, pool)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	return setUpService(pool, logger, healthService)
+	configReloader := &reloader{
+		lock:           &sync.Mutex{},
+		initial:        cfg,
+		cfg:            cfg,
+		logger:         logger,
+		sshServer:      sshServer,
+		authHandler:    authHandler,
+		backendHandler: containerBackend,
+	}
+
+	return setUpService(pool, logger, healthService, sshServer, configReloader)
 }
 
-func setUpService(pool service.Pool, logger log.Logger, healthService health.Service) (
+func setUpService(
+	pool service.Pool,
+	logger log.Logger,
+	healthService health.Service,
+	sshServer sshserver.Server,
+	configReloader *reloader,
+) (
 	Service,
 	service.Lifecycle,
 	error,
 ) {
+	poolDrainer := &drainer{
+		once:          &sync.Once{},
+		sshServer:     sshServer,
+		healthService: healthService,
+	}
 	poolWrapper := &servicePool{
 		pool,
 		logger,
+		configReloader,
+		poolDrainer,
 	}
 	lifecycle := service.NewLifecycle(poolWrapper)
+	poolDrainer.lifecycle = lifecycle
+	healthService.OnDrain(poolWrapper.Drain)
 	lifecycle.OnRunning(
 		func(s service.Service, l service.Lifecycle) {
 			healthService.ChangeStatus(true)
@@ -107,13 +140,23 @@ func setUpService(pool service.Pool, logger log.Logger, healthService health.Ser
 
 type servicePool struct {
 	service.Pool
-	logger log.Logger
+	logger   log.Logger
+	reloader *reloader
+	drainer  *drainer
 }
 
 func (s servicePool) RotateLogs() error {
 	return s.logger.Rotate()
 }
 
+func (s servicePool) Reload(cfg config.AppConfig) error {
+	return s.reloader.reload(cfg)
+}
+
+func (s servicePool) Drain() {
+	s.drainer.drain()
+}
+
 func createMetricsBackend(
 	cfg config.AppConfig,
 	collector metrics.Collector,
@@ -126,6 +169,21 @@ func createMetricsBackend(
 	)
 }
 
+func createLimitsHandler(
+	cfg config.AppConfig,
+	logger log.Logger,
+	handler sshserver.Handler,
+	collector metrics.Collector,
+) (sshserver.Handler, error) {
+	return limits.New(
+		cfg.SSH.Limits,
+		limits.ContainerModeOf(cfg),
+		handler,
+		logger.WithLabel("module", "limits"),
+		collector,
+	)
+}
+
 func createMetricsServer(
 	cfg config.AppConfig,
 	logger log.Logger,
@@ -147,20 +205,22 @@ func createMetricsServer(
 func createSSHServer(
 	cfg config.AppConfig,
 	logger log.Logger,
-	auditLogHandler sshserver.Handler,
+	handler sshserver.Handler,
+	geoIPLookupProvider geoipprovider.LookupProvider,
 	pool service.Pool,
-) error {
+) (sshserver.Server, error) {
 	sshLogger := logger.WithLabel("module", "ssh")
 	sshServer, err := sshserver.New(
 		cfg.SSH,
-		auditLogHandler,
+		handler,
 		sshLogger,
+		geoIPLookupProvider,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	pool.Add(sshServer)
-	return nil
+	return sshServer, nil
 }
 
 func createAuditLogHandler(
@@ -184,7 +244,7 @@ func createAuthHandler(
 	backend sshserver.Handler,
 	metricsCollector metrics.Collector,
 	pool service.Pool,
-) (sshserver.Handler, error) {
+) (authintegration.Handler, error) {
 	authLogger := logger.WithLabel("module", "auth")
 	handler, services, err := authintegration.New(
 		cfg.Auth,
@@ -202,7 +262,7 @@ func createAuthHandler(
 	return handler, nil
 }
 
-func createBackend(cfg config.AppConfig, logger log.Logger, metricsCollector metrics.Collector) (sshserver.Handler, error) {
+func createBackend(cfg config.AppConfig, logger log.Logger, metricsCollector metrics.Collector) (backend.Handler, error) {
 	backendLogger := logger.WithLabel("module", "backend")
 	containerBackend, err := backend.New(cfg, backendLogger, metricsCollector, sshserver.AuthResponseUnavailable)
 	if err != nil {
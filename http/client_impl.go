@@ -223,6 +223,7 @@ func (c *client) createRequestForURL(method string, u string, requestBody interf
 			panic(fmt.Errorf("invalid request encoding: %s", c.config.RequestEncoding))
 		}
 	}
+	body := buffer.Bytes()
 	req, err := http.NewRequest(
 		method,
 		u,
@@ -233,6 +234,13 @@ func (c *client) createRequestForURL(method string, u string, requestBody interf
 		logger.Critical(err)
 		return nil, err
 	}
+	if c.config.Signature.Secret != "" {
+		if err := signRequest(req, c.config.Signature, body); err != nil {
+			err := message.Wrap(err, message.EHTTPFailureEncodeFailed, "Failed to sign HTTP request")
+			logger.Error(err)
+			return nil, err
+		}
+	}
 	for header, values := range c.extraHeaders {
 		for i, value := range values {
 			if i == 0 {
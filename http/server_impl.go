@@ -11,6 +11,7 @@ import (
 	"sync"
 
     "go.containerssh.io/containerssh/config"
+    "go.containerssh.io/containerssh/internal/proxyprotocol"
     "go.containerssh.io/containerssh/message"
     "go.containerssh.io/containerssh/service"
 )
@@ -54,6 +55,11 @@ func (s *server) RunWithLifecycle(lifecycle service.Lifecycle) error {
 		return message.Wrap(err, message.EHTTPListenFailed, "Failed to listen on %s", s.srv.Addr)
 	}
 	defer func() { _ = ln.Close() }()
+	ln, err = proxyprotocol.NewListener(ln, s.config.ProxyProtocol)
+	if err != nil {
+		s.lock.Unlock()
+		return message.Wrap(err, message.EHTTPListenFailed, "Failed to listen on %s", s.srv.Addr)
+	}
 	var url string
 	if s.srv.TLSConfig != nil {
 		url = fmt.Sprintf("https://%s", s.config.Listen)
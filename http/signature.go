@@ -0,0 +1,217 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	goHttp "net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+const (
+	// SignatureHeader contains the signature of the request in the sha256=<hex> format. The signature is the
+	// HMAC-SHA256 of the HTTP method, the request URI (path and query), the timestamp, the nonce and the request body,
+	// separated by newlines. The request URI is the one the client sent the request to, so proxies that rewrite the
+	// path make the signature invalid unless the verifier is configured with the removed path prefix.
+	SignatureHeader = "X-ContainerSSH-Signature"
+	// SignatureTimestampHeader contains the time the request was signed at as a Unix timestamp in seconds.
+	SignatureTimestampHeader = "X-ContainerSSH-Timestamp"
+	// SignatureNonceHeader contains a random value that is unique for each request.
+	SignatureNonceHeader = "X-ContainerSSH-Nonce"
+	// SignatureKeyIDHeader contains the ID of the key the request was signed with, if configured.
+	SignatureKeyIDHeader = "X-ContainerSSH-Key-Id"
+)
+
+// maxSignedBodySize is the largest request body the signature verifier reads.
+const maxSignedBodySize = 1024 * 1024
+
+// pruneInterval is the minimum time between removing expired nonces.
+const pruneInterval = time.Minute
+
+func computeSignature(
+	secret string,
+	method string,
+	requestURI string,
+	timestamp string,
+	nonce string,
+	body []byte,
+) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(requestURI))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func signRequest(req *goHttp.Request, cfg config.HTTPClientSignatureConfig, body []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := hex.EncodeToString(nonceBytes)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureNonceHeader, nonce)
+	if cfg.KeyID != "" {
+		req.Header.Set(SignatureKeyIDHeader, cfg.KeyID)
+	}
+	req.Header.Set(SignatureHeader, computeSignature(
+		cfg.Secret,
+		req.Method,
+		req.URL.RequestURI(),
+		timestamp,
+		nonce,
+		body,
+	))
+	return nil
+}
+
+// NewSignatureVerifier wraps the handler so that it only receives requests with a valid signature from one of the
+// configured keys. Other requests are rejected with a 401 status code.
+//
+// The signature covers the request URI the client sent the request to. If a reverse proxy removes a path prefix before
+// forwarding the request, the prefix must be set in the PathPrefix option, otherwise all requests are rejected.
+//
+// The nonces of accepted requests are kept in memory by the returned handler. They are not shared between processes
+// or verifier instances, so when several replicas serve the same webhook a request captured in transit can be
+// replayed against another replica within the accepted time window. Such deployments should rely on TLS to protect
+// the requests in transit.
+func NewSignatureVerifier(
+	cfg config.HTTPServerSignatureConfig,
+	handler goHttp.Handler,
+	logger log.Logger,
+) (goHttp.Handler, error) {
+	if handler == nil {
+		panic("BUG: no handler provided to http.NewSignatureVerifier")
+	}
+	if logger == nil {
+		panic("BUG: no logger provided to http.NewSignatureVerifier")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &signatureVerifier{
+		keys:       cfg.Keys,
+		maxSkew:    cfg.MaxSkew,
+		pathPrefix: cfg.PathPrefix,
+		handler:    handler,
+		logger:     logger,
+		nonces:     map[string]time.Time{},
+	}, nil
+}
+
+type signatureVerifier struct {
+	keys       map[string]string
+	maxSkew    time.Duration
+	pathPrefix string
+	handler    goHttp.Handler
+	logger     log.Logger
+
+	lock      sync.Mutex
+	nonces    map[string]time.Time
+	lastPrune time.Time
+}
+
+func (v *signatureVerifier) ServeHTTP(w goHttp.ResponseWriter, r *goHttp.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+	if err != nil {
+		w.WriteHeader(goHttp.StatusBadRequest)
+		return
+	}
+	if len(body) > maxSignedBodySize {
+		w.WriteHeader(goHttp.StatusRequestEntityTooLarge)
+		return
+	}
+	if err := v.verify(r.Method, v.pathPrefix+r.URL.RequestURI(), r.Header, body); err != nil {
+		v.logger.
+			WithLabel("remoteAddr", r.RemoteAddr).
+			WithLabel("path", r.URL.Path).
+			Warning(err)
+		w.WriteHeader(goHttp.StatusUnauthorized)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	v.handler.ServeHTTP(w, r)
+}
+
+func (v *signatureVerifier) verify(method string, requestURI string, header goHttp.Header, body []byte) error {
+	timestamp := header.Get(SignatureTimestampHeader)
+	nonce := header.Get(SignatureNonceHeader)
+	signature := header.Get(SignatureHeader)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return message.NewMessage(message.EHTTPSignatureInvalid, "Request is not signed")
+	}
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return message.NewMessage(message.EHTTPSignatureInvalid, "Invalid signature timestamp: %s", timestamp)
+	}
+	signedAt := time.Unix(unixTime, 0)
+	if age := time.Since(signedAt); age > v.maxSkew || age < -v.maxSkew {
+		return message.NewMessage(
+			message.EHTTPSignatureInvalid,
+			"Signature timestamp is outside the accepted window of %s",
+			v.maxSkew,
+		)
+	}
+
+	var secrets []string
+	if keyID := header.Get(SignatureKeyIDHeader); keyID != "" {
+		secret, ok := v.keys[keyID]
+		if !ok {
+			return message.NewMessage(message.EHTTPSignatureInvalid, "Unknown signature key ID: %s", keyID)
+		}
+		secrets = []string{secret}
+	} else {
+		for _, secret := range v.keys {
+			secrets = append(secrets, secret)
+		}
+	}
+	valid := false
+	for _, secret := range secrets {
+		if hmac.Equal([]byte(computeSignature(secret, method, requestURI, timestamp, nonce, body)), []byte(signature)) {
+			valid = true
+		}
+	}
+	if !valid {
+		return message.NewMessage(message.EHTTPSignatureInvalid, "Invalid request signature")
+	}
+
+	// Nonces are only recorded after the signature is verified, so unsigned requests cannot fill the list.
+	return v.useNonce(nonce, signedAt.Add(v.maxSkew))
+}
+
+// useNonce records the nonce until the request timestamp leaves the accepted window and returns an error if it has
+// already been used.
+func (v *signatureVerifier) useNonce(nonce string, expires time.Time) error {
+	now := time.Now()
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	if now.Sub(v.lastPrune) > pruneInterval {
+		for n, e := range v.nonces {
+			if now.After(e) {
+				delete(v.nonces, n)
+			}
+		}
+		v.lastPrune = now
+	}
+	if e, ok := v.nonces[nonce]; ok && !now.After(e) {
+		return message.NewMessage(message.EHTTPSignatureReplay, "Request nonce has already been used")
+	}
+	v.nonces[nonce] = expires
+	return nil
+}
@@ -0,0 +1,182 @@
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	http2 "go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/service"
+)
+
+const (
+	oldSecret = "0123456789abcdef0123456789abcdef"
+	newSecret = "fedcba9876543210fedcba9876543210"
+)
+
+func startSignedServer(t *testing.T, serverConfig config.HTTPServerConfiguration, pathPrefix string) {
+	logger := log.NewTestLogger(t)
+	verifier, err := http2.NewSignatureVerifier(
+		config.HTTPServerSignatureConfig{
+			Keys: map[string]string{
+				"old": oldSecret,
+				"new": newSecret,
+			},
+			MaxSkew:    time.Minute,
+			PathPrefix: pathPrefix,
+		},
+		http2.NewServerHandler(&handler{}, logger),
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := http2.NewServer("HTTP", serverConfig, verifier, logger, func(_ string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := service.NewLifecycle(server)
+	ready := make(chan struct{})
+	lifecycle.OnRunning(func(s service.Service, l service.Lifecycle) {
+		close(ready)
+	})
+	go func() {
+		_ = lifecycle.Run()
+	}()
+	<-ready
+	t.Cleanup(func() {
+		lifecycle.Stop(context.Background())
+	})
+}
+
+func TestSignature(t *testing.T) {
+	clientConfig, serverConfig := createClientServerConfig(t)
+	startSignedServer(t, serverConfig, "")
+
+	for name, tc := range map[string]struct {
+		signature config.HTTPClientSignatureConfig
+		status    int
+	}{
+		"unsigned":       {config.HTTPClientSignatureConfig{}, 401},
+		"old key":        {config.HTTPClientSignatureConfig{KeyID: "old", Secret: oldSecret}, 200},
+		"new key":        {config.HTTPClientSignatureConfig{KeyID: "new", Secret: newSecret}, 200},
+		"no key ID":      {config.HTTPClientSignatureConfig{Secret: newSecret}, 200},
+		"wrong key ID":   {config.HTTPClientSignatureConfig{KeyID: "old", Secret: newSecret}, 401},
+		"unknown key ID": {config.HTTPClientSignatureConfig{KeyID: "other", Secret: newSecret}, 401},
+		"wrong secret":   {config.HTTPClientSignatureConfig{Secret: "00000000000000000000000000000000"}, 401},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := clientConfig
+			cfg.Signature = tc.signature
+			client, err := http2.NewClient(cfg, log.NewTestLogger(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.status != 200 {
+				status, err := client.Post("", &Request{Message: "Hi"}, nil)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.status, status)
+				return
+			}
+			response := Response{}
+			status, err := client.Post("", &Request{Message: "Hi"}, &response)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.status, status)
+			assert.Equal(t, "Hello world!", response.Message)
+		})
+	}
+}
+
+func TestSignatureReplay(t *testing.T) {
+	clientConfig, serverConfig := createClientServerConfig(t)
+	startSignedServer(t, serverConfig, "")
+
+	send := func(timestamp time.Time, nonce string) int {
+		return sendSigned(t, clientConfig.URL, http.MethodPost, "/", timestamp, nonce)
+	}
+
+	assert.Equal(t, 200, send(time.Now(), "nonce1"))
+	assert.Equal(t, 401, send(time.Now(), "nonce1"), "a replayed request must be rejected")
+	assert.Equal(t, 200, send(time.Now(), "nonce2"))
+	assert.Equal(t, 401, send(time.Now().Add(-2*time.Minute), "nonce3"), "an old request must be rejected")
+	assert.Equal(t, 401, send(time.Now().Add(2*time.Minute), "nonce4"), "a request from the future must be rejected")
+}
+
+func TestSignatureRequestTarget(t *testing.T) {
+	clientConfig, serverConfig := createClientServerConfig(t)
+	startSignedServer(t, serverConfig, "")
+
+	assert.Equal(
+		t,
+		401,
+		sendSigned(t, clientConfig.URL+"other", http.MethodPost, "/", time.Now(), "nonce1"),
+		"a signature for another path must be rejected",
+	)
+	assert.Equal(
+		t,
+		401,
+		sendSigned(t, clientConfig.URL, http.MethodPut, "/", time.Now(), "nonce2"),
+		"a signature for another method must be rejected",
+	)
+	assert.Equal(t, 200, sendSigned(t, clientConfig.URL, http.MethodPost, "/", time.Now(), "nonce3"))
+}
+
+func TestSignatureRewrittenPath(t *testing.T) {
+	// A reverse proxy forwards requests the client sent to /webhook/ to the root of the server, so the client signs
+	// /webhook/ while the server receives /.
+	t.Run("without path prefix", func(t *testing.T) {
+		clientConfig, serverConfig := createClientServerConfig(t)
+		startSignedServer(t, serverConfig, "")
+		assert.Equal(
+			t,
+			401,
+			sendSigned(t, clientConfig.URL, http.MethodPost, "/webhook/", time.Now(), "nonce1"),
+			"the signature of a rewritten path cannot be verified without the path prefix",
+		)
+	})
+	t.Run("with path prefix", func(t *testing.T) {
+		clientConfig, serverConfig := createClientServerConfig(t)
+		startSignedServer(t, serverConfig, "/webhook")
+		assert.Equal(t, 200, sendSigned(t, clientConfig.URL, http.MethodPost, "/webhook/", time.Now(), "nonce1"))
+		assert.Equal(
+			t,
+			401,
+			sendSigned(t, clientConfig.URL, http.MethodPost, "/", time.Now(), "nonce2"),
+			"the signature must cover the path prefix",
+		)
+	})
+}
+
+// sendSigned sends a request to url signed for the given method and request URI, and returns the status code.
+func sendSigned(t *testing.T, url string, signedMethod string, signedURI string, timestamp time.Time, nonce string) int {
+	body := []byte(`{"Message":"Hi"}`)
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(oldSecret))
+	mac.Write([]byte(fmt.Sprintf("%s\n%s\n%s\n%s\n", signedMethod, signedURI, ts, nonce)))
+	mac.Write(body)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(http2.SignatureTimestampHeader, ts)
+	req.Header.Set(http2.SignatureNonceHeader, nonce)
+	req.Header.Set(http2.SignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
@@ -1,12 +1,27 @@
 package agentforward
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"io"
 
 	"go.containerssh.io/containerssh/internal/sshserver"
 	"go.containerssh.io/containerssh/log"
 )
 
+// authAgentSocketName is the name of the forwarded SSH agent socket within its directory.
+const authAgentSocketName = "agent.sock"
+
+// newAuthAgentSocketDir returns a new, unpredictable directory path in the container for the forwarded SSH agent
+// socket. The directory must not exist yet, so other users of a shared container cannot prepare it in advance.
+func newAuthAgentSocketDir() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return "/tmp/containerssh-agent-" + hex.EncodeToString(random), nil
+}
+
 // AgentForward is a network connection forwarding interface that uses the ContainerSSH Agent protocol
 type AgentForward interface {
 	// NewX11Forwarding initializes the X11 forwarding mode of the agent
@@ -28,6 +43,25 @@ type AgentForward interface {
 		reverseHandler sshserver.ReverseForward,
 	) error
 
+	// NewAuthAgentForwarding initializes the SSH agent forwarding mode of the agent. The agent listens on a unix socket
+	// and each connection to it is forwarded to the SSH agent of the client. The socket is created in a new directory
+	// with 0700 permissions and is restricted to 0600, so only the user the commands run as can use it. Calling it
+	// again after the forwarding is set up only returns the socket path since agent forwarding is shared by all
+	// channels of a connection.
+	//
+	// setupAgentCallback is a function that should start the agent on the desired target if it's called. It should return an interface to the stdin and stdout of a new instance of the agent.
+	// runCommandCallback is a function that runs a command on the desired target as the session user and returns an error if it fails.
+	// logger is the logging interface to be used
+	// reverseHandler is an interface that notifies the caller of new connections
+	//
+	// Returns the path of the socket, which should be set as SSH_AUTH_SOCK
+	NewAuthAgentForwarding(
+		setupAgentCallback func() (io.Reader, io.Writer, error),
+		runCommandCallback func(program []string) error,
+		logger log.Logger,
+		reverseHandler sshserver.ReverseForward,
+	) (string, error)
+
 	// NewTCPReverseForwarding initializes the TCP reverse forwarding mode of the agent
 	//
 	// setupAgentCallback is a function that should start the agent on the desired target if it's called. It should return an interface to the stdin and stdout of a new instance of the agent.
@@ -16,6 +16,8 @@ type agentForward struct {
 	reverseForwards map[string]*protocol.ForwardCtx
 	nX11Channels    uint32
 	x11Forward      *protocol.ForwardCtx
+	authAgent       *protocol.ForwardCtx
+	authAgentPath   string
 	directForward   *protocol.ForwardCtx
 	logger          log.Logger
 }
@@ -145,6 +147,31 @@ func (f *agentForward) serveReverseForwardUnix(connChan chan *protocol.Connectio
 	}
 }
 
+func (f *agentForward) serveAuthAgent(connChan chan *protocol.Connection, reverseHandler sshserver.ReverseForward) {
+	for {
+		agentConn, ok := <-connChan
+		if !ok {
+			f.logger.Info("Connection channel closed, ending SSH agent forward")
+			return
+		}
+
+		forwardChannel, _, err := reverseHandler.NewChannelAuthAgent()
+		if err != nil {
+			f.logger.Warning("Failed to open SSH agent forwarding channel")
+			_ = agentConn.Reject()
+			continue
+		}
+
+		err = agentConn.Accept()
+		if err != nil {
+			_ = forwardChannel.Close()
+			return
+		}
+		go serveConnection(f.logger, forwardChannel, agentConn)
+		go serveConnection(f.logger, agentConn, forwardChannel)
+	}
+}
+
 func (f *agentForward) setupX11(
 	setupAgentCallback func() (io.Reader, io.Writer, error),
 	logger log.Logger,
@@ -201,6 +228,50 @@ func (f *agentForward) NewX11Forwarding(
 	return nil
 }
 
+func (f *agentForward) NewAuthAgentForwarding(
+	setupAgentCallback func() (io.Reader, io.Writer, error),
+	runCommandCallback func(program []string) error,
+	logger log.Logger,
+	reverseHandler sshserver.ReverseForward,
+) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.authAgent != nil {
+		return f.authAgentPath, nil
+	}
+
+	dir, err := newAuthAgentSocketDir()
+	if err != nil {
+		return "", err
+	}
+	// mkdir without -p fails if the directory already exists, so nobody else can own it.
+	if err := runCommandCallback([]string{"mkdir", "-m", "0700", dir}); err != nil {
+		return "", fmt.Errorf("failed to create SSH agent socket directory %s (%w)", dir, err)
+	}
+	path := dir + "/" + authAgentSocketName
+
+	fromAgent, toAgent, err := setupAgentCallback()
+	if err != nil {
+		return "", err
+	}
+
+	authAgent := protocol.NewForwardCtx(fromAgent, toAgent, logger)
+	connChan, err := authAgent.StartReverseForwardClientUnix(path, false)
+	if err != nil {
+		return "", err
+	}
+	if err := runCommandCallback([]string{"chmod", "0600", path}); err != nil {
+		authAgent.Kill()
+		return "", fmt.Errorf("failed to restrict SSH agent socket %s (%w)", path, err)
+	}
+	f.authAgent = authAgent
+	f.authAgentPath = path
+
+	go f.serveAuthAgent(connChan, reverseHandler)
+
+	return path, nil
+}
+
 func (f *agentForward) NewTCPReverseForwarding(
 	setupAgentCallback func() (io.Reader, io.Writer, error),
 	logger log.Logger,
@@ -398,6 +469,10 @@ func (f *agentForward) OnShutdown() {
 		_ = f.directForward.NoMoreConnections()
 		f.x11Forward.Kill()
 	}
+	if f.authAgent != nil {
+		_ = f.authAgent.NoMoreConnections()
+		f.authAgent.Kill()
+	}
 	for _, forward := range f.reverseForwards {
 		_ = forward.NoMoreConnections()
 		forward.Kill()
@@ -0,0 +1,249 @@
+package agentforward_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	protocol "go.containerssh.io/containerssh/agentprotocol"
+	"go.containerssh.io/containerssh/internal/agentforward"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+)
+
+func TestAuthAgentForwarding(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	agent := &testAgent{logger: logger}
+	commands := &commandRecorder{}
+	reverseHandler := &echoReverseForward{}
+	forward := agentforward.NewAgentForward(logger)
+	defer forward.OnShutdown()
+
+	path, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, reverseHandler)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(path, "/tmp/containerssh-agent-"))
+	assert.True(t, strings.HasSuffix(path, "/agent.sock"))
+	dir := strings.TrimSuffix(path, "/agent.sock")
+	assert.Equal(
+		t,
+		[][]string{
+			{"mkdir", "-m", "0700", dir},
+			{"chmod", "0600", path},
+		},
+		commands.get(),
+	)
+	setup := <-agent.setup
+	assert.Equal(t, "unix", setup.Protocol)
+	assert.Equal(t, path, setup.BindHost)
+
+	// Agent forwarding is shared by all channels of the connection.
+	secondPath, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, reverseHandler)
+	assert.NoError(t, err)
+	assert.Equal(t, path, secondPath)
+	assert.Equal(t, 1, agent.startCount())
+	assert.Len(t, commands.get(), 2)
+
+	// A connection to the socket in the container reaches the SSH agent of the client.
+	conn, err := agent.ctx.NewConnectionUnix(path, func() error { return nil })
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = conn.Write([]byte("request"))
+	assert.NoError(t, err)
+	buf := make([]byte, 7)
+	_, err = io.ReadFull(conn, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "request", string(buf))
+	assert.Equal(t, 1, reverseHandler.count())
+}
+
+func TestAuthAgentForwardingChannelRejected(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	agent := &testAgent{logger: logger}
+	commands := &commandRecorder{}
+	reverseHandler := &echoReverseForward{reject: true}
+	forward := agentforward.NewAgentForward(logger)
+	defer forward.OnShutdown()
+
+	path, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, reverseHandler)
+	if !assert.NoError(t, err) {
+		return
+	}
+	<-agent.setup
+
+	// The client refused the agent channel, so the connection in the container is rejected.
+	conn, err := agent.ctx.NewConnectionUnix(path, func() error { return nil })
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = conn.Write([]byte("request"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, reverseHandler.count())
+}
+
+func TestAuthAgentForwardingDirectoryFailed(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	agent := &testAgent{logger: logger}
+	commands := &commandRecorder{fail: "mkdir"}
+	forward := agentforward.NewAgentForward(logger)
+	defer forward.OnShutdown()
+
+	_, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, &echoReverseForward{})
+	assert.Error(t, err)
+	assert.Equal(t, 0, agent.startCount(), "the agent must not be started without the socket directory")
+}
+
+func TestAuthAgentForwardingChmodFailed(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	agent := &testAgent{logger: logger}
+	commands := &commandRecorder{fail: "chmod"}
+	forward := agentforward.NewAgentForward(logger)
+	defer forward.OnShutdown()
+
+	_, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, &echoReverseForward{})
+	assert.Error(t, err)
+	<-agent.setup
+
+	// The failed forwarding is not kept, so the next request sets it up again in a new directory.
+	commands.fail = ""
+	path, err := forward.NewAuthAgentForwarding(agent.start, commands.run, logger, &echoReverseForward{})
+	assert.NoError(t, err)
+	<-agent.setup
+	assert.Equal(t, 2, agent.startCount())
+	calls := commands.get()
+	if assert.Len(t, calls, 4) {
+		assert.NotEqual(t, calls[0][3], calls[2][3])
+		assert.Equal(t, []string{"chmod", "0600", path}, calls[3])
+	}
+}
+
+func TestAuthAgentForwardingSeparateConnections(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	paths := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		agent := &testAgent{logger: logger}
+		forward := agentforward.NewAgentForward(logger)
+		path, err := forward.NewAuthAgentForwarding(
+			agent.start,
+			(&commandRecorder{}).run,
+			logger,
+			&echoReverseForward{},
+		)
+		assert.NoError(t, err)
+		<-agent.setup
+		paths[path] = true
+		forward.OnShutdown()
+	}
+	assert.Len(t, paths, 2, "each connection must get its own socket directory")
+}
+
+// testAgent runs the agent side of the protocol in memory, the way the agent would run in the container.
+type testAgent struct {
+	logger log.Logger
+	lock   sync.Mutex
+	starts int
+	ctx    *protocol.ForwardCtx
+	setup  chan protocol.SetupPacket
+}
+
+func (a *testAgent) start() (io.Reader, io.Writer, error) {
+	toAgentReader, toAgentWriter := io.Pipe()
+	fromAgentReader, fromAgentWriter := io.Pipe()
+	ctx := protocol.NewForwardCtx(toAgentReader, fromAgentWriter, a.logger)
+
+	a.lock.Lock()
+	a.starts++
+	a.ctx = ctx
+	if a.setup == nil {
+		a.setup = make(chan protocol.SetupPacket, 2)
+	}
+	setupChannel := a.setup
+	a.lock.Unlock()
+
+	go func() {
+		_, setup, connChan, err := ctx.StartClient()
+		if err != nil {
+			return
+		}
+		setupChannel <- setup
+		for conn := range connChan {
+			_ = conn.Reject()
+		}
+	}()
+	return fromAgentReader, toAgentWriter, nil
+}
+
+func (a *testAgent) startCount() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.starts
+}
+
+// commandRecorder records the commands run in the container and fails the program named in fail.
+type commandRecorder struct {
+	lock     sync.Mutex
+	fail     string
+	commands [][]string
+}
+
+func (c *commandRecorder) run(program []string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.commands = append(c.commands, program)
+	if program[0] == c.fail {
+		return fmt.Errorf("%s failed", program[0])
+	}
+	return nil
+}
+
+func (c *commandRecorder) get() [][]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.commands
+}
+
+// echoReverseForward stands in for the SSH client. Its agent channels echo everything written to them.
+type echoReverseForward struct {
+	lock   sync.Mutex
+	reject bool
+	opened int
+}
+
+func (e *echoReverseForward) NewChannelAuthAgent() (sshserver.ForwardChannel, uint64, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.opened++
+	if e.reject {
+		return nil, 0, fmt.Errorf("agent forwarding rejected")
+	}
+	local, remote := net.Pipe()
+	go func() {
+		_, _ = io.Copy(remote, remote)
+		_ = remote.Close()
+	}()
+	return local, uint64(e.opened), nil
+}
+
+func (e *echoReverseForward) NewChannelTCP(_ string, _ uint32, _ string, _ uint32) (sshserver.ForwardChannel, uint64, error) {
+	return nil, 0, fmt.Errorf("not implemented")
+}
+
+func (e *echoReverseForward) NewChannelUnix(_ string) (sshserver.ForwardChannel, uint64, error) {
+	return nil, 0, fmt.Errorf("not implemented")
+}
+
+func (e *echoReverseForward) NewChannelX11(_ string, _ uint32) (sshserver.ForwardChannel, uint64, error) {
+	return nil, 0, fmt.Errorf("not implemented")
+}
+
+func (e *echoReverseForward) count() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.opened
+}
@@ -22,6 +22,9 @@ type Logger interface {
 type Connection interface {
 	// OnDisconnect creates an audit log message for a disconnect event.
 	OnDisconnect()
+	// OnTerminate creates an audit log message for the server closing the connection because of a session policy,
+	//             such as an idle timeout.
+	OnTerminate(reason string)
 
 	// OnAuthPassword creates an audit log message for an authentication attempt.
 	OnAuthPassword(username string, password []byte)
@@ -98,6 +101,9 @@ type Connection interface {
 	// OnReverseX11ForwardChannel creates an audit log message for requesting to open a channel to forward an X11 connection to the client.
 	OnReverseX11ForwardChannel(channelID message.ChannelID, originatorHost string, originatorPort uint32)
 
+	// OnReverseAuthAgentChannel creates an audit log message for requesting to open a channel to the SSH agent of the client.
+	OnReverseAuthAgentChannel(channelID message.ChannelID)
+
 	// OnDirectStreamLocal creates an audit log message for requesting to open a unix socket forwarding channel.
 	OnDirectStreamLocal(channelID message.ChannelID, path string)
 
@@ -126,11 +132,16 @@ type Channel interface {
 	OnRequestPty(requestID uint64, term string, columns uint32, rows uint32, width uint32, height uint32, modeList []byte)
 	// OnRequestX11 create an audit log message for a channel request to start X11 forwarding
 	OnRequestX11(requestID uint64, singleConnection bool, protocol string, cookie string, screen uint32)
+	// OnRequestAuthAgent creates an audit log message for a channel request to forward the SSH agent of the client.
+	OnRequestAuthAgent(requestID uint64)
 	// OnRequestShell creates an audit log message for a channel request to execute a shell.
 	OnRequestShell(requestID uint64)
 	// OnRequestSignal creates an audit log message for a channel request to send a signal to the currently running
 	//               program.
 	OnRequestSignal(requestID uint64, signal string)
+	// OnRequestBreak creates an audit log message for a channel request to send a break to the terminal of the
+	//                currently running program.
+	OnRequestBreak(requestID uint64, length uint32)
 	// OnRequestSubsystem creates an audit log message for a channel request to execute a well-known subsystem (e.g. SFTP)
 	OnRequestSubsystem(requestID uint64, subsystem string)
 	// OnRequestWindow creates an audit log message for a channel request to resize the current window.
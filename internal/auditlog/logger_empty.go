@@ -37,10 +37,14 @@ func (e *empty) OnRequestPty(_ uint64, _ string, _ uint32, _ uint32, _ uint32, _
 
 func (e *empty) OnRequestX11(_ uint64, _ bool, _ string, _ string, _ uint32) {}
 
+func (e *empty) OnRequestAuthAgent(_ uint64) {}
+
 func (e *empty) OnRequestShell(_ uint64) {}
 
 func (e *empty) OnRequestSignal(_ uint64, _ string) {}
 
+func (e *empty) OnRequestBreak(_ uint64, _ uint32) {}
+
 func (e *empty) OnRequestSubsystem(_ uint64, _ string) {}
 
 func (e *empty) OnRequestWindow(_ uint64, _ uint32, _ uint32, _ uint32, _ uint32) {}
@@ -71,6 +75,8 @@ func (e *empty) OnClose() {}
 
 func (e *empty) OnDisconnect() {}
 
+func (e *empty) OnTerminate(_ string) {}
+
 func (e *empty) OnAuthPassword(_ string, _ []byte) {}
 
 func (e *empty) OnAuthPasswordSuccess(_ string, _ []byte) {}
@@ -116,6 +122,8 @@ func (l *empty) OnReverseStreamLocalChannel(_ message.ChannelID, _ string) {}
 
 func (l *empty) OnReverseX11ForwardChannel(_ message.ChannelID, _ string, _ uint32) {}
 
+func (l *empty) OnReverseAuthAgentChannel(_ message.ChannelID) {}
+
 func (l *empty) OnDirectStreamLocal(_ message.ChannelID, _ string) {}
 
 func (l *empty) OnRequestStreamLocal(_ string) {}
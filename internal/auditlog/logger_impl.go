@@ -103,6 +103,18 @@ func (l *loggerConnection) OnDisconnect() {
 	l.closed = true
 }
 
+func (l *loggerConnection) OnTerminate(reason string) {
+	l.log(message.Message{
+		ConnectionID: l.connectionID,
+		Timestamp:    time.Now().UnixNano(),
+		MessageType:  message.TypeTerminate,
+		Payload: message.PayloadTerminate{
+			Reason: reason,
+		},
+		ChannelID: nil,
+	})
+}
+
 func (l *loggerConnection) OnAuthPassword(username string, password []byte) {
 	l.log(message.Message{
 		ConnectionID: l.connectionID,
@@ -437,6 +449,16 @@ func (l *loggerConnection) OnReverseX11ForwardChannel(channelID message.ChannelI
 	})
 }
 
+func (l *loggerConnection) OnReverseAuthAgentChannel(channelID message.ChannelID) {
+	l.log(message.Message{
+		ConnectionID: l.connectionID,
+		Timestamp:    time.Now().UnixNano(),
+		MessageType:  message.TypeNewReverseAuthAgentChannel,
+		Payload:      nil,
+		ChannelID:    channelID,
+	})
+}
+
 func (l *loggerConnection) OnDirectStreamLocal(channelID message.ChannelID, path string) {
 	l.log(message.Message{
 		ConnectionID: l.connectionID,
@@ -579,6 +601,18 @@ func (l *loggerChannel) OnRequestX11(
 	})
 }
 
+func (l *loggerChannel) OnRequestAuthAgent(requestID uint64) {
+	l.c.log(message.Message{
+		ConnectionID: l.c.connectionID,
+		Timestamp:    time.Now().UnixNano(),
+		MessageType:  message.TypeChannelRequestAuthAgent,
+		Payload: message.PayloadChannelRequestAuthAgent{
+			RequestID: requestID,
+		},
+		ChannelID: l.channelID,
+	})
+}
+
 func (l *loggerChannel) OnRequestShell(requestID uint64) {
 	l.c.log(message.Message{
 		ConnectionID: l.c.connectionID,
@@ -591,6 +625,19 @@ func (l *loggerChannel) OnRequestShell(requestID uint64) {
 	})
 }
 
+func (l *loggerChannel) OnRequestBreak(requestID uint64, length uint32) {
+	l.c.log(message.Message{
+		ConnectionID: l.c.connectionID,
+		Timestamp:    time.Now().UnixNano(),
+		MessageType:  message.TypeChannelRequestBreak,
+		Payload: message.PayloadChannelRequestBreak{
+			RequestID: requestID,
+			Length:    length,
+		},
+		ChannelID: l.channelID,
+	})
+}
+
 func (l *loggerChannel) OnRequestSignal(requestID uint64, signal string) {
 	l.c.log(message.Message{
 		ConnectionID: l.c.connectionID,
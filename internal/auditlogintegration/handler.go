@@ -48,7 +48,7 @@ func (h *handler) OnNetworkConnection(meta metadata.ConnectionMetadata) (
 	if err != nil {
 		return nil, meta, fmt.Errorf(
 			"failed to initialize audit logger for connection from %s (%w)",
-			meta.RemoteAddress.IP.String(),
+			meta.RemoteAddress.Host(),
 			err,
 		)
 	}
@@ -23,7 +23,7 @@ func (n *networkConnectionHandler) OnAuthKeyboardInteractive(
 		questions sshserver.KeyboardInteractiveQuestions,
 	) (answers sshserver.KeyboardInteractiveAnswers, err error),
 ) (response sshserver.AuthResponse, metadata metadata.ConnectionAuthenticatedMetadata, reason error) {
-	return n.backend.OnAuthKeyboardInteractive(
+	response, metadata, reason = n.backend.OnAuthKeyboardInteractive(
 		meta,
 		func(
 			instruction string,
@@ -49,6 +49,10 @@ func (n *networkConnectionHandler) OnAuthKeyboardInteractive(
 				if err != nil {
 					return answers, err
 				}
+				if q.Sensitive {
+					// Sensitive answers, such as one-time codes, must never end up in the audit log.
+					a = ""
+				}
 				auditAnswers = append(auditAnswers, message.KeyboardInteractiveAnswer{
 					Question: q.Question,
 					Answer:   a,
@@ -58,6 +62,17 @@ func (n *networkConnectionHandler) OnAuthKeyboardInteractive(
 			return answers, err
 		},
 	)
+	switch response {
+	case sshserver.AuthResponseFailure:
+		n.audit.OnAuthKeyboardInteractiveFailed(meta.Username)
+	case sshserver.AuthResponseUnavailable:
+		if reason != nil {
+			n.audit.OnAuthKeyboardInteractiveBackendError(meta.Username, reason.Error())
+		} else {
+			n.audit.OnAuthKeyboardInteractiveBackendError(meta.Username, "")
+		}
+	}
+	return response, metadata, reason
 }
 
 func (n *networkConnectionHandler) OnShutdown(shutdownContext context.Context) {
@@ -71,7 +86,7 @@ func (n *networkConnectionHandler) OnAuthPassword(
 	n.audit.OnAuthPassword(meta.Username, password)
 	response, authenticatedMetadata, reason = n.backend.OnAuthPassword(meta, password)
 	switch response {
-	case sshserver.AuthResponseSuccess:
+	case sshserver.AuthResponseSuccess, sshserver.AuthResponsePartialSuccess:
 		// TODO add authenticated username
 		n.audit.OnAuthPasswordSuccess(meta.Username, password)
 	case sshserver.AuthResponseFailure:
@@ -99,7 +114,7 @@ func (n *networkConnectionHandler) OnAuthPubKey(
 	n.audit.OnAuthPubKey(meta.Username, pubKey.PublicKey)
 	response, authMeta, reason := n.backend.OnAuthPubKey(meta, pubKey)
 	switch response {
-	case sshserver.AuthResponseSuccess:
+	case sshserver.AuthResponseSuccess, sshserver.AuthResponsePartialSuccess:
 		n.audit.OnAuthPubKeySuccess(authMeta.Username, pubKey.PublicKey)
 	case sshserver.AuthResponseFailure:
 		n.audit.OnAuthPubKeyFailed(authMeta.Username, pubKey.PublicKey)
@@ -113,6 +128,10 @@ func (n *networkConnectionHandler) OnAuthPubKey(
 	return response, authMeta, reason
 }
 
+func (n *networkConnectionHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return n.backend.OnBanner(meta, banner)
+}
+
 func (n *networkConnectionHandler) OnAuthGSSAPI(meta metadata.ConnectionMetadata) internalAuth.GSSAPIServer {
 	// TODO add audit logging
 	return n.backend.OnAuthGSSAPI(meta)
@@ -95,6 +95,15 @@ func (s *sessionChannelHandler) OnSignal(requestID uint64, signal string) error
 	return nil
 }
 
+func (s *sessionChannelHandler) OnBreak(requestID uint64, lengthMs uint32) error {
+	s.audit.OnRequestBreak(requestID, lengthMs)
+	if err := s.backend.OnBreak(requestID, lengthMs); err != nil {
+		s.audit.OnRequestFailed(requestID, err)
+		return err
+	}
+	return nil
+}
+
 func (s *sessionChannelHandler) OnSubsystem(
 	requestID uint64,
 	subsystem string,
@@ -148,3 +157,22 @@ func (s *sessionChannelHandler) OnX11Request(
 		},
 	)
 }
+
+func (s *sessionChannelHandler) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	s.audit.OnRequestAuthAgent(requestID)
+	if err := s.backend.OnAuthAgentRequest(
+		requestID,
+		&reverseHandlerProxy{
+			backend:           reverseHandler,
+			connectionHandler: s.connectionHandler,
+			channelType:       sshserver.ChannelTypeAuthAgent,
+		},
+	); err != nil {
+		s.audit.OnRequestFailed(requestID, err)
+		return err
+	}
+	return nil
+}
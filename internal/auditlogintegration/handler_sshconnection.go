@@ -19,6 +19,13 @@ func (s *sshConnectionHandler) OnShutdown(shutdownContext context.Context) {
 	s.backend.OnShutdown(shutdownContext)
 }
 
+func (s *sshConnectionHandler) OnConnection(connection sshserver.SSHConnection) {
+	s.backend.OnConnection(&connectionProxy{
+		backend: connection,
+		audit:   s.audit,
+	})
+}
+
 func (s *sshConnectionHandler) OnUnsupportedGlobalRequest(requestID uint64, requestType string, payload []byte) {
 	//todo audit payload
 	s.audit.OnGlobalRequestUnknown(requestType)
@@ -177,6 +184,18 @@ func (r *reverseHandlerProxy) NewChannelX11(originatorAddress string, originator
 	return forwardProxy, id, nil
 }
 
+func (r *reverseHandlerProxy) NewChannelAuthAgent() (sshserver.ForwardChannel, uint64, error) {
+	channel, id, err := r.backend.NewChannelAuthAgent()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.connectionHandler.audit.OnReverseAuthAgentChannel(message.MakeChannelID(id))
+	auditChannel := r.connectionHandler.audit.OnNewChannelSuccess(message.MakeChannelID(id), r.channelType)
+	forwardProxy := auditChannel.GetForwardingProxy(channel)
+	return forwardProxy, id, nil
+}
+
 type sessionProxy struct {
 	backend sshserver.SessionChannel
 	audit   auditlog.Channel
@@ -243,3 +262,13 @@ func (s *sessionProxy) Close() error {
 	// Audit logging is done via the session channel hook.
 	return s.backend.Close()
 }
+
+type connectionProxy struct {
+	backend sshserver.SSHConnection
+	audit   auditlog.Connection
+}
+
+func (c *connectionProxy) Terminate(reason string) error {
+	c.audit.OnTerminate(reason)
+	return c.backend.Terminate(reason)
+}
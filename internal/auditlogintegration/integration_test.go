@@ -290,6 +290,10 @@ func (b *backendHandler) OnSignal(_ uint64, _ string) error {
 	return fmt.Errorf("signals are not supported")
 }
 
+func (b *backendHandler) OnBreak(_ uint64, _ uint32) error {
+	return fmt.Errorf("break requests are not supported")
+}
+
 func (b *backendHandler) OnWindow(_ uint64, _ uint32, _ uint32, _ uint32, _ uint32) error {
 	return fmt.Errorf("window requests are not supported")
 }
@@ -305,6 +309,15 @@ func (s *backendHandler) OnX11Request(
 	return fmt.Errorf("Unimplemented")
 }
 
+func (s *backendHandler) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return fmt.Errorf("Unimplemented")
+}
+
+func (b *backendHandler) OnConnection(_ sshserver.SSHConnection) {}
+
 func (b *backendHandler) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {
 }
 
@@ -387,6 +400,10 @@ func (b *backendHandler) OnAuthPubKey(meta metadata.ConnectionAuthPendingMetadat
 	return sshserver.AuthResponseFailure, meta.AuthFailed(), nil
 }
 
+func (b *backendHandler) OnBanner(_ metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return banner
+}
+
 func (b *backendHandler) OnAuthGSSAPI(_ metadata.ConnectionMetadata) auth.GSSAPIServer {
 	return nil
 }
@@ -33,6 +33,10 @@ const AuthenticationTypeGSSAPI AuthenticationType = "gssapi"
 // if the user has permissions to log in. This does not make sense for all authentication methods.
 const AuthenticationTypeAuthz AuthenticationType = "authz"
 
+// AuthenticationTypeBanner is the banner request before authentication, which can be used to show a different banner
+// depending on the client.
+const AuthenticationTypeBanner AuthenticationType = "banner"
+
 // PasswordAuthenticator validates the password of a user.
 type PasswordAuthenticator interface {
 	// Password authenticates with a password from the urlEncodedClient. The returned AuthenticationContext contains the results
@@ -101,6 +105,9 @@ type KeyboardInteractiveQuestion struct {
 	Question string
 	// EchoResponse should be set to true to show the typed response to the user.
 	EchoResponse bool
+	// Sensitive should be set to true if the answer is a secret, such as a one-time password, which must not be
+	// recorded in the audit log.
+	Sensitive bool
 }
 
 // KeyboardInteractiveAnswers is a set of answer to a keyboard-interactive challenge.
@@ -29,6 +29,15 @@ func NewPasswordAuthenticator(
 	case config.PasswordAuthMethodKerberos:
 		cli, err := NewKerberosClient(AuthenticationTypePassword, cfg.Kerberos, logger, metrics)
 		return cli, nil, err
+	case config.PasswordAuthMethodFile:
+		cli, err := NewPasswordFileClient(cfg.File, logger, metrics)
+		return cli, nil, err
+	case config.PasswordAuthMethodLDAP:
+		cli, err := NewLDAPClient(cfg.LDAP, logger, metrics)
+		return cli, nil, err
+	case config.PasswordAuthMethodJWT:
+		cli, err := NewJWTClient(cfg.JWT, logger, metrics)
+		return cli, nil, err
 	default:
 		return nil, nil, fmt.Errorf("unsupported method: %s", cfg.Method)
 	}
@@ -51,6 +60,15 @@ func NewPublicKeyAuthenticator(
 	case config.PubKeyAuthMethodWebhook:
 		cli, err := NewWebhookClient(AuthenticationTypePublicKey, cfg.Webhook, logger, metrics)
 		return cli, nil, err
+	case config.PubKeyAuthMethodCertificate:
+		cli, err := NewCertificateClient(cfg.Certificate, logger, metrics)
+		return cli, nil, err
+	case config.PubKeyAuthMethodAuthorizedKeys:
+		cli, err := NewAuthorizedKeysClient(cfg.AuthorizedKeys, logger, metrics)
+		return cli, nil, err
+	case config.PubKeyAuthMethodLDAP:
+		cli, err := NewLDAPClient(cfg.LDAP, logger, metrics)
+		return cli, nil, err
 	default:
 		return nil, nil, fmt.Errorf("unsupported method: %s", cfg.Method)
 	}
@@ -72,6 +90,9 @@ func NewKeyboardInteractiveAuthenticator(
 		return nil, nil, nil
 	case config.KeyboardInteractiveAuthMethodOAuth2:
 		return NewOAuth2Client(cfg.OAuth2, logger, metrics)
+	case config.KeyboardInteractiveAuthMethodTOTP:
+		// TOTP is a second factor and is created by NewTOTPAuthenticator.
+		return nil, nil, nil
 	default:
 		return nil, nil, fmt.Errorf("unsupported method: %s", cfg.Method)
 	}
@@ -120,3 +141,23 @@ func NewAuthorizationProvider(
 		return nil, nil, fmt.Errorf("unsupported method: %s", cfg.Method)
 	}
 }
+
+// NewBannerProvider returns a banner provider as configured. If fetching the banner is disabled it returns nil. If the
+// configuration is invalid an error is returned.
+func NewBannerProvider(
+	cfg config.AuthBannerConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (BannerProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	switch cfg.Method {
+	case config.AuthBannerMethodDisabled:
+		return nil, nil
+	case config.AuthBannerMethodWebhook:
+		return NewWebhookClient(AuthenticationTypeBanner, cfg.Webhook, logger, metrics)
+	default:
+		return nil, fmt.Errorf("unsupported method: %s", cfg.Method)
+	}
+}
@@ -0,0 +1,27 @@
+package auth
+
+// AuthorizedKeysClient is the authenticator for local OpenSSH authorized_keys files. It checks the public key presented
+// by the client against the authorized_keys file of the user without contacting an external server.
+type AuthorizedKeysClient interface {
+	PublicKeyAuthenticator
+}
+
+// The following metadata keys are set on successful authorized_keys authentication. Backends and the security layer
+// can use them to apply the restrictions set in the options of the matching authorized_keys entry. The environment
+// option is applied to the environment of the connection.
+const (
+	// MetadataAuthorizedKeysFingerprint contains the SHA256 fingerprint of the key the user authenticated with.
+	MetadataAuthorizedKeysFingerprint = "SSH_AUTHORIZED_KEYS_FINGERPRINT"
+	// MetadataAuthorizedKeysCommand contains the command option of the matching entry, if any.
+	MetadataAuthorizedKeysCommand = "SSH_AUTHORIZED_KEYS_COMMAND"
+	// MetadataAuthorizedKeysFrom contains the from option of the matching entry, if any.
+	MetadataAuthorizedKeysFrom = "SSH_AUTHORIZED_KEYS_FROM"
+	// MetadataAuthorizedKeysNoPortForwarding is set if the matching entry disables port forwarding.
+	MetadataAuthorizedKeysNoPortForwarding = "SSH_AUTHORIZED_KEYS_NO_PORT_FORWARDING"
+	// MetadataAuthorizedKeysNoPTY is set if the matching entry disables PTY allocation.
+	MetadataAuthorizedKeysNoPTY = "SSH_AUTHORIZED_KEYS_NO_PTY"
+	// MetadataAuthorizedKeysNoAgentForwarding is set if the matching entry disables SSH agent forwarding.
+	MetadataAuthorizedKeysNoAgentForwarding = "SSH_AUTHORIZED_KEYS_NO_AGENT_FORWARDING"
+	// MetadataAuthorizedKeysNoX11Forwarding is set if the matching entry disables X11 forwarding.
+	MetadataAuthorizedKeysNoX11Forwarding = "SSH_AUTHORIZED_KEYS_NO_X11_FORWARDING"
+)
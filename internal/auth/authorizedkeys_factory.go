@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"text/template"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewAuthorizedKeysClient creates a new authenticator for local OpenSSH authorized_keys files.
+func NewAuthorizedKeysClient(
+	cfg config.AuthAuthorizedKeysConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (AuthorizedKeysClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Authorized keys authentication configuration failed to validate",
+		)
+	}
+	pathTemplate, err := template.New("path").Option("missingkey=error").Parse(cfg.Path)
+	if err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Failed to parse the authorized keys path template",
+		)
+	}
+
+	_, _, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+
+	return &authorizedKeysClient{
+		localAuthenticator: newLocalAuthenticator(
+			"authorizedkeys",
+			"Authorized keys",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		pathTemplate: pathTemplate,
+		files:        map[string]*authorizedKeysFile{},
+	}, nil
+}
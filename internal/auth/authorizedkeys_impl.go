@@ -0,0 +1,397 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+type authorizedKeysClient struct {
+	localAuthenticator
+
+	pathTemplate *template.Template
+
+	lock  sync.Mutex
+	files map[string]*authorizedKeysFile
+}
+
+// authorizedKeysPathData is the data available in the path template.
+type authorizedKeysPathData struct {
+	Username string
+}
+
+// authorizedKeysFile is a parsed authorized_keys file together with the modification time and size it was parsed at.
+type authorizedKeysFile struct {
+	modTime time.Time
+	size    int64
+	entries []authorizedKeyEntry
+}
+
+// authorizedKeyEntry is a single key from an authorized_keys file with its options.
+type authorizedKeyEntry struct {
+	key               []byte
+	command           string
+	from              string
+	environment       map[string]string
+	expiry            time.Time
+	noPortForwarding  bool
+	noPTY             bool
+	noAgentForwarding bool
+	noX11Forwarding   bool
+}
+
+func (c *authorizedKeysClient) PubKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth.PublicKey,
+) AuthenticationContext {
+	authMeta, err := c.checkKey(meta, pubKey)
+	return c.authContext(meta, authMeta, err, message.EAuthAuthorizedKeysReadFailed)
+}
+
+func (c *authorizedKeysClient) checkKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth.PublicKey,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	if meta.Username == "" || meta.Username == "." || meta.Username == ".." ||
+		strings.ContainsAny(meta.Username, "/\\\x00") {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthAuthorizedKeysInvalidUsername,
+			"Your username is not valid.",
+			"The username %q cannot be used to look up an authorized_keys file.",
+			meta.Username,
+		)
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubKey.PublicKey))
+	if err != nil {
+		return meta.AuthFailed(), message.WrapUser(
+			err,
+			message.EAuthAuthorizedKeysNoMatch,
+			"Your public key could not be parsed.",
+			"Failed to parse public key",
+		)
+	}
+	file, err := c.getFile(meta.Username)
+	if err != nil {
+		return meta.AuthFailed(), err
+	}
+
+	keyData := key.Marshal()
+	var reason error = message.UserMessage(
+		message.EAuthAuthorizedKeysNoMatch,
+		"Your public key is not authorized.",
+		"The public key %s is not listed in the authorized_keys file of the user.",
+		ssh.FingerprintSHA256(key),
+	)
+	// Like OpenSSH, if the key is listed multiple times the first entry whose options permit the login is used.
+	for _, entry := range file.entries {
+		if !bytes.Equal(entry.key, keyData) {
+			continue
+		}
+		if err := entry.check(meta.RemoteAddress.IP); err != nil {
+			reason = err
+			continue
+		}
+		authMeta := meta.Authenticated(meta.Username)
+		entry.addMetadata(authMeta, key)
+		return authMeta, nil
+	}
+	return meta.AuthFailed(), reason
+}
+
+// getFile returns the parsed authorized_keys file of the user, re-reading it if it has changed since it was last
+// read. If the file does not exist an empty file is returned.
+func (c *authorizedKeysClient) getFile(username string) (*authorizedKeysFile, error) {
+	buf := &bytes.Buffer{}
+	if err := c.pathTemplate.Execute(buf, authorizedKeysPathData{Username: username}); err != nil {
+		return nil, message.WrapUser(
+			err,
+			message.EAuthAuthorizedKeysReadFailed,
+			"Public key authentication is currently unavailable.",
+			"Failed to render the authorized_keys path",
+		)
+	}
+	filePath := buf.String()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		delete(c.files, filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return &authorizedKeysFile{}, nil
+		}
+		return nil, message.WrapUser(
+			err,
+			message.EAuthAuthorizedKeysReadFailed,
+			"Public key authentication is currently unavailable.",
+			"Failed to read the authorized_keys file %s",
+			filePath,
+		)
+	}
+	if file, ok := c.files[filePath]; ok && file.modTime.Equal(stat.ModTime()) && file.size == stat.Size() {
+		return file, nil
+	}
+	// We are deliberately loading a dynamic file here.
+	data, err := os.ReadFile(filePath) //nolint:gosec
+	if err != nil {
+		return nil, message.WrapUser(
+			err,
+			message.EAuthAuthorizedKeysReadFailed,
+			"Public key authentication is currently unavailable.",
+			"Failed to read the authorized_keys file %s",
+			filePath,
+		)
+	}
+	file := &authorizedKeysFile{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		entries: c.parseFile(filePath, data),
+	}
+	c.files[filePath] = file
+	return file, nil
+}
+
+// parseFile parses an authorized_keys file. Entries that cannot be parsed or contain unsupported options are logged
+// and skipped.
+func (c *authorizedKeysClient) parseFile(filePath string, data []byte) []authorizedKeyEntry {
+	var result []authorizedKeyEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entry, err := parseAuthorizedKeyEntry(line)
+		if err != nil {
+			c.logger.Warning(
+				message.Wrap(
+					err,
+					message.EAuthAuthorizedKeysInvalidEntry,
+					"Ignoring invalid entry on line %d of %s",
+					lineNo,
+					filePath,
+				),
+			)
+			continue
+		}
+		result = append(result, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		c.logger.Warning(
+			message.Wrap(
+				err,
+				message.EAuthAuthorizedKeysInvalidEntry,
+				"Ignoring the rest of %s after line %d",
+				filePath,
+				lineNo,
+			),
+		)
+	}
+	return result
+}
+
+func parseAuthorizedKeyEntry(line string) (authorizedKeyEntry, error) {
+	key, _, options, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return authorizedKeyEntry{}, err
+	}
+	entry := authorizedKeyEntry{
+		key:         key.Marshal(),
+		environment: map[string]string{},
+	}
+	restrict := false
+	permitted := map[string]bool{}
+	for _, option := range options {
+		name, value, hasValue := strings.Cut(option, "=")
+		name = strings.ToLower(name)
+		if hasValue {
+			value, err = unquoteAuthorizedKeyOption(value)
+			if err != nil {
+				return entry, fmt.Errorf("invalid value for option %s (%w)", name, err)
+			}
+		}
+		if hasValue != authorizedKeyOptionHasValue(name) {
+			return entry, fmt.Errorf("invalid option: %s", option)
+		}
+		switch name {
+		case "command":
+			entry.command = value
+		case "from":
+			if _, err := matchAuthorizedKeyFrom(net.IPv4zero, value); err != nil {
+				return entry, err
+			}
+			entry.from = value
+		case "environment":
+			envName, envValue, ok := strings.Cut(value, "=")
+			if !ok || envName == "" {
+				return entry, fmt.Errorf("invalid environment option: %s", value)
+			}
+			entry.environment[envName] = envValue
+		case "expiry-time":
+			expiry, err := parseAuthorizedKeyExpiry(value)
+			if err != nil {
+				return entry, err
+			}
+			entry.expiry = expiry
+		case "restrict":
+			restrict = true
+		case "port-forwarding", "pty", "agent-forwarding", "x11-forwarding":
+			permitted[name] = true
+		case "no-port-forwarding":
+			entry.noPortForwarding = true
+		case "no-pty":
+			entry.noPTY = true
+		case "no-agent-forwarding":
+			entry.noAgentForwarding = true
+		case "no-x11-forwarding":
+			entry.noX11Forwarding = true
+		case "no-user-rc", "user-rc":
+			// ContainerSSH does not run ~/.ssh/rc, so these options have no effect.
+		default:
+			return entry, fmt.Errorf("unsupported option: %s", name)
+		}
+	}
+	if restrict {
+		entry.noPortForwarding = entry.noPortForwarding || !permitted["port-forwarding"]
+		entry.noPTY = entry.noPTY || !permitted["pty"]
+		entry.noAgentForwarding = entry.noAgentForwarding || !permitted["agent-forwarding"]
+		entry.noX11Forwarding = entry.noX11Forwarding || !permitted["x11-forwarding"]
+	}
+	return entry, nil
+}
+
+func authorizedKeyOptionHasValue(name string) bool {
+	switch name {
+	case "command", "from", "environment", "expiry-time":
+		return true
+	default:
+		return false
+	}
+}
+
+// unquoteAuthorizedKeyOption removes the double quotes around an option value. Like OpenSSH, only escaped double
+// quotes are unescaped.
+func unquoteAuthorizedKeyOption(value string) (string, error) {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", fmt.Errorf("option value must be enclosed in double quotes")
+	}
+	return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`), nil
+}
+
+// parseAuthorizedKeyExpiry parses the YYYYMMDD[HHMM[SS]] format of the expiry-time option. The time is interpreted in
+// the local time zone unless it is suffixed with Z.
+func parseAuthorizedKeyExpiry(value string) (time.Time, error) {
+	location := time.Local
+	if strings.HasSuffix(value, "Z") || strings.HasSuffix(value, "z") {
+		location = time.UTC
+		value = value[:len(value)-1]
+	}
+	for _, layout := range []string{"20060102", "200601021504", "20060102150405"} {
+		if len(value) == len(layout) {
+			return time.ParseInLocation(layout, value, location)
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry-time: %s", value)
+}
+
+// matchAuthorizedKeyFrom checks the remote address against the comma-separated patterns of the from option. Patterns
+// can be IP addresses with * and ? wildcards or CIDR ranges and can be negated with !. Host names are not resolved
+// and never match. Unix socket clients have no IP address and never match either.
+func matchAuthorizedKeyFrom(remoteAddr net.IP, patterns string) (bool, error) {
+	if len(remoteAddr) == 0 {
+		return false, nil
+	}
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if pattern == "" {
+			return false, fmt.Errorf("empty pattern in from option: %s", patterns)
+		}
+		var match bool
+		if strings.Contains(pattern, "/") {
+			_, ipNet, err := net.ParseCIDR(pattern)
+			if err != nil {
+				return false, fmt.Errorf("invalid CIDR in from option: %s", pattern)
+			}
+			match = ipNet.Contains(remoteAddr)
+		} else {
+			var err error
+			match, err = path.Match(strings.ToLower(pattern), remoteAddr.String())
+			if err != nil {
+				return false, fmt.Errorf("invalid pattern in from option: %s", pattern)
+			}
+		}
+		if match && negated {
+			return false, nil
+		}
+		matched = matched || match
+	}
+	return matched, nil
+}
+
+// check verifies that the options of the entry permit a login from the remote address.
+func (e authorizedKeyEntry) check(remoteAddr net.IP) error {
+	if e.from != "" {
+		if ok, _ := matchAuthorizedKeyFrom(remoteAddr, e.from); !ok {
+			return message.UserMessage(
+				message.EAuthAuthorizedKeysSourceAddressMismatch,
+				"Your public key is not authorized from this address.",
+				"The public key cannot be used from %s because of the from option %s.",
+				remoteAddr,
+				e.from,
+			)
+		}
+	}
+	if !e.expiry.IsZero() && time.Now().After(e.expiry) {
+		return message.UserMessage(
+			message.EAuthAuthorizedKeysExpired,
+			"Your public key has expired.",
+			"The public key expired at %s.",
+			e.expiry,
+		)
+	}
+	return nil
+}
+
+func (e authorizedKeyEntry) addMetadata(authMeta metadata.ConnectionAuthenticatedMetadata, key ssh.PublicKey) {
+	md := authMeta.GetMetadata()
+	md[MetadataAuthorizedKeysFingerprint] = metadata.Value{Value: ssh.FingerprintSHA256(key)}
+	if e.command != "" {
+		md[MetadataAuthorizedKeysCommand] = metadata.Value{Value: e.command}
+	}
+	if e.from != "" {
+		md[MetadataAuthorizedKeysFrom] = metadata.Value{Value: e.from}
+	}
+	setFlag := func(set bool, key string) {
+		if set {
+			md[key] = metadata.Value{Value: "true"}
+		}
+	}
+	setFlag(e.noPortForwarding, MetadataAuthorizedKeysNoPortForwarding)
+	setFlag(e.noPTY, MetadataAuthorizedKeysNoPTY)
+	setFlag(e.noAgentForwarding, MetadataAuthorizedKeysNoAgentForwarding)
+	setFlag(e.noX11Forwarding, MetadataAuthorizedKeysNoX11Forwarding)
+
+	env := authMeta.GetEnvironment()
+	for name, value := range e.environment {
+		env[name] = metadata.Value{Value: value}
+	}
+}
@@ -0,0 +1,133 @@
+package auth_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	auth2 "go.containerssh.io/containerssh/auth"
+	configuration "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+func authorizedKeyLine(t *testing.T, options string) (string, auth2.PublicKey) {
+	key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(newCertificateTestSigner(t).PublicKey())))
+	line := key
+	if options != "" {
+		line = options + " " + key
+	}
+	return line, auth2.PublicKey{PublicKey: key}
+}
+
+func setupAuthorizedKeysClient(t *testing.T) (auth.AuthorizedKeysClient, string) {
+	dir := t.TempDir()
+	c, err := auth.NewAuthorizedKeysClient(
+		configuration.AuthAuthorizedKeysConfig{
+			Path: filepath.Join(dir, "{{ .Username }}"),
+		},
+		log.NewTestLogger(t),
+		metrics.New(dummy.New()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, dir
+}
+
+func writeAuthorizedKeys(t *testing.T, file string, modTime time.Time, lines ...string) {
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthorizedKeysAuth(t *testing.T) {
+	c, dir := setupAuthorizedKeysClient(t)
+	line, pubKey := authorizedKeyLine(
+		t,
+		`restrict,pty,command="/usr/bin/backup \"daily\"",environment="BACKUP=1",from="10.0.0.0/8,127.0.0.*"`,
+	)
+	_, otherKey := authorizedKeyLine(t, "")
+	writeAuthorizedKeys(t, filepath.Join(dir, "foo"), time.Now(), "# keys of foo", "not a key", line)
+
+	authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), pubKey)
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+	meta := authContext.Metadata()
+	assert.Equal(t, `/usr/bin/backup "daily"`, meta.Metadata[auth.MetadataAuthorizedKeysCommand].Value)
+	assert.Contains(t, meta.Metadata, auth.MetadataAuthorizedKeysNoPortForwarding)
+	assert.Contains(t, meta.Metadata, auth.MetadataAuthorizedKeysNoAgentForwarding)
+	assert.NotContains(t, meta.Metadata, auth.MetadataAuthorizedKeysNoPTY)
+	assert.Equal(t, "1", meta.Environment["BACKUP"].Value)
+
+	for name, tc := range map[string]struct {
+		username string
+		pubKey   auth2.PublicKey
+	}{
+		"other key":      {"foo", otherKey},
+		"missing file":   {"bar", pubKey},
+		"path traversal": {"../foo", pubKey},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata(tc.username), tc.pubKey)
+			assert.False(t, authContext.Success())
+			assert.NoError(t, authContext.Error())
+		})
+	}
+}
+
+func TestAuthorizedKeysAuthRejections(t *testing.T) {
+	c, dir := setupAuthorizedKeysClient(t)
+	for name, options := range map[string]string{
+		"source address":     `from="10.0.0.0/8"`,
+		"negated address":    `from="127.0.0.*,!127.0.0.1"`,
+		"expired":            `expiry-time="20000101"`,
+		"unsupported option": `permitopen="localhost:80"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			line, pubKey := authorizedKeyLine(t, options)
+			writeAuthorizedKeys(t, filepath.Join(dir, "foo"), time.Now().Add(-time.Hour), line)
+			// Make sure the file is detected as changed between the subtests.
+			writeAuthorizedKeys(t, filepath.Join(dir, "foo"), time.Now(), line)
+			authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), pubKey)
+			assert.False(t, authContext.Success())
+			assert.NoError(t, authContext.Error())
+		})
+	}
+}
+
+func TestAuthorizedKeysFromRejectsUnixSocket(t *testing.T) {
+	c, dir := setupAuthorizedKeysClient(t)
+	line, pubKey := authorizedKeyLine(t, `from="*"`)
+	writeAuthorizedKeys(t, filepath.Join(dir, "foo"), time.Now(), line)
+	meta := metadata.NewTestAuthenticatingMetadata("foo")
+	meta.RemoteAddress = metadata.UnixRemoteAddress
+	authContext := c.PubKey(meta, pubKey)
+	assert.False(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+}
+
+func TestAuthorizedKeysReload(t *testing.T) {
+	c, dir := setupAuthorizedKeysClient(t)
+	file := filepath.Join(dir, "foo")
+	oldLine, oldKey := authorizedKeyLine(t, "")
+	newLine, newKey := authorizedKeyLine(t, "")
+
+	writeAuthorizedKeys(t, file, time.Now().Add(-time.Hour), oldLine)
+	assert.True(t, c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), oldKey).Success())
+	assert.False(t, c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), newKey).Success())
+
+	writeAuthorizedKeys(t, file, time.Now(), newLine)
+	assert.False(t, c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), oldKey).Success())
+	assert.True(t, c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), newKey).Success())
+}
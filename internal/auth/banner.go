@@ -0,0 +1,12 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// BannerProvider provides the banner sent to the client before authentication.
+type BannerProvider interface {
+	// Banner returns the banner for the connection described by the metadata. The metadata contains the username the
+	// client requested. If an error is returned the caller should fall back to the configured banner.
+	Banner(meta metadata.ConnectionAuthPendingMetadata) (string, error)
+}
@@ -0,0 +1,27 @@
+package auth
+
+// CertificateClient is the authenticator for OpenSSH user certificates. It checks the certificates presented by the
+// client against the configured CA keys and revocation list without contacting an external server.
+type CertificateClient interface {
+	PublicKeyAuthenticator
+}
+
+// The following metadata keys are set on successful certificate authentication. Backends and the security layer can
+// use them to apply the restrictions contained in the certificate.
+const (
+	// MetadataCertKeyID contains the key ID of the certificate.
+	MetadataCertKeyID = "SSH_CERT_KEY_ID"
+	// MetadataCertSerial contains the serial number of the certificate in decimal form.
+	MetadataCertSerial = "SSH_CERT_SERIAL"
+	// MetadataCertPrincipals contains the comma-separated list of principals in the certificate.
+	MetadataCertPrincipals = "SSH_CERT_PRINCIPALS"
+	// MetadataCertCAFingerprint contains the SHA256 fingerprint of the CA key that signed the certificate.
+	MetadataCertCAFingerprint = "SSH_CERT_CA_FINGERPRINT"
+	// MetadataCertForceCommand contains the force-command critical option of the certificate, if any.
+	MetadataCertForceCommand = "SSH_CERT_FORCE_COMMAND"
+	// MetadataCertSourceAddress contains the source-address critical option of the certificate, if any.
+	MetadataCertSourceAddress = "SSH_CERT_SOURCE_ADDRESS"
+	// MetadataCertExtensionPrefix is the prefix for the extensions of the certificate, e.g.
+	// SSH_CERT_EXTENSION_PERMIT_PTY for permit-pty.
+	MetadataCertExtensionPrefix = "SSH_CERT_EXTENSION_"
+)
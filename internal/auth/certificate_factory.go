@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewCertificateClient creates a new authenticator for OpenSSH user certificates.
+func NewCertificateClient(
+	cfg config.AuthCertificateConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (CertificateClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Certificate authentication configuration failed to validate",
+		)
+	}
+
+	caKeys, err := cfg.LoadCAKeys()
+	if err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Failed to load certificate authority keys",
+		)
+	}
+	caKeyData := make([][]byte, len(caKeys))
+	for i, caKey := range caKeys {
+		caKeyData[i] = caKey.Marshal()
+	}
+
+	_, _, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+
+	client := &certificateClient{
+		localAuthenticator: newLocalAuthenticator(
+			"certificate",
+			"Certificate",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		config: cfg,
+		caKeys: caKeyData,
+	}
+	if cfg.RevocationList != "" {
+		if _, err := client.getRevocationList(); err != nil {
+			return nil, message.Wrap(
+				err,
+				message.EAuthConfigError,
+				"Failed to load certificate revocation list from %s",
+				cfg.RevocationList,
+			)
+		}
+	}
+	return client, nil
+}
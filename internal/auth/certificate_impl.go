@@ -0,0 +1,373 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+type certificateClient struct {
+	localAuthenticator
+
+	config config.AuthCertificateConfig
+	caKeys [][]byte
+
+	lock            sync.Mutex
+	revocationList  *certificateRevocationList
+	revocationMTime time.Time
+}
+
+func (c *certificateClient) PubKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth.PublicKey,
+) AuthenticationContext {
+	authMeta, err := c.checkCertificate(meta, pubKey)
+	return c.authContext(meta, authMeta, err, message.EAuthCertificateRevocationListFailed)
+}
+
+func (c *certificateClient) checkCertificate(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth.PublicKey,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubKey.PublicKey))
+	if err != nil {
+		return meta.AuthFailed(), message.WrapUser(
+			err,
+			message.EAuthCertificateInvalid,
+			"Your public key could not be parsed.",
+			"Failed to parse public key",
+		)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthCertificateUntrustedCA,
+			"Only certificates are accepted.",
+			"The client presented a plain public key, but only certificates are accepted.",
+		)
+	}
+	if cert.CertType != ssh.UserCert {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthCertificateInvalid,
+			"Your certificate is not a user certificate.",
+			"The client presented a host certificate (key ID %s).",
+			cert.KeyId,
+		)
+	}
+	if !c.isCA(cert.SignatureKey.Marshal()) {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthCertificateUntrustedCA,
+			"Your certificate was not signed by a trusted certificate authority.",
+			"The certificate with key ID %s was signed by an untrusted CA %s.",
+			cert.KeyId,
+			ssh.FingerprintSHA256(cert.SignatureKey),
+		)
+	}
+	revocationList, err := c.getRevocationList()
+	if err != nil {
+		return meta.AuthFailed(), message.WrapUser(
+			err,
+			message.EAuthCertificateRevocationListFailed,
+			"Certificate authentication is currently unavailable.",
+			"Failed to reload the certificate revocation list from %s",
+			c.config.RevocationList,
+		)
+	}
+	if revocationList.isRevoked(cert) {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthCertificateRevoked,
+			"Your certificate has been revoked.",
+			"The certificate with key ID %s and serial %d has been revoked.",
+			cert.KeyId,
+			cert.Serial,
+		)
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthCertificateInvalid,
+			"Your certificate does not contain any principals.",
+			"The certificate with key ID %s does not contain any principals.",
+			cert.KeyId,
+		)
+	}
+	principal := cert.ValidPrincipals[0]
+	if c.config.EnforcePrincipal {
+		principal = meta.Username
+	}
+	checker := ssh.CertChecker{
+		SupportedCriticalOptions: []string{"force-command", "source-address"},
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return c.isCA(auth.Marshal())
+		},
+	}
+	if err := checker.CheckCert(principal, cert); err != nil {
+		return meta.AuthFailed(), message.WrapUser(
+			err,
+			message.EAuthCertificateInvalid,
+			"Your certificate is not valid for this login.",
+			"The certificate with key ID %s failed validation",
+			cert.KeyId,
+		)
+	}
+	if sourceAddress, ok := cert.CriticalOptions["source-address"]; ok {
+		if err := checkSourceAddress(meta.RemoteAddress.IP, sourceAddress); err != nil {
+			return meta.AuthFailed(), message.WrapUser(
+				err,
+				message.EAuthCertificateSourceAddressMismatch,
+				"Your certificate is not valid from this address.",
+				"The certificate with key ID %s cannot be used from %s",
+				cert.KeyId,
+				meta.RemoteAddress.IP,
+			)
+		}
+	}
+
+	authMeta := meta.Authenticated(meta.Username)
+	c.addCertificateMetadata(authMeta.GetMetadata(), cert)
+	return authMeta, nil
+}
+
+func (c *certificateClient) addCertificateMetadata(md map[string]metadata.Value, cert *ssh.Certificate) {
+	md[MetadataCertKeyID] = metadata.Value{Value: cert.KeyId}
+	md[MetadataCertSerial] = metadata.Value{Value: strconv.FormatUint(cert.Serial, 10)}
+	md[MetadataCertPrincipals] = metadata.Value{Value: strings.Join(cert.ValidPrincipals, ",")}
+	md[MetadataCertCAFingerprint] = metadata.Value{Value: ssh.FingerprintSHA256(cert.SignatureKey)}
+	if forceCommand, ok := cert.CriticalOptions["force-command"]; ok {
+		md[MetadataCertForceCommand] = metadata.Value{Value: forceCommand}
+	}
+	if sourceAddress, ok := cert.CriticalOptions["source-address"]; ok {
+		md[MetadataCertSourceAddress] = metadata.Value{Value: sourceAddress}
+	}
+	for extension, value := range cert.Extensions {
+		md[CertificateExtensionMetadataKey(extension)] = metadata.Value{Value: value}
+	}
+}
+
+// CertificateExtensionMetadataKey returns the metadata key under which the specified certificate extension is stored,
+// e.g. SSH_CERT_EXTENSION_PERMIT_PTY for permit-pty.
+func CertificateExtensionMetadataKey(extension string) string {
+	return MetadataCertExtensionPrefix + strings.Map(
+		func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		},
+		strings.ToUpper(extension),
+	)
+}
+
+func (c *certificateClient) isCA(key []byte) bool {
+	for _, caKey := range c.caKeys {
+		if bytes.Equal(caKey, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRevocationList returns the current revocation list, reloading it from disk if the file has changed since it was
+// last read.
+func (c *certificateClient) getRevocationList() (*certificateRevocationList, error) {
+	if c.config.RevocationList == "" {
+		return &certificateRevocationList{}, nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat, err := os.Stat(c.config.RevocationList)
+	if err != nil {
+		return nil, err
+	}
+	if c.revocationList != nil && stat.ModTime().Equal(c.revocationMTime) {
+		return c.revocationList, nil
+	}
+	// We are deliberately loading a dynamic file here.
+	data, err := os.ReadFile(c.config.RevocationList) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	revocationList, err := parseCertificateRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+	c.revocationList = revocationList
+	c.revocationMTime = stat.ModTime()
+	return revocationList, nil
+}
+
+// checkSourceAddress verifies the remote address against the source-address critical option. Unix socket clients
+// have no IP address and are always rejected.
+func checkSourceAddress(remoteAddr net.IP, sourceAddress string) error {
+	if len(remoteAddr) == 0 {
+		return fmt.Errorf("unix socket clients have no address to check against the source-address option %s", sourceAddress)
+	}
+	for _, entry := range strings.Split(sourceAddress, ",") {
+		entry = strings.TrimSpace(entry)
+		if ip := net.ParseIP(entry); ip != nil {
+			if ip.Equal(remoteAddr) {
+				return nil
+			}
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid source-address entry: %s", entry)
+		}
+		if ipNet.Contains(remoteAddr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not listed in the source-address option %s", remoteAddr, sourceAddress)
+}
+
+type certificateSerialRange struct {
+	from uint64
+	to   uint64
+}
+
+type certificateSerialBitmap struct {
+	offset uint64
+	bits   *big.Int
+}
+
+// certificateRevocationList holds the parsed form of a revocation list, either in the binary KRL format or in the
+// text format accepted by ssh-keygen -k. Serial numbers and key IDs at the top level apply to certificates issued by
+// any of the configured CAs, the entries in authorities only to certificates issued by that CA.
+type certificateRevocationList struct {
+	serials     []certificateSerialRange
+	bitmaps     []certificateSerialBitmap
+	keyIDs      map[string]struct{}
+	keys        map[string]struct{}
+	hashes      map[string]struct{}
+	sha1Hashes  map[string]struct{}
+	authorities map[string]*certificateRevocationList
+}
+
+func newCertificateRevocationList() *certificateRevocationList {
+	return &certificateRevocationList{
+		keyIDs:      map[string]struct{}{},
+		keys:        map[string]struct{}{},
+		hashes:      map[string]struct{}{},
+		sha1Hashes:  map[string]struct{}{},
+		authorities: map[string]*certificateRevocationList{},
+	}
+}
+
+func parseCertificateRevocationList(data []byte) (*certificateRevocationList, error) {
+	if isBinaryKRL(data) {
+		return parseBinaryKRL(data)
+	}
+	result := newCertificateRevocationList()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		directive, value, found := strings.Cut(line, ":")
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		value = strings.TrimSpace(value)
+		switch {
+		case found && directive == "serial":
+			serialRange, err := parseCertificateSerialRange(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid serial on line %d (%w)", lineNo, err)
+			}
+			result.serials = append(result.serials, serialRange)
+		case found && directive == "id":
+			result.keyIDs[value] = struct{}{}
+		case found && directive == "hash":
+			result.hashes[value] = struct{}{}
+		case found && directive == "key":
+			if err := result.addKey(value); err != nil {
+				return nil, fmt.Errorf("invalid key on line %d (%w)", lineNo, err)
+			}
+		default:
+			if err := result.addKey(line); err != nil {
+				return nil, fmt.Errorf("invalid entry on line %d (%w)", lineNo, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func parseCertificateSerialRange(value string) (certificateSerialRange, error) {
+	fromString, toString, isRange := strings.Cut(value, "-")
+	from, err := strconv.ParseUint(strings.TrimSpace(fromString), 0, 64)
+	if err != nil {
+		return certificateSerialRange{}, err
+	}
+	to := from
+	if isRange {
+		to, err = strconv.ParseUint(strings.TrimSpace(toString), 0, 64)
+		if err != nil {
+			return certificateSerialRange{}, err
+		}
+		if to < from {
+			return certificateSerialRange{}, fmt.Errorf("serial range end %d is lower than start %d", to, from)
+		}
+	}
+	return certificateSerialRange{from: from, to: to}, nil
+}
+
+func (r *certificateRevocationList) addKey(value string) error {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value))
+	if err != nil {
+		return err
+	}
+	if cert, ok := key.(*ssh.Certificate); ok {
+		key = cert.Key
+	}
+	r.keys[string(key.Marshal())] = struct{}{}
+	return nil
+}
+
+func (r *certificateRevocationList) isRevoked(cert *ssh.Certificate) bool {
+	for _, serialRange := range r.serials {
+		if cert.Serial >= serialRange.from && cert.Serial <= serialRange.to {
+			return true
+		}
+	}
+	for _, bitmap := range r.bitmaps {
+		if cert.Serial >= bitmap.offset && cert.Serial-bitmap.offset < uint64(bitmap.bits.BitLen()) &&
+			bitmap.bits.Bit(int(cert.Serial-bitmap.offset)) == 1 {
+			return true
+		}
+	}
+	if _, ok := r.keyIDs[cert.KeyId]; ok {
+		return true
+	}
+	for _, key := range []ssh.PublicKey{cert.Key, cert.SignatureKey} {
+		if _, ok := r.keys[string(key.Marshal())]; ok {
+			return true
+		}
+		if _, ok := r.hashes[ssh.FingerprintSHA256(key)]; ok {
+			return true
+		}
+		if _, ok := r.sha1Hashes[sha1Fingerprint(key)]; ok {
+			return true
+		}
+	}
+	if authority, ok := r.authorities[string(cert.SignatureKey.Marshal())]; ok {
+		return authority.isRevoked(cert)
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec // SHA1 fingerprints are part of the KRL format.
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// The constants below are defined in the PROTOCOL.krl document of OpenSSH.
+const (
+	krlMagic         = "SSHKRL\n\x00"
+	krlFormatVersion = 1
+
+	krlSectionCertificates      = 1
+	krlSectionExplicitKey       = 2
+	krlSectionFingerprintSHA1   = 3
+	krlSectionSignature         = 4
+	krlSectionFingerprintSHA256 = 5
+
+	krlSectionCertSerialList   = 0x20
+	krlSectionCertSerialRange  = 0x21
+	krlSectionCertSerialBitmap = 0x22
+	krlSectionCertKeyID        = 0x23
+)
+
+type krlHeader struct {
+	Magic         uint64
+	FormatVersion uint32
+	KRLVersion    uint64
+	GeneratedDate uint64
+	Flags         uint64
+	Reserved      []byte
+	Comment       []byte
+	Rest          []byte `ssh:"rest"`
+}
+
+type krlSection struct {
+	Type byte
+	Data []byte
+	Rest []byte `ssh:"rest"`
+}
+
+type krlCertificatesHeader struct {
+	CAKey    []byte
+	Reserved []byte
+	Rest     []byte `ssh:"rest"`
+}
+
+type krlSerialRange struct {
+	Min uint64
+	Max uint64
+}
+
+type krlSerialBitmap struct {
+	Offset uint64
+	Bitmap *big.Int
+}
+
+type krlString struct {
+	Value []byte
+	Rest  []byte `ssh:"rest"`
+}
+
+// isBinaryKRL returns true if the data starts with the magic number of the binary KRL format.
+func isBinaryKRL(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(krlMagic))
+}
+
+// parseBinaryKRL parses a key revocation list in the binary format produced by ssh-keygen -k. Signatures are not
+// verified, the sections following a signature section are ignored.
+func parseBinaryKRL(data []byte) (*certificateRevocationList, error) {
+	header := krlHeader{}
+	if err := ssh.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid KRL header (%w)", err)
+	}
+	if header.FormatVersion != krlFormatVersion {
+		return nil, fmt.Errorf("unsupported KRL format version: %d", header.FormatVersion)
+	}
+	result := newCertificateRevocationList()
+	rest := header.Rest
+	for len(rest) > 0 {
+		section := krlSection{}
+		if err := ssh.Unmarshal(rest, &section); err != nil {
+			return nil, fmt.Errorf("invalid KRL section (%w)", err)
+		}
+		rest = section.Rest
+		var err error
+		switch section.Type {
+		case krlSectionCertificates:
+			err = result.addKRLCertificates(section.Data)
+		case krlSectionExplicitKey:
+			err = forEachKRLString(section.Data, func(value []byte) error {
+				key, err := ssh.ParsePublicKey(value)
+				if err != nil {
+					return err
+				}
+				result.keys[string(key.Marshal())] = struct{}{}
+				return nil
+			})
+		case krlSectionFingerprintSHA1:
+			err = forEachKRLString(section.Data, func(value []byte) error {
+				result.sha1Hashes[string(value)] = struct{}{}
+				return nil
+			})
+		case krlSectionFingerprintSHA256:
+			err = forEachKRLString(section.Data, func(value []byte) error {
+				result.hashes["SHA256:"+base64.RawStdEncoding.EncodeToString(value)] = struct{}{}
+				return nil
+			})
+		case krlSectionSignature:
+			return result, nil
+		default:
+			err = fmt.Errorf("unsupported section type: %d", section.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KRL section %d (%w)", section.Type, err)
+		}
+	}
+	return result, nil
+}
+
+// addKRLCertificates adds the revoked serials and key IDs of a certificates section. If the section names a CA key,
+// the entries only apply to certificates issued by that CA.
+func (r *certificateRevocationList) addKRLCertificates(data []byte) error {
+	header := krlCertificatesHeader{}
+	if err := ssh.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	target := r
+	if len(header.CAKey) > 0 {
+		caKey, err := ssh.ParsePublicKey(header.CAKey)
+		if err != nil {
+			return fmt.Errorf("invalid CA key (%w)", err)
+		}
+		target = r.authorities[string(caKey.Marshal())]
+		if target == nil {
+			target = newCertificateRevocationList()
+			r.authorities[string(caKey.Marshal())] = target
+		}
+	}
+	rest := header.Rest
+	for len(rest) > 0 {
+		section := krlSection{}
+		if err := ssh.Unmarshal(rest, &section); err != nil {
+			return err
+		}
+		rest = section.Rest
+		switch section.Type {
+		case krlSectionCertSerialList:
+			if len(section.Data)%8 != 0 {
+				return fmt.Errorf("invalid serial list length: %d", len(section.Data))
+			}
+			for i := 0; i < len(section.Data); i += 8 {
+				serial := binary.BigEndian.Uint64(section.Data[i:])
+				target.serials = append(target.serials, certificateSerialRange{from: serial, to: serial})
+			}
+		case krlSectionCertSerialRange:
+			serialRange := krlSerialRange{}
+			if err := ssh.Unmarshal(section.Data, &serialRange); err != nil {
+				return err
+			}
+			if serialRange.Max < serialRange.Min {
+				return fmt.Errorf("serial range end %d is lower than start %d", serialRange.Max, serialRange.Min)
+			}
+			target.serials = append(target.serials, certificateSerialRange{from: serialRange.Min, to: serialRange.Max})
+		case krlSectionCertSerialBitmap:
+			bitmap := krlSerialBitmap{}
+			if err := ssh.Unmarshal(section.Data, &bitmap); err != nil {
+				return err
+			}
+			target.bitmaps = append(target.bitmaps, certificateSerialBitmap{offset: bitmap.Offset, bits: bitmap.Bitmap})
+		case krlSectionCertKeyID:
+			if err := forEachKRLString(section.Data, func(value []byte) error {
+				target.keyIDs[string(value)] = struct{}{}
+				return nil
+			}); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported certificate section type: %d", section.Type)
+		}
+	}
+	return nil
+}
+
+func forEachKRLString(data []byte, handler func(value []byte) error) error {
+	for len(data) > 0 {
+		value := krlString{}
+		if err := ssh.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		if err := handler(value.Value); err != nil {
+			return err
+		}
+		data = value.Rest
+	}
+	return nil
+}
+
+func sha1Fingerprint(key ssh.PublicKey) string {
+	hash := sha1.Sum(key.Marshal()) //nolint:gosec // SHA1 fingerprints are part of the KRL format.
+	return string(hash[:])
+}
@@ -0,0 +1,250 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	auth2 "go.containerssh.io/containerssh/auth"
+	configuration "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+func newCertificateTestSigner(t *testing.T) ssh.Signer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func signTestCertificate(t *testing.T, ca ssh.Signer, modify func(cert *ssh.Certificate)) auth2.PublicKey {
+	userKey := newCertificateTestSigner(t)
+	cert := &ssh.Certificate{
+		Key:             userKey.PublicKey(),
+		Serial:          42,
+		CertType:        ssh.UserCert,
+		KeyId:           "foo@example.com",
+		ValidPrincipals: []string{"foo"},
+		ValidAfter:      uint64(time.Now().Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{},
+			Extensions: map[string]string{
+				"permit-pty": "",
+			},
+		},
+	}
+	if modify != nil {
+		modify(cert)
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return auth2.PublicKey{PublicKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(cert)))}
+}
+
+func setupCertificateClient(t *testing.T, cfg configuration.AuthCertificateConfig) auth.CertificateClient {
+	cfg.EnforcePrincipal = true
+	c, err := auth.NewCertificateClient(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCertificateAuth(t *testing.T) {
+	ca := newCertificateTestSigner(t)
+	c := setupCertificateClient(t, configuration.AuthCertificateConfig{
+		CAKeys: []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+	})
+
+	authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), signTestCertificate(t, ca, nil))
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+	md := authContext.Metadata().Metadata
+	assert.Equal(t, "foo@example.com", md[auth.MetadataCertKeyID].Value)
+	assert.Equal(t, "42", md[auth.MetadataCertSerial].Value)
+	assert.Equal(t, "foo", md[auth.MetadataCertPrincipals].Value)
+	assert.Equal(t, ssh.FingerprintSHA256(ca.PublicKey()), md[auth.MetadataCertCAFingerprint].Value)
+	_, ok := md["SSH_CERT_EXTENSION_PERMIT_PTY"]
+	assert.True(t, ok)
+}
+
+func TestCertificateAuthRejections(t *testing.T) {
+	ca := newCertificateTestSigner(t)
+	otherCA := newCertificateTestSigner(t)
+	revocationList := filepath.Join(t.TempDir(), "revoked")
+	if err := os.WriteFile(revocationList, []byte("# revoked certificates\nserial: 100-200\nid: revoked@example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := setupCertificateClient(t, configuration.AuthCertificateConfig{
+		CAKeys:         []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+		RevocationList: revocationList,
+	})
+
+	for name, tc := range map[string]struct {
+		username string
+		pubKey   auth2.PublicKey
+	}{
+		"plain key": {
+			"foo",
+			auth2.PublicKey{PublicKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(ca.PublicKey())))},
+		},
+		"untrusted CA":    {"foo", signTestCertificate(t, otherCA, nil)},
+		"wrong principal": {"bar", signTestCertificate(t, ca, nil)},
+		"expired": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.ValidBefore = uint64(time.Now().Add(-time.Minute).Unix())
+		})},
+		"host certificate": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.CertType = ssh.HostCert
+		})},
+		"revoked serial": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.Serial = 150
+		})},
+		"revoked key ID": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.KeyId = "revoked@example.com"
+		})},
+		"source address": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.CriticalOptions["source-address"] = "10.0.0.0/8"
+		})},
+		"unsupported critical option": {"foo", signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.CriticalOptions["verify-required"] = ""
+		})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata(tc.username), tc.pubKey)
+			assert.False(t, authContext.Success())
+			assert.NoError(t, authContext.Error())
+		})
+	}
+}
+
+// buildTestKRL builds a binary KRL revoking serials and key IDs of certificates issued by ca, and the revokedKey.
+func buildTestKRL(ca ssh.PublicKey, revokedKey ssh.PublicKey) []byte {
+	section := func(sectionType byte, data []byte) []byte {
+		return ssh.Marshal(struct {
+			Type byte
+			Data []byte
+		}{sectionType, data})
+	}
+	var certSections []byte
+	certSections = append(certSections, section(0x21, ssh.Marshal(struct{ Min, Max uint64 }{100, 200}))...)
+	certSections = append(certSections, section(0x22, ssh.Marshal(struct {
+		Offset uint64
+		Bitmap *big.Int
+	}{1000, big.NewInt(0b101)}))...)
+	certSections = append(certSections, section(0x23, ssh.Marshal(struct{ KeyID string }{"revoked@example.com"}))...)
+
+	krl := []byte("SSHKRL\n\x00")
+	krl = append(krl, ssh.Marshal(struct {
+		FormatVersion uint32
+		KRLVersion    uint64
+		GeneratedDate uint64
+		Flags         uint64
+		Reserved      string
+		Comment       string
+	}{1, 1, uint64(time.Now().Unix()), 0, "", ""})...)
+	krl = append(krl, section(1, append(ssh.Marshal(struct {
+		CAKey    []byte
+		Reserved string
+	}{ca.Marshal(), ""}), certSections...))...)
+	krl = append(krl, section(2, ssh.Marshal(struct{ Key []byte }{revokedKey.Marshal()}))...)
+	return krl
+}
+
+func TestCertificateAuthBinaryKRL(t *testing.T) {
+	ca := newCertificateTestSigner(t)
+	otherCA := newCertificateTestSigner(t)
+	revokedKey := newCertificateTestSigner(t)
+	revocationList := filepath.Join(t.TempDir(), "revoked.krl")
+	if err := os.WriteFile(revocationList, buildTestKRL(ca.PublicKey(), revokedKey.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := setupCertificateClient(t, configuration.AuthCertificateConfig{
+		CAKeys: []string{
+			string(ssh.MarshalAuthorizedKey(ca.PublicKey())),
+			string(ssh.MarshalAuthorizedKey(otherCA.PublicKey())),
+		},
+		RevocationList: revocationList,
+	})
+
+	serial := func(serial uint64) func(cert *ssh.Certificate) {
+		return func(cert *ssh.Certificate) {
+			cert.Serial = serial
+		}
+	}
+	for name, tc := range map[string]struct {
+		pubKey  auth2.PublicKey
+		revoked bool
+	}{
+		"valid":                {signTestCertificate(t, ca, nil), false},
+		"serial range":         {signTestCertificate(t, ca, serial(150)), true},
+		"serial bitmap":        {signTestCertificate(t, ca, serial(1002)), true},
+		"serial not in bitmap": {signTestCertificate(t, ca, serial(1001)), false},
+		"serial of another CA": {signTestCertificate(t, otherCA, serial(150)), false},
+		"key ID": {signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.KeyId = "revoked@example.com"
+		}), true},
+		"explicit key": {signTestCertificate(t, otherCA, func(cert *ssh.Certificate) {
+			cert.Key = revokedKey.PublicKey()
+		}), true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), tc.pubKey)
+			assert.Equal(t, !tc.revoked, authContext.Success())
+			assert.NoError(t, authContext.Error())
+		})
+	}
+}
+
+func TestCertificateAuthSourceAddressAndForceCommand(t *testing.T) {
+	ca := newCertificateTestSigner(t)
+	c := setupCertificateClient(t, configuration.AuthCertificateConfig{
+		CAKeys: []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+	})
+
+	authContext := c.PubKey(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.CriticalOptions["source-address"] = "10.0.0.0/8,127.0.0.1"
+			cert.CriticalOptions["force-command"] = "/usr/bin/backup"
+		}),
+	)
+	assert.True(t, authContext.Success())
+	md := authContext.Metadata().Metadata
+	assert.Equal(t, "/usr/bin/backup", md[auth.MetadataCertForceCommand].Value)
+}
+
+func TestCertificateAuthSourceAddressRejectsUnixSocket(t *testing.T) {
+	ca := newCertificateTestSigner(t)
+	c := setupCertificateClient(t, configuration.AuthCertificateConfig{
+		CAKeys: []string{string(ssh.MarshalAuthorizedKey(ca.PublicKey()))},
+	})
+
+	meta := metadata.NewTestAuthenticatingMetadata("foo")
+	meta.RemoteAddress = metadata.UnixRemoteAddress
+	authContext := c.PubKey(
+		meta,
+		signTestCertificate(t, ca, func(cert *ssh.Certificate) {
+			cert.CriticalOptions["source-address"] = "0.0.0.0/0,::/0"
+		}),
+	)
+	assert.False(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+}
@@ -40,3 +40,15 @@ type Handler interface {
 		meta metadata.ConnectionAuthenticatedMetadata,
 	) (bool, metadata.ConnectionAuthenticatedMetadata, error)
 }
+
+// BannerHandler can optionally be implemented by a Handler to provide the banner sent to the client before
+// authentication. If the Handler does not implement it, banner requests are answered with an HTTP 404 response.
+type BannerHandler interface {
+	// OnBanner is called when the urlEncodedClient requests the banner for a connection.
+	//
+	// - meta is the metadata of the connection, including the username provided by the user.
+	//
+	// The method returns the banner to send, which may be empty. If an error is returned the server responds with an
+	// HTTP 500 response.
+	OnBanner(meta metadata.ConnectionAuthPendingMetadata) (string, error)
+}
@@ -22,5 +22,9 @@ func NewHandler(h Handler, logger log.Logger) goHttp.Handler {
 			backend: h,
 			logger:  logger,
 		}, logger),
+		bannerHandler: http.NewServerHandler(&bannerHandler{
+			backend: h,
+			logger:  logger,
+		}, logger),
 	}
 }
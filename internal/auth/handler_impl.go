@@ -16,6 +16,7 @@ type handler struct {
 	authzHandler    goHttp.Handler
 	passwordHandler goHttp.Handler
 	pubkeyHandler   goHttp.Handler
+	bannerHandler   goHttp.Handler
 }
 
 func (h handler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request) {
@@ -27,6 +28,8 @@ func (h handler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request
 		h.passwordHandler.ServeHTTP(writer, request)
 	case "pubkey":
 		h.pubkeyHandler.ServeHTTP(writer, request)
+	case "banner":
+		h.bannerHandler.ServeHTTP(writer, request)
 	default:
 		writer.WriteHeader(404)
 	}
@@ -132,3 +135,30 @@ func (p *pubKeyHandler) OnRequest(request http.ServerRequest, response http.Serv
 	}
 	return nil
 }
+
+type bannerHandler struct {
+	backend Handler
+	logger  log.Logger
+}
+
+func (b *bannerHandler) OnRequest(request http.ServerRequest, response http.ServerResponse) error {
+	backend, ok := b.backend.(BannerHandler)
+	if !ok {
+		response.SetStatus(404)
+		response.SetBody(auth.BannerResponseBody{})
+		return nil
+	}
+	requestObject := auth.BannerRequest{}
+	if err := request.Decode(&requestObject); err != nil {
+		return err
+	}
+	banner, err := backend.OnBanner(requestObject.ConnectionAuthPendingMetadata)
+	if err != nil {
+		b.logger.Debug(message.Wrap(err, message.EAuthRequestDecodeFailed, "failed to execute banner request"))
+		response.SetStatus(500)
+		response.SetBody(auth.BannerResponseBody{})
+		return nil
+	}
+	response.SetBody(auth.BannerResponseBody{Banner: banner})
+	return nil
+}
@@ -0,0 +1,8 @@
+package auth
+
+// JWTClient is the authenticator accepting JSON Web Tokens as passwords. This lets CI jobs that hold an OIDC token,
+// but no SSH key, log in. The token is verified against the JSON Web Key Set of the issuer without contacting a
+// webhook.
+type JWTClient interface {
+	PasswordAuthenticator
+}
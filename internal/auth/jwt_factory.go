@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewJWTClient creates a new authenticator for JSON Web Tokens.
+func NewJWTClient(
+	cfg config.AuthJWTConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (JWTClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"JWT authentication configuration failed to validate",
+		)
+	}
+
+	algorithms := map[string]bool{}
+	for _, algorithm := range cfg.Algorithms {
+		algorithms[algorithm] = true
+	}
+	backendRequestsMetric, backendFailureMetric, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+	client := &jwtClient{
+		localAuthenticator: newLocalAuthenticator(
+			"jwt",
+			"JWT",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		config:                cfg,
+		algorithms:            algorithms,
+		backendRequestsMetric: backendRequestsMetric,
+		backendFailureMetric:  backendFailureMetric,
+	}
+
+	if cfg.JWKSFile != "" {
+		if _, err := client.getFileKeys(); err != nil {
+			return nil, message.Wrap(
+				err,
+				message.EAuthConfigError,
+				"Failed to load JWKS from %s",
+				cfg.JWKSFile,
+			)
+		}
+		return client, nil
+	}
+
+	// The keys are fetched on the first login so ContainerSSH can start while the issuer is unreachable.
+	httpClient, err := http.NewClientWithHeaders(cfg.ClientConfig(), logger, nil, true)
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthConfigError, "Failed to create HTTP client for fetching the JWKS")
+	}
+	client.httpClient = httpClient
+	client.discovery = newOIDCDiscovery(logger)
+	return client, nil
+}
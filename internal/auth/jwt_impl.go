@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// jwksFetchTimeout is the time allowed for discovering and fetching the JWKS.
+const jwksFetchTimeout = 10 * time.Second
+
+// jwksMinRefresh is the minimum time between fetching the JWKS because a token was signed with an unknown key.
+const jwksMinRefresh = time.Minute
+
+type jwtClient struct {
+	localAuthenticator
+
+	config                config.AuthJWTConfig
+	algorithms            map[string]bool
+	httpClient            http.Client
+	discovery             oidcDiscovery
+	backendRequestsMetric metrics.SimpleCounter
+	backendFailureMetric  metrics.SimpleCounter
+
+	lock        sync.Mutex
+	keys        []jwtKey
+	fetched     time.Time
+	lastAttempt time.Time
+	jwksURL     string
+	modTime     time.Time
+	size        int64
+}
+
+type jwtHeader struct {
+	Algorithm string   `json:"alg"`
+	KeyID     string   `json:"kid"`
+	Critical  []string `json:"crit"`
+}
+
+func (c *jwtClient) Password(
+	meta metadata.ConnectionAuthPendingMetadata,
+	password []byte,
+) AuthenticationContext {
+	authMeta, err := c.authenticate(meta, string(password))
+	return c.authContext(meta, authMeta, err, message.EAuthJWKSFailed)
+}
+
+func (c *jwtClient) authenticate(
+	meta metadata.ConnectionAuthPendingMetadata,
+	token string,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	header, claims, err := c.verify(token)
+	if err != nil {
+		return meta.AuthFailed(), err
+	}
+	if err := c.checkClaims(claims); err != nil {
+		return meta.AuthFailed(), err
+	}
+	username, ok := claims[c.config.UsernameClaim].(string)
+	if !ok || username == "" {
+		return meta.AuthFailed(), invalidJWT("The %s claim is missing or not a string.", c.config.UsernameClaim)
+	}
+	if c.config.MatchUsername && username != meta.Username {
+		return meta.AuthFailed(), invalidJWT(
+			"The token was issued for %s, but the user logged in as %s.",
+			username,
+			meta.Username,
+		)
+	}
+
+	authMeta := meta.Authenticated(username)
+	md := authMeta.GetMetadata()
+	for claim, key := range c.config.Metadata {
+		if value, ok := claimString(claims[claim]); ok {
+			md[key] = metadata.Value{Value: value}
+		}
+	}
+	env := authMeta.GetEnvironment()
+	for claim, key := range c.config.Environment {
+		if value, ok := claimString(claims[claim]); ok {
+			env[key] = metadata.Value{Value: value}
+		}
+	}
+	c.logger.
+		WithLabel("connectionId", meta.ConnectionID).
+		WithLabel("kid", header.KeyID).
+		Debug(message.NewMessage(message.MAuth, "Token for %s verified", username))
+	return authMeta, nil
+}
+
+// verify checks the token signature and returns the decoded header and claims.
+func (c *jwtClient) verify(token string) (jwtHeader, map[string]interface{}, error) {
+	header := jwtHeader{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return header, nil, invalidJWT("The password is not a JWT.")
+	}
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return header, nil, invalidJWT("The token header is not valid base64.")
+	}
+	if err := json.Unmarshal(headerData, &header); err != nil {
+		return header, nil, invalidJWT("The token header is not valid JSON.")
+	}
+	if !c.algorithms[header.Algorithm] {
+		return header, nil, invalidJWT("The token algorithm %s is not accepted.", header.Algorithm)
+	}
+	if len(header.Critical) > 0 {
+		return header, nil, invalidJWT("The token uses unsupported critical extensions: %v.", header.Critical)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return header, nil, invalidJWT("The token signature is not valid base64.")
+	}
+
+	keys, err := c.getKeys(header.KeyID)
+	if err != nil {
+		return header, nil, err
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if header.KeyID != "" && key.id != header.KeyID {
+			continue
+		}
+		if key.canVerify(header.Algorithm) && key.verify(header.Algorithm, signingInput, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return header, nil, invalidJWT("The token signature does not match any key of the issuer.")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return header, nil, invalidJWT("The token payload is not valid base64.")
+	}
+	claims := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return header, nil, invalidJWT("The token payload is not a valid JSON object.")
+	}
+	return header, claims, nil
+}
+
+// checkClaims checks the issuer, audience, validity time and the required claims.
+func (c *jwtClient) checkClaims(claims map[string]interface{}) error {
+	if issuer, _ := claims["iss"].(string); issuer != c.config.Issuer {
+		return invalidJWT("The token was issued by %s instead of %s.", issuer, c.config.Issuer)
+	}
+	if !matchesAny(claimValues(claims["aud"]), c.config.Audience) {
+		return invalidJWT("The token was not issued for any of the accepted audiences.")
+	}
+
+	now := time.Now()
+	expires, ok := claimTime(claims["exp"])
+	if !ok {
+		return invalidJWT("The token has no valid exp claim.")
+	}
+	if now.After(expires.Add(c.config.Leeway)) {
+		return invalidJWT("The token expired at %s.", expires.Format(time.RFC3339))
+	}
+	if _, present := claims["nbf"]; present {
+		notBefore, ok := claimTime(claims["nbf"])
+		if !ok {
+			return invalidJWT("The token has an invalid nbf claim.")
+		}
+		if now.Add(c.config.Leeway).Before(notBefore) {
+			return invalidJWT("The token is not valid before %s.", notBefore.Format(time.RFC3339))
+		}
+	}
+	if _, present := claims["iat"]; present {
+		issuedAt, ok := claimTime(claims["iat"])
+		if !ok {
+			return invalidJWT("The token has an invalid iat claim.")
+		}
+		if now.Add(c.config.Leeway).Before(issuedAt) {
+			return invalidJWT("The token was issued in the future at %s.", issuedAt.Format(time.RFC3339))
+		}
+	}
+
+	for claim, accepted := range c.config.Claims {
+		if !matchesAny(claimValues(claims[claim]), accepted) {
+			return invalidJWT("The %s claim does not have any of the accepted values.", claim)
+		}
+	}
+	return nil
+}
+
+// getKeys returns the keys of the issuer. Keys fetched over HTTP are refreshed periodically, or if the token is signed
+// with a key ID that is not known yet.
+func (c *jwtClient) getKeys(keyID string) ([]jwtKey, error) {
+	if c.config.JWKSFile != "" {
+		keys, err := c.getFileKeys()
+		if err != nil {
+			return nil, message.Wrap(err, message.EAuthJWKSFailed, "Failed to load JWKS from %s", c.config.JWKSFile)
+		}
+		return keys, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	refresh := c.keys == nil || now.Sub(c.fetched) > c.config.JWKSRefresh
+	if !refresh && keyID != "" && !hasKeyID(c.keys, keyID) && now.Sub(c.lastAttempt) > jwksMinRefresh {
+		refresh = true
+	}
+	if !refresh {
+		return c.keys, nil
+	}
+	if c.keys != nil && now.Sub(c.lastAttempt) < jwksMinRefresh {
+		// A recent attempt failed, keep using the keys we have.
+		return c.keys, nil
+	}
+	c.lastAttempt = now
+	keys, err := c.fetchKeys()
+	if err != nil {
+		c.backendFailureMetric.Increment(metrics.Label("type", "jwks"))
+		if c.keys != nil {
+			c.logger.Warning(message.Wrap(err, message.EAuthJWKSFailed, "Failed to refresh JWKS, using the previous keys"))
+			return c.keys, nil
+		}
+		return nil, err
+	}
+	c.keys = keys
+	c.fetched = now
+	return keys, nil
+}
+
+func (c *jwtClient) fetchKeys() ([]jwtKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	jwksURL := c.config.JWKSURL
+	if jwksURL == "" {
+		if c.jwksURL == "" {
+			c.backendRequestsMetric.Increment()
+			discoveryResponse, err := c.discovery.Discover(ctx, c.httpClient)
+			if err != nil {
+				return nil, message.Wrap(err, message.EAuthJWKSFailed, "Failed to discover the JWKS URL")
+			}
+			if discoveryResponse.JWKSURI == "" {
+				return nil, message.NewMessage(
+					message.EAuthJWKSFailed,
+					"The OpenID configuration of %s does not contain a jwks_uri",
+					c.config.Issuer,
+				)
+			}
+			c.jwksURL = discoveryResponse.JWKSURI
+		}
+		jwksURL = c.jwksURL
+	}
+
+	c.backendRequestsMetric.Increment()
+	jwks := jsonWebKeySet{}
+	statusCode, err := c.httpClient.RequestURL("GET", jwksURL, nil, &jwks)
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthJWKSFailed, "Failed to fetch JWKS from %s", jwksURL)
+	}
+	if statusCode != 200 {
+		return nil, message.NewMessage(
+			message.EAuthJWKSFailed,
+			"Fetching JWKS from %s failed with status code %d",
+			jwksURL,
+			statusCode,
+		)
+	}
+	keys, err := jwks.signingKeys()
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthJWKSFailed, "Invalid JWKS at %s", jwksURL)
+	}
+	return keys, nil
+}
+
+func (c *jwtClient) getFileKeys() ([]jwtKey, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat, err := os.Stat(c.config.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	if c.keys != nil && stat.ModTime().Equal(c.modTime) && stat.Size() == c.size {
+		return c.keys, nil
+	}
+	// We are deliberately loading a dynamic file here.
+	data, err := os.ReadFile(c.config.JWKSFile) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	c.modTime = stat.ModTime()
+	c.size = stat.Size()
+	return keys, nil
+}
+
+func invalidJWT(explanation string, args ...interface{}) error {
+	return message.UserMessage(message.EAuthJWTInvalid, "Invalid token.", explanation, args...)
+}
+
+func hasKeyID(keys []jwtKey, keyID string) bool {
+	for _, key := range keys {
+		if key.id == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(values []string, accepted []string) bool {
+	for _, value := range values {
+		for _, a := range accepted {
+			if value == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimValues returns the string forms of a claim. Array claims return one value per element.
+func claimValues(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var result []string
+		for _, element := range v {
+			result = append(result, claimValues(element)...)
+		}
+		return result
+	case map[string]interface{}:
+		return nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// claimString returns a claim as a string for metadata. Arrays of scalars are joined with commas, objects are
+// encoded as JSON.
+func claimString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	default:
+		return strings.Join(claimValues(v), ","), true
+	}
+}
+
+func claimTime(value interface{}) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
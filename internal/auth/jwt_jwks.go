@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing tokens.
+const minRSAKeyBits = 2048
+
+// jsonWebKeySet is a JSON Web Key Set as defined in RFC 7517.
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// jwtKey is a public key from the JWKS that can verify token signatures.
+type jwtKey struct {
+	id        string
+	algorithm string
+	publicKey crypto.PublicKey
+}
+
+// parseJWKS parses the signing keys of a JWKS. Keys that are not meant for signatures or use unsupported types are
+// skipped.
+func parseJWKS(data []byte) ([]jwtKey, error) {
+	jwks := jsonWebKeySet{}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS (%w)", err)
+	}
+	return jwks.signingKeys()
+}
+
+// signingKeys returns the keys of the JWKS usable for verifying signatures. Invalid keys are skipped so a single bad
+// key does not prevent logins with the others.
+func (jwks jsonWebKeySet) signingKeys() ([]jwtKey, error) {
+	var keys []jwtKey
+	var lastErr error
+	for i, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		publicKey, err := jwk.publicKey()
+		if err != nil {
+			lastErr = fmt.Errorf("invalid key %d in JWKS (%w)", i, err)
+			continue
+		}
+		if publicKey == nil {
+			continue
+		}
+		keys = append(keys, jwtKey{
+			id:        jwk.KeyID,
+			algorithm: jwk.Algorithm,
+			publicKey: publicKey,
+		})
+	}
+	if len(keys) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, fmt.Errorf("the JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey returns the public key of the JWK, or nil if the key type is not supported.
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeJWKInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus (%w)", err)
+		}
+		e, err := decodeJWKInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent (%w)", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		if n.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key too small: %d bits", n.BitLen())
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeJWKInt(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate (%w)", err)
+		}
+		y, err := decodeJWKInt(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate (%w)", err)
+		}
+		//nolint:staticcheck // The coordinates come from JSON, there is no byte encoding to parse.
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("the point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(jwk.X, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate (%w)", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key length: %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// canVerify returns true if the key can verify signatures of the algorithm.
+func (k jwtKey) canVerify(algorithm string) bool {
+	if k.algorithm != "" && k.algorithm != algorithm {
+		return false
+	}
+	switch key := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(algorithm, "RS") || strings.HasPrefix(algorithm, "PS")
+	case *ecdsa.PublicKey:
+		switch algorithm {
+		case "ES256":
+			return key.Curve == elliptic.P256()
+		case "ES384":
+			return key.Curve == elliptic.P384()
+		case "ES512":
+			return key.Curve == elliptic.P521()
+		}
+		return false
+	case ed25519.PublicKey:
+		return algorithm == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// verify checks the signature of the signing input with the algorithm. The caller must check canVerify first.
+func (k jwtKey) verify(algorithm string, signingInput []byte, signature []byte) bool {
+	if algorithm == "EdDSA" {
+		return ed25519.Verify(k.publicKey.(ed25519.PublicKey), signingInput, signature)
+	}
+	var hash crypto.Hash
+	switch algorithm[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return false
+	}
+	hasher := hash.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch key := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(algorithm, "PS") {
+			return rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{
+				SaltLength: rsa.PSSSaltLengthEqualsHash,
+			}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// JWS encodes ECDSA signatures as the fixed-size concatenation of r and s.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,418 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	goHttp "net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+	"go.containerssh.io/containerssh/service"
+)
+
+const jwtTestIssuer = "https://token.actions.example.com"
+
+type jwtTestKeys struct {
+	rsa     *rsa.PrivateKey
+	ecdsa   *ecdsa.PrivateKey
+	ed25519 ed25519.PrivateKey
+}
+
+func newJWTTestKeys(t *testing.T) jwtTestKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtTestKeys{rsaKey, ecdsaKey, ed25519Key}
+}
+
+func (k jwtTestKeys) jwks() []byte {
+	encode := func(data []byte) string {
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa",
+				"use": "sig",
+				"n":   encode(k.rsa.N.Bytes()),
+				"e":   encode(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   encode(k.ecdsa.X.FillBytes(make([]byte, 32))),
+				"y":   encode(k.ecdsa.Y.FillBytes(make([]byte, 32))),
+			},
+			{
+				"kty": "OKP",
+				"kid": "ed",
+				"crv": "Ed25519",
+				"x":   encode(k.ed25519.Public().(ed25519.PublicKey)),
+			},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func (k jwtTestKeys) sign(t *testing.T, alg string, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(
+			rand.Reader, k.rsa, crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash},
+		)
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ecdsa, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "EdDSA":
+		signature = ed25519.Sign(k.ed25519, []byte(signingInput))
+	default:
+		t.Fatalf("unsupported algorithm: %s", alg)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newJWTTestConfig() config.AuthJWTConfig {
+	return config.AuthJWTConfig{
+		HTTPClientConfiguration: config.HTTPClientConfiguration{
+			Timeout: 2 * time.Second,
+		},
+		Issuer:        jwtTestIssuer,
+		Audience:      []string{"containerssh"},
+		Algorithms:    []string{"RS256", "PS256", "ES256", "EdDSA"},
+		Leeway:        time.Minute,
+		JWKSRefresh:   time.Hour,
+		UsernameClaim: "sub",
+	}
+}
+
+func validJWTClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":        jwtTestIssuer,
+		"aud":        "containerssh",
+		"sub":        "ci",
+		"exp":        time.Now().Add(5 * time.Minute).Unix(),
+		"iat":        time.Now().Unix(),
+		"repository": "example/app",
+		"groups":     []string{"deploy", "build"},
+	}
+}
+
+func newJWTFileClient(t *testing.T, keys jwtTestKeys, cfg config.AuthJWTConfig) auth.JWTClient {
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, keys.jwks(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.JWKSFile = file
+	client, err := auth.NewJWTClient(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestJWTAuth(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	otherKeys := newJWTTestKeys(t)
+	cfg := newJWTTestConfig()
+	cfg.Claims = map[string][]string{
+		"repository": {"example/app", "example/lib"},
+		"groups":     {"deploy"},
+	}
+	client := newJWTFileClient(t, keys, cfg)
+
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validJWTClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	for name, tc := range map[string]struct {
+		token   string
+		success bool
+	}{
+		"RS256":             {keys.sign(t, "RS256", "rsa", validJWTClaims()), true},
+		"PS256":             {keys.sign(t, "PS256", "rsa", validJWTClaims()), true},
+		"ES256":             {keys.sign(t, "ES256", "ec", validJWTClaims()), true},
+		"EdDSA":             {keys.sign(t, "EdDSA", "ed", validJWTClaims()), true},
+		"no key ID":         {keys.sign(t, "ES256", "", validJWTClaims()), true},
+		"audience array":    {keys.sign(t, "RS256", "rsa", withClaim("aud", []string{"other", "containerssh"})), true},
+		"within leeway":     {keys.sign(t, "RS256", "rsa", withClaim("exp", time.Now().Add(-30*time.Second).Unix())), true},
+		"not a token":       {"password", false},
+		"wrong key":         {otherKeys.sign(t, "RS256", "rsa", validJWTClaims()), false},
+		"wrong key ID":      {keys.sign(t, "ES256", "rsa", validJWTClaims()), false},
+		"wrong issuer":      {keys.sign(t, "RS256", "rsa", withClaim("iss", "https://example.com")), false},
+		"wrong audience":    {keys.sign(t, "RS256", "rsa", withClaim("aud", "other")), false},
+		"expired":           {keys.sign(t, "RS256", "rsa", withClaim("exp", time.Now().Add(-5*time.Minute).Unix())), false},
+		"no expiry":         {keys.sign(t, "RS256", "rsa", withClaim("exp", nil)), false},
+		"not yet valid":     {keys.sign(t, "RS256", "rsa", withClaim("nbf", time.Now().Add(5*time.Minute).Unix())), false},
+		"wrong claim":       {keys.sign(t, "RS256", "rsa", withClaim("repository", "example/other")), false},
+		"missing claim":     {keys.sign(t, "RS256", "rsa", withClaim("repository", nil)), false},
+		"wrong array claim": {keys.sign(t, "RS256", "rsa", withClaim("groups", []string{"build"})), false},
+		"missing username":  {keys.sign(t, "RS256", "rsa", withClaim("sub", nil)), false},
+		"none algorithm":    {noneJWT(t, validJWTClaims()), false},
+		"corrupted header":  {keys.sign(t, "RS256", "rsa", validJWTClaims())[1:], false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authContext := client.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte(tc.token))
+			assert.NoError(t, authContext.Error())
+			assert.Equal(t, tc.success, authContext.Success())
+			if tc.success {
+				assert.Equal(t, "ci", authContext.Metadata().AuthenticatedUsername)
+			}
+		})
+	}
+}
+
+func noneJWT(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func TestJWTMatchUsername(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	cfg := newJWTTestConfig()
+	cfg.MatchUsername = true
+	client := newJWTFileClient(t, keys, cfg)
+	token := []byte(keys.sign(t, "RS256", "rsa", validJWTClaims()))
+
+	authContext := client.Password(metadata.NewTestAuthenticatingMetadata("ci"), token)
+	assert.True(t, authContext.Success())
+
+	authContext = client.Password(metadata.NewTestAuthenticatingMetadata("root"), token)
+	assert.NoError(t, authContext.Error())
+	assert.False(t, authContext.Success())
+}
+
+func TestJWTMetadata(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	cfg := newJWTTestConfig()
+	cfg.Metadata = map[string]string{
+		"repository": "REPOSITORY",
+		"groups":     "GROUPS",
+		"missing":    "MISSING",
+		"iat":        "ISSUED_AT",
+		"teams":      "TEAMS",
+	}
+	cfg.Environment = map[string]string{
+		"repository": "CI_REPOSITORY",
+	}
+	client := newJWTFileClient(t, keys, cfg)
+
+	claims := validJWTClaims()
+	claims["teams"] = []interface{}{[]string{"a", "b"}, "c", map[string]string{"d": "e"}}
+	authContext := client.Password(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		[]byte(keys.sign(t, "ES256", "ec", claims)),
+	)
+	assert.True(t, authContext.Success())
+	md := authContext.Metadata()
+	assert.Equal(t, strconv.FormatInt(claims["iat"].(int64), 10), md.GetMetadata()["ISSUED_AT"].Value)
+	assert.Equal(t, "a,b,c", md.GetMetadata()["TEAMS"].Value)
+	assert.Equal(t, "example/app", md.GetMetadata()["REPOSITORY"].Value)
+	assert.Equal(t, "deploy,build", md.GetMetadata()["GROUPS"].Value)
+	assert.NotContains(t, md.GetMetadata(), "MISSING")
+	assert.Equal(t, "example/app", md.GetEnvironment()["CI_REPOSITORY"].Value)
+}
+
+// jwksServer serves the OpenID configuration and the JWKS of an issuer.
+type jwksServer struct {
+	lock     sync.Mutex
+	url      string
+	jwks     []byte
+	requests map[string]int
+}
+
+func (s *jwksServer) ServeHTTP(w goHttp.ResponseWriter, r *goHttp.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.requests[r.URL.Path]++
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   s.url,
+			"jwks_uri": s.url + "/jwks",
+		})
+	case "/jwks":
+		_, _ = w.Write(s.jwks)
+	default:
+		w.WriteHeader(404)
+	}
+}
+
+func (s *jwksServer) setJWKS(jwks []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.jwks = jwks
+}
+
+func (s *jwksServer) count(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests[path]
+}
+
+func startJWKSServer(t *testing.T, jwks []byte) *jwksServer {
+	port := test.GetNextPort(t, "JWKS server")
+	stub := &jwksServer{
+		url:      fmt.Sprintf("http://127.0.0.1:%d", port),
+		jwks:     jwks,
+		requests: map[string]int{},
+	}
+	server, err := http.NewServer(
+		"JWKS",
+		config.HTTPServerConfiguration{
+			Listen: fmt.Sprintf("127.0.0.1:%d", port),
+		},
+		stub,
+		log.NewTestLogger(t),
+		func(_ string) {},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := service.NewLifecycle(server)
+	ready := make(chan struct{})
+	lifecycle.OnRunning(
+		func(_ service.Service, _ service.Lifecycle) {
+			close(ready)
+		},
+	)
+	go func() {
+		_ = lifecycle.Run()
+	}()
+	<-ready
+	t.Cleanup(func() {
+		lifecycle.Stop(context.Background())
+	})
+	return stub
+}
+
+func TestJWTDiscovery(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	server := startJWKSServer(t, keys.jwks())
+
+	cfg := newJWTTestConfig()
+	cfg.Issuer = server.url
+	client, err := auth.NewJWTClient(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := validJWTClaims()
+	claims["iss"] = server.url
+
+	for i := 0; i < 2; i++ {
+		authContext := client.Password(
+			metadata.NewTestAuthenticatingMetadata("foo"),
+			[]byte(keys.sign(t, "RS256", "rsa", claims)),
+		)
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+	}
+	assert.Equal(t, 1, server.count("/.well-known/openid-configuration"))
+	assert.Equal(t, 1, server.count("/jwks"))
+
+	// Tokens signed with an unknown key ID trigger a single refresh within the minimum interval.
+	authContext := client.Password(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		[]byte(keys.sign(t, "RS256", "rotated", claims)),
+	)
+	assert.NoError(t, authContext.Error())
+	assert.False(t, authContext.Success())
+	assert.Equal(t, 1, server.count("/jwks"))
+}
+
+func TestJWTIssuerUnavailable(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	server := startJWKSServer(t, []byte(`{"keys":[]}`))
+
+	cfg := newJWTTestConfig()
+	cfg.Issuer = server.url
+	client, err := auth.NewJWTClient(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := validJWTClaims()
+	claims["iss"] = server.url
+
+	authContext := client.Password(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		[]byte(keys.sign(t, "RS256", "rsa", claims)),
+	)
+	assert.Error(t, authContext.Error())
+	assert.False(t, authContext.Success())
+
+	server.setJWKS(keys.jwks())
+	authContext = client.Password(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		[]byte(keys.sign(t, "RS256", "rsa", claims)),
+	)
+	// Without usable keys the JWKS is fetched again on the next login.
+	assert.NoError(t, authContext.Error())
+	assert.True(t, authContext.Success())
+}
@@ -0,0 +1,17 @@
+package auth
+
+// LDAPClient is the authenticator for LDAP directories. Password authentication binds to the directory as the user,
+// while public key authentication compares the keys stored in the entry of the user.
+type LDAPClient interface {
+	PasswordAuthenticator
+	PublicKeyAuthenticator
+}
+
+// The following metadata keys are set on successful LDAP authentication in addition to the attributes configured in
+// the metadata option.
+const (
+	// MetadataLDAPDN contains the DN of the entry of the user.
+	MetadataLDAPDN = "LDAP_DN"
+	// MetadataLDAPGroups contains the comma-separated names of the groups of the user if group lookup is enabled.
+	MetadataLDAPGroups = "LDAP_GROUPS"
+)
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"text/template"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewLDAPClient creates a new authenticator for LDAP directories.
+func NewLDAPClient(
+	cfg config.AuthLDAPConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (LDAPClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"LDAP authentication configuration failed to validate",
+		)
+	}
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthConfigError, "Failed to load LDAP TLS configuration")
+	}
+	userFilter, err := template.New("userFilter").Parse(cfg.UserFilter)
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthConfigError, "Failed to parse the LDAP user filter")
+	}
+	groupFilter, err := template.New("groupFilter").Parse(cfg.GroupFilter)
+	if err != nil {
+		return nil, message.Wrap(err, message.EAuthConfigError, "Failed to parse the LDAP group filter")
+	}
+
+	backendRequestsMetric, backendFailureMetric, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+
+	return &ldapClient{
+		localAuthenticator: newLocalAuthenticator(
+			"ldap",
+			"LDAP",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		config:                cfg,
+		tlsConfig:             tlsConfig,
+		userFilter:            userFilter,
+		groupFilter:           groupFilter,
+		backendRequestsMetric: backendRequestsMetric,
+		backendFailureMetric:  backendFailureMetric,
+	}, nil
+}
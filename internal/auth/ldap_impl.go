@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"strings"
+	"text/template"
+
+	"github.com/go-ldap/ldap/v3"
+	"go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+type ldapClient struct {
+	localAuthenticator
+
+	config                config.AuthLDAPConfig
+	tlsConfig             *tls.Config
+	userFilter            *template.Template
+	groupFilter           *template.Template
+	backendRequestsMetric metrics.SimpleCounter
+	backendFailureMetric  metrics.SimpleCounter
+}
+
+// ldapFilterData is the data available in the user and group filter templates. All fields are escaped for use in
+// LDAP filters.
+type ldapFilterData struct {
+	Username string
+	DN       string
+}
+
+func (c *ldapClient) Password(
+	meta metadata.ConnectionAuthPendingMetadata,
+	password []byte,
+) AuthenticationContext {
+	return c.authenticate(meta, "password", func(conn *ldap.Conn, entry *ldap.Entry) error {
+		// An empty password would result in an unauthenticated bind, which most servers accept.
+		if len(password) == 0 {
+			return message.UserMessage(
+				message.EAuthLDAPInvalidCredentials,
+				"Invalid username or password.",
+				"Empty passwords are not accepted for LDAP authentication.",
+			)
+		}
+		if err := conn.Bind(entry.DN, string(password)); err != nil {
+			if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+				return message.WrapUser(
+					err,
+					message.EAuthLDAPInvalidCredentials,
+					"Invalid username or password.",
+					"The LDAP server rejected the password for %s",
+					entry.DN,
+				)
+			}
+			return c.failed(err, "Failed to bind as %s", entry.DN)
+		}
+		return nil
+	})
+}
+
+func (c *ldapClient) PubKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth.PublicKey,
+) AuthenticationContext {
+	return c.authenticate(meta, "pubkey", func(_ *ldap.Conn, entry *ldap.Entry) error {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubKey.PublicKey))
+		if err != nil {
+			return message.WrapUser(
+				err,
+				message.EAuthLDAPInvalidCredentials,
+				"Your public key could not be parsed.",
+				"Failed to parse public key",
+			)
+		}
+		keyData := key.Marshal()
+		for _, value := range entry.GetAttributeValues(c.config.PublicKeyAttribute) {
+			storedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value))
+			if err != nil {
+				c.logger.Debug(
+					message.Wrap(
+						err,
+						message.EAuthLDAPInvalidCredentials,
+						"Ignoring invalid public key in %s of %s",
+						c.config.PublicKeyAttribute,
+						entry.DN,
+					),
+				)
+				continue
+			}
+			if bytes.Equal(storedKey.Marshal(), keyData) {
+				return nil
+			}
+		}
+		return message.UserMessage(
+			message.EAuthLDAPInvalidCredentials,
+			"Your public key is not authorized.",
+			"The public key %s is not stored in the %s attribute of %s.",
+			ssh.FingerprintSHA256(key),
+			c.config.PublicKeyAttribute,
+			entry.DN,
+		)
+	})
+}
+
+// authenticate looks up the user entry and calls verify to check the credentials of the user. If verify succeeds the
+// group membership is checked and the metadata is filled from the entry.
+func (c *ldapClient) authenticate(
+	meta metadata.ConnectionAuthPendingMetadata,
+	authType string,
+	verify func(conn *ldap.Conn, entry *ldap.Entry) error,
+) AuthenticationContext {
+	authMeta, err := c.checkUser(meta, verify)
+	authContext := c.authContext(meta, authMeta, err, message.EAuthLDAPFailed)
+	if authContext.Error() != nil {
+		// The directory cannot be reached.
+		c.backendFailureMetric.Increment(metrics.Label("type", authType))
+	}
+	return authContext
+}
+
+func (c *ldapClient) checkUser(
+	meta metadata.ConnectionAuthPendingMetadata,
+	verify func(conn *ldap.Conn, entry *ldap.Entry) error,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	c.backendRequestsMetric.Increment()
+	conn, err := c.connect()
+	if err != nil {
+		return meta.AuthFailed(), err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := c.bindService(conn); err != nil {
+		return meta.AuthFailed(), err
+	}
+	entry, err := c.findUser(conn, meta.Username)
+	if err != nil {
+		return meta.AuthFailed(), err
+	}
+	if err := verify(conn, entry); err != nil {
+		return meta.AuthFailed(), err
+	}
+	groups, err := c.findGroups(conn, meta.Username, entry)
+	if err != nil {
+		return meta.AuthFailed(), err
+	}
+	if err := c.checkRequiredGroups(entry, groups); err != nil {
+		return meta.AuthFailed(), err
+	}
+
+	authMeta := meta.Authenticated(meta.Username)
+	md := authMeta.GetMetadata()
+	md[MetadataLDAPDN] = metadata.Value{Value: entry.DN}
+	if c.config.GroupBaseDN != "" {
+		md[MetadataLDAPGroups] = metadata.Value{Value: strings.Join(groups, ",")}
+	}
+	for attribute, key := range c.config.Metadata {
+		if values := entry.GetAttributeValues(attribute); len(values) > 0 {
+			md[key] = metadata.Value{Value: strings.Join(values, ",")}
+		}
+	}
+	env := authMeta.GetEnvironment()
+	for attribute, key := range c.config.Environment {
+		if values := entry.GetAttributeValues(attribute); len(values) > 0 {
+			env[key] = metadata.Value{Value: strings.Join(values, ",")}
+		}
+	}
+	return authMeta, nil
+}
+
+func (c *ldapClient) connect() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(
+		c.config.URL,
+		ldap.DialWithTLSConfig(c.tlsConfig),
+		ldap.DialWithDialer(&net.Dialer{Timeout: c.config.Timeout}),
+	)
+	if err != nil {
+		return nil, c.failed(err, "Failed to connect to the LDAP server")
+	}
+	conn.SetTimeout(c.config.Timeout)
+	if c.config.StartTLS {
+		if err := conn.StartTLS(c.tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, c.failed(err, "Failed to start TLS on the LDAP connection")
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the configured bind DN, or anonymously if no bind DN is configured.
+func (c *ldapClient) bindService(conn *ldap.Conn) error {
+	var err error
+	if c.config.BindDN == "" {
+		err = conn.UnauthenticatedBind("")
+	} else {
+		err = conn.Bind(c.config.BindDN, c.config.BindPassword)
+	}
+	if err != nil {
+		return c.failed(err, "Failed to bind as the LDAP bind DN")
+	}
+	return nil
+}
+
+func (c *ldapClient) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	filter, err := c.renderFilter(c.userFilter, ldapFilterData{Username: ldap.EscapeFilter(username)})
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{c.config.PublicKeyAttribute}
+	for attribute := range c.config.Metadata {
+		attributes = append(attributes, attribute)
+	}
+	for attribute := range c.config.Environment {
+		attributes = append(attributes, attribute)
+	}
+	result, err := conn.Search(
+		ldap.NewSearchRequest(
+			c.config.BaseDN,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			2,
+			int(c.config.Timeout.Seconds()),
+			false,
+			filter,
+			attributes,
+			nil,
+		),
+	)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, c.failed(err, "Failed to search for the user with the filter %s", filter)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		entries := 0
+		if result != nil {
+			entries = len(result.Entries)
+		}
+		return nil, message.UserMessage(
+			message.EAuthLDAPUserNotFound,
+			"Invalid username or password.",
+			"The LDAP user filter %s matched %d entries instead of exactly one.",
+			filter,
+			entries,
+		)
+	}
+	return result.Entries[0], nil
+}
+
+// findGroups returns the names of the groups of the user. If groups are not configured it returns nil. After a
+// password bind the connection is bound as the user, so it binds as the bind DN again before searching.
+func (c *ldapClient) findGroups(conn *ldap.Conn, username string, entry *ldap.Entry) ([]string, error) {
+	if c.config.GroupBaseDN == "" {
+		return nil, nil
+	}
+	if err := c.bindService(conn); err != nil {
+		return nil, err
+	}
+	filter, err := c.renderFilter(
+		c.groupFilter,
+		ldapFilterData{Username: ldap.EscapeFilter(username), DN: ldap.EscapeFilter(entry.DN)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	result, err := conn.Search(
+		ldap.NewSearchRequest(
+			c.config.GroupBaseDN,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			0,
+			int(c.config.Timeout.Seconds()),
+			false,
+			filter,
+			[]string{c.config.GroupNameAttribute},
+			nil,
+		),
+	)
+	if err != nil {
+		return nil, c.failed(err, "Failed to search for the groups with the filter %s", filter)
+	}
+	var groups []string
+	for _, group := range result.Entries {
+		groups = append(groups, group.GetAttributeValues(c.config.GroupNameAttribute)...)
+	}
+	return groups, nil
+}
+
+func (c *ldapClient) checkRequiredGroups(entry *ldap.Entry, groups []string) error {
+	if len(c.config.RequiredGroups) == 0 {
+		return nil
+	}
+	for _, required := range c.config.RequiredGroups {
+		for _, group := range groups {
+			if strings.EqualFold(required, group) {
+				return nil
+			}
+		}
+	}
+	return message.UserMessage(
+		message.EAuthLDAPGroupMismatch,
+		"You are not a member of a group permitted to log in.",
+		"%s is not a member of any of the required groups %s.",
+		entry.DN,
+		strings.Join(c.config.RequiredGroups, ", "),
+	)
+}
+
+func (c *ldapClient) renderFilter(tpl *template.Template, data ldapFilterData) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tpl.Execute(buf, data); err != nil {
+		return "", c.failed(err, "Failed to render the LDAP filter %s", tpl.Name())
+	}
+	return buf.String(), nil
+}
+
+func (c *ldapClient) failed(err error, explanation string, args ...interface{}) error {
+	return message.WrapUser(
+		err,
+		message.EAuthLDAPFailed,
+		"Authentication is currently unavailable.",
+		explanation,
+		args...,
+	)
+}
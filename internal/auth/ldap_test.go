@@ -0,0 +1,186 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	configuration "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+func newLDAPTestConfig(srv test.LDAPServerInstance) configuration.AuthLDAPConfig {
+	return configuration.AuthLDAPConfig{
+		URL:                srv.URL(),
+		Timeout:            5 * time.Second,
+		CACert:             srv.CACert(),
+		TLSVersion:         configuration.TLSVersion12,
+		BindDN:             "cn=admin,dc=example,dc=com",
+		BindPassword:       "admin",
+		BaseDN:             "ou=users,dc=example,dc=com",
+		UserFilter:         "(&(objectClass=person)(uid={{ .Username }}))",
+		GroupFilter:        "(member={{ .DN }})",
+		GroupNameAttribute: "cn",
+		PublicKeyAttribute: "sshPublicKey",
+	}
+}
+
+func setupLDAPClient(t *testing.T, cfg configuration.AuthLDAPConfig) auth.LDAPClient {
+	c, err := auth.NewLDAPClient(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func startLDAPTestServer(t *testing.T, ldaps bool, publicKey string) test.LDAPServerInstance {
+	return test.LDAPServer(
+		t,
+		ldaps,
+		test.LDAPEntry{
+			DN:       "cn=admin,dc=example,dc=com",
+			Password: "admin",
+		},
+		test.LDAPEntry{
+			DN:       "uid=foo,ou=users,dc=example,dc=com",
+			Password: "bar",
+			Attributes: map[string][]string{
+				"objectClass":  {"person"},
+				"uid":          {"foo"},
+				"mail":         {"foo@example.com"},
+				"loginShell":   {"/bin/bash"},
+				"sshPublicKey": {publicKey},
+			},
+		},
+		test.LDAPEntry{
+			DN:       "uid=baz,ou=users,dc=example,dc=com",
+			Password: "baz",
+			Attributes: map[string][]string{
+				"objectClass": {"person"},
+				"uid":         {"baz"},
+			},
+		},
+		test.LDAPEntry{
+			DN: "cn=admins,ou=groups,dc=example,dc=com",
+			Attributes: map[string][]string{
+				"cn":     {"admins"},
+				"member": {"uid=foo,ou=users,dc=example,dc=com"},
+			},
+		},
+		test.LDAPEntry{
+			DN: "cn=developers,ou=groups,dc=example,dc=com",
+			Attributes: map[string][]string{
+				"cn":     {"developers"},
+				"member": {"uid=foo,ou=users,dc=example,dc=com", "uid=baz,ou=users,dc=example,dc=com"},
+			},
+		},
+	)
+}
+
+func TestLDAPPassword(t *testing.T) {
+	srv := startLDAPTestServer(t, false, "")
+	c := setupLDAPClient(t, newLDAPTestConfig(srv))
+
+	for name, tc := range map[string]struct {
+		username string
+		password string
+		success  bool
+	}{
+		"success":        {"foo", "bar", true},
+		"wrong password": {"foo", "baz", false},
+		"empty password": {"foo", "", false},
+		"unknown user":   {"nobody", "bar", false},
+		"filter escape":  {"*", "bar", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			authContext := c.Password(metadata.NewTestAuthenticatingMetadata(tc.username), []byte(tc.password))
+			assert.Equal(t, tc.success, authContext.Success())
+			assert.NoError(t, authContext.Error())
+		})
+	}
+}
+
+func TestLDAPTLS(t *testing.T) {
+	t.Run("ldaps", func(t *testing.T) {
+		srv := startLDAPTestServer(t, true, "")
+		c := setupLDAPClient(t, newLDAPTestConfig(srv))
+		authContext := c.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+		assert.True(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+	t.Run("starttls", func(t *testing.T) {
+		srv := startLDAPTestServer(t, false, "")
+		cfg := newLDAPTestConfig(srv)
+		cfg.StartTLS = true
+		c := setupLDAPClient(t, cfg)
+		authContext := c.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+		assert.True(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+	t.Run("untrusted", func(t *testing.T) {
+		srv := startLDAPTestServer(t, true, "")
+		cfg := newLDAPTestConfig(srv)
+		cfg.CACert = test.LDAPServer(t, false).CACert()
+		c := setupLDAPClient(t, cfg)
+		authContext := c.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+		assert.False(t, authContext.Success())
+		assert.Error(t, authContext.Error())
+	})
+}
+
+func TestLDAPGroupsAndMetadata(t *testing.T) {
+	srv := startLDAPTestServer(t, false, "")
+	cfg := newLDAPTestConfig(srv)
+	cfg.GroupBaseDN = "ou=groups,dc=example,dc=com"
+	cfg.RequiredGroups = []string{"admins"}
+	cfg.Metadata = map[string]string{"mail": "EMAIL"}
+	cfg.Environment = map[string]string{"loginShell": "SHELL"}
+	c := setupLDAPClient(t, cfg)
+
+	authContext := c.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+	md := authContext.Metadata()
+	assert.Equal(t, "uid=foo,ou=users,dc=example,dc=com", md.Metadata[auth.MetadataLDAPDN].Value)
+	assert.Equal(t, "admins,developers", md.Metadata[auth.MetadataLDAPGroups].Value)
+	assert.Equal(t, "foo@example.com", md.Metadata["EMAIL"].Value)
+	assert.Equal(t, "/bin/bash", md.Environment["SHELL"].Value)
+
+	authContext = c.Password(metadata.NewTestAuthenticatingMetadata("baz"), []byte("baz"))
+	assert.False(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+}
+
+func TestLDAPPubKey(t *testing.T) {
+	line, pubKey := authorizedKeyLine(t, "")
+	_, otherKey := authorizedKeyLine(t, "")
+	srv := startLDAPTestServer(t, false, line)
+	c := setupLDAPClient(t, newLDAPTestConfig(srv))
+
+	authContext := c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), pubKey)
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+
+	authContext = c.PubKey(metadata.NewTestAuthenticatingMetadata("foo"), otherKey)
+	assert.False(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+
+	authContext = c.PubKey(metadata.NewTestAuthenticatingMetadata("baz"), pubKey)
+	assert.False(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+}
+
+func TestLDAPUnavailable(t *testing.T) {
+	srv := startLDAPTestServer(t, false, "")
+	cfg := newLDAPTestConfig(srv)
+	cfg.BindPassword = "wrong"
+	c := setupLDAPClient(t, cfg)
+	authContext := c.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+	assert.False(t, authContext.Success())
+	assert.Error(t, authContext.Error())
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// localAuthenticator holds the logger and metrics shared by the authenticators that check the credentials themselves
+// instead of asking a webhook.
+type localAuthenticator struct {
+	// authType is the value of the authtype label on the metrics.
+	authType string
+	// name is the human-readable name of the authenticator used in the log messages.
+	name string
+
+	logger            log.Logger
+	authSuccessMetric metrics.GeoCounter
+	authFailureMetric metrics.GeoCounter
+}
+
+func newLocalAuthenticator(
+	authType string,
+	name string,
+	logger log.Logger,
+	authSuccessMetric metrics.GeoCounter,
+	authFailureMetric metrics.GeoCounter,
+) localAuthenticator {
+	return localAuthenticator{
+		authType:          authType,
+		name:              name,
+		logger:            logger,
+		authSuccessMetric: authSuccessMetric,
+		authFailureMetric: authFailureMetric,
+	}
+}
+
+// authContext logs the result of the authentication, updates the metrics and returns the authentication context. An
+// error with the unavailableCode means that the credentials could not be checked at all, so it is returned in the
+// context and the authenticator is reported as unavailable. Any other error only rejects the user.
+func (l localAuthenticator) authContext(
+	meta metadata.ConnectionAuthPendingMetadata,
+	authMeta metadata.ConnectionAuthenticatedMetadata,
+	err error,
+	unavailableCode string,
+) AuthenticationContext {
+	logger := l.logger.
+		WithLabel("connectionId", meta.ConnectionID).
+		WithLabel("username", meta.Username)
+	labels := []metrics.MetricLabel{
+		metrics.Label("authtype", l.authType),
+	}
+
+	if err != nil {
+		l.authFailureMetric.Increment(meta.RemoteAddress.IP, labels...)
+		var typedErr message.Message
+		if errors.As(err, &typedErr) && typedErr.Code() == unavailableCode {
+			logger.Error(err)
+			return &localAuthContext{authMeta, false, err}
+		}
+		logger.Debug(err)
+		return &localAuthContext{authMeta, false, nil}
+	}
+	logger.Debug(
+		message.NewMessage(
+			message.MAuthSuccessful,
+			"%s authentication successful",
+			l.name,
+		),
+	)
+	l.authSuccessMetric.Increment(meta.RemoteAddress.IP, labels...)
+	return &localAuthContext{authMeta, true, nil}
+}
+
+type localAuthContext struct {
+	meta    metadata.ConnectionAuthenticatedMetadata
+	success bool
+	err     error
+}
+
+func (l localAuthContext) AuthenticatedUsername() string {
+	return l.meta.AuthenticatedUsername
+}
+
+func (l localAuthContext) Success() bool {
+	return l.success
+}
+
+func (l localAuthContext) Error() error {
+	return l.err
+}
+
+func (l localAuthContext) Metadata() metadata.ConnectionAuthenticatedMetadata {
+	return l.meta
+}
+
+func (l localAuthContext) OnDisconnect() {
+}
@@ -12,4 +12,14 @@ const (
 
 	// MetricNameAuthFailure captures the number of failed authentication attempts.
 	MetricNameAuthFailure = "containerssh_auth_failures_total"
+
+	// MetricNameAuthCacheHits is the number of webhook decisions served from the cache.
+	MetricNameAuthCacheHits = "containerssh_auth_cache_hits_total"
+
+	// MetricNameAuthCacheMisses is the number of cacheable webhook decisions that were not found in the cache.
+	MetricNameAuthCacheMisses = "containerssh_auth_cache_misses_total"
+
+	// MetricNameAuthCircuitBreakerStateChanges is the number of times the circuit breaker of an authentication server
+	// changed state.
+	MetricNameAuthCircuitBreakerStateChanges = "containerssh_auth_server_circuit_breaker_state_changes_total"
 )
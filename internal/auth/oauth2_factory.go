@@ -117,6 +117,16 @@ func NewOAuth2Client(cfg config.AuthOAuth2ClientConfig, logger log.Logger, colle
 		if err != nil {
 			return nil, nil, err
 		}
+	case config.AuthOAuth2GitLabProvider:
+		provider, err = newGitLabProvider(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+	case config.AuthOAuth2GiteaProvider:
+		provider, err = newGiteaProvider(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
 	default:
 		return nil, nil, message.NewMessage(
 			message.EAuthConfigError,
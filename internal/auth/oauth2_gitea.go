@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// giteaOrgPageSize is the number of organizations fetched per request.
+const giteaOrgPageSize = 50
+
+// newGiteaProvider creates a new OAuth2 provider for a Gitea instance.
+func newGiteaProvider(cfg config.AuthOAuth2ClientConfig, logger log.Logger) (OAuth2Provider, error) {
+	if cfg.Provider != config.AuthOAuth2GiteaProvider {
+		return nil, fmt.Errorf("Gitea is not configured as the oAuth2 provider")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid Gitea configuration (%w)", err)
+	}
+
+	clientConfig := cfg.Gitea.HTTPClientConfiguration
+	clientConfig.URL = strings.TrimSuffix(clientConfig.URL, "/")
+	clientConfig.RequestEncoding = config.RequestEncodingWWWURLEncoded
+
+	return &giteaProvider{
+		logger:       logger,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		config:       cfg.Gitea,
+		clientConfig: clientConfig,
+		requireOrgs:  cfg.Gitea.RequireOrgMembership,
+		scopes:       cfg.Gitea.ExtraScopes,
+	}, nil
+}
+
+type giteaProvider struct {
+	logger       log.Logger
+	clientID     string
+	clientSecret string
+	config       config.AuthGiteaConfig
+	clientConfig config.HTTPClientConfiguration
+	requireOrgs  []string
+	scopes       []string
+}
+
+func (p *giteaProvider) SupportsDeviceFlow() bool {
+	return false
+}
+
+func (p *giteaProvider) GetDeviceFlow(_ context.Context, _ metadata.ConnectionAuthPendingMetadata) (OAuth2DeviceFlow, error) {
+	panic(fmt.Errorf("BUG: the Gitea provider does not support the device flow"))
+}
+
+func (p *giteaProvider) SupportsAuthorizationCodeFlow() bool {
+	return true
+}
+
+func (p *giteaProvider) GetAuthorizationCodeFlow(_ context.Context, meta metadata.ConnectionAuthPendingMetadata) (OAuth2AuthorizationCodeFlow, error) {
+	logger := p.logger.WithLabel("connectionID", meta.ConnectionID).WithLabel("username", meta.Username)
+
+	client, err := http.NewClientWithHeaders(p.clientConfig, logger, nil, true)
+	if err != nil {
+		return nil, message.WrapUser(
+			err,
+			message.EAuthGiteaHTTPClientCreateFailed,
+			"Authentication currently unavailable.",
+			"Cannot create Gitea authenticator because the HTTP client configuration failed.",
+		)
+	}
+
+	return &giteaAuthorizationCodeFlow{
+		giteaFlow: giteaFlow{
+			provider: p,
+			meta:     meta,
+			logger:   logger,
+			client:   client,
+		},
+	}, nil
+}
+
+// getScope returns the space-separated scopes. Gitea only enforces scopes on OAuth2 tokens in newer versions, older
+// versions ignore them.
+func (p *giteaProvider) getScope() string {
+	scopes := []string{"read:user", "read:organization"}
+	for _, scope := range p.scopes {
+		if scope != "read:user" && scope != "read:organization" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+type giteaAccessTokenRequest struct {
+	ClientID     string `schema:"client_id"`
+	ClientSecret string `schema:"client_secret"`
+	Code         string `schema:"code"`
+	GrantType    string `schema:"grant_type"`
+	RedirectURI  string `schema:"redirect_uri"`
+}
+
+type giteaAccessTokenResponse struct {
+	AccessToken      string `json:"access_token,omitempty"`
+	TokenType        string `json:"token_type,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type giteaUserResponse struct {
+	ID        uint64 `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type giteaOrgResponse struct {
+	ID       uint64 `json:"id"`
+	Name     string `json:"name"`
+	UserName string `json:"username"`
+}
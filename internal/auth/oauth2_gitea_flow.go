@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type giteaFlow struct {
+	provider    *giteaProvider
+	meta        metadata.ConnectionAuthPendingMetadata
+	logger      log.Logger
+	client      http.Client
+	accessToken string
+}
+
+func (g *giteaFlow) getAPIClient(accessToken string) (http.Client, error) {
+	cfg := g.provider.clientConfig
+	cfg.RequestEncoding = config.RequestEncodingJSON
+	client, err := http.NewClientWithHeaders(
+		cfg,
+		g.logger,
+		map[string][]string{
+			"authorization": {"Bearer " + accessToken},
+		},
+		true,
+	)
+	if err != nil {
+		return nil, message.WrapUser(
+			err,
+			message.EAuthGiteaHTTPClientCreateFailed,
+			"Authentication currently unavailable.",
+			"Cannot create Gitea authenticator because the HTTP client configuration failed.",
+		)
+	}
+	return client, nil
+}
+
+// getJSON fetches an API endpoint and retries on server errors until ctx is canceled.
+func (g *giteaFlow) getJSON(ctx context.Context, client http.Client, path string, response interface{}) error {
+	for {
+		statusCode, err := client.Get(path, response)
+		if err == nil && statusCode == 200 {
+			return nil
+		}
+		if err == nil {
+			err = message.UserMessage(
+				message.EAuthGiteaUserRequestFailed,
+				"Cannot authenticate at this time.",
+				"Non-200 status code from Gitea %s endpoint (%d).",
+				path,
+				statusCode,
+			)
+			if statusCode > 399 && statusCode < 500 {
+				g.logger.Debug(err)
+				return err
+			}
+		}
+		g.logger.Debug(err)
+		select {
+		case <-ctx.Done():
+			return message.WrapUser(
+				err,
+				message.EAuthGiteaUserRequestFailed,
+				"Timeout while trying to fetch your identity from Gitea.",
+				"Timeout while trying to fetch the user identity from Gitea.",
+			)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// getOrgs returns the names of all organizations the user is a member of.
+func (g *giteaFlow) getOrgs(ctx context.Context, client http.Client) ([]string, error) {
+	var orgs []string
+	for page := 1; ; page++ {
+		var response []giteaOrgResponse
+		if err := g.getJSON(
+			ctx,
+			client,
+			fmt.Sprintf("/api/v1/user/orgs?page=%d&limit=%d", page, giteaOrgPageSize),
+			&response,
+		); err != nil {
+			return nil, err
+		}
+		for _, org := range response {
+			name := org.Name
+			if name == "" {
+				// Older Gitea versions only return the deprecated username field.
+				name = org.UserName
+			}
+			orgs = append(orgs, name)
+		}
+		if len(response) < giteaOrgPageSize {
+			return orgs, nil
+		}
+	}
+}
+
+func (g *giteaFlow) getIdentity(
+	ctx context.Context,
+	accessToken string,
+) (string, metadata.ConnectionAuthenticatedMetadata, error) {
+	meta := g.meta
+	apiClient, err := g.getAPIClient(accessToken)
+	if err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	user := &giteaUserResponse{}
+	if err := g.getJSON(ctx, apiClient, "/api/v1/user", user); err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+	if g.provider.config.EnforceUsername && user.Login != meta.Username {
+		err := message.UserMessage(
+			message.EAuthGiteaUsernameDoesNotMatch,
+			"Your Gitea username does not match your SSH login. Please try again and specify your Gitea username when connecting.",
+			"User did not use their Gitea username in the SSH login.",
+		)
+		g.logger.Debug(err)
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	orgs, err := g.getOrgs(ctx, apiClient)
+	if err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+	if len(g.provider.requireOrgs) > 0 && !isGroupMember(orgs, g.provider.requireOrgs) {
+		err := message.UserMessage(
+			message.EAuthGiteaNotOrgMember,
+			"You are not a member of any of the Gitea organizations allowed to access this server.",
+			"The user is not a member of any of the required organizations: %s",
+			strings.Join(g.provider.requireOrgs, ", "),
+		)
+		g.logger.Debug(err)
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	m := meta.GetMetadata()
+	// Note: we are adding personally identifiable data as sensitive so it is not passed around or logged needlessly.
+	m["GITEA_TOKEN"] = metadata.Value{Value: accessToken, Sensitive: true}
+	m["GITEA_LOGIN"] = metadata.Value{Value: user.Login, Sensitive: true}
+	m["GITEA_ID"] = metadata.Value{Value: fmt.Sprintf("%d", user.ID), Sensitive: true}
+	m["GITEA_NAME"] = metadata.Value{Value: user.FullName, Sensitive: true}
+	m["GITEA_EMAIL"] = metadata.Value{Value: user.Email, Sensitive: true}
+	m["GITEA_AVATAR_URL"] = metadata.Value{Value: user.AvatarURL, Sensitive: true}
+	m["GITEA_ORGS"] = metadata.Value{Value: strings.Join(orgs, ",")}
+	return accessToken, meta.Authenticated(user.Login), nil
+}
+
+func (g *giteaFlow) Deauthorize(_ context.Context) {
+	// Gitea has no endpoint to revoke OAuth2 access tokens. They expire on their own, and users can revoke the grant
+	// in their account settings.
+	g.accessToken = ""
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type giteaAuthorizationCodeFlow struct {
+	giteaFlow
+}
+
+func (g *giteaAuthorizationCodeFlow) GetAuthorizationURL(_ context.Context) (string, error) {
+	link, err := url.Parse(g.provider.clientConfig.URL + "/login/oauth/authorize")
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("client_id", g.provider.clientID)
+	query.Set("redirect_uri", g.provider.config.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("scope", g.provider.getScope())
+	query.Set("state", g.meta.ConnectionID)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+func (g *giteaAuthorizationCodeFlow) Verify(ctx context.Context, state string, authorizationCode string) (
+	string,
+	metadata.ConnectionAuthenticatedMetadata,
+	error,
+) {
+	if state != g.meta.ConnectionID {
+		return "", g.meta.AuthFailed(), message.UserMessage(
+			message.EAuthOAuth2StateMismatch,
+			"The returned code is invalid.",
+			"The user provided a code that contained an invalid state component.",
+		)
+	}
+	req := &giteaAccessTokenRequest{
+		ClientID:     g.provider.clientID,
+		ClientSecret: g.provider.clientSecret,
+		Code:         authorizationCode,
+		GrantType:    "authorization_code",
+		RedirectURI:  g.provider.config.RedirectURI,
+	}
+	var lastError error
+loop:
+	for {
+		resp := &giteaAccessTokenResponse{}
+		statusCode, err := g.client.Post("/login/oauth/access_token", req, resp)
+		if err == nil && statusCode == 200 && resp.Error == "" {
+			g.accessToken = resp.AccessToken
+			return g.getIdentity(ctx, resp.AccessToken)
+		}
+		if statusCode != 200 || resp.Error != "" {
+			err = message.UserMessage(
+				message.EAuthGiteaAccessTokenFetchFailed,
+				"Cannot authenticate at this time.",
+				"Non-200 status code from Gitea access token API (%d; %s; %s).",
+				statusCode,
+				resp.Error,
+				resp.ErrorDescription,
+			)
+		}
+		lastError = err
+		g.logger.Debug(err)
+		if resp.Error != "" || (statusCode > 399 && statusCode < 500) {
+			// The OAuth2 server rejected the code, retrying will not help.
+			return "", g.meta.AuthFailed(), err
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-time.After(10 * time.Second):
+		}
+	}
+	err := message.WrapUser(
+		lastError,
+		message.EAuthOAuth2Timeout,
+		"Timeout while trying to obtain Gitea authentication data.",
+		"Timeout while trying to obtain Gitea authentication data.",
+	)
+	g.logger.Debug(err)
+	return "", g.meta.AuthFailed(), err
+}
@@ -0,0 +1,68 @@
+package auth_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+func TestGiteaAuthorizationCodeFlow(t *testing.T) {
+	server := test.GiteaServer(t)
+	// More organizations than fit on a single page.
+	var orgs []string
+	for i := 0; i < 60; i++ {
+		orgs = append(orgs, fmt.Sprintf("org%d", i))
+	}
+	server.AddUser("alice", orgs...)
+	server.AddUser("bob", "other")
+
+	client := newTestOAuth2Client(t, config.AuthOAuth2ClientConfig{
+		ClientID:     server.ClientID(),
+		ClientSecret: server.ClientSecret(),
+		Provider:     config.AuthOAuth2GiteaProvider,
+		Gitea: config.AuthGiteaConfig{
+			HTTPClientConfiguration: config.HTTPClientConfiguration{
+				URL:     server.BaseURL(),
+				Timeout: 2 * time.Second,
+			},
+			RedirectURI:          testRedirectURI,
+			EnforceUsername:      true,
+			RequireOrgMembership: []string{"org59"},
+		},
+	})
+
+	t.Run("success", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("alice"),
+			authorizationCodeLogin(t, server.Login, "alice"),
+		)
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+		md := authContext.Metadata()
+		assert.Equal(t, "alice", md.AuthenticatedUsername)
+		assert.Equal(t, strings.Join(orgs, ","), md.GetMetadata()["GITEA_ORGS"].Value)
+		assert.Equal(t, "alice@example.com", md.GetMetadata()["GITEA_EMAIL"].Value)
+	})
+	t.Run("not an org member", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("bob"),
+			authorizationCodeLogin(t, server.Login, "bob"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+	})
+	t.Run("username mismatch", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("bob"),
+			authorizationCodeLogin(t, server.Login, "alice"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+	})
+}
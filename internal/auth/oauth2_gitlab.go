@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// newGitLabProvider creates a new OAuth2 provider for GitLab.com or a self-hosted GitLab instance.
+func newGitLabProvider(cfg config.AuthOAuth2ClientConfig, logger log.Logger) (OAuth2Provider, error) {
+	if cfg.Provider != config.AuthOAuth2GitLabProvider {
+		return nil, fmt.Errorf("GitLab is not configured as the oAuth2 provider")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid GitLab configuration (%w)", err)
+	}
+
+	clientConfig := cfg.GitLab.HTTPClientConfiguration
+	clientConfig.URL = strings.TrimSuffix(clientConfig.URL, "/")
+	clientConfig.RequestEncoding = config.RequestEncodingWWWURLEncoded
+
+	return &gitLabProvider{
+		logger:        logger,
+		clientID:      cfg.ClientID,
+		clientSecret:  cfg.ClientSecret,
+		config:        cfg.GitLab,
+		clientConfig:  clientConfig,
+		requireGroups: cfg.GitLab.RequireGroupMembership,
+		scopes:        cfg.GitLab.ExtraScopes,
+	}, nil
+}
+
+type gitLabProvider struct {
+	logger        log.Logger
+	clientID      string
+	clientSecret  string
+	config        config.AuthGitLabConfig
+	clientConfig  config.HTTPClientConfiguration
+	requireGroups []string
+	scopes        []string
+}
+
+func (p *gitLabProvider) SupportsDeviceFlow() bool {
+	return p.config.DeviceFlow
+}
+
+func (p *gitLabProvider) GetDeviceFlow(ctx context.Context, meta metadata.ConnectionAuthPendingMetadata) (OAuth2DeviceFlow, error) {
+	flow, err := p.createFlow(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitLabDeviceFlow{
+		gitLabFlow: flow,
+		interval:   5 * time.Second,
+	}, nil
+}
+
+func (p *gitLabProvider) SupportsAuthorizationCodeFlow() bool {
+	return p.config.AuthorizationCodeFlow
+}
+
+func (p *gitLabProvider) GetAuthorizationCodeFlow(ctx context.Context, meta metadata.ConnectionAuthPendingMetadata) (OAuth2AuthorizationCodeFlow, error) {
+	flow, err := p.createFlow(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitLabAuthorizationCodeFlow{
+		gitLabFlow: flow,
+	}, nil
+}
+
+func (p *gitLabProvider) createFlow(meta metadata.ConnectionAuthPendingMetadata) (gitLabFlow, error) {
+	logger := p.logger.WithLabel("connectionID", meta.ConnectionID).WithLabel("username", meta.Username)
+
+	client, err := http.NewClientWithHeaders(p.clientConfig, logger, nil, true)
+	if err != nil {
+		return gitLabFlow{}, message.WrapUser(
+			err,
+			message.EAuthGitLabHTTPClientCreateFailed,
+			"Authentication currently unavailable.",
+			"Cannot create GitLab authenticator because the HTTP client configuration failed.",
+		)
+	}
+
+	return gitLabFlow{
+		provider: p,
+		meta:     meta,
+		logger:   logger,
+		client:   client,
+	}, nil
+}
+
+// getScope returns the space-separated scopes. The openid scope is needed to read the group memberships from the
+// userinfo endpoint, the read_user scope to read the user profile.
+func (p *gitLabProvider) getScope() string {
+	scopes := []string{"openid", "read_user"}
+	for _, scope := range p.scopes {
+		if scope != "openid" && scope != "read_user" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+type gitLabDeviceRequest struct {
+	ClientID string `schema:"client_id"`
+	Scope    string `schema:"scope"`
+}
+
+type gitLabDeviceResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               uint   `json:"expires_in"`
+	Interval                uint   `json:"interval"`
+	Error                   string `json:"error"`
+	ErrorDescription        string `json:"error_description"`
+}
+
+type gitLabAccessTokenRequest struct {
+	ClientID     string `schema:"client_id"`
+	ClientSecret string `schema:"client_secret,omitempty"`
+	Code         string `schema:"code,omitempty"`
+	DeviceCode   string `schema:"device_code,omitempty"`
+	GrantType    string `schema:"grant_type"`
+	RedirectURI  string `schema:"redirect_uri,omitempty"`
+}
+
+type gitLabAccessTokenResponse struct {
+	AccessToken      string `json:"access_token,omitempty"`
+	TokenType        string `json:"token_type,omitempty"`
+	Scope            string `json:"scope,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type gitLabRevokeRequest struct {
+	ClientID     string `schema:"client_id"`
+	ClientSecret string `schema:"client_secret"`
+	Token        string `schema:"token"`
+}
+
+type gitLabUserResponse struct {
+	ID        uint64 `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	State     string `json:"state"`
+	AvatarURL string `json:"avatar_url"`
+	WebURL    string `json:"web_url"`
+}
+
+type gitLabUserInfoResponse struct {
+	Groups []string `json:"groups"`
+}
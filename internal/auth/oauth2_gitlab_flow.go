@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type gitLabFlow struct {
+	provider    *gitLabProvider
+	meta        metadata.ConnectionAuthPendingMetadata
+	logger      log.Logger
+	client      http.Client
+	accessToken string
+}
+
+// getAccessToken requests an access token from the token endpoint. It returns the error code of the OAuth2 server
+// separately so the device flow can keep polling while the authorization is pending.
+func (g *gitLabFlow) getAccessToken(req *gitLabAccessTokenRequest) (string, string, error) {
+	resp := &gitLabAccessTokenResponse{}
+	statusCode, err := g.client.Post("/oauth/token", req, resp)
+	if err != nil && resp.Error == "" {
+		return "", "", err
+	}
+	if statusCode != 200 || resp.Error != "" {
+		return "", resp.Error, message.UserMessage(
+			message.EAuthGitLabAccessTokenFetchFailed,
+			"Cannot authenticate at this time.",
+			"Non-200 status code from GitLab access token API (%d; %s; %s).",
+			statusCode,
+			resp.Error,
+			resp.ErrorDescription,
+		)
+	}
+	return resp.AccessToken, "", nil
+}
+
+func (g *gitLabFlow) getAPIClient(accessToken string) (http.Client, error) {
+	cfg := g.provider.clientConfig
+	cfg.RequestEncoding = config.RequestEncodingJSON
+	client, err := http.NewClientWithHeaders(
+		cfg,
+		g.logger,
+		map[string][]string{
+			"authorization": {"Bearer " + accessToken},
+		},
+		true,
+	)
+	if err != nil {
+		return nil, message.WrapUser(
+			err,
+			message.EAuthGitLabHTTPClientCreateFailed,
+			"Authentication currently unavailable.",
+			"Cannot create GitLab authenticator because the HTTP client configuration failed.",
+		)
+	}
+	return client, nil
+}
+
+// getJSON fetches an API endpoint and retries on server errors until ctx is canceled.
+func (g *gitLabFlow) getJSON(ctx context.Context, client http.Client, path string, response interface{}) error {
+	for {
+		statusCode, err := client.Get(path, response)
+		if err == nil && statusCode == 200 {
+			return nil
+		}
+		if err == nil {
+			err = message.UserMessage(
+				message.EAuthGitLabUserRequestFailed,
+				"Cannot authenticate at this time.",
+				"Non-200 status code from GitLab %s endpoint (%d).",
+				path,
+				statusCode,
+			)
+			if statusCode > 399 && statusCode < 500 {
+				g.logger.Debug(err)
+				return err
+			}
+		}
+		g.logger.Debug(err)
+		select {
+		case <-ctx.Done():
+			return message.WrapUser(
+				err,
+				message.EAuthGitLabUserRequestFailed,
+				"Timeout while trying to fetch your identity from GitLab.",
+				"Timeout while trying to fetch the user identity from GitLab.",
+			)
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (g *gitLabFlow) getIdentity(
+	ctx context.Context,
+	accessToken string,
+) (string, metadata.ConnectionAuthenticatedMetadata, error) {
+	meta := g.meta
+	apiClient, err := g.getAPIClient(accessToken)
+	if err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	user := &gitLabUserResponse{}
+	if err := g.getJSON(ctx, apiClient, "/api/v4/user", user); err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+	if g.provider.config.EnforceUsername && user.Username != meta.Username {
+		err := message.UserMessage(
+			message.EAuthGitLabUsernameDoesNotMatch,
+			"Your GitLab username does not match your SSH login. Please try again and specify your GitLab username when connecting.",
+			"User did not use their GitLab username in the SSH login.",
+		)
+		g.logger.Debug(err)
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	userInfo := &gitLabUserInfoResponse{}
+	if err := g.getJSON(ctx, apiClient, "/oauth/userinfo", userInfo); err != nil {
+		return accessToken, meta.AuthFailed(), err
+	}
+	if len(g.provider.requireGroups) > 0 && !isGroupMember(userInfo.Groups, g.provider.requireGroups) {
+		err := message.UserMessage(
+			message.EAuthGitLabNotGroupMember,
+			"You are not a member of any of the GitLab groups allowed to access this server.",
+			"The user is not a member of any of the required groups: %s",
+			strings.Join(g.provider.requireGroups, ", "),
+		)
+		g.logger.Debug(err)
+		return accessToken, meta.AuthFailed(), err
+	}
+
+	m := meta.GetMetadata()
+	// Note: we are adding personally identifiable data as sensitive so it is not passed around or logged needlessly.
+	m["GITLAB_TOKEN"] = metadata.Value{Value: accessToken, Sensitive: true}
+	m["GITLAB_LOGIN"] = metadata.Value{Value: user.Username, Sensitive: true}
+	m["GITLAB_ID"] = metadata.Value{Value: fmt.Sprintf("%d", user.ID), Sensitive: true}
+	m["GITLAB_NAME"] = metadata.Value{Value: user.Name, Sensitive: true}
+	m["GITLAB_EMAIL"] = metadata.Value{Value: user.Email, Sensitive: true}
+	m["GITLAB_AVATAR_URL"] = metadata.Value{Value: user.AvatarURL, Sensitive: true}
+	m["GITLAB_PROFILE_URL"] = metadata.Value{Value: user.WebURL, Sensitive: true}
+	m["GITLAB_GROUPS"] = metadata.Value{Value: strings.Join(userInfo.Groups, ",")}
+	return accessToken, meta.Authenticated(user.Username), nil
+}
+
+// isGroupMember returns true if any of the memberships matches any of the required groups or organizations. The
+// comparison is case-insensitive as both GitLab and Gitea treat names case-insensitively.
+func isGroupMember(memberships []string, required []string) bool {
+	for _, membership := range memberships {
+		for _, group := range required {
+			if strings.EqualFold(membership, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (g *gitLabFlow) Deauthorize(ctx context.Context) {
+	if g.accessToken == "" {
+		return
+	}
+	req := &gitLabRevokeRequest{
+		ClientID:     g.provider.clientID,
+		ClientSecret: g.provider.clientSecret,
+		Token:        g.accessToken,
+	}
+	for {
+		statusCode, err := g.client.Post("/oauth/revoke", req, nil)
+		if err == nil && statusCode == 200 {
+			g.accessToken = ""
+			return
+		}
+		if err == nil {
+			err = message.NewMessage(
+				message.EAuthGitLabDeauthorizeFailed,
+				"Failed to revoke GitLab access token, invalid status code: %d",
+				statusCode,
+			)
+		}
+		g.logger.Debug(err)
+		if statusCode > 399 && statusCode < 500 {
+			return
+		}
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type gitLabDeviceFlow struct {
+	gitLabFlow
+
+	interval   time.Duration
+	deviceCode string
+}
+
+func (d *gitLabDeviceFlow) GetAuthorizationURL(ctx context.Context) (
+	verificationLink string,
+	userCode string,
+	expiration time.Duration,
+	err error,
+) {
+	req := &gitLabDeviceRequest{
+		ClientID: d.provider.clientID,
+		Scope:    d.provider.getScope(),
+	}
+	var lastError error
+loop:
+	for {
+		resp := &gitLabDeviceResponse{}
+		var statusCode int
+		statusCode, lastError = d.client.Post("/oauth/authorize_device", req, resp)
+		if lastError == nil {
+			if statusCode == 200 {
+				if resp.Interval > 0 {
+					d.interval = time.Duration(resp.Interval) * time.Second
+				}
+				d.deviceCode = resp.DeviceCode
+				verificationLink = resp.VerificationURI
+				if resp.VerificationURIComplete != "" {
+					verificationLink = resp.VerificationURIComplete
+				}
+				return verificationLink, resp.UserCode, time.Duration(resp.ExpiresIn) * time.Second, nil
+			}
+			lastError = message.UserMessage(
+				message.EAuthOAuth2DeviceCodeRequestFailed,
+				"Cannot authenticate at this time.",
+				"Non-200 status code from GitLab device code API (%d; %s; %s).",
+				statusCode,
+				resp.Error,
+				resp.ErrorDescription,
+			)
+			if statusCode > 399 && statusCode < 500 {
+				// The device flow is most likely not enabled for the application.
+				d.logger.Debug(lastError)
+				return "", "", 0, lastError
+			}
+		}
+		d.logger.Debug(lastError)
+		select {
+		case <-time.After(10 * time.Second):
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	err = message.WrapUser(
+		lastError,
+		message.EAuthOAuth2Timeout,
+		"Cannot authenticate at this time.",
+		"Timeout while trying to obtain a GitLab device code.",
+	)
+	d.logger.Debug(err)
+	return "", "", 0, err
+}
+
+func (d *gitLabDeviceFlow) Verify(ctx context.Context) (string, metadata.ConnectionAuthenticatedMetadata, error) {
+	req := &gitLabAccessTokenRequest{
+		ClientID:     d.provider.clientID,
+		ClientSecret: d.provider.clientSecret,
+		DeviceCode:   d.deviceCode,
+		GrantType:    "urn:ietf:params:oauth:grant-type:device_code",
+	}
+	var lastError error
+loop:
+	for {
+		accessToken, errorCode, err := d.getAccessToken(req)
+		switch errorCode {
+		case "":
+			if err == nil {
+				d.accessToken = accessToken
+				return d.getIdentity(ctx, accessToken)
+			}
+		case "authorization_pending":
+			err = message.NewMessage(
+				message.EAuthOAuth2AuthorizationPending,
+				"User authorization still pending, retrying in %s.",
+				d.interval,
+			)
+		case "slow_down":
+			// RFC 8628 requires increasing the polling interval by 5 seconds.
+			d.interval += 5 * time.Second
+		case "access_denied":
+			return "", d.meta.AuthFailed(), message.UserMessage(
+				message.EAuthFailed,
+				"GitLab authentication failed",
+				"User canceled GitLab authentication",
+			)
+		default:
+			return "", d.meta.AuthFailed(), err
+		}
+		lastError = err
+		d.logger.Debug(err)
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-time.After(d.interval):
+		}
+	}
+	err := message.WrapUser(
+		lastError,
+		message.EAuthOAuth2Timeout,
+		"Timeout while trying to obtain GitLab authentication data.",
+		"Timeout while trying to obtain GitLab authentication data.",
+	)
+	d.logger.Debug(err)
+	return "", d.meta.AuthFailed(), err
+}
@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+const testRedirectURI = "http://127.0.0.1:8080"
+
+func newTestOAuth2Client(t *testing.T, cfg config.AuthOAuth2ClientConfig) auth.KeyboardInteractiveAuthenticator {
+	cfg.Redirect.Listen = "127.0.0.1:8080"
+	client, _, err := auth.NewOAuth2Client(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// authorizationCodeLogin returns a keyboard-interactive challenge handler that logs in on the authorization URL as
+// the specified user and answers with the code the redirect page would display.
+func authorizationCodeLogin(
+	t *testing.T,
+	login func(authorizationURL string, username string) (string, string, error),
+	username string,
+) func(string, auth.KeyboardInteractiveQuestions) (auth.KeyboardInteractiveAnswers, error) {
+	return func(
+		instruction string,
+		questions auth.KeyboardInteractiveQuestions,
+	) (auth.KeyboardInteractiveAnswers, error) {
+		fields := strings.Fields(instruction)
+		if len(fields) == 0 || len(questions) != 1 {
+			t.Fatalf("unexpected challenge: %s", instruction)
+		}
+		state, code, err := login(fields[len(fields)-1], username)
+		if err != nil {
+			return auth.KeyboardInteractiveAnswers{}, err
+		}
+		return auth.KeyboardInteractiveAnswers{
+			Answers: map[string]string{
+				questions[0].ID: state + "|" + code,
+			},
+		}, nil
+	}
+}
+
+func newGitLabTestConfig(server test.GitLabServerInstance) config.AuthOAuth2ClientConfig {
+	return config.AuthOAuth2ClientConfig{
+		ClientID:     server.ClientID(),
+		ClientSecret: server.ClientSecret(),
+		Provider:     config.AuthOAuth2GitLabProvider,
+		GitLab: config.AuthGitLabConfig{
+			HTTPClientConfiguration: config.HTTPClientConfiguration{
+				URL:     server.BaseURL(),
+				Timeout: 2 * time.Second,
+			},
+			AuthorizationCodeFlow: true,
+			RedirectURI:           testRedirectURI,
+			EnforceUsername:       true,
+		},
+	}
+}
+
+func TestGitLabAuthorizationCodeFlow(t *testing.T) {
+	server := test.GitLabServer(t)
+	server.AddUser("alice", "example", "example/infra")
+	server.AddUser("bob", "other")
+
+	cfg := newGitLabTestConfig(server)
+	cfg.GitLab.RequireGroupMembership = []string{"example/infra"}
+	client := newTestOAuth2Client(t, cfg)
+
+	t.Run("success", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("alice"),
+			authorizationCodeLogin(t, server.Login, "alice"),
+		)
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+		md := authContext.Metadata()
+		assert.Equal(t, "alice", md.AuthenticatedUsername)
+		assert.Equal(t, "example,example/infra", md.GetMetadata()["GITLAB_GROUPS"].Value)
+		assert.Equal(t, "alice", md.GetMetadata()["GITLAB_LOGIN"].Value)
+		assert.True(t, md.GetMetadata()["GITLAB_TOKEN"].Sensitive)
+
+		authContext.OnDisconnect()
+		assert.Equal(t, 0, server.ActiveTokens())
+	})
+	t.Run("not a group member", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("bob"),
+			authorizationCodeLogin(t, server.Login, "bob"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+		authContext.OnDisconnect()
+		assert.Equal(t, 0, server.ActiveTokens())
+	})
+	t.Run("username mismatch", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("bob"),
+			authorizationCodeLogin(t, server.Login, "alice"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+	})
+	t.Run("invalid code", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("alice"),
+			func(
+				instruction string,
+				questions auth.KeyboardInteractiveQuestions,
+			) (auth.KeyboardInteractiveAnswers, error) {
+				return auth.KeyboardInteractiveAnswers{
+					Answers: map[string]string{questions[0].ID: "wrong|wrong"},
+				}, nil
+			},
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+	})
+}
+
+func TestGitLabDeviceFlow(t *testing.T) {
+	server := test.GitLabServer(t)
+	server.AddUser("alice", "example")
+
+	cfg := newGitLabTestConfig(server)
+	cfg.GitLab.DeviceFlow = true
+	cfg.GitLab.AuthorizationCodeFlow = false
+	cfg.GitLab.EnforceUsername = false
+	client := newTestOAuth2Client(t, cfg)
+
+	authContext := client.KeyboardInteractive(
+		metadata.NewTestAuthenticatingMetadata("root"),
+		func(
+			instruction string,
+			questions auth.KeyboardInteractiveQuestions,
+		) (auth.KeyboardInteractiveAnswers, error) {
+			assert.Empty(t, questions)
+			fields := strings.Fields(instruction)
+			userCode := fields[len(fields)-1]
+			if err := server.ApproveDevice(userCode, "alice"); err != nil {
+				return auth.KeyboardInteractiveAnswers{}, fmt.Errorf("failed to approve device (%w)", err)
+			}
+			return auth.KeyboardInteractiveAnswers{}, nil
+		},
+	)
+	assert.NoError(t, authContext.Error())
+	assert.True(t, authContext.Success())
+	md := authContext.Metadata()
+	assert.Equal(t, "alice", md.AuthenticatedUsername)
+	assert.Equal(t, "example", md.GetMetadata()["GITLAB_GROUPS"].Value)
+}
@@ -13,24 +13,34 @@ import (
 	"go.containerssh.io/containerssh/metadata"
 )
 
-func newOIDCProvider(config config.AuthOAuth2ClientConfig, logger log.Logger) (OAuth2Provider, error) {
+func newOIDCProvider(cfg config.AuthOAuth2ClientConfig, logger log.Logger) (OAuth2Provider, error) {
+	requiredClaims := make([]config.OIDCClaimCondition, len(cfg.OIDC.RequiredClaims))
+	for i, expression := range cfg.OIDC.RequiredClaims {
+		condition, err := expression.Parse()
+		if err != nil {
+			return nil, err
+		}
+		requiredClaims[i] = condition
+	}
 	return &oidcProvider{
-		clientID:      config.ClientID,
-		clientSecret:  config.ClientSecret,
-		config:        config.OIDC,
-		scopes:        config.OIDC.ExtraScopes,
-		enforceScopes: config.OIDC.EnforceScopes,
-		logger:        logger,
+		clientID:       cfg.ClientID,
+		clientSecret:   cfg.ClientSecret,
+		config:         cfg.OIDC,
+		scopes:         cfg.OIDC.ExtraScopes,
+		enforceScopes:  cfg.OIDC.EnforceScopes,
+		requiredClaims: requiredClaims,
+		logger:         logger,
 	}, nil
 }
 
 type oidcProvider struct {
-	clientID      string
-	clientSecret  string
-	config        config.AuthOIDCConfig
-	scopes        []string
-	enforceScopes bool
-	logger        log.Logger
+	clientID       string
+	clientSecret   string
+	config         config.AuthOIDCConfig
+	scopes         []string
+	enforceScopes  bool
+	requiredClaims []config.OIDCClaimCondition
+	logger         log.Logger
 }
 
 func (o *oidcProvider) SupportsDeviceFlow() bool {
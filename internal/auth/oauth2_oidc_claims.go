@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// decodeIDTokenClaims returns the payload of the ID token. The signature is not checked because the token was
+// received directly from the token endpoint, in which case OIDC allows relying on the TLS connection instead. The
+// claims must be checked with validateIDTokenClaims before they are used.
+func decodeIDTokenClaims(idToken string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("the ID token does not consist of three parts")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the ID token payload (%w)", err)
+	}
+	claims := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode the ID token claims (%w)", err)
+	}
+	return claims, nil
+}
+
+// oidcIDTokenLeeway is the clock difference tolerated when checking the expiry of the ID token.
+const oidcIDTokenLeeway = time.Minute
+
+// validateIDTokenClaims checks the iss, aud, azp, exp and sub claims of the ID token as required by OpenID Connect
+// Core 1.0 section 3.1.3.7. The issuer is the one announced by the discovery endpoint.
+func validateIDTokenClaims(claims map[string]interface{}, issuer string, clientID string, now time.Time) error {
+	if iss, _ := claims["iss"].(string); iss == "" || iss != issuer {
+		return invalidIDToken("The ID token was issued by %q instead of %q.", iss, issuer)
+	}
+	if !matchesAny(claimValues(claims["aud"]), []string{clientID}) {
+		return invalidIDToken("The ID token was not issued for the client ID %s.", clientID)
+	}
+	if azp, present := claims["azp"]; present && azp != clientID {
+		return invalidIDToken("The ID token was issued to the authorized party %v instead of %s.", azp, clientID)
+	}
+	expires, ok := claimTime(claims["exp"])
+	if !ok {
+		return invalidIDToken("The ID token has no valid exp claim.")
+	}
+	if now.After(expires.Add(oidcIDTokenLeeway)) {
+		return invalidIDToken("The ID token expired at %s.", expires.Format(time.RFC3339))
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return invalidIDToken("The ID token has no sub claim.")
+	}
+	return nil
+}
+
+func invalidIDToken(explanation string, args ...interface{}) error {
+	return message.UserMessage(message.EAuthOIDCIDTokenInvalid, "Authentication failed.", explanation, args...)
+}
+
+// lookupClaim returns the claim at the dot-separated path. Keys containing dots, such as namespaced claims, are
+// matched before the path is split.
+func lookupClaim(claims map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := claims[path]; ok {
+		return value, true
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		value, ok := claims[path[:i]]
+		if !ok {
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if result, ok := lookupClaim(nested, path[i+1:]); ok {
+				return result, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// claimPresent returns true if the claim is set to a value other than false, null, an empty string or an empty array.
+func claimPresent(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+func claimMatches(claims map[string]interface{}, condition config.OIDCClaimCondition) bool {
+	value, ok := lookupClaim(claims, condition.Claim)
+	switch condition.Operator {
+	case config.OIDCClaimOperatorPresent:
+		return ok && claimPresent(value)
+	case config.OIDCClaimOperatorEquals:
+		return ok && claimEquals(value, condition.Value)
+	case config.OIDCClaimOperatorNotEquals:
+		return !ok || !claimEquals(value, condition.Value)
+	case config.OIDCClaimOperatorContains:
+		if !ok {
+			return false
+		}
+		elements := claimValues(value)
+		if s, isString := value.(string); isString {
+			elements = strings.Fields(s)
+		}
+		for _, element := range elements {
+			if element == condition.Value {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func claimEquals(value interface{}, expected string) bool {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		return false
+	}
+	values := claimValues(value)
+	return len(values) == 1 && values[0] == expected
+}
+
+// checkClaims verifies the required claim expressions and copies the mapped claims to the metadata and the
+// environment.
+func (o *oidcFlow) checkClaims(
+	claims map[string]interface{},
+	authMeta metadata.ConnectionAuthenticatedMetadata,
+) error {
+	for i, condition := range o.provider.requiredClaims {
+		if !claimMatches(claims, condition) {
+			err := message.UserMessage(
+				message.EAuthOIDCRequiredClaimFailed,
+				"You are not authorized to log in.",
+				"The user's claims do not satisfy the required claim expression %q.",
+				o.provider.config.RequiredClaims[i],
+			)
+			o.logger.Debug(err)
+			return err
+		}
+	}
+	md := authMeta.GetMetadata()
+	for claim, key := range o.provider.config.Metadata {
+		if value, ok := lookupClaim(claims, claim); ok {
+			if valueString, ok := claimString(value); ok {
+				md[key] = metadata.Value{Value: valueString}
+			}
+		}
+	}
+	env := authMeta.GetEnvironment()
+	for claim, name := range o.provider.config.Environment {
+		if value, ok := lookupClaim(claims, claim); ok {
+			if valueString, ok := claimString(value); ok {
+				env[name] = metadata.Value{Value: valueString}
+			}
+		}
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ type oidcFlow struct {
 	meta              metadata.ConnectionAuthPendingMetadata
 	discoveryResponse oidcDiscoveryResponse
 	accessToken       string
+	idToken           string
 }
 
 type revocationRequest struct {
@@ -122,7 +123,26 @@ func (o *oidcFlow) getIdentity(
 		statusCode, err := client.RequestURL("GET", o.discoveryResponse.UserInfoEndpoint, nil, &resp)
 		if err == nil {
 			if statusCode > 100 && statusCode < 300 {
-				username, ok := resp[o.provider.config.UsernameField]
+				claims, err := o.getIDTokenClaims()
+				if err != nil {
+					return token, meta.AuthFailed(), err
+				}
+				// The userinfo response must not be used if it belongs to a different user than the ID token.
+				if sub, ok := claims["sub"]; ok && resp["sub"] != sub {
+					err = message.UserMessage(
+						message.EAuthOIDCUserInfoSubjectMismatch,
+						"Authentication failed.",
+						"The sub claim returned from the userinfo endpoint (%v) does not match the ID token (%v).",
+						resp["sub"],
+						sub,
+					)
+					o.logger.Warning(err)
+					return token, meta.AuthFailed(), err
+				}
+				for field, value := range resp {
+					claims[field] = value
+				}
+				username, ok := lookupClaim(claims, o.provider.config.UsernameField)
 				if !ok {
 					err = message.UserMessage(
 						message.EAuthOIDCNoUsername,
@@ -157,7 +177,11 @@ func (o *oidcFlow) getIdentity(
 						}
 					}
 				}
-				return token, meta.Authenticated(usernameString), nil
+				authMeta := meta.Authenticated(usernameString)
+				if err := o.checkClaims(claims, authMeta); err != nil {
+					return token, meta.AuthFailed(), err
+				}
+				return token, authMeta, nil
 			}
 			err = message.UserMessage(
 				message.EAuthOIDCUserInfoFetchFailed,
@@ -182,6 +206,30 @@ func (o *oidcFlow) getIdentity(
 	}
 }
 
+// getIDTokenClaims returns the validated claims of the ID token, or an empty map if the server did not return an ID
+// token.
+func (o *oidcFlow) getIDTokenClaims() (map[string]interface{}, error) {
+	if o.idToken == "" {
+		return map[string]interface{}{}, nil
+	}
+	claims, err := decodeIDTokenClaims(o.idToken)
+	if err != nil {
+		err = message.WrapUser(
+			err,
+			message.EAuthOIDCIDTokenDecodeFailed,
+			"Authentication failed.",
+			"Failed to decode the ID token.",
+		)
+		o.logger.Warning(err)
+		return nil, err
+	}
+	if err := validateIDTokenClaims(claims, o.discoveryResponse.Issuer, o.provider.clientID, time.Now()); err != nil {
+		o.logger.Warning(err)
+		return nil, err
+	}
+	return claims, nil
+}
+
 // checkGrantedScopes validates that the user granted all required scopes.
 // It compares the granted scopes from the token response with the requested extra scopes.
 // Returns an error if enforceScopes is enabled and any required scopes are missing.
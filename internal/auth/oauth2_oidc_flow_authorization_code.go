@@ -44,6 +44,7 @@ type oidcAccessTokenResponse struct {
 	ExpiresIn        int    `json:"expires_in,omitempty"`
 	RefreshToken     string `json:"refresh_token,omitempty"`
 	Scope            string `json:"scope,omitempty"`
+	IDToken          string `json:"id_token,omitempty"`
 	Error            string `json:"error,omitempty"`
 	ErrorDescription string `json:"error_description,omitempty"`
 	ErrorURI         string `json:"error_uri,omitempty"`
@@ -89,6 +90,7 @@ loop:
 					resp.ErrorDescription,
 				)
 			} else {
+				o.idToken = resp.IDToken
 				return resp.AccessToken, o.checkGrantedScopes(resp.Scope)
 			}
 		}
@@ -166,6 +166,7 @@ loop:
 				// User hit don't authorize
 				return "", message.UserMessage(message.EAuthFailed, "GitHub authentication failed", "User canceled GitHub authentication")
 			case "":
+				o.idToken = resp.IDToken
 				return resp.AccessToken, o.checkGrantedScopes(resp.Scope)
 			}
 		}
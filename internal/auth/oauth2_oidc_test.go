@@ -0,0 +1,196 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+func TestOIDCClaims(t *testing.T) {
+	server := test.OIDCProviderServer(t)
+	server.AddUser(
+		"alice",
+		map[string]interface{}{
+			"groups": []string{"developers", "ssh-users"},
+			"realm_access": map[string]interface{}{
+				"roles": []string{"admin", "user"},
+			},
+			"https://example.com/team": "infra",
+		},
+		map[string]interface{}{
+			"preferred_username": "alice",
+			"email":              "alice@example.com",
+			"email_verified":     true,
+			"uid":                1234567,
+		},
+	)
+	server.AddUser(
+		"bob",
+		map[string]interface{}{
+			"groups": []string{"developers"},
+		},
+		map[string]interface{}{
+			"preferred_username": "bob",
+			"email":              "bob@example.com",
+			"email_verified":     true,
+		},
+	)
+	server.AddUser(
+		"mallory",
+		map[string]interface{}{
+			"groups": []string{"ssh-users"},
+		},
+		map[string]interface{}{
+			"preferred_username": "mallory",
+			"email":              "mallory@example.com",
+			"email_verified":     false,
+		},
+	)
+
+	client := newTestOAuth2Client(t, config.AuthOAuth2ClientConfig{
+		ClientID:     server.ClientID(),
+		ClientSecret: server.ClientSecret(),
+		Provider:     config.AuthOAuth2OIDCProvider,
+		OIDC: config.AuthOIDCConfig{
+			HTTPClientConfiguration: config.HTTPClientConfiguration{
+				URL:     server.BaseURL() + "/",
+				Timeout: 2 * time.Second,
+			},
+			AuthorizationCodeFlow: true,
+			UsernameField:         "preferred_username",
+			RedirectURI:           testRedirectURI,
+			Metadata: map[string]string{
+				"groups":                   "GROUPS",
+				"realm_access.roles":       "ROLES",
+				"https://example.com/team": "TEAM",
+				"uid":                      "UID",
+			},
+			Environment: map[string]string{
+				"email": "EMAIL",
+			},
+			RequiredClaims: []config.OIDCClaimExpression{
+				"groups contains ssh-users",
+				"email_verified == true",
+			},
+		},
+	})
+
+	t.Run("success", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("alice"),
+			authorizationCodeLogin(t, server.Login, "alice"),
+		)
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+		md := authContext.Metadata()
+		assert.Equal(t, "alice", md.AuthenticatedUsername)
+		assert.Equal(t, "developers,ssh-users", md.GetMetadata()["GROUPS"].Value)
+		assert.Equal(t, "admin,user", md.GetMetadata()["ROLES"].Value)
+		assert.Equal(t, "infra", md.GetMetadata()["TEAM"].Value)
+		assert.Equal(t, "1234567", md.GetMetadata()["UID"].Value)
+		assert.Equal(t, "alice@example.com", md.GetEnvironment()["EMAIL"].Value)
+		assert.Equal(t, "alice@example.com", md.GetMetadata()["OIDC_USERINFO_EMAIL"].Value)
+
+		authContext.OnDisconnect()
+		assert.Equal(t, 0, server.ActiveTokens())
+	})
+	t.Run("missing group", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("bob"),
+			authorizationCodeLogin(t, server.Login, "bob"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+		authContext.OnDisconnect()
+		assert.Equal(t, 0, server.ActiveTokens())
+	})
+	t.Run("claim not equal", func(t *testing.T) {
+		authContext := client.KeyboardInteractive(
+			metadata.NewTestAuthenticatingMetadata("mallory"),
+			authorizationCodeLogin(t, server.Login, "mallory"),
+		)
+		assert.Error(t, authContext.Error())
+		assert.False(t, authContext.Success())
+	})
+}
+
+func TestOIDCIDTokenValidation(t *testing.T) {
+	server := test.OIDCProviderServer(t)
+	users := map[string]struct {
+		idTokenClaims  map[string]interface{}
+		userinfoClaims map[string]interface{}
+	}{
+		"valid": {},
+		"wrong issuer": {
+			idTokenClaims: map[string]interface{}{"iss": "https://attacker.example.com"},
+		},
+		"wrong audience": {
+			idTokenClaims: map[string]interface{}{"aud": []string{"other-client"}},
+		},
+		"wrong authorized party": {
+			idTokenClaims: map[string]interface{}{
+				"aud": []string{server.ClientID(), "other-client"},
+				"azp": "other-client",
+			},
+		},
+		"expired": {
+			idTokenClaims: map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()},
+		},
+		"missing expiry": {
+			idTokenClaims: map[string]interface{}{"exp": nil},
+		},
+		"userinfo subject mismatch": {
+			userinfoClaims: map[string]interface{}{"sub": "someone-else"},
+		},
+	}
+	for username, claims := range users {
+		userinfoClaims := map[string]interface{}{
+			"preferred_username": username,
+			"groups":             []string{"ssh-users"},
+		}
+		for claim, value := range claims.userinfoClaims {
+			userinfoClaims[claim] = value
+		}
+		server.AddUser(username, claims.idTokenClaims, userinfoClaims)
+	}
+
+	client := newTestOAuth2Client(t, config.AuthOAuth2ClientConfig{
+		ClientID:     server.ClientID(),
+		ClientSecret: server.ClientSecret(),
+		Provider:     config.AuthOAuth2OIDCProvider,
+		OIDC: config.AuthOIDCConfig{
+			HTTPClientConfiguration: config.HTTPClientConfiguration{
+				URL:     server.BaseURL() + "/",
+				Timeout: 2 * time.Second,
+			},
+			AuthorizationCodeFlow: true,
+			UsernameField:         "preferred_username",
+			RedirectURI:           testRedirectURI,
+			RequiredClaims: []config.OIDCClaimExpression{
+				"groups contains ssh-users",
+			},
+		},
+	})
+
+	for username := range users {
+		username := username
+		t.Run(username, func(t *testing.T) {
+			authContext := client.KeyboardInteractive(
+				metadata.NewTestAuthenticatingMetadata(username),
+				authorizationCodeLogin(t, server.Login, username),
+			)
+			defer authContext.OnDisconnect()
+			if username == "valid" {
+				assert.NoError(t, authContext.Error())
+				assert.True(t, authContext.Success())
+			} else {
+				assert.Error(t, authContext.Error())
+				assert.False(t, authContext.Success())
+			}
+		})
+	}
+}
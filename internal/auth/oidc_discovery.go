@@ -20,11 +20,13 @@ type oidcDiscovery interface {
 }
 
 type oidcDiscoveryResponse struct {
+	Issuer                      string `json:"issuer"`
 	AuthorizationEndpoint       string `json:"authorization_endpoint"`
 	TokenEndpoint               string `json:"token_endpoint"`
 	UserInfoEndpoint            string `json:"userinfo_endpoint"`
 	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
 	RevocationEndpoint          string `json:"revocation_endpoint,omitempty"`
+	JWKSURI                     string `json:"jwks_uri,omitempty"`
 }
 
 type oidcDiscoverImpl struct {
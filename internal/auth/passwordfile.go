@@ -0,0 +1,7 @@
+package auth
+
+// PasswordFileClient is the authenticator for local htpasswd-style password files. It checks the password of the user
+// against the hash stored in the file without contacting an external server.
+type PasswordFileClient interface {
+	PasswordAuthenticator
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewPasswordFileClient creates a new authenticator for local password files.
+func NewPasswordFileClient(
+	cfg config.AuthPasswordFileConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (PasswordFileClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Password file authentication configuration failed to validate",
+		)
+	}
+
+	dummyHash, err := newDummyPasswordHash(cfg.DefaultAlgorithm)
+	if err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Failed to create the dummy password hash",
+		)
+	}
+
+	_, _, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+
+	client := &passwordFileClient{
+		localAuthenticator: newLocalAuthenticator(
+			"file",
+			"Password file",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		config:    cfg,
+		dummyHash: dummyHash,
+	}
+	if _, err := client.getFile(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"Failed to load password file from %s",
+			cfg.Path,
+		)
+	}
+	return client, nil
+}
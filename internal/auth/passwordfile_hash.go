@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.containerssh.io/containerssh/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordHash is a parsed password hash from the password file.
+type passwordHash interface {
+	// verify returns true if the password matches the hash.
+	verify(password []byte) bool
+}
+
+// parsePasswordHash parses a bcrypt ($2a$, $2b$, $2y$), argon2id ($argon2id$) or sha512-crypt ($6$) hash.
+func parsePasswordHash(hash string) (passwordHash, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("invalid bcrypt hash (%w)", err)
+		}
+		return bcryptHash(hash), nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return parseArgon2idHash(hash)
+	case strings.HasPrefix(hash, "$6$"):
+		return parseSHA512CryptHash(hash)
+	default:
+		return nil, fmt.Errorf("unsupported password hash, only bcrypt, argon2id and sha512-crypt are supported")
+	}
+}
+
+// newDummyPasswordHash returns a hash of a random password with the default parameters of the algorithm. Unknown
+// users are verified against it so that they take as long to reject as existing users.
+func newDummyPasswordHash(algorithm config.PasswordHashAlgorithm) (passwordHash, error) {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, err
+	}
+	salt := make([]byte, sha512CryptMaxSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	switch algorithm {
+	case config.PasswordHashAlgorithmArgon2id:
+		result := &argon2idHash{memory: 64 * 1024, time: 3, threads: 4, salt: salt}
+		result.hash = argon2.IDKey(password, result.salt, result.time, result.memory, result.threads, 32)
+		return result, nil
+	case config.PasswordHashAlgorithmSHA512Crypt:
+		cryptSalt := make([]byte, len(salt))
+		for i, b := range salt {
+			cryptSalt[i] = cryptAlphabet[int(b)%len(cryptAlphabet)]
+		}
+		return &sha512CryptHash{
+			rounds: sha512CryptDefaultRounds,
+			salt:   cryptSalt,
+			hash:   sha512Crypt(password, cryptSalt, sha512CryptDefaultRounds),
+		}, nil
+	default:
+		hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		return bcryptHash(hash), nil
+	}
+}
+
+type bcryptHash string
+
+func (b bcryptHash) verify(password []byte) bool {
+	return bcrypt.CompareHashAndPassword([]byte(b), password) == nil
+}
+
+// argon2idHash is a hash in the PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=4$salt$hash.
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	hash    []byte
+}
+
+func parseArgon2idHash(hash string) (passwordHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version: %s", parts[2])
+	}
+	result := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &result.memory, &result.time, &result.threads); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters: %s (%w)", parts[3], err)
+	}
+	var err error
+	if result.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt (%w)", err)
+	}
+	if result.hash, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id hash (%w)", err)
+	}
+	if result.time == 0 || result.threads == 0 || len(result.hash) == 0 {
+		return nil, fmt.Errorf("invalid argon2id parameters: %s", parts[3])
+	}
+	return result, nil
+}
+
+func (a *argon2idHash) verify(password []byte) bool {
+	hash := argon2.IDKey(password, a.salt, a.time, a.memory, a.threads, uint32(len(a.hash)))
+	return subtle.ConstantTimeCompare(hash, a.hash) == 1
+}
+
+const (
+	sha512CryptDefaultRounds = 5000
+	sha512CryptMinRounds     = 1000
+	sha512CryptMaxRounds     = 999999999
+	sha512CryptMaxSaltLength = 16
+	cryptAlphabet            = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// sha512CryptPermutation is the order in which the bytes of the final digest are encoded.
+var sha512CryptPermutation = [21][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+	{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+	{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+}
+
+// sha512CryptHash is a hash in the sha512-crypt format, e.g. $6$rounds=5000$salt$hash.
+type sha512CryptHash struct {
+	rounds int
+	salt   []byte
+	hash   string
+}
+
+func parseSHA512CryptHash(hash string) (passwordHash, error) {
+	parts := strings.Split(strings.TrimPrefix(hash, "$6$"), "$")
+	result := &sha512CryptHash{rounds: sha512CryptDefaultRounds}
+	if len(parts) == 3 && strings.HasPrefix(parts[0], "rounds=") {
+		rounds, err := strconv.Atoi(strings.TrimPrefix(parts[0], "rounds="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sha512-crypt rounds (%w)", err)
+		}
+		result.rounds = min(max(rounds, sha512CryptMinRounds), sha512CryptMaxRounds)
+		parts = parts[1:]
+	}
+	if len(parts) != 2 || len(parts[1]) != 86 {
+		return nil, fmt.Errorf("invalid sha512-crypt hash")
+	}
+	result.salt = []byte(parts[0])
+	if len(result.salt) > sha512CryptMaxSaltLength {
+		result.salt = result.salt[:sha512CryptMaxSaltLength]
+	}
+	result.hash = parts[1]
+	return result, nil
+}
+
+func (s *sha512CryptHash) verify(password []byte) bool {
+	hash := sha512Crypt(password, s.salt, s.rounds)
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(s.hash)) == 1
+}
+
+// sha512Crypt implements the hash part of the SHA-512 based crypt scheme as specified by Ulrich Drepper.
+func sha512Crypt(password []byte, salt []byte, rounds int) string {
+	digestB := sha512.New()
+	digestB.Write(password)
+	digestB.Write(salt)
+	digestB.Write(password)
+	b := digestB.Sum(nil)
+
+	digestA := sha512.New()
+	digestA.Write(password)
+	digestA.Write(salt)
+	i := len(password)
+	for ; i > 64; i -= 64 {
+		digestA.Write(b)
+	}
+	digestA.Write(b[:i])
+	for i = len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			digestA.Write(b)
+		} else {
+			digestA.Write(password)
+		}
+	}
+	a := digestA.Sum(nil)
+
+	digestP := sha512.New()
+	for i = 0; i < len(password); i++ {
+		digestP.Write(password)
+	}
+	p := repeatBytes(digestP.Sum(nil), len(password))
+
+	digestS := sha512.New()
+	for i = 0; i < 16+int(a[0]); i++ {
+		digestS.Write(salt)
+	}
+	s := repeatBytes(digestS.Sum(nil), len(salt))
+
+	c := a
+	for round := 0; round < rounds; round++ {
+		digestC := sha512.New()
+		if round&1 != 0 {
+			digestC.Write(p)
+		} else {
+			digestC.Write(c)
+		}
+		if round%3 != 0 {
+			digestC.Write(s)
+		}
+		if round%7 != 0 {
+			digestC.Write(p)
+		}
+		if round&1 != 0 {
+			digestC.Write(c)
+		} else {
+			digestC.Write(p)
+		}
+		c = digestC.Sum(nil)
+	}
+
+	result := &strings.Builder{}
+	for _, indexes := range sha512CryptPermutation {
+		encodeCrypt64(result, uint(c[indexes[0]])<<16|uint(c[indexes[1]])<<8|uint(c[indexes[2]]), 4)
+	}
+	encodeCrypt64(result, uint(c[63]), 2)
+	return result.String()
+}
+
+// repeatBytes returns the digest repeated until it is length bytes long.
+func repeatBytes(digest []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result) < length {
+		result = append(result, digest[:min(len(digest), length-len(result))]...)
+	}
+	return result
+}
+
+func encodeCrypt64(result *strings.Builder, value uint, chars int) {
+	for ; chars > 0; chars-- {
+		result.WriteByte(cryptAlphabet[value&0x3f])
+		value >>= 6
+	}
+}
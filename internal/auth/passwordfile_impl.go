@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type passwordFileClient struct {
+	localAuthenticator
+
+	config config.AuthPasswordFileConfig
+
+	// dummyHash is verified for unknown users so the response time does not reveal which users exist.
+	dummyHash passwordHash
+
+	lock    sync.Mutex
+	users   map[string]passwordFileEntry
+	modTime time.Time
+	size    int64
+}
+
+// passwordFileEntry is a single user from the password file.
+type passwordFileEntry struct {
+	hash        passwordHash
+	metadata    map[string]string
+	environment map[string]string
+}
+
+func (c *passwordFileClient) Password(
+	meta metadata.ConnectionAuthPendingMetadata,
+	password []byte,
+) AuthenticationContext {
+	authMeta, err := c.checkPassword(meta, password)
+	return c.authContext(meta, authMeta, err, message.EAuthPasswordFileReadFailed)
+}
+
+func (c *passwordFileClient) checkPassword(
+	meta metadata.ConnectionAuthPendingMetadata,
+	password []byte,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	users, err := c.getFile()
+	if err != nil {
+		return meta.AuthFailed(), message.WrapUser(
+			err,
+			message.EAuthPasswordFileReadFailed,
+			"Password authentication is currently unavailable.",
+			"Failed to reload the password file from %s",
+			c.config.Path,
+		)
+	}
+	entry, ok := users[meta.Username]
+	if !ok {
+		entry.hash = c.dummyHash
+	}
+	if !entry.hash.verify(password) || !ok {
+		return meta.AuthFailed(), message.UserMessage(
+			message.EAuthPasswordFileMismatch,
+			"Invalid username or password.",
+			"The user is not listed in the password file or the password does not match.",
+		)
+	}
+	authMeta := meta.Authenticated(meta.Username)
+	md := authMeta.GetMetadata()
+	for key, value := range entry.metadata {
+		md[key] = metadata.Value{Value: value}
+	}
+	env := authMeta.GetEnvironment()
+	for key, value := range entry.environment {
+		env[key] = metadata.Value{Value: value}
+	}
+	return authMeta, nil
+}
+
+// getFile returns the users in the password file, reloading it from disk if the file has changed since it was last
+// read.
+func (c *passwordFileClient) getFile() (map[string]passwordFileEntry, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat, err := os.Stat(c.config.Path)
+	if err != nil {
+		return nil, err
+	}
+	if c.users != nil && stat.ModTime().Equal(c.modTime) && stat.Size() == c.size {
+		return c.users, nil
+	}
+	// We are deliberately loading a dynamic file here.
+	data, err := os.ReadFile(c.config.Path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	c.users = c.parseFile(data)
+	c.modTime = stat.ModTime()
+	c.size = stat.Size()
+	return c.users, nil
+}
+
+// parseFile parses the password file. Entries that cannot be parsed or use unsupported hashes are logged and skipped.
+func (c *passwordFileClient) parseFile(data []byte) map[string]passwordFileEntry {
+	result := map[string]passwordFileEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, entry, err := parsePasswordFileEntry(line)
+		if err != nil {
+			c.logger.Warning(
+				message.Wrap(
+					err,
+					message.EAuthPasswordFileInvalidEntry,
+					"Ignoring invalid entry on line %d of %s",
+					lineNo,
+					c.config.Path,
+				),
+			)
+			continue
+		}
+		result[username] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		c.logger.Warning(
+			message.Wrap(
+				err,
+				message.EAuthPasswordFileInvalidEntry,
+				"Ignoring the rest of %s after line %d",
+				c.config.Path,
+				lineNo,
+			),
+		)
+	}
+	return result
+}
+
+func parsePasswordFileEntry(line string) (string, passwordFileEntry, error) {
+	parts := strings.SplitN(line, ":", 4)
+	if len(parts) < 2 || parts[0] == "" {
+		return "", passwordFileEntry{}, fmt.Errorf("expected username:hash")
+	}
+	hash, err := parsePasswordHash(parts[1])
+	if err != nil {
+		return "", passwordFileEntry{}, err
+	}
+	entry := passwordFileEntry{
+		hash:        hash,
+		metadata:    map[string]string{},
+		environment: map[string]string{},
+	}
+	for i, target := range []map[string]string{entry.metadata, entry.environment} {
+		if len(parts) <= i+2 {
+			break
+		}
+		values, err := url.ParseQuery(parts[i+2])
+		if err != nil {
+			return "", passwordFileEntry{}, fmt.Errorf("invalid metadata or environment column (%w)", err)
+		}
+		for key, value := range values {
+			target[key] = value[len(value)-1]
+		}
+	}
+	return parts[0], entry, nil
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	goHttp "net/http"
+
 	"go.containerssh.io/containerssh/config"
 	"go.containerssh.io/containerssh/http"
 	"go.containerssh.io/containerssh/log"
@@ -12,11 +14,34 @@ func NewServer(
 	configuration config.HTTPServerConfiguration,
 	h Handler,
 	logger log.Logger,
+) (http.Server, error) {
+	return newServer(configuration, NewHandler(h, logger), logger)
+}
+
+// NewSignedServer returns a complete HTTP server that responds to the authentication requests and rejects requests
+// without a valid HMAC-SHA256 signature from one of the configured keys.
+func NewSignedServer(
+	configuration config.HTTPServerConfiguration,
+	signature config.HTTPServerSignatureConfig,
+	h Handler,
+	logger log.Logger,
+) (http.Server, error) {
+	handler, err := http.NewSignatureVerifier(signature, NewHandler(h, logger), logger)
+	if err != nil {
+		return nil, err
+	}
+	return newServer(configuration, handler, logger)
+}
+
+func newServer(
+	configuration config.HTTPServerConfiguration,
+	handler goHttp.Handler,
+	logger log.Logger,
 ) (http.Server, error) {
 	return http.NewServer(
 		"Auth Server",
 		configuration,
-		NewHandler(h, logger),
+		handler,
 		logger,
 		func(url string) {
 			logger.Info(message.NewMessage(
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// TOTPAuthenticator checks time-based one-time passwords (RFC 6238) as a second factor after a successful password or
+// public key authentication.
+type TOTPAuthenticator interface {
+	// Required returns true if the user authenticated by the first factor must enter a code before they are logged
+	// in. The meta parameter is the metadata returned by the first factor.
+	Required(meta metadata.ConnectionAuthenticatedMetadata) bool
+
+	// TOTP asks the user for a code using the challenge function and checks it. The meta parameter is the metadata
+	// returned by the first factor. If the check succeeds the returned AuthenticationContext contains this metadata
+	// without the secret.
+	TOTP(
+		meta metadata.ConnectionAuthenticatedMetadata,
+		challenge func(
+			instruction string,
+			questions KeyboardInteractiveQuestions,
+		) (answers KeyboardInteractiveAnswers, err error),
+	) AuthenticationContext
+}
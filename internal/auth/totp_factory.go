@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// NewTOTPAuthenticator creates a new TOTP second factor if the keyboard-interactive method is set to totp. For all other
+// methods it returns nil.
+func NewTOTPAuthenticator(
+	cfg config.KeyboardInteractiveAuthConfig,
+	logger log.Logger,
+	metrics metrics.Collector,
+) (TOTPAuthenticator, error) {
+	if cfg.Method != config.KeyboardInteractiveAuthMethodTOTP {
+		return nil, nil
+	}
+	if err := cfg.TOTP.Validate(); err != nil {
+		return nil, message.Wrap(
+			err,
+			message.EAuthConfigError,
+			"TOTP configuration failed to validate",
+		)
+	}
+
+	_, _, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+
+	client := &totpClient{
+		localAuthenticator: newLocalAuthenticator(
+			"totp",
+			"TOTP",
+			logger,
+			authSuccessMetric,
+			authFailureMetric,
+		),
+		config:   cfg.TOTP,
+		lastUsed: map[string]int64{},
+	}
+	if cfg.TOTP.SecretsFile != "" {
+		if _, err := client.getSecrets(); err != nil {
+			return nil, message.Wrap(
+				err,
+				message.EAuthConfigError,
+				"Failed to load TOTP secrets file from %s",
+				cfg.TOTP.SecretsFile,
+			)
+		}
+	}
+	return client, nil
+}
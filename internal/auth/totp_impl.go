@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // SHA-1 is the default TOTP algorithm and is used as HMAC, not as a plain hash.
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// totpQuestionID is the ID of the question asking for the code.
+const totpQuestionID = "totp"
+
+type totpClient struct {
+	localAuthenticator
+
+	config config.AuthTOTPConfig
+
+	lock    sync.Mutex
+	secrets map[string]string
+	modTime time.Time
+	size    int64
+	// lastUsed contains the time step of the last accepted code for each user. Codes from this or earlier time steps
+	// are rejected so an intercepted code cannot be replayed.
+	lastUsed map[string]int64
+}
+
+func (c *totpClient) Required(meta metadata.ConnectionAuthenticatedMetadata) bool {
+	if !c.config.Optional {
+		return true
+	}
+	_, found, err := c.getSecret(meta)
+	// If the secrets cannot be read we require the code, so the user is rejected instead of skipping the check.
+	return found || err != nil
+}
+
+func (c *totpClient) TOTP(
+	meta metadata.ConnectionAuthenticatedMetadata,
+	challenge func(
+		instruction string,
+		questions KeyboardInteractiveQuestions,
+	) (answers KeyboardInteractiveAnswers, err error),
+) AuthenticationContext {
+	authMeta, err := c.checkCode(meta, challenge)
+	return c.authContext(meta.ConnectionAuthPendingMetadata, authMeta, err, message.EAuthTOTPReadFailed)
+}
+
+func (c *totpClient) checkCode(
+	meta metadata.ConnectionAuthenticatedMetadata,
+	challenge func(
+		instruction string,
+		questions KeyboardInteractiveQuestions,
+	) (answers KeyboardInteractiveAnswers, err error),
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	failedMeta := meta.ConnectionAuthPendingMetadata.AuthFailed()
+	secret, found, err := c.getSecret(meta)
+	if err != nil {
+		return failedMeta, message.WrapUser(
+			err,
+			message.EAuthTOTPReadFailed,
+			"Authentication is currently unavailable.",
+			"Failed to reload the TOTP secrets file from %s",
+			c.config.SecretsFile,
+		)
+	}
+	if !found {
+		return failedMeta, message.UserMessage(
+			message.EAuthTOTPNoSecret,
+			"Authentication failed.",
+			"No TOTP secret is configured for the user.",
+		)
+	}
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		err = message.WrapUser(
+			err,
+			message.EAuthTOTPInvalidSecret,
+			"Authentication failed.",
+			"The TOTP secret of the user is not valid base32",
+		)
+		c.logger.Warning(err)
+		return failedMeta, err
+	}
+
+	answers, err := challenge(
+		"",
+		KeyboardInteractiveQuestions{
+			{
+				ID:           totpQuestionID,
+				Question:     c.config.Prompt,
+				EchoResponse: false,
+				Sensitive:    true,
+			},
+		},
+	)
+	if err != nil {
+		return failedMeta, message.WrapUser(
+			err,
+			message.EAuthTOTPFailed,
+			"Authentication failed.",
+			"Failed to ask the user for a TOTP code",
+		)
+	}
+	code := strings.TrimSpace(answers.Answers[totpQuestionID])
+
+	now := time.Now().Unix() / int64(c.config.Period/time.Second)
+	step, ok := c.findStep(key, code, now)
+	if !ok {
+		return failedMeta, message.UserMessage(
+			message.EAuthTOTPFailed,
+			"Invalid verification code.",
+			"The TOTP code entered by the user is invalid or expired.",
+		)
+	}
+	if !c.markUsed(totpIdentity(meta), step, now) {
+		return failedMeta, message.UserMessage(
+			message.EAuthTOTPReplay,
+			"Invalid verification code.",
+			"The TOTP code entered by the user has already been used.",
+		)
+	}
+
+	return c.removeSecret(meta), nil
+}
+
+// findStep returns the time step within the allowed skew the code belongs to.
+func (c *totpClient) findStep(key []byte, code string, now int64) (int64, bool) {
+	if len(code) != c.config.Digits {
+		return 0, false
+	}
+	skew := int64(c.config.Skew)
+	for step := now - skew; step <= now+skew; step++ {
+		expected := generateTOTP(key, step, c.config.Algorithm, c.config.Digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// markUsed records the time step of an accepted code. It returns false if a code from the same or a later time step
+// has already been accepted for the user.
+func (c *totpClient) markUsed(identity string, step int64, now int64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if lastUsed, ok := c.lastUsed[identity]; ok && step <= lastUsed {
+		return false
+	}
+	c.lastUsed[identity] = step
+	// Steps before the window can never be accepted again, so remembering them is not needed.
+	for user, lastUsed := range c.lastUsed {
+		if lastUsed < now-int64(c.config.Skew) {
+			delete(c.lastUsed, user)
+		}
+	}
+	return true
+}
+
+// removeSecret returns a copy of the metadata without the secret, so it is not passed on to the backend.
+func (c *totpClient) removeSecret(meta metadata.ConnectionAuthenticatedMetadata) metadata.ConnectionAuthenticatedMetadata {
+	if _, ok := meta.Metadata[c.config.SecretMetadata]; !ok {
+		return meta
+	}
+	md := make(map[string]metadata.Value, len(meta.Metadata))
+	for key, value := range meta.Metadata {
+		if key != c.config.SecretMetadata {
+			md[key] = value
+		}
+	}
+	meta.Metadata = md
+	return meta
+}
+
+// getSecret returns the secret of the user from the metadata returned by the first factor, or from the secrets file.
+func (c *totpClient) getSecret(meta metadata.ConnectionAuthenticatedMetadata) (string, bool, error) {
+	if c.config.SecretMetadata != "" {
+		if value, ok := meta.Metadata[c.config.SecretMetadata]; ok {
+			return value.Value, true, nil
+		}
+	}
+	if c.config.SecretsFile == "" {
+		return "", false, nil
+	}
+	secrets, err := c.getSecrets()
+	if err != nil {
+		return "", false, err
+	}
+	secret, ok := secrets[totpIdentity(meta)]
+	return secret, ok, nil
+}
+
+// totpIdentity returns the user the secret belongs to. Several login names may map to the same authenticated user,
+// so both the secret lookup and the replay protection use the authenticated username.
+func totpIdentity(meta metadata.ConnectionAuthenticatedMetadata) string {
+	if meta.AuthenticatedUsername != "" {
+		return meta.AuthenticatedUsername
+	}
+	return meta.Username
+}
+
+// getSecrets returns the secrets from the secrets file, reloading it from disk if the file has changed since it was
+// last read.
+func (c *totpClient) getSecrets() (map[string]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	stat, err := os.Stat(c.config.SecretsFile)
+	if err != nil {
+		return nil, err
+	}
+	if c.secrets != nil && stat.ModTime().Equal(c.modTime) && stat.Size() == c.size {
+		return c.secrets, nil
+	}
+	// We are deliberately loading a dynamic file here.
+	data, err := os.ReadFile(c.config.SecretsFile) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	c.secrets = c.parseSecrets(data)
+	c.modTime = stat.ModTime()
+	c.size = stat.Size()
+	return c.secrets, nil
+}
+
+// parseSecrets parses the username:secret lines of the secrets file. Invalid lines are logged and skipped.
+func (c *totpClient) parseSecrets(data []byte) map[string]string {
+	result := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		username, secret, ok := strings.Cut(line, ":")
+		if !ok || username == "" || secret == "" {
+			c.logger.Warning(
+				message.NewMessage(
+					message.EAuthTOTPInvalidSecret,
+					"Ignoring invalid entry on line %d of %s, expected username:secret",
+					lineNo,
+					c.config.SecretsFile,
+				),
+			)
+			continue
+		}
+		result[username] = secret
+	}
+	return result
+}
+
+// decodeTOTPSecret decodes a base32 secret as shown by authenticator apps. Spaces, lower case letters and missing
+// padding are accepted.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		return nil, fmt.Errorf("empty secret")
+	}
+	return key, nil
+}
+
+// generateTOTP generates the code for a time step as specified in RFC 4226 and RFC 6238.
+func generateTOTP(key []byte, step int64, algorithm config.TOTPAlgorithm, digits int) string {
+	var hashFunc func() hash.Hash
+	switch algorithm {
+	case config.TOTPAlgorithmSHA256:
+		hashFunc = sha256.New
+	case config.TOTPAlgorithmSHA512:
+		hashFunc = sha512.New
+	default:
+		hashFunc = sha1.New
+	}
+	mac := hmac.New(hashFunc, key)
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
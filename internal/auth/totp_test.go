@@ -0,0 +1,236 @@
+package auth_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // Required by RFC 6238.
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	configuration "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// totpSecret is the shared secret from the RFC 6238 test vectors.
+var totpSecret = []byte("12345678901234567890")
+
+// totpCode generates a code the same way authenticator apps do.
+func totpCode(key []byte, at time.Time, algorithm configuration.TOTPAlgorithm, digits int) string {
+	hashFunc := map[configuration.TOTPAlgorithm]func() hash.Hash{
+		configuration.TOTPAlgorithmSHA1:   sha1.New,
+		configuration.TOTPAlgorithmSHA256: sha256.New,
+		configuration.TOTPAlgorithmSHA512: sha512.New,
+	}[algorithm]
+	mac := hmac.New(hashFunc, key)
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/30))
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+func newTOTPTestConfig() configuration.KeyboardInteractiveAuthConfig {
+	return configuration.KeyboardInteractiveAuthConfig{
+		Method: configuration.KeyboardInteractiveAuthMethodTOTP,
+		TOTP: configuration.AuthTOTPConfig{
+			SecretMetadata: "TOTP_SECRET",
+			Algorithm:      configuration.TOTPAlgorithmSHA1,
+			Digits:         6,
+			Period:         30 * time.Second,
+			Skew:           1,
+			Prompt:         "Verification code: ",
+		},
+	}
+}
+
+func setupTOTPAuthenticator(t *testing.T, cfg configuration.KeyboardInteractiveAuthConfig) auth.TOTPAuthenticator {
+	a, err := auth.NewTOTPAuthenticator(cfg, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func totpMetadata(username string, secret []byte) metadata.ConnectionAuthenticatedMetadata {
+	meta := metadata.NewTestAuthenticatingMetadata(username).Authenticated(username)
+	meta.Metadata = map[string]metadata.Value{
+		"FOO": {Value: "bar"},
+	}
+	if secret != nil {
+		meta.Metadata["TOTP_SECRET"] = metadata.Value{
+			Value:     base32.StdEncoding.EncodeToString(secret),
+			Sensitive: true,
+		}
+	}
+	return meta
+}
+
+// totpAnswer returns a challenge function that answers the TOTP question with the given code.
+func totpAnswer(t *testing.T, code string) func(
+	instruction string,
+	questions auth.KeyboardInteractiveQuestions,
+) (auth.KeyboardInteractiveAnswers, error) {
+	return func(
+		instruction string,
+		questions auth.KeyboardInteractiveQuestions,
+	) (auth.KeyboardInteractiveAnswers, error) {
+		assert.Len(t, questions, 1)
+		assert.False(t, questions[0].EchoResponse)
+		assert.True(t, questions[0].Sensitive)
+		return auth.KeyboardInteractiveAnswers{
+			Answers: map[string]string{
+				questions[0].ID: code,
+			},
+		}, nil
+	}
+}
+
+func TestTOTPCodeGeneration(t *testing.T) {
+	// Test vectors from RFC 6238 Appendix B.
+	at := time.Unix(1111111109, 0)
+	assert.Equal(t, "07081804", totpCode(totpSecret, at, configuration.TOTPAlgorithmSHA1, 8))
+	assert.Equal(
+		t,
+		"68084774",
+		totpCode([]byte("12345678901234567890123456789012"), at, configuration.TOTPAlgorithmSHA256, 8),
+	)
+}
+
+func TestTOTP(t *testing.T) {
+	a := setupTOTPAuthenticator(t, newTOTPTestConfig())
+	code := totpCode(totpSecret, time.Now(), configuration.TOTPAlgorithmSHA1, 6)
+	wrongCode := totpCode([]byte("01234567890123456789"), time.Now(), configuration.TOTPAlgorithmSHA1, 6)
+
+	t.Run("wrong code", func(t *testing.T) {
+		authContext := a.TOTP(totpMetadata("foo", totpSecret), totpAnswer(t, wrongCode))
+		assert.False(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+	t.Run("success", func(t *testing.T) {
+		authContext := a.TOTP(totpMetadata("foo", totpSecret), totpAnswer(t, code))
+		assert.True(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+		md := authContext.Metadata()
+		assert.Equal(t, "foo", md.AuthenticatedUsername)
+		assert.Equal(t, "bar", md.Metadata["FOO"].Value)
+		_, ok := md.Metadata["TOTP_SECRET"]
+		assert.False(t, ok, "the secret must be removed from the metadata")
+	})
+	t.Run("replay", func(t *testing.T) {
+		authContext := a.TOTP(totpMetadata("foo", totpSecret), totpAnswer(t, code))
+		assert.False(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+	t.Run("replay with another login name", func(t *testing.T) {
+		meta := metadata.NewTestAuthenticatingMetadata("foo-alias").Authenticated("foo")
+		meta.Metadata = totpMetadata("foo", totpSecret).Metadata
+		authContext := a.TOTP(meta, totpAnswer(t, code))
+		assert.False(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+	t.Run("no secret", func(t *testing.T) {
+		authContext := a.TOTP(totpMetadata("bar", nil), totpAnswer(t, code))
+		assert.False(t, authContext.Success())
+		assert.NoError(t, authContext.Error())
+	})
+}
+
+func TestTOTPAlgorithm(t *testing.T) {
+	cfg := newTOTPTestConfig()
+	cfg.TOTP.Algorithm = configuration.TOTPAlgorithmSHA256
+	cfg.TOTP.Digits = 8
+	a := setupTOTPAuthenticator(t, cfg)
+
+	authContext := a.TOTP(
+		totpMetadata("foo", totpSecret),
+		totpAnswer(t, totpCode(totpSecret, time.Now(), configuration.TOTPAlgorithmSHA1, 8)),
+	)
+	assert.False(t, authContext.Success())
+
+	authContext = a.TOTP(
+		totpMetadata("foo", totpSecret),
+		totpAnswer(t, totpCode(totpSecret, time.Now(), configuration.TOTPAlgorithmSHA256, 8)),
+	)
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+}
+
+func TestTOTPSecretsFile(t *testing.T) {
+	otherSecret := []byte("abcdefghijabcdefghij")
+	secretsFile := filepath.Join(t.TempDir(), "totp")
+	assert.NoError(t, os.WriteFile(
+		secretsFile,
+		[]byte(fmt.Sprintf(
+			"# Comment\nfoo:%s\nbaz:%s\n",
+			base32.StdEncoding.EncodeToString(totpSecret),
+			base32.StdEncoding.EncodeToString(otherSecret),
+		)),
+		0600,
+	))
+	cfg := newTOTPTestConfig()
+	cfg.TOTP.SecretsFile = secretsFile
+	cfg.TOTP.Optional = true
+	a := setupTOTPAuthenticator(t, cfg)
+
+	assert.True(t, a.Required(totpMetadata("foo", nil)))
+	assert.False(t, a.Required(totpMetadata("bar", nil)))
+	assert.True(t, a.Required(totpMetadata("bar", otherSecret)))
+
+	authContext := a.TOTP(
+		totpMetadata("foo", nil),
+		totpAnswer(t, totpCode(totpSecret, time.Now(), configuration.TOTPAlgorithmSHA1, 6)),
+	)
+	assert.True(t, authContext.Success())
+	assert.NoError(t, authContext.Error())
+
+	// The secret in the metadata takes precedence over the file.
+	authContext = a.TOTP(
+		totpMetadata("baz", totpSecret),
+		totpAnswer(t, totpCode(otherSecret, time.Now(), configuration.TOTPAlgorithmSHA1, 6)),
+	)
+	assert.False(t, authContext.Success())
+	authContext = a.TOTP(
+		totpMetadata("baz", totpSecret),
+		totpAnswer(t, totpCode(totpSecret, time.Now(), configuration.TOTPAlgorithmSHA1, 6)),
+	)
+	assert.True(t, authContext.Success())
+
+	// Removing the file makes the authenticator unavailable instead of letting users in.
+	assert.NoError(t, os.Remove(secretsFile))
+	assert.True(t, a.Required(totpMetadata("bar", nil)))
+	authContext = a.TOTP(totpMetadata("bar", nil), totpAnswer(t, "123456"))
+	assert.False(t, authContext.Success())
+	assert.Error(t, authContext.Error())
+}
+
+func TestTOTPRequired(t *testing.T) {
+	a := setupTOTPAuthenticator(t, newTOTPTestConfig())
+	assert.True(t, a.Required(totpMetadata("foo", nil)))
+	assert.True(t, a.Required(totpMetadata("foo", totpSecret)))
+}
+
+func TestTOTPWithOAuth2Rejected(t *testing.T) {
+	cfg := newTOTPTestConfig()
+	assert.NoError(t, cfg.Validate())
+	cfg.OAuth2.Provider = configuration.AuthOAuth2GitHubProvider
+	cfg.OAuth2.ClientID = "foo"
+	assert.Error(t, cfg.Validate())
+}
@@ -8,4 +8,5 @@ type WebhookClient interface {
 	PasswordAuthenticator
 	PublicKeyAuthenticator
 	AuthzProvider
+	BannerProvider
 }
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// webhookEndpoint is one of the servers implementing the webhook.
+type webhookEndpoint struct {
+	url        string
+	httpClient http.Client
+	breaker    *circuitBreaker
+}
+
+type circuitState string
+
+const (
+	circuitClosed   circuitState = "closed"
+	circuitOpen     circuitState = "open"
+	circuitHalfOpen circuitState = "half-open"
+)
+
+// circuitBreaker skips a server after a number of consecutive failures until the reset timeout passes. After that,
+// a single trial request is let through. If it succeeds, the server is used again, otherwise it is skipped for
+// another reset timeout.
+type circuitBreaker struct {
+	url          string
+	threshold    int
+	resetTimeout time.Duration
+	logger       log.Logger
+	stateMetric  metrics.Counter
+
+	lock     sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(
+	url string,
+	cfg config.AuthWebhookCircuitBreakerConfig,
+	logger log.Logger,
+	stateMetric metrics.Counter,
+) *circuitBreaker {
+	return &circuitBreaker{
+		url:          url,
+		threshold:    cfg.FailureThreshold,
+		resetTimeout: cfg.ResetTimeout,
+		logger:       logger.WithLabel("url", url),
+		stateMetric:  stateMetric,
+		state:        circuitClosed,
+	}
+}
+
+// allow returns true if a request may be sent to the server. Every allowed request must be followed by a call to
+// success or failure.
+func (b *circuitBreaker) allow() bool {
+	if b.threshold == 0 {
+		return true
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.resetTimeout {
+			return false
+		}
+		b.setState(circuitHalfOpen)
+		b.trial = true
+		return true
+	case circuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success records a successful request.
+func (b *circuitBreaker) success() {
+	if b.threshold == 0 {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failures = 0
+	b.trial = false
+	if b.state != circuitClosed {
+		b.setState(circuitClosed)
+	}
+}
+
+// failure records a failed request and opens the circuit if the threshold is reached or the trial request failed.
+func (b *circuitBreaker) failure() {
+	if b.threshold == 0 {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.trial = false
+	b.failures++
+	if b.state == circuitHalfOpen || (b.state == circuitClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.setState(circuitOpen)
+	}
+}
+
+func (b *circuitBreaker) setState(state circuitState) {
+	b.state = state
+	b.stateMetric.Increment(metrics.Label("url", b.url), metrics.Label("state", string(state)))
+	switch state {
+	case circuitOpen:
+		b.logger.Warning(
+			message.NewMessage(
+				message.EAuthCircuitOpen,
+				"Authentication server failed %d times in a row, skipping it for %s",
+				b.failures,
+				b.resetTimeout,
+			),
+		)
+	case circuitHalfOpen:
+		b.logger.Info(
+			message.NewMessage(
+				message.MAuthCircuitHalfOpen,
+				"Checking if the authentication server has recovered",
+			),
+		)
+	case circuitClosed:
+		b.logger.Info(
+			message.NewMessage(
+				message.MAuthCircuitClosed,
+				"Authentication server recovered",
+			),
+		)
+	}
+}
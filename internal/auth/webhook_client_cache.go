@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+// webhookCache caches the public key and authorization decisions of the webhook.
+type webhookCache struct {
+	ttl         time.Duration
+	negativeTTL time.Duration
+	maxEntries  int
+
+	lock    sync.Mutex
+	entries map[string]webhookCacheEntry
+}
+
+type webhookCacheEntry struct {
+	response auth.ResponseBody
+	expires  time.Time
+}
+
+func newWebhookCache(cfg config.AuthWebhookCacheConfig) *webhookCache {
+	if !cfg.Enable {
+		return nil
+	}
+	return &webhookCache{
+		ttl:         cfg.TTL,
+		negativeTTL: cfg.NegativeTTL,
+		maxEntries:  cfg.MaxEntries,
+		entries:     map[string]webhookCacheEntry{},
+	}
+}
+
+// pubKeyCacheKey returns the cache key for a public key decision. Besides the username, the remote IP address and the
+// key fingerprint the key covers the authentication context the webhook receives, so a decision made for one listener
+// or authentication chain stage is not reused for another. Keys that cannot be parsed are cached by their textual
+// form.
+func pubKeyCacheKey(meta metadata.ConnectionAuthPendingMetadata, pubKey auth.PublicKey) string {
+	fingerprint := pubKey.PublicKey
+	if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pubKey.PublicKey)); err == nil {
+		fingerprint = ssh.FingerprintSHA256(key)
+	}
+	authContext, ok := authContextHash(meta)
+	if !ok {
+		// Not cacheable.
+		return ""
+	}
+	return strings.Join(
+		[]string{
+			"pubkey",
+			meta.RemoteAddress.Host(),
+			meta.Username,
+			fingerprint,
+			authContext,
+		},
+		"\x00",
+	)
+}
+
+// authzCacheKey returns the cache key for an authorization decision. Besides the usernames and the remote IP address
+// the key covers the authentication context the webhook receives, since the webhook may base its decision on it.
+func authzCacheKey(meta metadata.ConnectionAuthenticatedMetadata) string {
+	authContext, ok := authContextHash(meta.ConnectionAuthPendingMetadata)
+	if !ok {
+		// Not cacheable.
+		return ""
+	}
+	return strings.Join(
+		[]string{
+			"authz",
+			meta.RemoteAddress.Host(),
+			meta.Username,
+			meta.AuthenticatedUsername,
+			authContext,
+		},
+		"\x00",
+	)
+}
+
+// authContextHash returns a hash of the authentication context (listener, methods, metadata, environment and files)
+// sent to the webhook. It returns false if the context cannot be serialized.
+func authContextHash(meta metadata.ConnectionAuthPendingMetadata) (string, bool) {
+	authContext, err := json.Marshal(struct {
+		Listener             string                          `json:"listener"`
+		ClientVersion        string                          `json:"clientVersion"`
+		AuthenticatedMethods []string                        `json:"authenticatedMethods"`
+		Metadata             map[string]metadata.Value       `json:"metadata"`
+		Environment          map[string]metadata.Value       `json:"environment"`
+		Files                map[string]metadata.BinaryValue `json:"files"`
+	}{
+		Listener:             meta.Listener,
+		ClientVersion:        meta.ClientVersion,
+		AuthenticatedMethods: meta.AuthenticatedMethods,
+		Metadata:             meta.Metadata,
+		Environment:          meta.Environment,
+		Files:                meta.Files,
+	})
+	if err != nil {
+		return "", false
+	}
+	hash := sha256.Sum256(authContext)
+	return hex.EncodeToString(hash[:]), true
+}
+
+// get returns the cached response for the key if it has not expired yet.
+func (c *webhookCache) get(key string) (auth.ResponseBody, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return auth.ResponseBody{}, false
+	}
+	if !time.Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return auth.ResponseBody{}, false
+	}
+	return entry.response, true
+}
+
+// set stores the response for the TTL requested by the webhook, or the configured TTL if the webhook did not set one.
+func (c *webhookCache) set(key string, response auth.ResponseBody) {
+	ttl := c.ttl
+	if !response.Success {
+		ttl = c.negativeTTL
+	}
+	if response.CacheTTL != nil {
+		ttl = time.Duration(*response.CacheTTL) * time.Second
+	}
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = webhookCacheEntry{
+		response: response,
+		expires:  now.Add(ttl),
+	}
+}
+
+// evict removes the expired entries. If none have expired, it removes the entry that expires first to make room for a
+// new one.
+func (c *webhookCache) evict(now time.Time) {
+	var firstKey string
+	var firstExpires time.Time
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if firstKey == "" || entry.expires.Before(firstExpires) {
+			firstKey = key
+			firstExpires = entry.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && firstKey != "" {
+		delete(c.entries, firstKey)
+	}
+}
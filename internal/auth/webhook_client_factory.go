@@ -20,27 +20,56 @@ func NewWebhookClient(
 		return nil, err
 	}
 
-	realClient, err := http.NewClient(
-		cfg.HTTPClientConfiguration,
-		logger,
+	stateMetric := metrics.MustCreateCounter(
+		MetricNameAuthCircuitBreakerStateChanges,
+		"changes_total",
+		"The number of times the circuit breaker of an authentication server changed state.",
 	)
-	if err != nil {
-		return nil, err
+	var endpoints []*webhookEndpoint
+	for _, url := range append([]string{cfg.URL}, cfg.FallbackURLs...) {
+		clientConfig := cfg.HTTPClientConfiguration
+		clientConfig.URL = url
+		realClient, err := http.NewClient(
+			clientConfig,
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, &webhookEndpoint{
+			url:        url,
+			httpClient: realClient,
+			breaker:    newCircuitBreaker(url, cfg.CircuitBreaker, logger, stateMetric),
+		})
 	}
 
 	backendRequestsMetric, backendFailureMetric, authSuccessMetric, authFailureMetric := createMetrics(metrics)
+	cacheHitsMetric := metrics.MustCreateCounter(
+		MetricNameAuthCacheHits,
+		"hits_total",
+		"The number of authentication decisions served from the cache.",
+	)
+	cacheMissesMetric := metrics.MustCreateCounter(
+		MetricNameAuthCacheMisses,
+		"misses_total",
+		"The number of cacheable authentication decisions not found in the cache.",
+	)
 	return &webhookClient{
 		timeout:               cfg.AuthTimeout,
-		httpClient:            realClient,
+		endpoints:             endpoints,
+		cache:                 newWebhookCache(cfg.Cache),
 		logger:                logger,
 		metrics:               metrics,
 		backendRequestsMetric: backendRequestsMetric,
 		backendFailureMetric:  backendFailureMetric,
 		authSuccessMetric:     authSuccessMetric,
 		authFailureMetric:     authFailureMetric,
+		cacheHitsMetric:       cacheHitsMetric,
+		cacheMissesMetric:     cacheMissesMetric,
 		enablePassword:        authType == AuthenticationTypePassword || authType == AuthenticationTypeAll,
 		enablePubKey:          authType == AuthenticationTypePublicKey || authType == AuthenticationTypeAll,
 		enableAuthz:           authType == AuthenticationTypeAuthz || authType == AuthenticationTypeAll,
+		enableBanner:          authType == AuthenticationTypeBanner || authType == AuthenticationTypeAll,
 	}, nil
 }
 
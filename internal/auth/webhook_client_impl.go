@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"go.containerssh.io/containerssh/auth"
-	"go.containerssh.io/containerssh/http"
 	"go.containerssh.io/containerssh/internal/metrics"
 	"go.containerssh.io/containerssh/log"
 	"go.containerssh.io/containerssh/message"
@@ -18,17 +17,21 @@ import (
 
 type webhookClient struct {
 	timeout               time.Duration
-	httpClient            http.Client
+	endpoints             []*webhookEndpoint
 	endpoint              string
+	cache                 *webhookCache
 	logger                log.Logger
 	metrics               metrics.Collector
 	backendRequestsMetric metrics.SimpleCounter
 	backendFailureMetric  metrics.SimpleCounter
 	authSuccessMetric     metrics.GeoCounter
 	authFailureMetric     metrics.GeoCounter
+	cacheHitsMetric       metrics.Counter
+	cacheMissesMetric     metrics.Counter
 	enablePassword        bool
 	enablePubKey          bool
 	enableAuthz           bool
+	enableBanner          bool
 }
 
 func (client *webhookClient) Authorize(
@@ -49,7 +52,36 @@ func (client *webhookClient) Authorize(
 		ConnectionAuthenticatedMetadata: meta,
 	}
 
-	return client.processAuthzWithRetry(meta, url, authzRequest)
+	return client.processAuthzWithRetry(meta, url, authzRequest, authzCacheKey(meta))
+}
+
+func (client *webhookClient) Banner(meta metadata.ConnectionAuthPendingMetadata) (string, error) {
+	if !client.enableBanner {
+		return "", message.NewMessage(message.EAuthDisabled, "Fetching the banner is disabled.")
+	}
+	// The banner is requested before authentication, so it is not retried to avoid holding up the connection.
+	labels := []metrics.MetricLabel{
+		metrics.Label("authtype", "banner"),
+		metrics.Label("retry", "0"),
+	}
+	client.backendRequestsMetric.Increment(labels...)
+	response := &auth.BannerResponseBody{}
+	if err := client.authServerRequest(
+		client.endpoint+"/banner",
+		auth.BannerRequest{ConnectionAuthPendingMetadata: meta},
+		response,
+	); err != nil {
+		client.backendFailureMetric.Increment(
+			append(
+				[]metrics.MetricLabel{
+					metrics.Label("type", "hard"),
+					metrics.Label("reason", client.getReason(err)),
+				}, labels...,
+			)...,
+		)
+		return "", err
+	}
+	return response.Banner, nil
 }
 
 func (client *webhookClient) Password(
@@ -73,7 +105,8 @@ func (client *webhookClient) Password(
 		Password:                      base64.StdEncoding.EncodeToString(password),
 	}
 
-	return client.processAuthWithRetry(meta, method, authType, url, authRequest)
+	// Passwords are never cached.
+	return client.processAuthWithRetry(meta, method, authType, url, authRequest, "")
 }
 
 func (client *webhookClient) PubKey(
@@ -97,7 +130,7 @@ func (client *webhookClient) PubKey(
 	method := "Public key"
 	authType := "pubkey"
 
-	return client.processAuthWithRetry(meta, method, authType, url, authRequest)
+	return client.processAuthWithRetry(meta, method, authType, url, authRequest, pubKeyCacheKey(meta, pubKey))
 }
 
 func (client *webhookClient) processAuthWithRetry(
@@ -106,6 +139,7 @@ func (client *webhookClient) processAuthWithRetry(
 	authType string,
 	url string,
 	authRequest interface{},
+	cacheKey string,
 ) AuthenticationContext {
 	ctx, cancel := context.WithTimeout(context.Background(), client.timeout)
 	defer cancel()
@@ -116,6 +150,22 @@ func (client *webhookClient) processAuthWithRetry(
 		WithLabel("username", meta.Username).
 		WithLabel("url", url).
 		WithLabel("authtype", authType)
+	if authResponse, ok := client.cachedResponse(logger, authType, cacheKey); ok {
+		authenticatedMeta := meta.Authenticated("")
+		authenticatedMeta.Merge(authResponse.ConnectionAuthenticatedMetadata)
+		client.logAuthResponse(
+			logger,
+			method,
+			authResponse,
+			[]metrics.MetricLabel{metrics.Label("authtype", authType), metrics.Label("cached", "1")},
+			authenticatedMeta.RemoteAddress.IP,
+		)
+		return &webhookClientContext{
+			authenticatedMeta,
+			authResponse.Success,
+			nil,
+		}
+	}
 loop:
 	for {
 		lastLabels = []metrics.MetricLabel{
@@ -140,6 +190,7 @@ loop:
 			authenticatedMeta := meta.Authenticated("")
 			authenticatedMeta.Merge(authResponse.ConnectionAuthenticatedMetadata)
 			client.logAuthResponse(logger, method, authResponse, lastLabels, authenticatedMeta.RemoteAddress.IP)
+			client.storeResponse(cacheKey, authResponse)
 
 			return &webhookClientContext{
 				authenticatedMeta,
@@ -149,6 +200,10 @@ loop:
 		}
 		reason := client.getReason(lastError)
 		lastLabels = append(lastLabels, metrics.Label("reason", reason))
+		if reason == message.EAuthNoServerAvailable {
+			// Retrying is pointless until a circuit breaker lets a request through.
+			break loop
+		}
 		client.logTemporaryFailure(logger, lastError, method, reason, lastLabels)
 		select {
 		case <-ctx.Done():
@@ -256,8 +311,73 @@ func (client *webhookClient) logAuthResponse(
 	}
 }
 
-func (client *webhookClient) authServerRequest(endpoint string, requestObject interface{}, response interface{}) error {
-	statusCode, err := client.httpClient.Post(endpoint, requestObject, response)
+// cachedResponse returns the cached decision for the cache key. An empty cache key means the request is not
+// cacheable.
+func (client *webhookClient) cachedResponse(
+	logger log.Logger,
+	authType string,
+	cacheKey string,
+) (*auth.ResponseBody, bool) {
+	if client.cache == nil || cacheKey == "" {
+		return nil, false
+	}
+	authResponse, ok := client.cache.get(cacheKey)
+	if !ok {
+		client.cacheMissesMetric.Increment(metrics.Label("authtype", authType))
+		return nil, false
+	}
+	client.cacheHitsMetric.Increment(metrics.Label("authtype", authType))
+	logger.Debug(
+		message.NewMessage(
+			message.MAuthCacheHit,
+			"Using cached %s decision",
+			authType,
+		),
+	)
+	return &authResponse, true
+}
+
+func (client *webhookClient) storeResponse(cacheKey string, authResponse *auth.ResponseBody) {
+	if client.cache == nil || cacheKey == "" {
+		return
+	}
+	client.cache.set(cacheKey, *authResponse)
+}
+
+// authServerRequest sends the request to the configured servers in order until one of them responds, skipping the
+// servers whose circuit breaker is open.
+func (client *webhookClient) authServerRequest(path string, requestObject interface{}, response interface{}) error {
+	var lastError error
+	for _, endpoint := range client.endpoints {
+		if !endpoint.breaker.allow() {
+			continue
+		}
+		lastError = endpoint.request(path, requestObject, response)
+		if lastError == nil {
+			endpoint.breaker.success()
+			return nil
+		}
+		endpoint.breaker.failure()
+		client.logger.WithLabel("url", endpoint.url).Debug(
+			message.Wrap(
+				lastError,
+				message.EAuthBackendError,
+				"request to authentication server failed",
+			),
+		)
+	}
+	if lastError == nil {
+		return message.UserMessage(
+			message.EAuthNoServerAvailable,
+			"Cannot authenticate at this time.",
+			"all authentication servers are skipped because their circuit breakers are open",
+		)
+	}
+	return lastError
+}
+
+func (endpoint *webhookEndpoint) request(path string, requestObject interface{}, response interface{}) error {
+	statusCode, err := endpoint.httpClient.Post(path, requestObject, response)
 	if err != nil {
 		return err
 	}
@@ -276,6 +396,7 @@ func (client *webhookClient) processAuthzWithRetry(
 	meta metadata.ConnectionAuthenticatedMetadata,
 	url string,
 	authzRequest interface{},
+	cacheKey string,
 ) AuthenticationContext {
 	ctx, cancel := context.WithTimeout(context.Background(), client.timeout)
 	defer cancel()
@@ -286,6 +407,21 @@ func (client *webhookClient) processAuthzWithRetry(
 		WithLabel("authenticatedUsername", meta.AuthenticatedUsername).
 		WithLabel("providedUsername", meta.Username).
 		WithLabel("url", client.endpoint)
+	if authResponse, ok := client.cachedResponse(logger, "authorization", cacheKey); ok {
+		authenticatedMeta := meta.Authenticated("")
+		authenticatedMeta.Merge(authResponse.ConnectionAuthenticatedMetadata)
+		client.logAuthzResponse(
+			authenticatedMeta,
+			logger,
+			authResponse,
+			[]metrics.MetricLabel{metrics.Label("authtype", "authorization"), metrics.Label("cached", "1")},
+		)
+		return &webhookClientContext{
+			authenticatedMeta,
+			authResponse.Success,
+			nil,
+		}
+	}
 loop:
 	for {
 		lastLabels = []metrics.MetricLabel{
@@ -310,6 +446,7 @@ loop:
 			authenticatedMeta := meta.Authenticated("")
 			authenticatedMeta.Merge(authResponse.ConnectionAuthenticatedMetadata)
 			client.logAuthzResponse(authenticatedMeta, logger, authResponse, lastLabels)
+			client.storeResponse(cacheKey, authResponse)
 			return &webhookClientContext{
 				authenticatedMeta,
 				authResponse.Success,
@@ -318,6 +455,10 @@ loop:
 		}
 		reason := client.getReason(lastError)
 		lastLabels = append(lastLabels, metrics.Label("reason", reason))
+		if reason == message.EAuthNoServerAvailable {
+			// Retrying is pointless until a circuit breaker lets a request through.
+			break loop
+		}
 		client.logTemporaryAuthzFailure(logger, lastError, reason, lastLabels)
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,320 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	goHttp "net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	auth3 "go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+	"go.containerssh.io/containerssh/service"
+)
+
+// webhookStub is an authentication server that counts the requests per path.
+type webhookStub struct {
+	lock     sync.Mutex
+	requests map[string]int
+	status   int
+	response func(path string, body map[string]interface{}) auth3.ResponseBody
+}
+
+func (s *webhookStub) ServeHTTP(w goHttp.ResponseWriter, r *goHttp.Request) {
+	s.lock.Lock()
+	s.requests[r.URL.Path]++
+	status := s.status
+	s.lock.Unlock()
+
+	body := map[string]interface{}{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(s.response(r.URL.Path, body))
+}
+
+func (s *webhookStub) count(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests[path]
+}
+
+func (s *webhookStub) setStatus(status int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = status
+}
+
+func startWebhookStub(
+	t *testing.T,
+	response func(path string, body map[string]interface{}) auth3.ResponseBody,
+) (*webhookStub, string) {
+	stub := &webhookStub{
+		requests: map[string]int{},
+		status:   200,
+		response: response,
+	}
+	port := test.GetNextPort(t, "auth server")
+	server, err := http.NewServer(
+		"auth",
+		config.HTTPServerConfiguration{
+			Listen: fmt.Sprintf("127.0.0.1:%d", port),
+		},
+		stub,
+		log.NewTestLogger(t),
+		func(_ string) {},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := service.NewLifecycle(server)
+	ready := make(chan struct{})
+	lifecycle.OnRunning(
+		func(_ service.Service, _ service.Lifecycle) {
+			close(ready)
+		},
+	)
+	go func() {
+		_ = lifecycle.Run()
+	}()
+	<-ready
+	t.Cleanup(func() {
+		lifecycle.Stop(context.Background())
+	})
+	return stub, fmt.Sprintf("http://127.0.0.1:%d", port)
+}
+
+func newTestWebhookClient(
+	t *testing.T,
+	cfg config.AuthWebhookClientConfig,
+) (auth.WebhookClient, metrics.Collector) {
+	cfg.Timeout = 2 * time.Second
+	if cfg.AuthTimeout == 0 {
+		cfg.AuthTimeout = 2 * time.Second
+	}
+	collector := metrics.New(dummy.New())
+	client, err := auth.NewWebhookClient(auth.AuthenticationTypeAll, cfg, log.NewTestLogger(t), collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, collector
+}
+
+func metricSum(collector metrics.Collector, name string) float64 {
+	sum := float64(0)
+	for _, value := range collector.GetMetric(name) {
+		sum += value.Value
+	}
+	return sum
+}
+
+func TestWebhookCache(t *testing.T) {
+	zero := 0
+	stub, url := startWebhookStub(t, func(path string, body map[string]interface{}) auth3.ResponseBody {
+		response := auth3.ResponseBody{}
+		switch path {
+		case "/pubkey":
+			response.Success = body["publicKey"] == "ssh-rsa asdf"
+			response.Metadata = map[string]metadata.Value{"GROUP": {Value: "admins"}}
+		case "/authz":
+			response.Success = body["authenticatedUsername"] == "foo"
+			if body["username"] == "nocache" {
+				response.CacheTTL = &zero
+			}
+		case "/password":
+			response.Success = true
+		}
+		return response
+	})
+	client, collector := newTestWebhookClient(t, config.AuthWebhookClientConfig{
+		HTTPClientConfiguration: config.HTTPClientConfiguration{URL: url},
+		Cache: config.AuthWebhookCacheConfig{
+			Enable:      true,
+			TTL:         time.Minute,
+			NegativeTTL: time.Minute,
+			MaxEntries:  10,
+		},
+	})
+
+	t.Run("positive", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			authContext := client.PubKey(
+				metadata.NewTestAuthenticatingMetadata("foo"),
+				auth3.PublicKey{PublicKey: "ssh-rsa asdf"},
+			)
+			assert.NoError(t, authContext.Error())
+			assert.True(t, authContext.Success())
+			assert.Equal(t, "admins", authContext.Metadata().Metadata["GROUP"].Value)
+		}
+		assert.Equal(t, 1, stub.count("/pubkey"))
+		assert.Equal(t, float64(1), metricSum(collector, auth.MetricNameAuthCacheHits))
+	})
+	t.Run("other user", func(t *testing.T) {
+		authContext := client.PubKey(
+			metadata.NewTestAuthenticatingMetadata("bar"),
+			auth3.PublicKey{PublicKey: "ssh-rsa asdf"},
+		)
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 2, stub.count("/pubkey"))
+	})
+	t.Run("negative", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			authContext := client.PubKey(
+				metadata.NewTestAuthenticatingMetadata("foo"),
+				auth3.PublicKey{PublicKey: "ssh-rsa asdx"},
+			)
+			assert.NoError(t, authContext.Error())
+			assert.False(t, authContext.Success())
+		}
+		assert.Equal(t, 3, stub.count("/pubkey"))
+	})
+	t.Run("authz", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			authContext := client.Authorize(metadata.NewTestAuthenticatingMetadata("foo").Authenticated("foo"))
+			assert.True(t, authContext.Success())
+		}
+		assert.Equal(t, 1, stub.count("/authz"))
+	})
+	t.Run("ttl from response", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			authContext := client.Authorize(metadata.NewTestAuthenticatingMetadata("nocache").Authenticated("bar"))
+			assert.False(t, authContext.Success())
+		}
+		assert.Equal(t, 3, stub.count("/authz"))
+	})
+	t.Run("password", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			authContext := client.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+			assert.True(t, authContext.Success())
+		}
+		assert.Equal(t, 2, stub.count("/password"))
+	})
+	otherIP := func(meta metadata.ConnectionAuthPendingMetadata) metadata.ConnectionAuthPendingMetadata {
+		meta.RemoteAddress = metadata.RemoteAddress(net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 2222})
+		meta.ConnectionID = "FEDCBA9876543210"
+		return meta
+	}
+	t.Run("pubkey from other IP", func(t *testing.T) {
+		authContext := client.PubKey(
+			otherIP(metadata.NewTestAuthenticatingMetadata("foo")),
+			auth3.PublicKey{PublicKey: "ssh-rsa asdf"},
+		)
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 4, stub.count("/pubkey"))
+	})
+	t.Run("authz from other IP", func(t *testing.T) {
+		authContext := client.Authorize(otherIP(metadata.NewTestAuthenticatingMetadata("foo")).Authenticated("foo"))
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 4, stub.count("/authz"))
+	})
+	t.Run("authz with other metadata", func(t *testing.T) {
+		meta := metadata.NewTestAuthenticatingMetadata("foo").Authenticated("foo")
+		meta.Metadata = map[string]metadata.Value{"GROUP": {Value: "admins"}}
+		authContext := client.Authorize(meta)
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 5, stub.count("/authz"))
+	})
+	t.Run("pubkey from other listener", func(t *testing.T) {
+		meta := metadata.NewTestAuthenticatingMetadata("foo")
+		meta.Listener = "internal"
+		authContext := client.PubKey(meta, auth3.PublicKey{PublicKey: "ssh-rsa asdf"})
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 5, stub.count("/pubkey"))
+	})
+	t.Run("pubkey in other chain stage", func(t *testing.T) {
+		meta := metadata.NewTestAuthenticatingMetadata("foo")
+		meta.AuthenticatedMethods = []string{"password"}
+		meta.Metadata = map[string]metadata.Value{"GROUP": {Value: "admins"}}
+		authContext := client.PubKey(meta, auth3.PublicKey{PublicKey: "ssh-rsa asdf"})
+		assert.True(t, authContext.Success())
+		assert.Equal(t, 6, stub.count("/pubkey"))
+	})
+}
+
+func TestWebhookCircuitBreaker(t *testing.T) {
+	success := func(_ string, _ map[string]interface{}) auth3.ResponseBody {
+		return auth3.ResponseBody{Success: true}
+	}
+	primary, primaryURL := startWebhookStub(t, success)
+	primary.setStatus(500)
+	fallback, fallbackURL := startWebhookStub(t, success)
+
+	client, collector := newTestWebhookClient(t, config.AuthWebhookClientConfig{
+		HTTPClientConfiguration: config.HTTPClientConfiguration{URL: primaryURL},
+		FallbackURLs:            []string{fallbackURL},
+		CircuitBreaker: config.AuthWebhookCircuitBreakerConfig{
+			FailureThreshold: 2,
+			ResetTimeout:     500 * time.Millisecond,
+		},
+	})
+	password := func() auth.AuthenticationContext {
+		return client.Password(metadata.NewTestAuthenticatingMetadata("foo"), []byte("bar"))
+	}
+
+	for i := 0; i < 3; i++ {
+		authContext := password()
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+	}
+	// The primary server is skipped after the second failure.
+	assert.Equal(t, 2, primary.count("/password"))
+	assert.Equal(t, 3, fallback.count("/password"))
+	assert.Equal(t, float64(1), metricSum(collector, auth.MetricNameAuthCircuitBreakerStateChanges))
+
+	// After the reset timeout a single request checks if the primary server has recovered.
+	primary.setStatus(200)
+	time.Sleep(600 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		authContext := password()
+		assert.NoError(t, authContext.Error())
+		assert.True(t, authContext.Success())
+	}
+	assert.Equal(t, 4, primary.count("/password"))
+	assert.Equal(t, 3, fallback.count("/password"))
+	assert.Equal(t, float64(3), metricSum(collector, auth.MetricNameAuthCircuitBreakerStateChanges))
+}
+
+func TestWebhookCircuitBreakerNoServer(t *testing.T) {
+	primary, primaryURL := startWebhookStub(t, func(_ string, _ map[string]interface{}) auth3.ResponseBody {
+		return auth3.ResponseBody{Success: true}
+	})
+	primary.setStatus(500)
+
+	client, _ := newTestWebhookClient(t, config.AuthWebhookClientConfig{
+		HTTPClientConfiguration: config.HTTPClientConfiguration{URL: primaryURL},
+		AuthTimeout:             500 * time.Millisecond,
+		CircuitBreaker: config.AuthWebhookCircuitBreakerConfig{
+			FailureThreshold: 1,
+			ResetTimeout:     time.Minute,
+		},
+	})
+
+	authContext := client.PubKey(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		auth3.PublicKey{PublicKey: "ssh-rsa asdf"},
+	)
+	assert.Error(t, authContext.Error())
+	assert.Equal(t, 1, primary.count("/pubkey"))
+
+	// With the circuit open the request fails immediately instead of waiting for the auth timeout.
+	start := time.Now()
+	authContext = client.PubKey(
+		metadata.NewTestAuthenticatingMetadata("foo"),
+		auth3.PublicKey{PublicKey: "ssh-rsa asdf"},
+	)
+	assert.Error(t, authContext.Error())
+	assert.False(t, authContext.Success())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Equal(t, 1, primary.count("/pubkey"))
+}
@@ -86,6 +86,13 @@ func (h *handler) OnAuthorization(meta metadata.ConnectionAuthenticatedMetadata)
 	return false, meta.AuthFailed(), nil
 }
 
+func (h *handler) OnBanner(meta metadata.ConnectionAuthPendingMetadata) (string, error) {
+	if meta.Username == "crash" {
+		return "", fmt.Errorf("database error")
+	}
+	return fmt.Sprintf("Hello %s!", meta.Username), nil
+}
+
 func TestAuth(t *testing.T) {
 	logger := log.NewTestLogger(t)
 	logger.Info(
@@ -174,6 +181,13 @@ func TestAuth(t *testing.T) {
 				)
 				assert.NotEqual(t, nil, authenticationContext.Error())
 				assert.Equal(t, false, authenticationContext.Success())
+
+				banner, err := client.Banner(metadata.NewTestAuthenticatingMetadata("foo"))
+				assert.NoError(t, err)
+				assert.Equal(t, "Hello foo!", banner)
+
+				_, err = client.Banner(metadata.NewTestAuthenticatingMetadata("crash"))
+				assert.Error(t, err)
 			},
 		)
 	}
@@ -2,11 +2,18 @@ package authintegration
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"reflect"
+	"sync"
 
 	auth2 "go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/config"
 	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/lockout"
+	"go.containerssh.io/containerssh/internal/metrics"
 	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
 	"go.containerssh.io/containerssh/message"
 	"go.containerssh.io/containerssh/metadata"
 )
@@ -40,14 +47,56 @@ func (behavior Behavior) validate() bool {
 	}
 }
 
-type handler struct {
-	backend                          sshserver.Handler
+// Handler is an SSH server handler that authenticates users before passing the connection to the backend.
+type Handler interface {
+	sshserver.Handler
+
+	// Reload replaces the authenticators with ones created from the new configuration. Only new connections use the
+	// new authenticators. Configurations that need background services, such as OAuth2, cannot be reloaded and
+	// require a restart. The lockout rules are replaced, but recorded failures and bans are kept.
+	Reload(config config.AuthConfig) error
+}
+
+type authenticators struct {
 	passwordAuthenticator            auth.PasswordAuthenticator
 	publicKeyAuthenticator           auth.PublicKeyAuthenticator
 	gssapiAuthenticator              auth.GSSAPIAuthenticator
 	keyboardInteractiveAuthenticator auth.KeyboardInteractiveAuthenticator
+	totpAuthenticator                auth.TOTPAuthenticator
 	authorizationProvider            auth.AuthzProvider
-	behavior                         Behavior
+	bannerProvider                   auth.BannerProvider
+	required                         config.AuthRequiredMethods
+}
+
+type handler struct {
+	backend          sshserver.Handler
+	authenticators   *authenticators
+	behavior         Behavior
+	logger           log.Logger
+	metricsCollector metrics.Collector
+	lock             *sync.Mutex
+	lockout          lockout.Tracker
+	lockoutServer    config.AuthLockoutServerConfig
+}
+
+func (h *handler) Reload(config config.AuthConfig) error {
+	if !reflect.DeepEqual(config.Lockout.Server, h.lockoutServer) {
+		return fmt.Errorf("the lockout server configuration can only be changed with a restart")
+	}
+	newAuthenticators, services, err := createAuthenticators(config, h.logger, h.metricsCollector)
+	if err != nil {
+		return err
+	}
+	if len(services) > 0 {
+		return fmt.Errorf("the authentication configuration requires background services and can only be changed with a restart")
+	}
+	if err := h.lockout.Reload(config.Lockout); err != nil {
+		return err
+	}
+	h.lock.Lock()
+	h.authenticators = newAuthenticators
+	h.lock.Unlock()
+	return nil
 }
 
 func (h *handler) OnReady() error {
@@ -77,30 +126,46 @@ func (h *handler) OnNetworkConnection(meta metadata.ConnectionMetadata) (
 		}
 	}
 
+	h.lock.Lock()
+	currentAuthenticators := h.authenticators
+	h.lock.Unlock()
+
 	authHandler := networkConnectionHandler{
 		connectionID:                     meta.ConnectionID,
 		ip:                               meta.RemoteAddress.IP,
 		backend:                          backend,
 		behavior:                         h.behavior,
-		passwordAuthenticator:            h.passwordAuthenticator,
-		publicKeyAuthenticator:           h.publicKeyAuthenticator,
-		gssapiAuthenticator:              h.gssapiAuthenticator,
-		keyboardInteractiveAuthenticator: h.keyboardInteractiveAuthenticator,
-		authorizationProvider:            h.authorizationProvider,
+		passwordAuthenticator:            currentAuthenticators.passwordAuthenticator,
+		publicKeyAuthenticator:           currentAuthenticators.publicKeyAuthenticator,
+		gssapiAuthenticator:              currentAuthenticators.gssapiAuthenticator,
+		keyboardInteractiveAuthenticator: currentAuthenticators.keyboardInteractiveAuthenticator,
+		totpAuthenticator:                currentAuthenticators.totpAuthenticator,
+		authorizationProvider:            currentAuthenticators.authorizationProvider,
+		bannerProvider:                   currentAuthenticators.bannerProvider,
+		required:                         currentAuthenticators.required,
+		logger:                           h.logger,
 	}
 
-	if h.authorizationProvider != nil {
+	// The lockout handler sits between the authorization and the authentication handler, so users rejected by the
+	// authorization server are not counted as failed attempts.
+	lockoutHandler := lockoutNetworkConnectionHandler{
+		backend: &authHandler,
+		ip:      meta.RemoteAddress.IP,
+		tracker: h.lockout,
+	}
+
+	if currentAuthenticators.authorizationProvider != nil {
 		// We inject the authz handler before the normal authentication handler in the chain as we need the authenticated metadata the handler returns.
 		// Authentications request will first hit the authz handler which will pass it through to the authHandler, once it returns we can perform authorization.
 		authzHandler := authzNetworkConnectionHandler{
 			connectionID:          meta.ConnectionID,
 			ip:                    meta.RemoteAddress.IP,
-			authorizationProvider: h.authorizationProvider,
-			backend:               &authHandler,
+			authorizationProvider: currentAuthenticators.authorizationProvider,
+			backend:               &lockoutHandler,
 		}
 		return &authzHandler, meta, nil
 	}
-	return &authHandler, meta, nil
+	return &lockoutHandler, meta, nil
 }
 
 type networkConnectionHandler struct {
@@ -113,13 +178,38 @@ type networkConnectionHandler struct {
 	publicKeyAuthenticator           auth.PublicKeyAuthenticator
 	gssapiAuthenticator              auth.GSSAPIAuthenticator
 	keyboardInteractiveAuthenticator auth.KeyboardInteractiveAuthenticator
+	totpAuthenticator                auth.TOTPAuthenticator
 	authorizationProvider            auth.AuthzProvider
+	bannerProvider                   auth.BannerProvider
+	required                         config.AuthRequiredMethods
+	logger                           log.Logger
 }
 
 func (h *networkConnectionHandler) OnShutdown(shutdownContext context.Context) {
 	h.backend.OnShutdown(shutdownContext)
 }
 
+func (h *networkConnectionHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	if h.backend != nil {
+		banner = h.backend.OnBanner(meta, banner)
+	}
+	if h.bannerProvider == nil {
+		return banner
+	}
+	fetchedBanner, err := h.bannerProvider.Banner(meta)
+	if err != nil {
+		h.logger.Warning(
+			message.Wrap(
+				err,
+				message.EAuthBannerFailed,
+				"Failed to fetch the banner from the authentication server, sending the configured banner",
+			).Label("connectionId", meta.ConnectionID),
+		)
+		return banner
+	}
+	return fetchedBanner
+}
+
 func (h *networkConnectionHandler) OnAuthPassword(
 	meta metadata.ConnectionAuthPendingMetadata,
 	password []byte,
@@ -134,24 +224,31 @@ func (h *networkConnectionHandler) OnAuthPassword(
 			"Password authentication is disabled.",
 		)
 	}
+	if err := h.checkMethod(meta, config.SSHAuthMethodPassword); err != nil {
+		return sshserver.AuthResponseUnavailable, meta.AuthFailed(), err
+	}
+	passthrough := func() (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+		response, authenticatedMeta, err := h.backend.OnAuthPassword(meta, password)
+		return h.requireComplete(meta, config.SSHAuthMethodPassword, response, authenticatedMeta, err)
+	}
 	authContext := h.passwordAuthenticator.Password(meta, password)
 	h.authContext = authContext
 	if !authContext.Success() {
 		if authContext.Error() != nil {
 			if h.behavior == BehaviorPassthroughOnUnavailable {
-				return h.backend.OnAuthPassword(meta, password)
+				return passthrough()
 			}
 			return sshserver.AuthResponseUnavailable, meta.AuthFailed(), authContext.Error()
 		}
 		if h.behavior == BehaviorPassthroughOnFailure {
-			return h.backend.OnAuthPassword(meta, password)
+			return passthrough()
 		}
 		return sshserver.AuthResponseFailure, meta.AuthFailed(), nil
 	}
 	if h.behavior == BehaviorPassthroughOnSuccess {
-		return h.backend.OnAuthPassword(meta, password)
+		return passthrough()
 	} else {
-		return sshserver.AuthResponseSuccess, authContext.Metadata(), nil
+		return h.methodSuccess(meta, config.SSHAuthMethodPassword, authContext)
 	}
 }
 
@@ -169,24 +266,31 @@ func (h *networkConnectionHandler) OnAuthPubKey(
 			"Public key authentication is disabled.",
 		)
 	}
+	if err := h.checkMethod(meta, config.SSHAuthMethodPublicKey); err != nil {
+		return sshserver.AuthResponseUnavailable, meta.AuthFailed(), err
+	}
+	passthrough := func() (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+		response, authenticatedMeta, err := h.backend.OnAuthPubKey(meta, pubKey)
+		return h.requireComplete(meta, config.SSHAuthMethodPublicKey, response, authenticatedMeta, err)
+	}
 	authContext := h.publicKeyAuthenticator.PubKey(meta, pubKey)
 	h.authContext = authContext
 	if !authContext.Success() {
 		if authContext.Error() != nil {
 			if h.behavior == BehaviorPassthroughOnUnavailable {
-				return h.backend.OnAuthPubKey(meta, pubKey)
+				return passthrough()
 			}
 			return sshserver.AuthResponseUnavailable, authContext.Metadata(), authContext.Error()
 		}
 		if h.behavior == BehaviorPassthroughOnFailure {
-			return h.backend.OnAuthPubKey(meta, pubKey)
+			return passthrough()
 		}
 		return sshserver.AuthResponseFailure, authContext.Metadata(), authContext.Error()
 	}
 	if h.behavior == BehaviorPassthroughOnSuccess {
-		return h.backend.OnAuthPubKey(meta, pubKey)
+		return passthrough()
 	}
-	return sshserver.AuthResponseSuccess, authContext.Metadata(), authContext.Error()
+	return h.methodSuccess(meta, config.SSHAuthMethodPublicKey, authContext)
 }
 
 func (h *networkConnectionHandler) OnAuthKeyboardInteractive(
@@ -199,6 +303,12 @@ func (h *networkConnectionHandler) OnAuthKeyboardInteractive(
 	if h.authContext != nil {
 		h.authContext.OnDisconnect()
 	}
+	if err := h.checkMethod(meta, config.SSHAuthMethodKeyboardInteractive); err != nil {
+		return sshserver.AuthResponseUnavailable, meta.AuthFailed(), err
+	}
+	if h.totpAuthenticator != nil {
+		return h.onAuthTOTP(meta, challenge)
+	}
 	if h.keyboardInteractiveAuthenticator == nil {
 		return sshserver.AuthResponseUnavailable, meta.AuthFailed(), message.UserMessage(
 			message.ESSHAuthUnavailable,
@@ -206,57 +316,94 @@ func (h *networkConnectionHandler) OnAuthKeyboardInteractive(
 			"Keyboard-interactive authentication is disabled.",
 		)
 	}
-	authContext := h.keyboardInteractiveAuthenticator.KeyboardInteractive(
-		meta,
-		func(instruction string, questions auth.KeyboardInteractiveQuestions) (
-			answers auth.KeyboardInteractiveAnswers,
-			err error,
-		) {
-			q := make(sshserver.KeyboardInteractiveQuestions, len(questions))
-			for i, question := range questions {
-				q[i] = sshserver.KeyboardInteractiveQuestion(question)
-			}
-			a, err := challenge(instruction, q)
-			if err != nil {
-				return auth.KeyboardInteractiveAnswers{}, err
-			}
-			answers = auth.KeyboardInteractiveAnswers{
-				Answers: make(map[string]string, len(questions)),
-			}
-			for i, question := range questions {
-				ans, err := a.Get(q[i])
-				if err != nil {
-					ans = ""
-				}
-				answers.Answers[question.ID] = ans
-			}
-			return answers, nil
-		},
-	)
+	passthrough := func() (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+		response, authenticatedMeta, err := h.backend.OnAuthKeyboardInteractive(meta, challenge)
+		return h.requireComplete(meta, config.SSHAuthMethodKeyboardInteractive, response, authenticatedMeta, err)
+	}
+	authContext := h.keyboardInteractiveAuthenticator.KeyboardInteractive(meta, adaptChallenge(challenge))
 	h.authContext = authContext
 	if !authContext.Success() {
 		if authContext.Error() != nil {
 			if h.behavior == BehaviorPassthroughOnUnavailable {
-				return h.backend.OnAuthKeyboardInteractive(meta, challenge)
+				return passthrough()
 			}
 			return sshserver.AuthResponseUnavailable, authContext.Metadata(), authContext.Error()
 		}
 		if h.behavior == BehaviorPassthroughOnFailure {
-			return h.backend.OnAuthKeyboardInteractive(meta, challenge)
+			return passthrough()
 		}
 		return sshserver.AuthResponseFailure, authContext.Metadata(), authContext.Error()
 	}
 	if h.behavior == BehaviorPassthroughOnSuccess {
-		return h.backend.OnAuthKeyboardInteractive(meta, challenge)
+		return passthrough()
+	}
+	return h.methodSuccess(meta, config.SSHAuthMethodKeyboardInteractive, authContext)
+}
+
+// onAuthTOTP asks for the TOTP code of the user. The metadata contains the metadata returned by the methods the
+// user passed before, so the secret can be returned by the authentication webhook.
+func (h *networkConnectionHandler) onAuthTOTP(
+	meta metadata.ConnectionAuthPendingMetadata,
+	challenge func(
+		instruction string,
+		questions sshserver.KeyboardInteractiveQuestions,
+	) (answers sshserver.KeyboardInteractiveAnswers, err error),
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	authContext := h.totpAuthenticator.TOTP(meta.Authenticated(meta.Username), adaptChallenge(challenge))
+	if !authContext.Success() {
+		if authContext.Error() != nil {
+			return sshserver.AuthResponseUnavailable, authContext.Metadata(), authContext.Error()
+		}
+		return sshserver.AuthResponseFailure, authContext.Metadata(), nil
+	}
+	return h.methodSuccess(meta, config.SSHAuthMethodKeyboardInteractive, authContext)
+}
+
+// adaptChallenge converts the challenge function of the SSH server to the one used by the authenticators.
+func adaptChallenge(
+	challenge func(
+		instruction string,
+		questions sshserver.KeyboardInteractiveQuestions,
+	) (answers sshserver.KeyboardInteractiveAnswers, err error),
+) func(instruction string, questions auth.KeyboardInteractiveQuestions) (auth.KeyboardInteractiveAnswers, error) {
+	return func(instruction string, questions auth.KeyboardInteractiveQuestions) (
+		answers auth.KeyboardInteractiveAnswers,
+		err error,
+	) {
+		q := make(sshserver.KeyboardInteractiveQuestions, len(questions))
+		for i, question := range questions {
+			q[i] = sshserver.KeyboardInteractiveQuestion(question)
+		}
+		a, err := challenge(instruction, q)
+		if err != nil {
+			return auth.KeyboardInteractiveAnswers{}, err
+		}
+		answers = auth.KeyboardInteractiveAnswers{
+			Answers: make(map[string]string, len(questions)),
+		}
+		for i, question := range questions {
+			ans, err := a.Get(q[i])
+			if err != nil {
+				ans = ""
+			}
+			answers.Answers[question.ID] = ans
+		}
+		return answers, nil
 	}
-	return sshserver.AuthResponseSuccess, authContext.Metadata(), authContext.Error()
 }
 
 func (h *networkConnectionHandler) OnAuthGSSAPI(meta metadata.ConnectionMetadata) auth.GSSAPIServer {
 	if h.gssapiAuthenticator == nil {
 		return nil
 	}
-	return h.gssapiAuthenticator.GSSAPI(meta)
+	server := h.gssapiAuthenticator.GSSAPI(meta)
+	if len(h.requiredMethods()) == 0 {
+		return server
+	}
+	return &requiredGSSAPIServer{
+		GSSAPIServer: server,
+		handler:      h,
+	}
 }
 
 func (h *networkConnectionHandler) OnHandshakeFailed(meta metadata.ConnectionMetadata, reason error) {
@@ -305,6 +452,10 @@ func (a *authzNetworkConnectionHandler) genericAuthorization(
 
 // OnAuthPassword is called when a user attempts a password authentication. The implementation must always supply
 // AuthResponse and may supply error as a reason description.
+func (a *authzNetworkConnectionHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return a.backend.OnBanner(meta, banner)
+}
+
 func (a *authzNetworkConnectionHandler) OnAuthPassword(meta metadata.ConnectionAuthPendingMetadata, password []byte) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
 	authResponse, authenticatedMeta, err := a.backend.OnAuthPassword(meta, password)
 	return a.genericAuthorization(meta, authResponse, authenticatedMeta, err)
@@ -2,9 +2,11 @@ package authintegration
 
 import (
 	"fmt"
+	"sync"
 
     "go.containerssh.io/containerssh/config"
     "go.containerssh.io/containerssh/internal/auth"
+    "go.containerssh.io/containerssh/internal/lockout"
     "go.containerssh.io/containerssh/internal/metrics"
     "go.containerssh.io/containerssh/internal/sshserver"
     "go.containerssh.io/containerssh/log"
@@ -19,7 +21,7 @@ func New(
 	logger log.Logger,
 	metricsCollector metrics.Collector,
 	behavior Behavior,
-) (sshserver.Handler, []service.Service, error) {
+) (Handler, []service.Service, error) {
 	if backend == nil {
 		return nil, nil, fmt.Errorf("the backend parameter to authintegration.New cannot be nil")
 	}
@@ -27,6 +29,40 @@ func New(
 		return nil, nil, fmt.Errorf("the behavior field contains an invalid value: %d", behavior)
 	}
 
+	authenticators, services, err := createAuthenticators(config, logger, metricsCollector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracker, err := lockout.New(config.Lockout, logger, metricsCollector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.Lockout.Server.Enable {
+		svc, err := lockout.NewServer(config.Lockout.Server, tracker, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		services = append(services, svc)
+	}
+
+	return &handler{
+		authenticators:   authenticators,
+		backend:          backend,
+		behavior:         behavior,
+		logger:           logger,
+		metricsCollector: metricsCollector,
+		lock:             &sync.Mutex{},
+		lockout:          tracker,
+		lockoutServer:    config.Lockout.Server,
+	}, services, nil
+}
+
+func createAuthenticators(
+	config config.AuthConfig,
+	logger log.Logger,
+	metricsCollector metrics.Collector,
+) (*authenticators, []service.Service, error) {
 	var services []service.Service
 
 	passwordAuthenticator, svc, err := auth.NewPasswordAuthenticator(config.PasswordAuth, logger, metricsCollector)
@@ -57,6 +93,11 @@ func New(
 		services = append(services, svc)
 	}
 
+	totpAuthenticator, err := auth.NewTOTPAuthenticator(config.KeyboardInteractiveAuth, logger, metricsCollector)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	gssapiAuthenticator, svc, err := auth.NewGSSAPIAuthenticator(
 		config.GSSAPIAuth,
 		logger,
@@ -77,13 +118,19 @@ func New(
 		services = append(services, svc)
 	}
 
-	return &handler{
+	bannerProvider, err := auth.NewBannerProvider(config.Banner, logger, metricsCollector)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &authenticators{
 		passwordAuthenticator:            passwordAuthenticator,
 		publicKeyAuthenticator:           publicKeyAuthenticator,
 		keyboardInteractiveAuthenticator: keyboardInteractiveAuthenticator,
+		totpAuthenticator:                totpAuthenticator,
 		gssapiAuthenticator:              gssapiAuthenticator,
 		authorizationProvider:            authorizationProvider,
-		backend:                          backend,
-		behavior:                         behavior,
+		bannerProvider:                   bannerProvider,
+		required:                         config.Required,
 	}, services, nil
 }
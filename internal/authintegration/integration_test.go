@@ -2,7 +2,17 @@ package authintegration_test
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // Required by RFC 6238.
+	"encoding/base32"
+	"encoding/binary"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,10 +38,16 @@ func TestAuthentication(t *testing.T) {
 
 	authServerPort := test.GetNextPort(t, "auth server")
 
-	authLifecycle := startAuthServer(t, logger, authServerPort)
+	authLifecycle := startAuthServer(t, logger, authServerPort, &authHandler{})
 	defer authLifecycle.Stop(context.Background())
 
-	sshServerConfig, lifecycle := startSSHServer(t, logger, authServerPort)
+	sshServerConfig, lifecycle := startSSHServer(
+		t,
+		logger,
+		authServerPort,
+		config.AuthLockoutConfig{},
+		nil,
+	)
 	defer lifecycle.Stop(context.Background())
 
 	testConnection(t, "foo", ssh.Password("bar"), sshServerConfig, true)
@@ -41,12 +57,226 @@ func TestAuthentication(t *testing.T) {
 	testConnection(t, "foonoauthz", ssh.Password("baz"), sshServerConfig, false)
 }
 
-func startAuthServer(t *testing.T, logger log.Logger, authServerPort int) service.Lifecycle {
+func TestLockout(t *testing.T) {
+	logger := log.NewTestLogger(t)
+
+	authServerPort := test.GetNextPort(t, "auth server")
+
+	authLifecycle := startAuthServer(t, logger, authServerPort, &authHandler{})
+	defer authLifecycle.Stop(context.Background())
+
+	lockoutConfig := config.AuthLockoutConfig{}
+	structutils.Defaults(&lockoutConfig)
+	lockoutConfig.Username.MaxFailures = 2
+	sshServerConfig, lifecycle := startSSHServer(
+		t,
+		logger,
+		authServerPort,
+		lockoutConfig,
+		nil,
+	)
+	defer lifecycle.Stop(context.Background())
+
+	testConnection(t, "foo", ssh.Password("baz"), sshServerConfig, false)
+	testConnection(t, "foo", ssh.Password("bar"), sshServerConfig, true)
+	// The successful login resets the failure counter.
+	testConnection(t, "foo", ssh.Password("baz"), sshServerConfig, false)
+	testConnection(t, "foo", ssh.Password("baz"), sshServerConfig, false)
+	// The username is now banned, so even the correct password is rejected.
+	testConnection(t, "foo", ssh.Password("bar"), sshServerConfig, false)
+}
+
+func TestTOTP(t *testing.T) {
+	logger := log.NewTestLogger(t)
+
+	authServerPort := test.GetNextPort(t, "auth server")
+
+	authLifecycle := startAuthServer(t, logger, authServerPort, &authHandler{})
+	defer authLifecycle.Stop(context.Background())
+
+	secret := []byte("12345678901234567890")
+	secretsFile := filepath.Join(t.TempDir(), "totp")
+	assert.NoError(t, os.WriteFile(secretsFile, []byte("foo:"+base32.StdEncoding.EncodeToString(secret)+"\n"), 0600))
+	kiConfig := config.KeyboardInteractiveAuthConfig{}
+	structutils.Defaults(&kiConfig)
+	kiConfig.Method = config.KeyboardInteractiveAuthMethodTOTP
+	kiConfig.TOTP.SecretsFile = secretsFile
+
+	sshServerConfig, lifecycle := startSSHServer(
+		t,
+		logger,
+		authServerPort,
+		config.AuthLockoutConfig{},
+		func(cfg *config.AuthConfig) {
+			cfg.KeyboardInteractiveAuth = kiConfig
+		},
+	)
+	defer lifecycle.Stop(context.Background())
+
+	answer := func(code string) ssh.AuthMethod {
+		return ssh.KeyboardInteractive(
+			func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range questions {
+					answers[i] = code
+				}
+				return answers, nil
+			},
+		)
+	}
+	code := totpCode(secret, time.Now())
+
+	// The password alone only results in a partial success.
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.Password("bar")}, sshServerConfig, false)
+	// The code alone is not accepted either.
+	testConnections(t, "foo", []ssh.AuthMethod{answer(code)}, sshServerConfig, false)
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.Password("bar"), answer("000000")}, sshServerConfig, false)
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.Password("bar"), answer(code)}, sshServerConfig, true)
+	// The same code cannot be used twice.
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.Password("bar"), answer(code)}, sshServerConfig, false)
+}
+
+// totpCode generates the current RFC 6238 code with the default settings.
+func totpCode(secret []byte, at time.Time) string {
+	mac := hmac.New(sha1.New, secret)
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/30))
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func TestRequiredMethods(t *testing.T) {
+	logger := log.NewTestLogger(t)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	assert.NoError(t, err)
+	_, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherPrivateKey)
+	assert.NoError(t, err)
+
+	authServerPort := test.GetNextPort(t, "auth server")
+	handler := &authHandler{
+		authorizedKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))),
+	}
+	authLifecycle := startAuthServer(t, logger, authServerPort, handler)
+	defer authLifecycle.Stop(context.Background())
+
+	sshServerConfig, lifecycle := startSSHServer(
+		t,
+		logger,
+		authServerPort,
+		config.AuthLockoutConfig{},
+		func(cfg *config.AuthConfig) {
+			cfg.PublicKeyAuth = config.PublicKeyAuthConfig{
+				Method:  config.PubKeyAuthMethodWebhook,
+				Webhook: cfg.PasswordAuth.Webhook,
+			}
+			cfg.Required = config.AuthRequiredMethods{
+				{config.SSHAuthMethodPublicKey, config.SSHAuthMethodPassword},
+			}
+		},
+	)
+	defer lifecycle.Stop(context.Background())
+
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.Password("bar")}, sshServerConfig, false)
+	testConnections(t, "foo", []ssh.AuthMethod{ssh.PublicKeys(signer)}, sshServerConfig, false)
+	testConnections(
+		t,
+		"foo",
+		[]ssh.AuthMethod{ssh.PublicKeys(otherSigner), ssh.Password("bar")},
+		sshServerConfig,
+		false,
+	)
+	testConnections(
+		t,
+		"foo",
+		[]ssh.AuthMethod{ssh.PublicKeys(signer), ssh.Password("baz")},
+		sshServerConfig,
+		false,
+	)
+	// The methods must be passed in the configured order.
+	testConnections(
+		t,
+		"foo",
+		[]ssh.AuthMethod{ssh.Password("bar"), ssh.PublicKeys(signer)},
+		sshServerConfig,
+		false,
+	)
+	testConnections(
+		t,
+		"foo",
+		[]ssh.AuthMethod{ssh.PublicKeys(signer), ssh.Password("bar")},
+		sshServerConfig,
+		true,
+	)
+	assert.Equal(t, []string{"publickey"}, handler.getPasswordMethods())
+}
+
+func TestRequiredMethodsPassthrough(t *testing.T) {
+	logger := log.NewTestLogger(t)
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	assert.NoError(t, err)
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+
+	authServerPort := test.GetNextPort(t, "auth server")
+	authLifecycle := startAuthServer(t, logger, authServerPort, &authHandler{authorizedKey: authorizedKey})
+	defer authLifecycle.Stop(context.Background())
+
+	webhookConfig := config.AuthWebhookClientConfig{
+		HTTPClientConfiguration: config.HTTPClientConfiguration{
+			URL:     fmt.Sprintf("http://127.0.0.1:%d", authServerPort),
+			Timeout: 10 * time.Second,
+		},
+		AuthTimeout: 30 * time.Second,
+	}
+	handler, _, err := authintegration.New(
+		config.AuthConfig{
+			PasswordAuth: config.PasswordAuthConfig{
+				Method:  config.PasswordAuthMethodWebhook,
+				Webhook: webhookConfig,
+			},
+			PublicKeyAuth: config.PublicKeyAuthConfig{
+				Method:  config.PubKeyAuthMethodWebhook,
+				Webhook: webhookConfig,
+			},
+			Required: config.AuthRequiredMethods{
+				{config.SSHAuthMethodPublicKey, config.SSHAuthMethodPassword},
+			},
+		},
+		&passthroughBackend{},
+		logger,
+		metrics.New(dummy.New()),
+		authintegration.BehaviorPassthroughOnSuccess,
+	)
+	assert.NoError(t, err)
+	connectionHandler, _, err := handler.OnNetworkConnection(metadata.NewTestMetadata())
+	assert.NoError(t, err)
+
+	// The backend accepts every login, but the first method must not complete the authentication.
+	meta := metadata.NewTestAuthenticatingMetadata("foo")
+	response, _, _ := connectionHandler.OnAuthPubKey(meta, publicAuth.PublicKey{PublicKey: authorizedKey})
+	assert.Equal(t, sshserver.AuthResponsePartialSuccess, response)
+
+	meta.AuthenticatedMethods = []string{string(config.SSHAuthMethodPublicKey)}
+	response, _, _ = connectionHandler.OnAuthPassword(meta, []byte("bar"))
+	assert.Equal(t, sshserver.AuthResponseSuccess, response)
+}
+
+func startAuthServer(t *testing.T, logger log.Logger, authServerPort int, handler *authHandler) service.Lifecycle {
 	server, err := auth.NewServer(
 		config.HTTPServerConfiguration{
 			Listen: fmt.Sprintf("127.0.0.1:%d", authServerPort),
 		},
-		&authHandler{},
+		handler,
 		logger,
 	)
 	assert.NoError(t, err)
@@ -66,7 +296,13 @@ func startAuthServer(t *testing.T, logger log.Logger, authServerPort int) servic
 	return lifecycle
 }
 
-func startSSHServer(t *testing.T, logger log.Logger, authServerPort int) (config.SSHConfig, service.Lifecycle) {
+func startSSHServer(
+	t *testing.T,
+	logger log.Logger,
+	authServerPort int,
+	lockoutConfig config.AuthLockoutConfig,
+	configure func(cfg *config.AuthConfig),
+) (config.SSHConfig, service.Lifecycle) {
 	backend := &testBackend{}
 	collector := metrics.New(dummy.New())
 	webhookConfig := config.AuthWebhookClientConfig{
@@ -76,17 +312,22 @@ func startSSHServer(t *testing.T, logger log.Logger, authServerPort int) (config
 		},
 		AuthTimeout: 30 * time.Second,
 	}
-	handler, _, err := authintegration.New(
-		config.AuthConfig{
-			PasswordAuth: config.PasswordAuthConfig{
-				Method:  config.PasswordAuthMethodWebhook,
-				Webhook: webhookConfig,
-			},
-			Authz: config.AuthzConfig{
-				Method:  config.AuthzMethodWebhook,
-				Webhook: webhookConfig,
-			},
+	authConfig := config.AuthConfig{
+		PasswordAuth: config.PasswordAuthConfig{
+			Method:  config.PasswordAuthMethodWebhook,
+			Webhook: webhookConfig,
+		},
+		Authz: config.AuthzConfig{
+			Method:  config.AuthzMethodWebhook,
+			Webhook: webhookConfig,
 		},
+		Lockout: lockoutConfig,
+	}
+	if configure != nil {
+		configure(&authConfig)
+	}
+	handler, _, err := authintegration.New(
+		authConfig,
 		backend,
 		logger,
 		collector,
@@ -101,6 +342,7 @@ func startSSHServer(t *testing.T, logger log.Logger, authServerPort int) (config
 		sshServerConfig,
 		handler,
 		logger,
+		nil,
 	)
 	assert.NoError(t, err)
 
@@ -121,10 +363,20 @@ func startSSHServer(t *testing.T, logger log.Logger, authServerPort int) (config
 }
 
 func testConnection(t *testing.T, username string, authMethod ssh.AuthMethod, sshServerConfig config.SSHConfig, success bool) {
+	testConnections(t, username, []ssh.AuthMethod{authMethod}, sshServerConfig, success)
+}
+
+func testConnections(
+	t *testing.T,
+	username string,
+	authMethods []ssh.AuthMethod,
+	sshServerConfig config.SSHConfig,
+	success bool,
+) {
 	clientConfig := ssh.ClientConfig{
 		Config: ssh.Config{},
 		User:   username,
-		Auth:   []ssh.AuthMethod{authMethod},
+		Auth:   authMethods,
 		// We don't care about host key verification for this test.
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
 	}
@@ -144,6 +396,8 @@ type testBackend struct {
 	sshserver.AbstractNetworkConnectionHandler
 }
 
+func (t *testBackend) OnConnection(_ sshserver.SSHConnection) {}
+
 func (t *testBackend) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {}
 
 func (b *testBackend) OnFailedDecodeGlobalRequest(_ uint64, _ string, _ []byte, _ error) {}
@@ -232,16 +486,57 @@ func (t *testBackend) OnNetworkConnection(meta metadata.ConnectionMetadata) (
 	return t, meta, nil
 }
 
+// passthroughBackend accepts every password and public key.
+type passthroughBackend struct {
+	testBackend
+}
+
+func (p *passthroughBackend) OnNetworkConnection(meta metadata.ConnectionMetadata) (
+	sshserver.NetworkConnectionHandler,
+	metadata.ConnectionMetadata,
+	error,
+) {
+	return p, meta, nil
+}
+
+func (p *passthroughBackend) OnAuthPassword(
+	meta metadata.ConnectionAuthPendingMetadata,
+	_ []byte,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	return sshserver.AuthResponseSuccess, meta.Authenticated(meta.Username), nil
+}
+
+func (p *passthroughBackend) OnAuthPubKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	_ publicAuth.PublicKey,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	return sshserver.AuthResponseSuccess, meta.Authenticated(meta.Username), nil
+}
+
 // endregion
 
 // region AuthHandler
 type authHandler struct {
+	authorizedKey string
+
+	lock            sync.Mutex
+	passwordMethods []string
+}
+
+// getPasswordMethods returns the methods the user has passed before the last password authentication.
+func (h *authHandler) getPasswordMethods() []string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.passwordMethods
 }
 
 func (h *authHandler) OnPassword(
 	meta metadata.ConnectionAuthPendingMetadata,
 	Password []byte,
 ) (bool, metadata.ConnectionAuthenticatedMetadata, error) {
+	h.lock.Lock()
+	h.passwordMethods = meta.AuthenticatedMethods
+	h.lock.Unlock()
 	if (meta.Username == "foo" || meta.Username == "foonoauthz") && string(Password) == "bar" {
 		return true, meta.Authenticated(meta.Username), nil
 	}
@@ -254,8 +549,11 @@ func (h *authHandler) OnPassword(
 
 func (h *authHandler) OnPubKey(
 	meta metadata.ConnectionAuthPendingMetadata,
-	_ publicAuth.PublicKey,
+	pubKey publicAuth.PublicKey,
 ) (bool, metadata.ConnectionAuthenticatedMetadata, error) {
+	if h.authorizedKey != "" && meta.Username == "foo" && pubKey.PublicKey == h.authorizedKey {
+		return true, meta.Authenticated(meta.Username), nil
+	}
 	return false, meta.AuthFailed(), nil
 }
 
@@ -0,0 +1,122 @@
+package authintegration
+
+import (
+	"context"
+	"net"
+	"time"
+
+	auth2 "go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/lockout"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// lockoutNetworkConnectionHandler rejects authentication attempts of banned clients before they reach the
+// authentication backends and records the outcome of all other attempts.
+type lockoutNetworkConnectionHandler struct {
+	backend sshserver.NetworkConnectionHandler
+	ip      net.IP
+	tracker lockout.Tracker
+}
+
+// check returns an error if the user is banned.
+func (l *lockoutNetworkConnectionHandler) check(meta metadata.ConnectionAuthPendingMetadata) error {
+	ban := l.tracker.Check(meta.Username, l.ip)
+	if ban == nil {
+		return nil
+	}
+	return message.UserMessage(
+		message.EAuthLockedOut,
+		"Too many failed authentication attempts, please try again later.",
+		"Authentication rejected because the %s rule banned the user until %s.",
+		ban.Scope,
+		ban.Until.Format(time.RFC3339),
+	)
+}
+
+// record updates the failure counters with the result of an authentication attempt. Unavailable responses are not
+// counted since the user is not at fault.
+func (l *lockoutNetworkConnectionHandler) record(
+	meta metadata.ConnectionAuthPendingMetadata,
+	authResponse sshserver.AuthResponse,
+) {
+	switch authResponse {
+	case sshserver.AuthResponseSuccess:
+		l.tracker.OnSuccess(meta.Username, l.ip)
+	case sshserver.AuthResponseFailure:
+		l.tracker.OnFailure(meta.Username, l.ip)
+	}
+}
+
+func (l *lockoutNetworkConnectionHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return l.backend.OnBanner(meta, banner)
+}
+
+func (l *lockoutNetworkConnectionHandler) OnAuthPassword(
+	meta metadata.ConnectionAuthPendingMetadata,
+	password []byte,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	if err := l.check(meta); err != nil {
+		return sshserver.AuthResponseFailure, meta.AuthFailed(), err
+	}
+	authResponse, authenticatedMeta, err := l.backend.OnAuthPassword(meta, password)
+	l.record(meta, authResponse)
+	return authResponse, authenticatedMeta, err
+}
+
+// OnAuthPubKey rejects banned users, but does not count rejected keys as failures. Clients offer all keys they have
+// one after the other, which would quickly ban users with several keys in their agent.
+func (l *lockoutNetworkConnectionHandler) OnAuthPubKey(
+	meta metadata.ConnectionAuthPendingMetadata,
+	pubKey auth2.PublicKey,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	if err := l.check(meta); err != nil {
+		return sshserver.AuthResponseFailure, meta.AuthFailed(), err
+	}
+	authResponse, authenticatedMeta, err := l.backend.OnAuthPubKey(meta, pubKey)
+	if authResponse == sshserver.AuthResponseSuccess {
+		l.record(meta, authResponse)
+	}
+	return authResponse, authenticatedMeta, err
+}
+
+func (l *lockoutNetworkConnectionHandler) OnAuthKeyboardInteractive(
+	meta metadata.ConnectionAuthPendingMetadata,
+	challenge func(
+		instruction string,
+		questions sshserver.KeyboardInteractiveQuestions,
+	) (answers sshserver.KeyboardInteractiveAnswers, err error),
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	if err := l.check(meta); err != nil {
+		return sshserver.AuthResponseFailure, meta.AuthFailed(), err
+	}
+	authResponse, authenticatedMeta, err := l.backend.OnAuthKeyboardInteractive(meta, challenge)
+	l.record(meta, authResponse)
+	return authResponse, authenticatedMeta, err
+}
+
+func (l *lockoutNetworkConnectionHandler) OnAuthGSSAPI(metadata metadata.ConnectionMetadata) auth.GSSAPIServer {
+	return l.backend.OnAuthGSSAPI(metadata)
+}
+
+func (l *lockoutNetworkConnectionHandler) OnHandshakeFailed(metadata metadata.ConnectionMetadata, reason error) {
+	l.backend.OnHandshakeFailed(metadata, reason)
+}
+
+func (l *lockoutNetworkConnectionHandler) OnHandshakeSuccess(metadata metadata.ConnectionAuthenticatedMetadata) (
+	sshserver.SSHConnectionHandler,
+	metadata.ConnectionAuthenticatedMetadata,
+	error,
+) {
+	return l.backend.OnHandshakeSuccess(metadata)
+}
+
+func (l *lockoutNetworkConnectionHandler) OnDisconnect() {
+	l.backend.OnDisconnect()
+}
+
+func (l *lockoutNetworkConnectionHandler) OnShutdown(shutdownContext context.Context) {
+	l.backend.OnShutdown(shutdownContext)
+}
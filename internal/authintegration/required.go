@@ -0,0 +1,140 @@
+package authintegration
+
+import (
+	"strings"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// requiredMethods returns the sequences of methods the user must pass. If none are configured, but the TOTP second
+// factor is enabled, keyboard-interactive authentication is required after a password or public key.
+func (h *networkConnectionHandler) requiredMethods() config.AuthRequiredMethods {
+	if len(h.required) > 0 || h.totpAuthenticator == nil {
+		return h.required
+	}
+	return config.AuthRequiredMethods{
+		{config.SSHAuthMethodPassword, config.SSHAuthMethodKeyboardInteractive},
+		{config.SSHAuthMethodPublicKey, config.SSHAuthMethodKeyboardInteractive},
+		{config.SSHAuthMethodGSSAPI},
+	}
+}
+
+// checkMethod returns an error if the method is not the next step of any of the required sequences after the methods
+// the user has already passed.
+func (h *networkConnectionHandler) checkMethod(
+	meta metadata.ConnectionAuthPendingMetadata,
+	method config.SSHAuthMethod,
+) error {
+	required := h.requiredMethods()
+	if len(required) == 0 {
+		return nil
+	}
+	for _, methods := range required {
+		if continuesWith(methods, meta.AuthenticatedMethods, method) {
+			return nil
+		}
+	}
+	passed := "no other method"
+	if len(meta.AuthenticatedMethods) > 0 {
+		passed = strings.Join(meta.AuthenticatedMethods, ", ")
+	}
+	return message.UserMessage(
+		message.EAuthMethodNotAllowed,
+		"This authentication method is not available at this point.",
+		"The %s method is not allowed after %s because of the required authentication methods.",
+		method,
+		passed,
+	)
+}
+
+// methodSuccess returns a success if the method completes one of the required sequences and a partial success
+// otherwise.
+func (h *networkConnectionHandler) methodSuccess(
+	meta metadata.ConnectionAuthPendingMetadata,
+	method config.SSHAuthMethod,
+	authContext auth.AuthenticationContext,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	authenticatedMeta := authContext.Metadata()
+	if h.complete(meta, method, authenticatedMeta) {
+		return sshserver.AuthResponseSuccess, authenticatedMeta, authContext.Error()
+	}
+	return sshserver.AuthResponsePartialSuccess, authenticatedMeta, nil
+}
+
+// requireComplete turns a success returned by the backend into a partial success if the method does not complete
+// one of the required sequences, so a passthrough backend cannot end the authentication early.
+func (h *networkConnectionHandler) requireComplete(
+	meta metadata.ConnectionAuthPendingMetadata,
+	method config.SSHAuthMethod,
+	response sshserver.AuthResponse,
+	authenticatedMeta metadata.ConnectionAuthenticatedMetadata,
+	err error,
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	if response == sshserver.AuthResponseSuccess && !h.complete(meta, method, authenticatedMeta) {
+		return sshserver.AuthResponsePartialSuccess, authenticatedMeta, nil
+	}
+	return response, authenticatedMeta, err
+}
+
+func (h *networkConnectionHandler) complete(
+	meta metadata.ConnectionAuthPendingMetadata,
+	method config.SSHAuthMethod,
+	authenticatedMeta metadata.ConnectionAuthenticatedMetadata,
+) bool {
+	required := h.requiredMethods()
+	if len(required) == 0 {
+		return true
+	}
+	if len(h.required) == 0 && method != config.SSHAuthMethodKeyboardInteractive &&
+		!h.totpAuthenticator.Required(authenticatedMeta) {
+		// Users without a TOTP secret may log in with the first factor alone if TOTP is optional.
+		return true
+	}
+	for _, methods := range required {
+		if len(methods) == len(meta.AuthenticatedMethods)+1 && continuesWith(methods, meta.AuthenticatedMethods, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// continuesWith returns true if the passed methods are the beginning of the sequence and the method is the next one.
+func continuesWith(methods []config.SSHAuthMethod, passed []string, method config.SSHAuthMethod) bool {
+	if len(methods) <= len(passed) {
+		return false
+	}
+	for i, passedMethod := range passed {
+		if string(methods[i]) != passedMethod {
+			return false
+		}
+	}
+	return methods[len(passed)] == method
+}
+
+// requiredGSSAPIServer applies the required methods to GSSAPI authentication.
+type requiredGSSAPIServer struct {
+	auth.GSSAPIServer
+
+	handler *networkConnectionHandler
+}
+
+func (r *requiredGSSAPIServer) AllowLogin(
+	username string,
+	meta metadata.ConnectionAuthPendingMetadata,
+) (metadata.ConnectionAuthenticatedMetadata, error) {
+	if err := r.handler.checkMethod(meta, config.SSHAuthMethodGSSAPI); err != nil {
+		return meta.AuthFailed(), err
+	}
+	authenticatedMeta, err := r.GSSAPIServer.AllowLogin(username, meta)
+	if err != nil {
+		return authenticatedMeta, err
+	}
+	if !r.handler.complete(meta, config.SSHAuthMethodGSSAPI, authenticatedMeta) {
+		return authenticatedMeta, sshserver.ErrAuthPartialSuccess
+	}
+	return authenticatedMeta, nil
+}
@@ -17,11 +17,22 @@ import (
     "go.containerssh.io/containerssh/internal/sshproxy"
     "go.containerssh.io/containerssh/internal/sshserver"
     "go.containerssh.io/containerssh/internal/structutils"
+    "go.containerssh.io/containerssh/internal/throttle"
     "go.containerssh.io/containerssh/log"
     "go.containerssh.io/containerssh/message"
     "go.containerssh.io/containerssh/metadata"
 )
 
+// Handler is the backend handler that creates the configured backend for each connection.
+type Handler interface {
+	sshserver.Handler
+
+	// Reload replaces the configuration used for new connections. This includes the configuration server, the
+	// backend selection, the backend, the security and the throttle settings. Existing connections keep their
+	// configuration.
+	Reload(config config.AppConfig) error
+}
+
 type handler struct {
 	sshserver.AbstractHandler
 
@@ -32,17 +43,40 @@ type handler struct {
 	logger                 log.Logger
 	backendRequestsCounter metrics.Counter
 	backendErrorCounter    metrics.Counter
+	throttleMetrics        *throttle.Metrics
 	lock                   *sync.Mutex
 }
 
+func (h *handler) Reload(config config.AppConfig) error {
+	loader, err := internalConfig.NewHTTPLoader(
+		config.ConfigServer,
+		h.logger,
+		h.metricsCollector,
+	)
+	if err != nil {
+		return err
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.config = config
+	h.configLoader = loader
+	return nil
+}
+
 func (h *handler) OnNetworkConnection(
 	meta metadata.ConnectionMetadata,
 ) (sshserver.NetworkConnectionHandler, metadata.ConnectionMetadata, error) {
+	h.lock.Lock()
+	appConfig := h.config
+	configLoader := h.configLoader
+	h.lock.Unlock()
 	return &networkHandler{
 		logger: h.logger.
 			WithLabel("connectionId", meta.ConnectionID).
-			WithLabel("remoteAddr", meta.RemoteAddress.IP.String()),
+			WithLabel("remoteAddr", meta.RemoteAddress.Host()),
 		rootHandler:  h,
+		config:       appConfig,
+		configLoader: configLoader,
 		remoteAddr:   net.TCPAddr(meta.RemoteAddress),
 		connectionID: meta.ConnectionID,
 		lock:         &sync.Mutex{},
@@ -53,6 +87,8 @@ type networkHandler struct {
 	sshserver.AbstractNetworkConnectionHandler
 
 	rootHandler  *handler
+	config       config.AppConfig
+	configLoader internalConfig.Loader
 	remoteAddr   net.TCPAddr
 	connectionID string
 	backend      sshserver.NetworkConnectionHandler
@@ -126,11 +162,23 @@ func (n *networkHandler) initBackend(
 		return nil, meta, failureReason
 	}
 
+	// Inject the message of the day below the security overlay so it is only shown for permitted shells
+	backend, failureReason = newMOTD(appConfig.SSH.MOTD, backend, backendLogger)
+	if failureReason != nil {
+		return nil, meta, failureReason
+	}
+
 	// Inject security overlay
 	backend, failureReason = security.New(appConfig.Security, backend, n.logger)
 	if failureReason != nil {
 		return nil, meta, failureReason
 	}
+
+	// Inject bandwidth limits
+	backend, failureReason = throttle.New(appConfig.Throttle, backend, n.rootHandler.throttleMetrics)
+	if failureReason != nil {
+		return nil, meta, failureReason
+	}
 	n.backend = backend
 
 	return backend.OnHandshakeSuccess(meta)
@@ -187,11 +235,11 @@ func (n *networkHandler) loadConnectionSpecificConfig(
 	defer cancelFunc()
 
 	appConfig := config.AppConfig{}
-	if err := structutils.Copy(&appConfig, &n.rootHandler.config); err != nil {
+	if err := structutils.Copy(&appConfig, &n.config); err != nil {
 		return appConfig, meta, fmt.Errorf("failed to copy application configuration (%w)", err)
 	}
 
-	newMeta, err := n.rootHandler.configLoader.LoadConnection(
+	newMeta, err := n.configLoader.LoadConnection(
 		ctx,
 		meta,
 		&appConfig,
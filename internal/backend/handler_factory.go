@@ -7,6 +7,7 @@ import (
     internalConfig "go.containerssh.io/containerssh/internal/config"
     "go.containerssh.io/containerssh/internal/metrics"
     "go.containerssh.io/containerssh/internal/sshserver"
+    "go.containerssh.io/containerssh/internal/throttle"
     "go.containerssh.io/containerssh/log"
 )
 
@@ -17,7 +18,7 @@ func New(
 	logger log.Logger,
 	metricsCollector metrics.Collector,
 	defaultAuthResponse sshserver.AuthResponse,
-) (sshserver.Handler, error) {
+) (Handler, error) {
 	loader, err := internalConfig.NewHTTPLoader(
 		config.ConfigServer,
 		logger,
@@ -46,6 +47,7 @@ func New(
 		logger:                 logger,
 		backendRequestsCounter: backendRequestsCounter,
 		backendErrorCounter:    backendErrorCounter,
+		throttleMetrics:        throttle.NewMetrics(metricsCollector),
 		lock:                   &sync.Mutex{},
 	}, nil
 }
@@ -49,7 +49,7 @@ func TestSimpleContainerLaunch(t *testing.T) {
 	assert.NoError(t, err)
 
 	sshServerLogger := log.NewTestLogger(t)
-	sshServer, err := sshserver.New(cfg.SSH, b, sshServerLogger)
+	sshServer, err := sshserver.New(cfg.SSH, b, sshServerLogger, nil)
 	assert.NoError(t, err)
 
 	lifecycle := service.NewLifecycle(sshServer)
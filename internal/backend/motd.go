@@ -0,0 +1,209 @@
+package backend
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// MOTDData is the data available in the message of the day template configured in the ssh.motd option.
+type MOTDData struct {
+	// Username is the username the user provided when connecting.
+	Username string
+	// AuthenticatedUsername is the username the authentication server returned.
+	AuthenticatedUsername string
+	// RemoteAddress is the IP address the user connected from, or "unix" for Unix socket clients.
+	RemoteAddress string
+	// ConnectionID is the opaque ID of the SSH connection.
+	ConnectionID string
+	// Listener is the name of the listener the user connected through.
+	Listener string
+	// Metadata contains the non-sensitive metadata values returned by the authentication and configuration server.
+	Metadata map[string]string
+}
+
+// newMOTD wraps the backend so it writes the message of the day to the standard error when a shell starts. If
+// motdTemplate is empty the backend is returned unchanged.
+func newMOTD(
+	motdTemplate string,
+	backend sshserver.NetworkConnectionHandler,
+	logger log.Logger,
+) (sshserver.NetworkConnectionHandler, error) {
+	if motdTemplate == "" {
+		return backend, nil
+	}
+	tpl, err := template.New("motd").Parse(motdTemplate)
+	if err != nil {
+		return nil, message.Wrap(err, message.EBackendConfig, "Invalid message of the day template")
+	}
+	return &motdNetworkHandler{
+		NetworkConnectionHandler: backend,
+		template:                 tpl,
+		logger:                   logger,
+	}, nil
+}
+
+type motdNetworkHandler struct {
+	sshserver.NetworkConnectionHandler
+
+	template *template.Template
+	logger   log.Logger
+}
+
+func (m *motdNetworkHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticatedMetadata) (
+	connection sshserver.SSHConnectionHandler,
+	metadata metadata.ConnectionAuthenticatedMetadata,
+	failureReason error,
+) {
+	connection, metadata, failureReason = m.NetworkConnectionHandler.OnHandshakeSuccess(meta)
+	if failureReason != nil {
+		return connection, metadata, failureReason
+	}
+	return &motdSSHConnectionHandler{
+		SSHConnectionHandler: connection,
+		network:              m,
+		meta:                 metadata,
+	}, metadata, nil
+}
+
+// render renders the message of the day for a connection. Line endings are converted to CRLF since the output is
+// usually sent to a terminal in raw mode.
+func (m *motdNetworkHandler) render(meta metadata.ConnectionAuthenticatedMetadata) ([]byte, error) {
+	data := MOTDData{
+		Username:              meta.Username,
+		AuthenticatedUsername: meta.AuthenticatedUsername,
+		RemoteAddress:         meta.RemoteAddress.Host(),
+		ConnectionID:          meta.ConnectionID,
+		Listener:              meta.Listener,
+		Metadata:              map[string]string{},
+	}
+	for key, value := range meta.Metadata {
+		if !value.Sensitive {
+			data.Metadata[key] = value.Value
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := m.template.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	text := strings.ReplaceAll(strings.ReplaceAll(buf.String(), "\r\n", "\n"), "\n", "\r\n")
+	return []byte(text), nil
+}
+
+type motdSSHConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+
+	network *motdNetworkHandler
+	meta    metadata.ConnectionAuthenticatedMetadata
+}
+
+func (m *motdSSHConnectionHandler) OnSessionChannel(
+	channelMetadata metadata.ChannelMetadata,
+	extraData []byte,
+	session sshserver.SessionChannel,
+) (channel sshserver.SessionChannelHandler, failureReason sshserver.ChannelRejection) {
+	motdSession := &motdSession{
+		SessionChannel: session,
+		lock:           &sync.Mutex{},
+	}
+	channel, failureReason = m.SSHConnectionHandler.OnSessionChannel(channelMetadata, extraData, motdSession)
+	if failureReason != nil {
+		return channel, failureReason
+	}
+	return &motdSessionChannelHandler{
+		SessionChannelHandler: channel,
+		connection:            m,
+		session:               motdSession,
+	}, nil
+}
+
+type motdSessionChannelHandler struct {
+	sshserver.SessionChannelHandler
+
+	connection *motdSSHConnectionHandler
+	session    *motdSession
+}
+
+// OnShell writes the message of the day once the backend has accepted the shell. The output of the shell is held back
+// until then so it does not interleave with the message of the day.
+func (m *motdSessionChannelHandler) OnShell(requestID uint64) error {
+	network := m.connection.network
+	motd, err := network.render(m.connection.meta)
+	if err != nil {
+		network.logger.Warning(
+			message.Wrap(err, message.EBackendMOTDFailed, "Failed to show the message of the day"),
+		)
+		return m.SessionChannelHandler.OnShell(requestID)
+	}
+	if len(motd) == 0 {
+		return m.SessionChannelHandler.OnShell(requestID)
+	}
+
+	m.session.hold()
+	defer m.session.release()
+	if err := m.SessionChannelHandler.OnShell(requestID); err != nil {
+		return err
+	}
+	if _, err := m.session.SessionChannel.Stderr().Write(motd); err != nil {
+		network.logger.Warning(
+			message.Wrap(err, message.EBackendMOTDFailed, "Failed to show the message of the day"),
+		)
+	}
+	return nil
+}
+
+// motdSession holds back the output of the backend while the message of the day is pending.
+type motdSession struct {
+	sshserver.SessionChannel
+
+	lock *sync.Mutex
+	// pending is closed when the message of the day has been written. It is nil if no message is pending.
+	pending chan struct{}
+}
+
+func (m *motdSession) hold() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pending = make(chan struct{})
+}
+
+func (m *motdSession) release() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	close(m.pending)
+	m.pending = nil
+}
+
+func (m *motdSession) wait() {
+	m.lock.Lock()
+	pending := m.pending
+	m.lock.Unlock()
+	if pending != nil {
+		<-pending
+	}
+}
+
+func (m *motdSession) Stdout() io.Writer {
+	return &motdWriter{backend: m.SessionChannel.Stdout(), session: m}
+}
+
+func (m *motdSession) Stderr() io.Writer {
+	return &motdWriter{backend: m.SessionChannel.Stderr(), session: m}
+}
+
+type motdWriter struct {
+	backend io.Writer
+	session *motdSession
+}
+
+func (m *motdWriter) Write(p []byte) (int, error) {
+	m.session.wait()
+	return m.backend.Write(p)
+}
@@ -0,0 +1,131 @@
+package backend //nolint:testpackage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+func TestMOTDAfterShellAccepted(t *testing.T) {
+	session := &motdTestSession{lock: &sync.Mutex{}, buffer: &bytes.Buffer{}}
+	channel, backend := openMOTDTestChannel(t, session, true)
+
+	assert.NoError(t, channel.OnShell(0))
+	select {
+	case <-backend.written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the shell output was not written")
+	}
+	assert.Equal(t, "Welcome foo\r\nshell output", session.output())
+}
+
+func TestMOTDShellRejected(t *testing.T) {
+	session := &motdTestSession{lock: &sync.Mutex{}, buffer: &bytes.Buffer{}}
+	channel, _ := openMOTDTestChannel(t, session, false)
+
+	assert.Error(t, channel.OnShell(0))
+	assert.Equal(t, "", session.output())
+}
+
+func openMOTDTestChannel(
+	t *testing.T,
+	session sshserver.SessionChannel,
+	accept bool,
+) (sshserver.SessionChannelHandler, *motdTestChannel) {
+	logger := log.NewTestLogger(t)
+	network, err := newMOTD("Welcome {{ .Username }}\n", &sshserver.AbstractNetworkConnectionHandler{}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &motdTestSSHConnectionHandler{accept: accept}
+	connection := &motdSSHConnectionHandler{
+		SSHConnectionHandler: backend,
+		network:              network.(*motdNetworkHandler),
+		meta:                 metadata.NewTestAuthenticatingMetadata("foo").Authenticated("foo"),
+	}
+	channel, rejection := connection.OnSessionChannel(metadata.ChannelMetadata{}, nil, session)
+	if rejection != nil {
+		t.Fatal(rejection)
+	}
+	return channel, backend.channel
+}
+
+type motdTestSSHConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+
+	accept  bool
+	channel *motdTestChannel
+}
+
+func (m *motdTestSSHConnectionHandler) OnSessionChannel(
+	_ metadata.ChannelMetadata,
+	_ []byte,
+	session sshserver.SessionChannel,
+) (channel sshserver.SessionChannelHandler, failureReason sshserver.ChannelRejection) {
+	m.channel = &motdTestChannel{
+		session: session,
+		accept:  m.accept,
+		written: make(chan struct{}),
+	}
+	return m.channel, nil
+}
+
+type motdTestChannel struct {
+	sshserver.AbstractSessionChannelHandler
+
+	session sshserver.SessionChannel
+	accept  bool
+	written chan struct{}
+}
+
+// OnShell starts writing the output of the shell right away, like a backend streaming the output of a container.
+func (m *motdTestChannel) OnShell(_ uint64) error {
+	if !m.accept {
+		return fmt.Errorf("shell rejected")
+	}
+	go func() {
+		_, _ = m.session.Stdout().Write([]byte("shell output"))
+		close(m.written)
+	}()
+	select {
+	case <-m.written:
+	case <-time.After(100 * time.Millisecond):
+	}
+	return nil
+}
+
+// motdTestSession records stdout and stderr in the same buffer to check the order of the output.
+type motdTestSession struct {
+	sshserver.SessionChannel
+
+	lock   *sync.Mutex
+	buffer *bytes.Buffer
+}
+
+func (m *motdTestSession) Stdout() io.Writer {
+	return m
+}
+
+func (m *motdTestSession) Stderr() io.Writer {
+	return m
+}
+
+func (m *motdTestSession) Write(p []byte) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.buffer.Write(p)
+}
+
+func (m *motdTestSession) output() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.buffer.String()
+}
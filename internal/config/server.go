@@ -1,6 +1,8 @@
 package config
 
 import (
+    "net/http"
+
     "go.containerssh.io/containerssh/config"
     http2 "go.containerssh.io/containerssh/http"
     "go.containerssh.io/containerssh/log"
@@ -17,6 +19,33 @@ func NewServer(
 	if err != nil {
 		return nil, err
 	}
+	return newServer(configuration, handler, logger)
+}
+
+// NewSignedServer returns a complete HTTP server that responds to the configuration requests and rejects requests
+// without a valid HMAC-SHA256 signature from one of the configured keys.
+func NewSignedServer(
+	configuration config.HTTPServerConfiguration,
+	signature config.HTTPServerSignatureConfig,
+	h RequestHandler,
+	logger log.Logger,
+) (http2.Server, error) {
+	handler, err := NewHandler(h, logger)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := http2.NewSignatureVerifier(signature, handler, logger)
+	if err != nil {
+		return nil, err
+	}
+	return newServer(configuration, verifier, logger)
+}
+
+func newServer(
+	configuration config.HTTPServerConfiguration,
+	handler http.Handler,
+	logger log.Logger,
+) (http2.Server, error) {
 	return http2.NewServer(
 		"Config Server",
 		configuration,
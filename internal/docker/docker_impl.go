@@ -777,7 +777,7 @@ func (d *dockerV20Exec) signal(ctx context.Context, sig string) error {
 }
 
 func (d *dockerV20Exec) realSendSignal(ctx context.Context, sig string, pid int) error {
-	exec, err := d.container.lockedCreateExec(
+	return d.runAgentCommand(
 		ctx, []string{
 			d.container.config.Execution.AgentPath,
 			"signal",
@@ -785,8 +785,65 @@ func (d *dockerV20Exec) realSendSignal(ctx context.Context, sig string, pid int)
 			strconv.Itoa(pid),
 			"--signal",
 			sig,
-		}, map[string]string{}, false,
+		},
 	)
+}
+
+func (d *dockerV20Exec) sendBreak(ctx context.Context, lengthMs uint32) error {
+	if d.container.config.Execution.DisableAgent {
+		err := message.UserMessage(
+			message.EDockerCannotSendBreakNoAgent,
+			"Cannot send break to process.",
+			"Cannot send break to process because the ContainerSSH agent is disabled",
+		)
+		d.logger.Debug(err)
+		return err
+	}
+	d.lock.Lock()
+	if d.pid <= 0 {
+		d.lock.Unlock()
+		return message.UserMessage(message.EDockerFailedSignalNoPID, "Cannot send break to process", "could not send break to exec, process ID not found")
+	}
+	if d.container.shutdown {
+		d.lock.Unlock()
+		err := message.UserMessage(
+			message.EDockerFailedExecBreak,
+			"Cannot send break to process.",
+			"Not sending break to process, container is already shutting down.",
+		)
+		d.logger.Debug(err)
+		return err
+	}
+	d.container.wg.Add(1)
+	pid := d.pid
+	d.lock.Unlock()
+	err := d.runAgentCommand(
+		ctx, []string{
+			d.container.config.Execution.AgentPath,
+			"break",
+			"--pid",
+			strconv.Itoa(pid),
+			"--length",
+			strconv.FormatUint(uint64(lengthMs), 10),
+		},
+	)
+	if err != nil {
+		err = message.WrapUser(
+			err,
+			message.EDockerFailedExecBreak,
+			"Cannot send break to process.",
+			"Cannot send break to container %s pid %d",
+			d.container.containerID, pid,
+		)
+		d.logger.Debug(err)
+	}
+	return err
+}
+
+// runAgentCommand runs the agent with the specified arguments in the container and waits for it to exit. The caller
+// must increment the wait group of the container.
+func (d *dockerV20Exec) runAgentCommand(ctx context.Context, args []string) error {
+	exec, err := d.container.lockedCreateExec(ctx, args, map[string]string{}, false)
 	if err != nil {
 		return err
 	}
@@ -799,7 +856,7 @@ func (d *dockerV20Exec) realSendSignal(ctx context.Context, sig string, pid int)
 			return nil
 		}, func(exitStatus int) {
 			if exitStatus != 0 {
-				err = fmt.Errorf("signal program exited with status %d", exitStatus)
+				err = fmt.Errorf("%s program exited with status %d", args[1], exitStatus)
 			}
 			done <- struct{}{}
 		},
@@ -0,0 +1,76 @@
+package docker //nolint:testpackage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/message"
+)
+
+func TestBreak(t *testing.T) {
+	t.Run("not running", func(t *testing.T) {
+		c := newBreakTestHandler(nil, true)
+		assertErrorCode(t, c.OnBreak(0, 500), message.EDockerProgramNotRunning)
+	})
+	t.Run("no pty", func(t *testing.T) {
+		exec := &breakRecordingExec{}
+		c := newBreakTestHandler(exec, false)
+		assertErrorCode(t, c.OnBreak(0, 500), message.EDockerFailedExecBreak)
+		if len(exec.breaks) != 0 {
+			t.Fatalf("a break was sent to a session without a PTY")
+		}
+	})
+	t.Run("delivered", func(t *testing.T) {
+		exec := &breakRecordingExec{}
+		c := newBreakTestHandler(exec, true)
+		if err := c.OnBreak(0, 500); err != nil {
+			t.Fatalf("failed to send break (%v)", err)
+		}
+		if len(exec.breaks) != 1 || exec.breaks[0] != 500 {
+			t.Fatalf("the break length was not passed to the agent: %v", exec.breaks)
+		}
+	})
+}
+
+func newBreakTestHandler(exec dockerExecution, pty bool) *channelHandler {
+	cfg := config.DockerConfig{}
+	cfg.Timeouts.Signal = time.Minute
+	c := &channelHandler{
+		networkHandler: &networkHandler{
+			mutex:  &sync.Mutex{},
+			config: cfg,
+		},
+		pty: pty,
+	}
+	if exec != nil {
+		c.exec = exec
+	}
+	return c
+}
+
+func assertErrorCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var typedErr message.Message
+	if !errors.As(err, &typedErr) {
+		t.Fatalf("expected a typed error, got: %v", err)
+	}
+	if typedErr.Code() != code {
+		t.Fatalf("unexpected error code: %s", typedErr.Code())
+	}
+}
+
+// breakRecordingExec records the breaks sent to the running program.
+type breakRecordingExec struct {
+	dockerExecution
+
+	breaks []uint32
+}
+
+func (b *breakRecordingExec) sendBreak(_ context.Context, lengthMs uint32) error {
+	b.breaks = append(b.breaks, lengthMs)
+	return nil
+}
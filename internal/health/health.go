@@ -2,6 +2,8 @@ package health
 
 import (
 	"fmt"
+	goHttp "net/http"
+	"sync"
 
 	"go.containerssh.io/containerssh/config"
 	http2 "go.containerssh.io/containerssh/http"
@@ -17,10 +19,21 @@ func New(cfg config.HealthConfig, logger log.Logger) (Service, error) {
 	}
 
 	handler := &requestHandler{}
+	drain := &drainHandler{
+		lock:   &sync.Mutex{},
+		logger: logger,
+	}
+	var httpHandler goHttp.Handler = http2.NewServerHandlerNegotiate(handler, logger)
+	if cfg.Drain {
+		mux := goHttp.NewServeMux()
+		mux.Handle("/", httpHandler)
+		mux.Handle("/drain", drain)
+		httpHandler = mux
+	}
 	svc, err := http2.NewServer(
 		"Health check endpoint",
 		cfg.HTTPServerConfiguration,
-		http2.NewServerHandlerNegotiate(handler, logger),
+		httpHandler,
 		logger,
 		func(url string) {
 			logger.Info(message.NewMessage(message.MHealthServiceAvailable, "Health check endpoint available at %s", url))
@@ -33,6 +46,7 @@ func New(cfg config.HealthConfig, logger log.Logger) (Service, error) {
 	return &healthCheckService{
 		Service:        svc,
 		requestHandler: handler,
+		drainHandler:   drain,
 	}, nil
 }
 
@@ -61,6 +75,9 @@ func NewClient(cfg config.HealthConfig, logger log.Logger) (Client, error) {
 type Service interface {
 	service.Service
 	ChangeStatus(ok bool)
+	// OnDrain sets the function that is called when drain mode is requested with a POST request to /drain. The path
+	// is only available if drain is enabled in the configuration.
+	OnDrain(callback func())
 }
 
 // Client is the client to run health checks.
@@ -72,12 +89,44 @@ type Client interface {
 type healthCheckService struct {
 	service.Service
 	requestHandler *requestHandler
+	drainHandler   *drainHandler
 }
 
 func (h *healthCheckService) ChangeStatus(ok bool) {
 	h.requestHandler.ok = ok
 }
 
+func (h *healthCheckService) OnDrain(callback func()) {
+	h.drainHandler.lock.Lock()
+	defer h.drainHandler.lock.Unlock()
+	h.drainHandler.callback = callback
+}
+
+type drainHandler struct {
+	lock     *sync.Mutex
+	callback func()
+	logger   log.Logger
+}
+
+func (d *drainHandler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if request.Method != goHttp.MethodPost {
+		writer.Header().Set("Allow", goHttp.MethodPost)
+		writer.WriteHeader(goHttp.StatusMethodNotAllowed)
+		return
+	}
+	d.lock.Lock()
+	callback := d.callback
+	d.lock.Unlock()
+	if callback == nil {
+		writer.WriteHeader(goHttp.StatusServiceUnavailable)
+		return
+	}
+	d.logger.Notice(message.NewMessage(message.MHealthDrainRequested, "Drain mode requested through the health check endpoint"))
+	callback()
+	writer.WriteHeader(goHttp.StatusAccepted)
+	_, _ = writer.Write([]byte("draining"))
+}
+
 type requestHandler struct {
 	ok bool
 }
@@ -1,8 +1,21 @@
 package health_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+
     "go.containerssh.io/containerssh/config"
     "go.containerssh.io/containerssh/internal/health"
+    "go.containerssh.io/containerssh/internal/structutils"
     "go.containerssh.io/containerssh/log"
     service2 "go.containerssh.io/containerssh/service"
 
@@ -61,3 +74,176 @@ func TestOk(t *testing.T) {
 		t.Fatal("Health check did not fail, even though status is false.")
 	}
 }
+
+func TestDrainRequiresClientCertificates(t *testing.T) {
+	cfg := config.HealthConfig{
+		Enable: true,
+		HTTPServerConfiguration: config.HTTPServerConfiguration{
+			Listen: "127.0.0.1:23075",
+		},
+		Client: config.HTTPClientConfiguration{
+			URL:     "http://127.0.0.1:23075",
+			Timeout: 5 * time.Second,
+		},
+		Drain: true,
+	}
+	if _, err := health.New(cfg, log.NewTestLogger(t)); err == nil {
+		t.Fatal("Drain was enabled without client certificate authentication.")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	caKey, caCert, caPEM := createTestCA(t)
+	serverCert, serverKey := createTestCert(t, caKey, caCert, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := createTestCert(t, caKey, caCert, x509.ExtKeyUsageClientAuth)
+	cfg := config.HealthConfig{
+		Enable: true,
+		HTTPServerConfiguration: config.HTTPServerConfiguration{
+			Listen:       "127.0.0.1:23075",
+			Cert:         serverCert,
+			Key:          serverKey,
+			ClientCACert: caPEM,
+		},
+		Client: config.HTTPClientConfiguration{
+			URL:            "https://127.0.0.1:23075",
+			AllowRedirects: false,
+			Timeout:        5 * time.Second,
+			CACert:         caPEM,
+			ClientCert:     clientCert,
+			ClientKey:      clientKey,
+		},
+		Drain: true,
+	}
+	structutils.Defaults(&cfg.HTTPServerConfiguration)
+	structutils.Defaults(&cfg.Client)
+	srv, err := health.New(cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	drained := make(chan struct{}, 1)
+	srv.OnDrain(func() {
+		drained <- struct{}{}
+	})
+	l := service2.NewLifecycle(srv)
+	running := make(chan struct{})
+	l.OnRunning(func(s service2.Service, l service2.Lifecycle) {
+		running <- struct{}{}
+	})
+	go func() {
+		_ = l.Run()
+	}()
+	<-running
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(caCert)
+	anonymousClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS13}},
+	}
+	if response, err := anonymousClient.Post("https://127.0.0.1:23075/drain", "text/plain", strings.NewReader("")); err == nil {
+		_ = response.Body.Close()
+		t.Fatalf("POST request to /drain without a client certificate succeeded with status %d.", response.StatusCode)
+	}
+
+	keyPair, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      rootCAs,
+				Certificates: []tls.Certificate{keyPair},
+				MinVersion:   tls.VersionTLS13,
+			},
+		},
+	}
+
+	response, err := httpClient.Get("https://127.0.0.1:23075/drain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("Unexpected status code for GET request to /drain: %d", response.StatusCode)
+	}
+
+	response, err = httpClient.Post("https://127.0.0.1:23075/drain", "text/plain", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("Unexpected status code for POST request to /drain: %d", response.StatusCode)
+	}
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drain callback was not called.")
+	}
+
+	client, err := health.NewClient(cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.ChangeStatus(true)
+	if !client.Run() {
+		t.Fatal("Health check failed, even though status is true.")
+	}
+}
+
+func createTestCA(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Health check test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certData, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certData}))
+}
+
+func createTestCert(
+	t *testing.T,
+	caKey *ecdsa.PrivateKey,
+	caCert *x509.Certificate,
+	usage x509.ExtKeyUsage,
+) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	certData, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyData, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certData})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyData}))
+}
@@ -308,6 +308,32 @@ func (c *channelHandler) OnSignal(_ uint64, signal string) error {
 	return c.exec.signal(ctx, signal)
 }
 
+func (c *channelHandler) OnBreak(_ uint64, lengthMs uint32) error {
+	c.networkHandler.mutex.Lock()
+	defer c.networkHandler.mutex.Unlock()
+	if c.exec == nil {
+		return message.UserMessage(
+			message.EKubernetesProgramNotRunning,
+			"Cannot send break, program is not running.",
+			"Cannot send break, program is not running.",
+		)
+	}
+	if !c.pty {
+		return message.UserMessage(
+			message.EKubernetesFailedExecBreak,
+			"Cannot send break, no terminal is allocated.",
+			"Cannot send break because the session has no PTY.",
+		)
+	}
+	ctx, cancelFunc := context.WithTimeout(
+		context.Background(),
+		c.networkHandler.config.Timeouts.Signal,
+	)
+	defer cancelFunc()
+
+	return c.exec.sendBreak(ctx, lengthMs)
+}
+
 func (c *channelHandler) OnWindow(_ uint64, columns uint32, rows uint32, _ uint32, _ uint32) error {
 	c.networkHandler.mutex.Lock()
 	defer c.networkHandler.mutex.Unlock()
@@ -358,6 +384,32 @@ func (c *channelHandler) OnX11Request(
 	return nil
 }
 
+func (c *channelHandler) OnAuthAgentRequest(
+	_ uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	if c.exec != nil {
+		return message.UserMessage(
+			message.EKubernetesProgramAlreadyRunning,
+			"program already running",
+			"SSH agent forwarding must be requested before the program is started",
+		)
+	}
+
+	socketPath, err := c.connectionHandler.agentForward.NewAuthAgentForwarding(
+		c.connectionHandler.setupAgent,
+		c.connectionHandler.runCommand,
+		c.networkHandler.logger,
+		reverseHandler,
+	)
+	if err != nil {
+		return err
+	}
+	c.env["SSH_AUTH_SOCK"] = socketPath
+
+	return nil
+}
+
 func (c *channelHandler) OnClose() {
 	if c.exec != nil {
 		c.exec.kill()
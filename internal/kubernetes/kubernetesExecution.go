@@ -12,6 +12,9 @@ type kubernetesExecution interface {
 	// signal sends the given signal to the currently running process. Returns an error if the process is not running,
 	// the signal is not known or permitted, or the process ID is not known.
 	signal(ctx context.Context, sig string) error
+	// sendBreak sends a break of the given length to the terminal of the currently running process using the agent,
+	// similar to tcsendbreak. Returns an error if the process is not running or the agent is disabled.
+	sendBreak(ctx context.Context, lengthMs uint32) error
 	// run runs the process in question.
 	run(
 		stdin io.Reader,
@@ -136,7 +136,7 @@ func (k *kubernetesExecutionImpl) logAndReturnNonPositivePidOnSignal(sig string)
 }
 
 func (k *kubernetesExecutionImpl) processSignalExec(ctx context.Context, sig string, pid int) error {
-	podExec, err := k.pod.createExecLocked(
+	return k.runAgentCommand(
 		ctx, []string{
 			k.pod.config.Pod.AgentPath,
 			"signal",
@@ -144,8 +144,70 @@ func (k *kubernetesExecutionImpl) processSignalExec(ctx context.Context, sig str
 			strconv.Itoa(pid),
 			"--signal",
 			sig,
-		}, map[string]string{}, false,
+		},
 	)
+}
+
+func (k *kubernetesExecutionImpl) sendBreak(ctx context.Context, lengthMs uint32) error {
+	if k.pod.config.Pod.DisableAgent {
+		err := message.UserMessage(
+			message.EKubernetesCannotSendBreakNoAgent,
+			"Cannot send break to process.",
+			"Cannot send break to process because the ContainerSSH agent is disabled",
+		)
+		k.logger.Debug(err)
+		return err
+	}
+	k.lock.Lock()
+	if k.pid <= 0 {
+		k.lock.Unlock()
+		return message.UserMessage(message.EKubernetesFailedSignalNoPID, "Cannot send break to process", "could not send break to exec, process ID not found")
+	}
+	if k.exited {
+		k.lock.Unlock()
+		return message.UserMessage(message.EKubernetesFailedSignalExited, "Cannot send break to process", "could not send break to exec, process already exited")
+	}
+	if k.pod.shutdown {
+		k.lock.Unlock()
+		err := message.UserMessage(
+			message.EKubernetesFailedExecBreak,
+			"Cannot send break to process.",
+			"Not sending break to process, pod is already shutting down.",
+		)
+		k.logger.Debug(err)
+		return err
+	}
+	k.pod.wg.Add(1)
+	pid := k.pid
+	k.lock.Unlock()
+
+	err := k.runAgentCommand(
+		ctx, []string{
+			k.pod.config.Pod.AgentPath,
+			"break",
+			"--pid",
+			strconv.Itoa(pid),
+			"--length",
+			strconv.FormatUint(uint64(lengthMs), 10),
+		},
+	)
+	if err != nil {
+		err = message.WrapUser(
+			err,
+			message.EKubernetesFailedExecBreak,
+			"Cannot send break to process.",
+			"Cannot send break to pod %s pid %d",
+			k.pod.pod.Name, pid,
+		)
+		k.logger.Debug(err)
+	}
+	return err
+}
+
+// runAgentCommand runs the agent with the specified arguments in the pod and waits for it to exit. The caller must
+// increment the wait group of the pod.
+func (k *kubernetesExecutionImpl) runAgentCommand(ctx context.Context, args []string) error {
+	podExec, err := k.pod.createExecLocked(ctx, args, map[string]string{}, false)
 	if err != nil {
 		k.pod.wg.Done()
 		return err
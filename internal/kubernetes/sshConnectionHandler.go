@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -21,6 +22,8 @@ type sshConnectionHandler struct {
 	agentForward   agentforward.AgentForward
 }
 
+func (s *sshConnectionHandler) OnConnection(_ sshserver.SSHConnection) {}
+
 func (s *sshConnectionHandler) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {
 }
 
@@ -129,6 +132,37 @@ func (s *sshConnectionHandler) OnRequestCancelStreamLocal(
 	return s.agentForward.CancelStreamLocalForwarding(path)
 }
 
+// runCommand runs a program in the pod as the session user and waits for it to exit.
+func (c *sshConnectionHandler) runCommand(program []string) error {
+	ctx, cancelFunc := context.WithTimeout(
+		context.Background(),
+		c.networkHandler.config.Timeouts.CommandStart,
+	)
+	defer cancelFunc()
+
+	exec, err := c.networkHandler.pod.createExec(ctx, program, map[string]string{}, false)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	done := make(chan int)
+	exec.run(
+		bytes.NewReader(nil),
+		io.Discard,
+		&stderr,
+		func() error {
+			return nil
+		},
+		func(exitStatus int) {
+			done <- exitStatus
+		},
+	)
+	if exitStatus := <-done; exitStatus != 0 {
+		return fmt.Errorf("%s exited with status %d (%s)", program[0], exitStatus, stderr.String())
+	}
+	return nil
+}
+
 func (c *sshConnectionHandler) setupAgent() (io.Reader, io.Writer, error) {
 	ctx, cancelFunc := context.WithTimeout(
 		context.Background(),
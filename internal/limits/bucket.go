@@ -0,0 +1,69 @@
+package limits
+
+import (
+	"time"
+)
+
+// tokenBucket is a single token bucket. It is not thread safe, the lock of the handler must be held while using it.
+type tokenBucket struct {
+	tokens   float64
+	lastFill time.Time
+}
+
+// tokenBuckets holds one token bucket per source network.
+type tokenBuckets struct {
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newTokenBuckets(rate float64, burst int) *tokenBuckets {
+	return &tokenBuckets{
+		rate:      rate,
+		burst:     float64(burst),
+		buckets:   map[string]*tokenBucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// take removes one token from the bucket of the specified source and returns false if the bucket is empty. If no rate
+// is configured it always returns true.
+func (b *tokenBuckets) take(source string, now time.Time) bool {
+	if b.rate <= 0 {
+		return true
+	}
+	b.sweep(now)
+	bucket, ok := b.buckets[source]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens:   b.burst,
+			lastFill: now,
+		}
+		b.buckets[source] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.lastFill).Seconds() * b.rate
+	if bucket.tokens > b.burst {
+		bucket.tokens = b.burst
+	}
+	bucket.lastFill = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweep removes the buckets that have been refilled completely since their last use, so sources that stopped
+// connecting do not use memory.
+func (b *tokenBuckets) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < time.Minute {
+		return
+	}
+	b.lastSweep = now
+	for source, bucket := range b.buckets {
+		if bucket.tokens+now.Sub(bucket.lastFill).Seconds()*b.rate >= b.burst {
+			delete(b.buckets, source)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type handler struct {
+	config        config.SSHLimitsConfig
+	containerMode ContainerMode
+	backend       sshserver.Handler
+	logger        log.Logger
+
+	lock             *sync.Mutex
+	connections      int
+	unauthenticated  int
+	sourceCounts     map[string]int
+	buckets          *tokenBuckets
+	maxStartupsStart int
+	maxStartupsRate  int
+	maxStartupsFull  int
+	rejectedMetric   metrics.GeoCounter
+	userConnections  map[string]int
+	userContainers   map[string]int
+	groupConnections map[string]int
+	groupContainers  map[string]int
+}
+
+func (h *handler) OnReady() error {
+	return h.backend.OnReady()
+}
+
+func (h *handler) OnShutdown(shutdownContext context.Context) {
+	h.backend.OnShutdown(shutdownContext)
+}
+
+func (h *handler) OnNetworkConnection(
+	meta metadata.ConnectionMetadata,
+) (sshserver.NetworkConnectionHandler, metadata.ConnectionMetadata, error) {
+	source := h.sourceNetwork(meta.RemoteAddress.IP)
+	if err := h.acquire(source); err != nil {
+		reason := message.EUnknownError
+		var typedErr message.Message
+		if errors.As(err, &typedErr) {
+			reason = typedErr.Code()
+		}
+		h.rejectedMetric.Increment(meta.RemoteAddress.IP, metrics.Label("reason", reason))
+		return nil, meta, err
+	}
+	networkBackend, meta, err := h.backend.OnNetworkConnection(meta)
+	if err != nil {
+		h.release(source, true)
+		return networkBackend, meta, err
+	}
+	return &networkHandler{
+		backend:         networkBackend,
+		handler:         h,
+		source:          source,
+		lock:            &sync.Mutex{},
+		unauthenticated: true,
+	}, meta, nil
+}
+
+// acquire checks all limits for a new connection from the specified source network and, if the connection is
+// allowed, counts it as an open and unauthenticated connection.
+func (h *handler) acquire(source string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.config.MaxConnections > 0 && h.connections >= h.config.MaxConnections {
+		return message.UserMessage(
+			message.ESSHConnectionLimitReached,
+			"Too many connections, please try again later.",
+			"Rejected connection because the maximum of %d concurrent connections has been reached.",
+			h.config.MaxConnections,
+		)
+	}
+	if source != "" && h.config.MaxConnectionsPerSource > 0 &&
+		h.sourceCounts[source] >= h.config.MaxConnectionsPerSource {
+		return message.UserMessage(
+			message.ESSHSourceConnectionLimitReached,
+			"Too many connections, please try again later.",
+			"Rejected connection because %s already has %d concurrent connections.",
+			source,
+			h.config.MaxConnectionsPerSource,
+		)
+	}
+	if h.dropStartup() {
+		return message.UserMessage(
+			message.ESSHMaxStartupsReached,
+			"Too many connections, please try again later.",
+			"Rejected connection because there are %d unauthenticated connections (maxStartups is %s).",
+			h.unauthenticated,
+			h.config.MaxStartups,
+		)
+	}
+	if source != "" && !h.buckets.take(source, time.Now()) {
+		return message.UserMessage(
+			message.ESSHConnectionRateLimited,
+			"Too many connections, please try again later.",
+			"Rejected connection because %s exceeded the rate of %g new connections per second.",
+			source,
+			h.config.ConnectionRate,
+		)
+	}
+
+	h.connections++
+	h.unauthenticated++
+	if source != "" {
+		h.sourceCounts[source]++
+	}
+	return nil
+}
+
+// dropStartup decides if a new connection should be dropped based on the number of unauthenticated connections. This
+// follows the random early drop logic of the OpenSSH MaxStartups option.
+func (h *handler) dropStartup() bool {
+	if h.maxStartupsFull == 0 || h.unauthenticated < h.maxStartupsStart {
+		return false
+	}
+	if h.unauthenticated >= h.maxStartupsFull {
+		return true
+	}
+	probability := h.maxStartupsRate +
+		(100-h.maxStartupsRate)*(h.unauthenticated-h.maxStartupsStart)/(h.maxStartupsFull-h.maxStartupsStart)
+	// The early drop does not need a cryptographically secure random number.
+	return rand.Intn(100) < probability //nolint:gosec
+}
+
+// authenticated marks a connection as no longer unauthenticated.
+func (h *handler) authenticated() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.unauthenticated--
+}
+
+// release removes a closed connection from the counters.
+func (h *handler) release(source string, unauthenticated bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.connections--
+	if unauthenticated {
+		h.unauthenticated--
+	}
+	if source == "" {
+		return
+	}
+	h.sourceCounts[source]--
+	if h.sourceCounts[source] <= 0 {
+		delete(h.sourceCounts, source)
+	}
+}
+
+// sourceNetwork returns the network of the specified IP address based on the configured prefix lengths. Unix socket
+// clients have no IP address and get an empty source, which is exempt from the per-source limits.
+func (h *handler) sourceNetwork(ip net.IP) string {
+	if len(ip) == 0 {
+		return ""
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{
+			IP:   ip4.Mask(net.CIDRMask(h.config.IPv4PrefixLength, 32)),
+			Mask: net.CIDRMask(h.config.IPv4PrefixLength, 32),
+		}).String()
+	}
+	return (&net.IPNet{
+		IP:   ip.Mask(net.CIDRMask(h.config.IPv6PrefixLength, 128)),
+		Mask: net.CIDRMask(h.config.IPv6PrefixLength, 128),
+	}).String()
+}
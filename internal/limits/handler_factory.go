@@ -0,0 +1,58 @@
+package limits
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+)
+
+// New creates a handler that rejects incoming connections exceeding the configured connection limits before they
+// reach the backend. The containerMode determines how the per-user and per-group container limits are counted.
+func New(
+	cfg config.SSHLimitsConfig,
+	containerMode ContainerMode,
+	backend sshserver.Handler,
+	logger log.Logger,
+	metricsCollector metrics.Collector,
+) (sshserver.Handler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid connection limits configuration (%w)", err)
+	}
+	maxStartupsStart, maxStartupsRate, maxStartupsFull, err := cfg.MaxStartups.Parse()
+	if err != nil {
+		return nil, err
+	}
+	burst := cfg.ConnectionBurst
+	if burst == 0 {
+		burst = int(math.Ceil(cfg.ConnectionRate))
+	}
+
+	rejectedMetric := metricsCollector.MustCreateCounterGeo(
+		MetricNameRejectedConnections,
+		"connections_total",
+		MetricHelpRejectedConnections,
+	)
+
+	return &handler{
+		config:           cfg,
+		containerMode:    containerMode,
+		backend:          backend,
+		logger:           logger,
+		lock:             &sync.Mutex{},
+		sourceCounts:     map[string]int{},
+		buckets:          newTokenBuckets(cfg.ConnectionRate, burst),
+		maxStartupsStart: maxStartupsStart,
+		maxStartupsRate:  maxStartupsRate,
+		maxStartupsFull:  maxStartupsFull,
+		rejectedMetric:   rejectedMetric,
+		userConnections:  map[string]int{},
+		userContainers:   map[string]int{},
+		groupConnections: map[string]int{},
+		groupContainers:  map[string]int{},
+	}, nil
+}
@@ -0,0 +1,126 @@
+package limits
+
+import (
+	"context"
+	"sync"
+
+	auth2 "go.containerssh.io/containerssh/auth"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+type networkHandler struct {
+	backend sshserver.NetworkConnectionHandler
+	handler *handler
+	source  string
+
+	lock            *sync.Mutex
+	unauthenticated bool
+	disconnected    bool
+	userAcquired    bool
+	user            string
+	group           string
+}
+
+func (n *networkHandler) OnAuthPassword(meta metadata.ConnectionAuthPendingMetadata, password []byte) (
+	response sshserver.AuthResponse,
+	metadata metadata.ConnectionAuthenticatedMetadata,
+	reason error,
+) {
+	return n.backend.OnAuthPassword(meta, password)
+}
+
+func (n *networkHandler) OnAuthPubKey(meta metadata.ConnectionAuthPendingMetadata, pubKey auth2.PublicKey) (
+	response sshserver.AuthResponse,
+	metadata metadata.ConnectionAuthenticatedMetadata,
+	reason error,
+) {
+	return n.backend.OnAuthPubKey(meta, pubKey)
+}
+
+func (n *networkHandler) OnAuthKeyboardInteractive(
+	meta metadata.ConnectionAuthPendingMetadata,
+	challenge func(
+		instruction string,
+		questions sshserver.KeyboardInteractiveQuestions,
+	) (answers sshserver.KeyboardInteractiveAnswers, err error),
+) (sshserver.AuthResponse, metadata.ConnectionAuthenticatedMetadata, error) {
+	return n.backend.OnAuthKeyboardInteractive(meta, challenge)
+}
+
+func (n *networkHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return n.backend.OnBanner(meta, banner)
+}
+
+func (n *networkHandler) OnAuthGSSAPI(meta metadata.ConnectionMetadata) auth.GSSAPIServer {
+	return n.backend.OnAuthGSSAPI(meta)
+}
+
+func (n *networkHandler) OnHandshakeFailed(meta metadata.ConnectionMetadata, reason error) {
+	n.markAuthenticated()
+	n.backend.OnHandshakeFailed(meta, reason)
+}
+
+func (n *networkHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticatedMetadata) (
+	connection sshserver.SSHConnectionHandler,
+	metadata metadata.ConnectionAuthenticatedMetadata,
+	failureReason error,
+) {
+	n.markAuthenticated()
+	user, group := n.handler.userKeys(meta)
+	if err := n.handler.acquireUser(user, group); err != nil {
+		n.handler.rejectUser(meta, err)
+		return &rejectedSSHConnectionHandler{rejection: err}, meta, nil
+	}
+	connection, meta, failureReason = n.backend.OnHandshakeSuccess(meta)
+	if failureReason != nil {
+		n.handler.releaseUser(user, group)
+		return connection, meta, failureReason
+	}
+	n.lock.Lock()
+	n.userAcquired = true
+	n.user = user
+	n.group = group
+	n.lock.Unlock()
+	if n.handler.containerMode == ContainerModeSession {
+		connection = &containerLimitSSHConnectionHandler{
+			SSHConnectionHandler: connection,
+			handler:              n.handler,
+			meta:                 meta,
+			user:                 user,
+			group:                group,
+		}
+	}
+	return connection, meta, nil
+}
+
+func (n *networkHandler) OnDisconnect() {
+	n.lock.Lock()
+	if !n.disconnected {
+		n.disconnected = true
+		n.handler.release(n.source, n.unauthenticated)
+		n.unauthenticated = false
+		if n.userAcquired {
+			n.handler.releaseUser(n.user, n.group)
+			n.userAcquired = false
+		}
+	}
+	n.lock.Unlock()
+	n.backend.OnDisconnect()
+}
+
+func (n *networkHandler) OnShutdown(shutdownContext context.Context) {
+	n.backend.OnShutdown(shutdownContext)
+}
+
+// markAuthenticated removes the connection from the unauthenticated connections once the handshake has finished,
+// regardless of its outcome.
+func (n *networkHandler) markAuthenticated() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.unauthenticated && !n.disconnected {
+		n.unauthenticated = false
+		n.handler.authenticated()
+	}
+}
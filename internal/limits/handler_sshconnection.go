@@ -0,0 +1,109 @@
+package limits
+
+import (
+	"sync"
+
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// rejectedSSHConnectionHandler is returned for authenticated connections over the per-user or per-group connection
+// limit. Instead of closing the connection without an explanation it rejects every channel and request with the
+// reason, which clients such as OpenSSH display to the user. The backend is never asked to handle the connection.
+type rejectedSSHConnectionHandler struct {
+	sshserver.AbstractSSHConnectionHandler
+
+	rejection sshserver.ChannelRejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnSessionChannel(
+	_ metadata.ChannelMetadata,
+	_ []byte,
+	_ sshserver.SessionChannel,
+) (channel sshserver.SessionChannelHandler, failureReason sshserver.ChannelRejection) {
+	return nil, r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnTCPForwardChannel(
+	_ uint64,
+	_ string,
+	_ uint32,
+	_ string,
+	_ uint32,
+) (channel sshserver.ForwardChannel, failureReason sshserver.ChannelRejection) {
+	return nil, r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnRequestTCPReverseForward(
+	_ string,
+	_ uint32,
+	_ sshserver.ReverseForward,
+) error {
+	return r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnRequestCancelTCPReverseForward(_ string, _ uint32) error {
+	return r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnDirectStreamLocal(
+	_ uint64,
+	_ string,
+) (channel sshserver.ForwardChannel, failureReason sshserver.ChannelRejection) {
+	return nil, r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnRequestStreamLocal(_ string, _ sshserver.ReverseForward) error {
+	return r.rejection
+}
+
+func (r *rejectedSSHConnectionHandler) OnRequestCancelStreamLocal(_ string) error {
+	return r.rejection
+}
+
+// containerLimitSSHConnectionHandler enforces the per-user and per-group container limits on session channels when
+// the backend launches one container per session.
+type containerLimitSSHConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+
+	handler *handler
+	meta    metadata.ConnectionAuthenticatedMetadata
+	user    string
+	group   string
+}
+
+func (c *containerLimitSSHConnectionHandler) OnSessionChannel(
+	channelMetadata metadata.ChannelMetadata,
+	extraData []byte,
+	session sshserver.SessionChannel,
+) (channel sshserver.SessionChannelHandler, failureReason sshserver.ChannelRejection) {
+	if err := c.handler.acquireContainer(c.user, c.group); err != nil {
+		c.handler.rejectUser(c.meta, err)
+		return nil, err
+	}
+	channel, failureReason = c.SSHConnectionHandler.OnSessionChannel(channelMetadata, extraData, session)
+	if failureReason != nil {
+		c.handler.releaseContainer(c.user, c.group)
+		return nil, failureReason
+	}
+	return &containerLimitSessionChannelHandler{
+		SessionChannelHandler: channel,
+		release: func() {
+			c.handler.releaseContainer(c.user, c.group)
+		},
+		once: &sync.Once{},
+	}, nil
+}
+
+// containerLimitSessionChannelHandler releases the container slot of a session once the session channel is closed.
+type containerLimitSessionChannelHandler struct {
+	sshserver.SessionChannelHandler
+
+	release func()
+	once    *sync.Once
+}
+
+func (c *containerLimitSessionChannelHandler) OnClose() {
+	c.SessionChannelHandler.OnClose()
+	c.once.Do(c.release)
+}
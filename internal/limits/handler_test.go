@@ -0,0 +1,239 @@
+package limits_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/limits"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/internal/structutils"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+type backendHandler struct {
+}
+
+func (b *backendHandler) OnReady() error {
+	return nil
+}
+
+func (b *backendHandler) OnShutdown(_ context.Context) {
+}
+
+func (b *backendHandler) OnNetworkConnection(meta metadata.ConnectionMetadata) (
+	sshserver.NetworkConnectionHandler,
+	metadata.ConnectionMetadata,
+	error,
+) {
+	return &networkConnectionHandler{}, meta, nil
+}
+
+type networkConnectionHandler struct {
+	sshserver.AbstractNetworkConnectionHandler
+}
+
+func (n *networkConnectionHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticatedMetadata) (
+	sshserver.SSHConnectionHandler,
+	metadata.ConnectionAuthenticatedMetadata,
+	error,
+) {
+	return &sshConnectionHandler{}, meta, nil
+}
+
+type sshConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+}
+
+func (s *sshConnectionHandler) OnSessionChannel(_ metadata.ChannelMetadata, _ []byte, _ sshserver.SessionChannel) (
+	sshserver.SessionChannelHandler,
+	sshserver.ChannelRejection,
+) {
+	return &sshserver.AbstractSessionChannelHandler{}, nil
+}
+
+func newLimitsHandler(t *testing.T, modify func(cfg *config.SSHLimitsConfig)) sshserver.Handler {
+	return newLimitsHandlerWithMode(t, limits.ContainerModeConnection, modify)
+}
+
+func newLimitsHandlerWithMode(
+	t *testing.T,
+	containerMode limits.ContainerMode,
+	modify func(cfg *config.SSHLimitsConfig),
+) sshserver.Handler {
+	cfg := config.SSHLimitsConfig{}
+	structutils.Defaults(&cfg)
+	modify(&cfg)
+	handler, err := limits.New(cfg, containerMode, &backendHandler{}, log.NewTestLogger(t), metrics.New(dummy.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handler
+}
+
+func connect(handler sshserver.Handler, ip string) (sshserver.NetworkConnectionHandler, error) {
+	meta := metadata.NewTestMetadata()
+	meta.RemoteAddress = metadata.RemoteAddress(net.TCPAddr{IP: net.ParseIP(ip), Port: 2222})
+	networkHandler, _, err := handler.OnNetworkConnection(meta)
+	return networkHandler, err
+}
+
+func authenticate(
+	t *testing.T,
+	handler sshserver.Handler,
+	username string,
+	team string,
+) (sshserver.NetworkConnectionHandler, sshserver.SSHConnectionHandler) {
+	networkHandler, err := connect(handler, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := metadata.NewTestAuthenticatingMetadata(username).Authenticated(username)
+	if team != "" {
+		meta.GetMetadata()["team"] = metadata.Value{Value: team}
+	}
+	connection, _, err := networkHandler.OnHandshakeSuccess(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return networkHandler, connection
+}
+
+func openSession(connection sshserver.SSHConnectionHandler) (
+	sshserver.SessionChannelHandler,
+	sshserver.ChannelRejection,
+) {
+	return connection.OnSessionChannel(metadata.ChannelMetadata{}, nil, nil)
+}
+
+func TestMaxConnectionsPerUser(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.MaxConnectionsPerUser = 1
+	})
+	first, connection := authenticate(t, handler, "foo", "")
+	_, err := openSession(connection)
+	assert.Nil(t, err)
+
+	_, connection = authenticate(t, handler, "foo", "")
+	_, err = openSession(connection)
+	assert.NotNil(t, err)
+	assert.Equal(t, message.ESSHUserConnectionLimitReached, err.Code())
+	assert.Equal(t, ssh.ResourceShortage, err.Reason())
+
+	_, connection = authenticate(t, handler, "bar", "")
+	_, err = openSession(connection)
+	assert.Nil(t, err)
+
+	first.OnDisconnect()
+	_, connection = authenticate(t, handler, "foo", "")
+	_, err = openSession(connection)
+	assert.Nil(t, err)
+}
+
+func TestMaxContainersPerGroupSessionMode(t *testing.T) {
+	handler := newLimitsHandlerWithMode(t, limits.ContainerModeSession, func(cfg *config.SSHLimitsConfig) {
+		cfg.GroupMetadataKey = "team"
+		cfg.MaxContainersPerGroup = 2
+	})
+	_, fooConnection := authenticate(t, handler, "foo", "devs")
+	_, barConnection := authenticate(t, handler, "bar", "devs")
+	_, bazConnection := authenticate(t, handler, "baz", "ops")
+
+	first, err := openSession(fooConnection)
+	assert.Nil(t, err)
+	_, err = openSession(barConnection)
+	assert.Nil(t, err)
+	_, err = openSession(fooConnection)
+	assert.NotNil(t, err)
+	assert.Equal(t, message.ESSHGroupContainerLimitReached, err.Code())
+	_, err = openSession(bazConnection)
+	assert.Nil(t, err)
+
+	first.OnClose()
+	_, err = openSession(barConnection)
+	assert.Nil(t, err)
+}
+
+func TestMaxConnections(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.MaxConnections = 2
+	})
+	first, err := connect(handler, "127.0.0.1")
+	assert.NoError(t, err)
+	_, err = connect(handler, "127.0.0.2")
+	assert.NoError(t, err)
+	_, err = connect(handler, "127.0.0.3")
+	assert.Error(t, err)
+
+	first.OnDisconnect()
+	_, err = connect(handler, "127.0.0.3")
+	assert.NoError(t, err)
+}
+
+func TestMaxConnectionsPerSource(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.MaxConnectionsPerSource = 1
+		cfg.IPv4PrefixLength = 24
+	})
+	_, err := connect(handler, "192.168.0.1")
+	assert.NoError(t, err)
+	_, err = connect(handler, "192.168.0.2")
+	assert.Error(t, err)
+	_, err = connect(handler, "192.168.1.1")
+	assert.NoError(t, err)
+}
+
+func TestUnixSocketSkipsSourceLimits(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.MaxConnections = 3
+		cfg.MaxConnectionsPerSource = 1
+		cfg.ConnectionRate = 0.001
+		cfg.ConnectionBurst = 1
+	})
+	// Unix socket clients have no source address, so only the global limits apply to them.
+	for i := 0; i < 3; i++ {
+		_, err := connect(handler, "unix")
+		assert.NoError(t, err)
+	}
+	_, err := connect(handler, "unix")
+	assert.Error(t, err)
+}
+
+func TestConnectionRate(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.ConnectionRate = 0.001
+		cfg.ConnectionBurst = 2
+	})
+	for i := 0; i < 2; i++ {
+		networkHandler, err := connect(handler, "127.0.0.1")
+		assert.NoError(t, err)
+		networkHandler.OnDisconnect()
+	}
+	_, err := connect(handler, "127.0.0.1")
+	assert.Error(t, err)
+	_, err = connect(handler, "127.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestMaxStartups(t *testing.T) {
+	handler := newLimitsHandler(t, func(cfg *config.SSHLimitsConfig) {
+		cfg.MaxStartups = "2"
+	})
+	first, err := connect(handler, "127.0.0.1")
+	assert.NoError(t, err)
+	_, err = connect(handler, "127.0.0.1")
+	assert.NoError(t, err)
+	_, err = connect(handler, "127.0.0.1")
+	assert.Error(t, err)
+
+	first.OnHandshakeFailed(metadata.NewTestMetadata(), nil)
+	_, err = connect(handler, "127.0.0.1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,177 @@
+package limits
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+// ContainerMode describes when the backend launches a container and therefore what counts towards the per-user and
+// per-group container limits.
+type ContainerMode int
+
+const (
+	// ContainerModeNone indicates that the backend does not launch containers, and container limits are not enforced.
+	ContainerModeNone ContainerMode = iota
+	// ContainerModeConnection indicates that the backend launches one container per connection.
+	ContainerModeConnection
+	// ContainerModeSession indicates that the backend launches one container per session channel.
+	ContainerModeSession
+)
+
+// ContainerModeOf returns the container mode of the backend configured in cfg. Backend overrides returned by the
+// configuration server for individual connections are not taken into account.
+func ContainerModeOf(cfg config.AppConfig) ContainerMode {
+	switch cfg.Backend {
+	case config.BackendDocker:
+		if cfg.Docker.Execution.Mode == config.DockerExecutionModeSession {
+			return ContainerModeSession
+		}
+		return ContainerModeConnection
+	case config.BackendKubernetes:
+		if cfg.Kubernetes.Pod.Mode == config.KubernetesExecutionModeSession {
+			return ContainerModeSession
+		}
+		return ContainerModeConnection
+	default:
+		return ContainerModeNone
+	}
+}
+
+// userKeys returns the username and the group an authenticated connection is counted for. The group is empty if
+// group limits are not configured or the authentication server did not return the group metadata key.
+func (h *handler) userKeys(meta metadata.ConnectionAuthenticatedMetadata) (user string, group string) {
+	user = meta.AuthenticatedUsername
+	if user == "" {
+		user = meta.Username
+	}
+	if h.config.GroupMetadataKey != "" {
+		if value, ok := meta.Metadata[h.config.GroupMetadataKey]; ok {
+			group = value.Value
+		}
+	}
+	return user, group
+}
+
+// acquireUser checks the per-user and per-group connection limits for an authenticated connection and, if the
+// connection is allowed, counts it. In connection container mode the connection also counts as a container.
+func (h *handler) acquireUser(user string, group string) sshserver.ChannelRejection {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.checkLimit(
+		h.userConnections, user, h.config.MaxConnectionsPerUser,
+		message.ESSHUserConnectionLimitReached,
+		"Too many concurrent connections for your user, please close an existing connection and try again.",
+		"Rejected connection because user %s already has %d concurrent connections.",
+	); err != nil {
+		return err
+	}
+	if err := h.checkLimit(
+		h.groupConnections, group, h.config.MaxConnectionsPerGroup,
+		message.ESSHGroupConnectionLimitReached,
+		"Too many concurrent connections for your group, please close an existing connection and try again.",
+		"Rejected connection because group %s already has %d concurrent connections.",
+	); err != nil {
+		return err
+	}
+	if h.containerMode == ContainerModeConnection {
+		if err := h.checkContainerLimits(user, group); err != nil {
+			return err
+		}
+		increment(h.userContainers, user)
+		increment(h.groupContainers, group)
+	}
+	increment(h.userConnections, user)
+	increment(h.groupConnections, group)
+	return nil
+}
+
+// releaseUser removes a closed authenticated connection from the per-user and per-group counters.
+func (h *handler) releaseUser(user string, group string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.containerMode == ContainerModeConnection {
+		decrement(h.userContainers, user)
+		decrement(h.groupContainers, group)
+	}
+	decrement(h.userConnections, user)
+	decrement(h.groupConnections, group)
+}
+
+// acquireContainer checks the per-user and per-group container limits for a new session in session container mode
+// and, if the session is allowed, counts it.
+func (h *handler) acquireContainer(user string, group string) sshserver.ChannelRejection {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if err := h.checkContainerLimits(user, group); err != nil {
+		return err
+	}
+	increment(h.userContainers, user)
+	increment(h.groupContainers, group)
+	return nil
+}
+
+// releaseContainer removes a closed session from the per-user and per-group container counters.
+func (h *handler) releaseContainer(user string, group string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	decrement(h.userContainers, user)
+	decrement(h.groupContainers, group)
+}
+
+func (h *handler) checkContainerLimits(user string, group string) sshserver.ChannelRejection {
+	if err := h.checkLimit(
+		h.userContainers, user, h.config.MaxContainersPerUser,
+		message.ESSHUserContainerLimitReached,
+		"Too many concurrent containers for your user, please close an existing session and try again.",
+		"Rejected container because user %s already has %d concurrent containers.",
+	); err != nil {
+		return err
+	}
+	return h.checkLimit(
+		h.groupContainers, group, h.config.MaxContainersPerGroup,
+		message.ESSHGroupContainerLimitReached,
+		"Too many concurrent containers for your group, please close an existing session and try again.",
+		"Rejected container because group %s already has %d concurrent containers.",
+	)
+}
+
+func (h *handler) checkLimit(
+	counts map[string]int,
+	key string,
+	limit int,
+	code string,
+	userMessage string,
+	explanation string,
+) sshserver.ChannelRejection {
+	if key == "" || limit <= 0 || counts[key] < limit {
+		return nil
+	}
+	return sshserver.NewChannelRejection(ssh.ResourceShortage, code, userMessage, explanation, key, limit)
+}
+
+// rejectUser logs and counts a connection or session rejected due to a per-user or per-group limit.
+func (h *handler) rejectUser(meta metadata.ConnectionAuthenticatedMetadata, err sshserver.ChannelRejection) {
+	h.logger.Notice(err)
+	h.rejectedMetric.Increment(meta.RemoteAddress.IP, metrics.Label("reason", err.Code()))
+}
+
+func increment(counts map[string]int, key string) {
+	if key != "" {
+		counts[key]++
+	}
+}
+
+func decrement(counts map[string]int, key string) {
+	if key == "" {
+		return
+	}
+	counts[key]--
+	if counts[key] <= 0 {
+		delete(counts, key)
+	}
+}
@@ -0,0 +1,9 @@
+package limits
+
+// MetricNameRejectedConnections is the number of connections rejected due to connection limits. Sessions rejected due
+// to the per-user or per-group container limits are also counted. The reason label contains the message code of the
+// limit that was hit.
+const MetricNameRejectedConnections = "containerssh_ssh_rejected_connections_total"
+
+// MetricHelpRejectedConnections is the help text for the number of connections rejected due to connection limits.
+const MetricHelpRejectedConnections = "Connections rejected due to connection limits since start"
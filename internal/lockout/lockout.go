@@ -0,0 +1,49 @@
+package lockout
+
+import (
+	"net"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+)
+
+// Scope is the kind of key a ban applies to.
+type Scope string
+
+const (
+	// ScopeUsername bans a username from all source addresses.
+	ScopeUsername Scope = "username"
+	// ScopeSourceIP bans a source address for all usernames.
+	ScopeSourceIP Scope = "sourceIP"
+	// ScopeUsernameSourceIP bans a username when logging in from a specific source address.
+	ScopeUsernameSourceIP Scope = "usernameSourceIP"
+)
+
+// Ban describes a username, source address or their combination that is currently banned.
+type Ban struct {
+	// Scope is the rule that issued the ban.
+	Scope Scope `json:"scope"`
+	// Username is the banned username. Empty for source address bans.
+	Username string `json:"username,omitempty"`
+	// SourceIP is the banned source address. Empty for username bans.
+	SourceIP string `json:"sourceIP,omitempty"`
+	// Until is the time the ban expires.
+	Until time.Time `json:"until"`
+}
+
+// Tracker records failed authentication attempts and bans the usernames and source addresses that exceed the
+// configured thresholds. Clients connected through a Unix socket have a nil IP address and are only tracked by
+// username.
+type Tracker interface {
+	// Check returns the ban that applies to the username logging in from the specified address, or nil if the
+	// attempt is allowed. Rejected attempts are counted in the metrics.
+	Check(username string, ip net.IP) *Ban
+	// OnFailure records a failed authentication attempt.
+	OnFailure(username string, ip net.IP)
+	// OnSuccess clears the failed attempts of the username.
+	OnSuccess(username string, ip net.IP)
+	// Bans returns all bans that are currently in effect.
+	Bans() []Ban
+	// Reload replaces the lockout rules. Recorded failures and existing bans are kept unless their rule was disabled.
+	Reload(cfg config.AuthLockoutConfig) error
+}
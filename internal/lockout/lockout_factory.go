@@ -0,0 +1,35 @@
+package lockout
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+)
+
+// New creates a tracker with the configured lockout rules.
+func New(cfg config.AuthLockoutConfig, logger log.Logger, metricsCollector metrics.Collector) (Tracker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid lockout configuration (%w)", err)
+	}
+	return &tracker{
+		lock:      &sync.Mutex{},
+		cfg:       cfg,
+		logger:    logger,
+		entries:   map[Scope]map[string]*entry{},
+		lastSweep: time.Now(),
+		rejectedMetric: metricsCollector.MustCreateCounterGeo(
+			MetricNameRejected,
+			"attempts_total",
+			MetricHelpRejected,
+		),
+		bansMetric: metricsCollector.MustCreateCounter(
+			MetricNameBans,
+			"bans_total",
+			MetricHelpBans,
+		),
+	}, nil
+}
@@ -0,0 +1,236 @@
+package lockout
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// sweepInterval is the minimum time between removing expired entries.
+const sweepInterval = time.Minute
+
+// entry holds the recent failures and the ban of a single key. It is not thread safe, the lock of the tracker must be
+// held while using it.
+type entry struct {
+	username    string
+	sourceIP    string
+	failures    []time.Time
+	bannedUntil time.Time
+}
+
+// expire removes the failures that are older than the window.
+func (e *entry) expire(window time.Duration, now time.Time) {
+	i := 0
+	for i < len(e.failures) && now.Sub(e.failures[i]) >= window {
+		i++
+	}
+	e.failures = e.failures[i:]
+}
+
+type tracker struct {
+	lock           *sync.Mutex
+	cfg            config.AuthLockoutConfig
+	logger         log.Logger
+	entries        map[Scope]map[string]*entry
+	lastSweep      time.Time
+	rejectedMetric metrics.GeoCounter
+	bansMetric     metrics.Counter
+}
+
+// key identifies the entry of a username and source address within a scope.
+type key struct {
+	scope    Scope
+	rule     config.AuthLockoutRule
+	id       string
+	username string
+	sourceIP string
+}
+
+// keys returns the keys of the enabled rules that apply to a username and source address.
+func (t *tracker) keys(username string, ip net.IP) []key {
+	sourceIP := ip.String()
+	candidates := []key{
+		{ScopeUsername, t.cfg.Username, username, username, ""},
+		{ScopeSourceIP, t.cfg.SourceIP, sourceIP, "", sourceIP},
+		{ScopeUsernameSourceIP, t.cfg.UsernameSourceIP, username + "@" + sourceIP, username, sourceIP},
+	}
+	result := make([]key, 0, len(candidates))
+	for _, candidate := range candidates {
+		// Unix socket clients have no source address, banning them by address would ban all of them together.
+		if len(ip) == 0 && candidate.sourceIP != "" {
+			continue
+		}
+		if candidate.rule.MaxFailures > 0 {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func (t *tracker) Check(username string, ip net.IP) *Ban {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	for _, k := range t.keys(username, ip) {
+		e, ok := t.entries[k.scope][k.id]
+		if !ok || !e.bannedUntil.After(now) {
+			continue
+		}
+		t.rejectedMetric.Increment(ip, metrics.Label("scope", string(k.scope)))
+		return &Ban{
+			Scope:    k.scope,
+			Username: e.username,
+			SourceIP: e.sourceIP,
+			Until:    e.bannedUntil,
+		}
+	}
+	return nil
+}
+
+func (t *tracker) OnFailure(username string, ip net.IP) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	t.sweep(now)
+	for _, k := range t.keys(username, ip) {
+		scopeEntries, ok := t.entries[k.scope]
+		if !ok {
+			scopeEntries = map[string]*entry{}
+			t.entries[k.scope] = scopeEntries
+		}
+		e, ok := scopeEntries[k.id]
+		if !ok {
+			e = &entry{
+				username: k.username,
+				sourceIP: k.sourceIP,
+			}
+			scopeEntries[k.id] = e
+		}
+		if e.bannedUntil.After(now) {
+			continue
+		}
+		e.expire(k.rule.Window, now)
+		e.failures = append(e.failures, now)
+		if len(e.failures) < k.rule.MaxFailures {
+			continue
+		}
+		e.failures = nil
+		e.bannedUntil = now.Add(k.rule.BanDuration)
+		t.bansMetric.Increment(metrics.Label("scope", string(k.scope)))
+		t.logger.Notice(
+			message.NewMessage(
+				message.MAuthLockoutBanned,
+				"Banned %s after %d failed authentication attempts within %s until %s",
+				describe(k),
+				k.rule.MaxFailures,
+				k.rule.Window,
+				e.bannedUntil.Format(time.RFC3339),
+			),
+		)
+	}
+}
+
+// OnSuccess clears the failures of the username and of the username from this source address. The failures of the
+// source address are kept, otherwise an attacker with a single valid account could reset the counter by logging in
+// between guesses.
+func (t *tracker) OnSuccess(username string, ip net.IP) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	for _, k := range t.keys(username, ip) {
+		if k.scope == ScopeSourceIP {
+			continue
+		}
+		if e, ok := t.entries[k.scope][k.id]; ok && !e.bannedUntil.After(now) {
+			delete(t.entries[k.scope], k.id)
+		}
+	}
+}
+
+func (t *tracker) Bans() []Ban {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	now := time.Now()
+	result := []Ban{}
+	for scope, scopeEntries := range t.entries {
+		for _, e := range scopeEntries {
+			if !e.bannedUntil.After(now) {
+				continue
+			}
+			result = append(result, Ban{
+				Scope:    scope,
+				Username: e.username,
+				SourceIP: e.sourceIP,
+				Until:    e.bannedUntil,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Scope != result[j].Scope {
+			return result[i].Scope < result[j].Scope
+		}
+		if result[i].Username != result[j].Username {
+			return result[i].Username < result[j].Username
+		}
+		return result[i].SourceIP < result[j].SourceIP
+	})
+	return result
+}
+
+func (t *tracker) Reload(cfg config.AuthLockoutConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid lockout configuration (%w)", err)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.cfg = cfg
+	for scope, rule := range map[Scope]config.AuthLockoutRule{
+		ScopeUsername:         cfg.Username,
+		ScopeSourceIP:         cfg.SourceIP,
+		ScopeUsernameSourceIP: cfg.UsernameSourceIP,
+	} {
+		if rule.MaxFailures == 0 {
+			delete(t.entries, scope)
+		}
+	}
+	return nil
+}
+
+// sweep removes the entries that have neither recent failures nor an active ban, so clients that stopped trying do
+// not use memory.
+func (t *tracker) sweep(now time.Time) {
+	if now.Sub(t.lastSweep) < sweepInterval {
+		return
+	}
+	t.lastSweep = now
+	for _, k := range []key{
+		{scope: ScopeUsername, rule: t.cfg.Username},
+		{scope: ScopeSourceIP, rule: t.cfg.SourceIP},
+		{scope: ScopeUsernameSourceIP, rule: t.cfg.UsernameSourceIP},
+	} {
+		for id, e := range t.entries[k.scope] {
+			e.expire(k.rule.Window, now)
+			if len(e.failures) == 0 && !e.bannedUntil.After(now) {
+				delete(t.entries[k.scope], id)
+			}
+		}
+	}
+}
+
+func describe(k key) string {
+	switch k.scope {
+	case ScopeUsername:
+		return fmt.Sprintf("username %s", k.username)
+	case ScopeSourceIP:
+		return fmt.Sprintf("source address %s", k.sourceIP)
+	default:
+		return fmt.Sprintf("username %s from source address %s", k.username, k.sourceIP)
+	}
+}
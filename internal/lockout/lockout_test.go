@@ -0,0 +1,202 @@
+package lockout_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	goHttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/lockout"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/structutils"
+	"go.containerssh.io/containerssh/internal/test"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/service"
+)
+
+func newTracker(t *testing.T, modify func(cfg *config.AuthLockoutConfig)) (lockout.Tracker, metrics.Collector) {
+	cfg := config.AuthLockoutConfig{}
+	structutils.Defaults(&cfg)
+	modify(&cfg)
+	collector := metrics.New(dummy.New())
+	tracker, err := lockout.New(cfg, log.NewTestLogger(t), collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker, collector
+}
+
+func TestUsernameLockout(t *testing.T) {
+	tracker, collector := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.Username.MaxFailures = 2
+	})
+	ip1 := net.ParseIP("127.0.0.1")
+	ip2 := net.ParseIP("127.0.0.2")
+
+	tracker.OnFailure("foo", ip1)
+	assert.Nil(t, tracker.Check("foo", ip1))
+	tracker.OnFailure("foo", ip2)
+
+	ban := tracker.Check("foo", ip1)
+	assert.NotNil(t, ban)
+	assert.Equal(t, lockout.ScopeUsername, ban.Scope)
+	assert.Equal(t, "foo", ban.Username)
+	assert.NotNil(t, tracker.Check("foo", ip2), "the username ban should apply to all source addresses")
+	assert.Nil(t, tracker.Check("bar", ip1), "other usernames should not be banned")
+
+	assert.Equal(t, float64(1), collector.GetMetric(lockout.MetricNameBans)[0].Value)
+	assert.Equal(t, float64(2), collector.GetMetric(lockout.MetricNameRejected)[0].Value)
+}
+
+func TestSourceIPLockout(t *testing.T) {
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.SourceIP.MaxFailures = 2
+	})
+	ip1 := net.ParseIP("127.0.0.1")
+	ip2 := net.ParseIP("127.0.0.2")
+
+	tracker.OnFailure("foo", ip1)
+	// A successful login must not reset the counter of the source address.
+	tracker.OnSuccess("bar", ip1)
+	tracker.OnFailure("baz", ip1)
+
+	ban := tracker.Check("bar", ip1)
+	assert.NotNil(t, ban)
+	assert.Equal(t, lockout.ScopeSourceIP, ban.Scope)
+	assert.Equal(t, "127.0.0.1", ban.SourceIP)
+	assert.Nil(t, tracker.Check("foo", ip2))
+}
+
+func TestUsernameSourceIPLockout(t *testing.T) {
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.UsernameSourceIP.MaxFailures = 2
+	})
+	ip1 := net.ParseIP("127.0.0.1")
+	ip2 := net.ParseIP("127.0.0.2")
+
+	tracker.OnFailure("foo", ip1)
+	tracker.OnSuccess("foo", ip1)
+	tracker.OnFailure("foo", ip1)
+	assert.Nil(t, tracker.Check("foo", ip1), "a successful login should reset the counter")
+	tracker.OnFailure("foo", ip1)
+
+	assert.NotNil(t, tracker.Check("foo", ip1))
+	assert.Nil(t, tracker.Check("foo", ip2))
+	assert.Nil(t, tracker.Check("bar", ip1))
+}
+
+func TestUnixSocketLockout(t *testing.T) {
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.Username.MaxFailures = 2
+		cfg.SourceIP.MaxFailures = 1
+		cfg.UsernameSourceIP.MaxFailures = 1
+	})
+
+	// Unix socket clients have no source address, one user's failures must not lock out the others.
+	tracker.OnFailure("foo", nil)
+	assert.Nil(t, tracker.Check("foo", nil))
+	assert.Nil(t, tracker.Check("bar", nil))
+	tracker.OnFailure("foo", nil)
+
+	ban := tracker.Check("foo", nil)
+	assert.NotNil(t, ban)
+	assert.Equal(t, lockout.ScopeUsername, ban.Scope)
+	assert.Nil(t, tracker.Check("bar", nil))
+}
+
+func TestLockoutExpiry(t *testing.T) {
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.Username.MaxFailures = 2
+		cfg.Username.Window = 100 * time.Millisecond
+		cfg.Username.BanDuration = 200 * time.Millisecond
+	})
+	ip := net.ParseIP("127.0.0.1")
+
+	tracker.OnFailure("foo", ip)
+	time.Sleep(150 * time.Millisecond)
+	tracker.OnFailure("foo", ip)
+	assert.Nil(t, tracker.Check("foo", ip), "failures outside the window should not be counted")
+
+	tracker.OnFailure("foo", ip)
+	assert.NotNil(t, tracker.Check("foo", ip))
+	assert.Len(t, tracker.Bans(), 1)
+
+	time.Sleep(250 * time.Millisecond)
+	assert.Nil(t, tracker.Check("foo", ip), "the ban should expire")
+	assert.Len(t, tracker.Bans(), 0)
+}
+
+func TestLockoutReload(t *testing.T) {
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.Username.MaxFailures = 1
+	})
+	ip := net.ParseIP("127.0.0.1")
+	tracker.OnFailure("foo", ip)
+	assert.NotNil(t, tracker.Check("foo", ip))
+
+	cfg := config.AuthLockoutConfig{}
+	structutils.Defaults(&cfg)
+	cfg.SourceIP.MaxFailures = 1
+	assert.NoError(t, tracker.Reload(cfg))
+	assert.Nil(t, tracker.Check("foo", ip), "bans of disabled rules should be removed")
+
+	cfg.SourceIP.MaxFailures = -1
+	assert.Error(t, tracker.Reload(cfg))
+}
+
+func TestBanListServer(t *testing.T) {
+	logger := log.NewTestLogger(t)
+	tracker, _ := newTracker(t, func(cfg *config.AuthLockoutConfig) {
+		cfg.Username.MaxFailures = 1
+		cfg.SourceIP.MaxFailures = 1
+	})
+	tracker.OnFailure("foo", net.ParseIP("127.0.0.1"))
+
+	port := test.GetNextPort(t, "lockout server")
+	serverConfig := config.AuthLockoutServerConfig{}
+	structutils.Defaults(&serverConfig)
+	serverConfig.Enable = true
+	serverConfig.Listen = fmt.Sprintf("127.0.0.1:%d", port)
+	srv, err := lockout.NewServer(serverConfig, tracker, logger)
+	if !assert.NoError(t, err) {
+		return
+	}
+	lifecycle := service.NewLifecycle(srv)
+	ready := make(chan struct{})
+	lifecycle.OnRunning(
+		func(s service.Service, l service.Lifecycle) {
+			close(ready)
+		},
+	)
+	go func() {
+		_ = lifecycle.Run()
+	}()
+	<-ready
+	defer lifecycle.Stop(context.Background())
+
+	response, err := goHttp.Get(fmt.Sprintf("http://127.0.0.1:%d/", port))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+	assert.Equal(t, 200, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	var bans []lockout.Ban
+	assert.NoError(t, json.Unmarshal(body, &bans))
+	if assert.Len(t, bans, 2) {
+		assert.Equal(t, lockout.ScopeSourceIP, bans[0].Scope)
+		assert.Equal(t, "127.0.0.1", bans[0].SourceIP)
+		assert.Equal(t, lockout.ScopeUsername, bans[1].Scope)
+		assert.Equal(t, "foo", bans[1].Username)
+	}
+}
@@ -0,0 +1,14 @@
+package lockout
+
+// MetricNameRejected is the number of authentication attempts rejected because of a ban. The scope label contains
+// the rule that issued the ban.
+const MetricNameRejected = "containerssh_auth_lockout_rejected_total"
+
+// MetricHelpRejected is the help text for the number of authentication attempts rejected because of a ban.
+const MetricHelpRejected = "Authentication attempts rejected due to a lockout since start"
+
+// MetricNameBans is the number of bans issued. The scope label contains the rule that issued the ban.
+const MetricNameBans = "containerssh_auth_lockout_bans_total"
+
+// MetricHelpBans is the help text for the number of bans issued.
+const MetricHelpBans = "Lockout bans issued since start"
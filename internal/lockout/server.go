@@ -0,0 +1,36 @@
+package lockout
+
+import (
+	"go.containerssh.io/containerssh/config"
+	http2 "go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/service"
+)
+
+// NewServer creates an HTTP service that returns the current bans of the tracker as a JSON list.
+func NewServer(cfg config.AuthLockoutServerConfig, tracker Tracker, logger log.Logger) (service.Service, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return http2.NewServer(
+		"Lockout ban list",
+		cfg.HTTPServerConfiguration,
+		http2.NewServerHandler(&banListHandler{tracker: tracker}, logger),
+		logger,
+		func(url string) {
+			logger.Info(
+				message.NewMessage(message.MAuthLockoutServerAvailable, "Lockout ban list available at %s", url),
+			)
+		},
+	)
+}
+
+type banListHandler struct {
+	tracker Tracker
+}
+
+func (b *banListHandler) OnRequest(_ http2.ServerRequest, response http2.ServerResponse) error {
+	response.SetBody(b.tracker.Bans())
+	return nil
+}
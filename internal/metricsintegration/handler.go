@@ -88,6 +88,10 @@ func (m *metricsNetworkHandler) OnAuthKeyboardInteractive(
 	return m.backend.OnAuthKeyboardInteractive(meta, challenge)
 }
 
+func (m *metricsNetworkHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return m.backend.OnBanner(meta, banner)
+}
+
 func (m *metricsNetworkHandler) OnAuthGSSAPI(meta metadata.ConnectionMetadata) auth.GSSAPIServer {
 	return m.backend.OnAuthGSSAPI(meta)
 }
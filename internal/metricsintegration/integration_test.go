@@ -199,6 +199,10 @@ func (d *dummyBackendHandler) OnAuthKeyboardInteractive(
 	}
 }
 
+func (d *dummyBackendHandler) OnBanner(_ metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return banner
+}
+
 func (d *dummyBackendHandler) OnAuthGSSAPI(_ metadata.ConnectionMetadata) auth.GSSAPIServer {
 	return nil
 }
@@ -215,6 +219,8 @@ func (d *dummyBackendHandler) OnHandshakeSuccess(authenticatedMetadata metadata.
 	return d, authenticatedMetadata, nil
 }
 
+func (d *dummyBackendHandler) OnConnection(_ sshserver.SSHConnection) {}
+
 func (d *dummyBackendHandler) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {
 
 }
@@ -362,6 +368,10 @@ func (d *dummySession) OnSignal(_ uint64, _ string) error {
 	return fmt.Errorf("signal not supported")
 }
 
+func (d *dummySession) OnBreak(_ uint64, _ uint32) error {
+	return fmt.Errorf("break requests are not supported")
+}
+
 func (d *dummySession) OnWindow(
 	_ uint64,
 	_ uint32,
@@ -382,3 +392,10 @@ func (s *dummySession) OnX11Request(
 ) error {
 	return fmt.Errorf("Unimplemented")
 }
+
+func (s *dummySession) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return fmt.Errorf("Unimplemented")
+}
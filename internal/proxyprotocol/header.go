@@ -0,0 +1,128 @@
+package proxyprotocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// v2Signature is the fixed signature every PROXY protocol v2 header starts with.
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// v1MaxLength is the maximum length of a PROXY protocol v1 header including the trailing CRLF.
+const v1MaxLength = 107
+
+// readHeader reads a PROXY protocol v1 or v2 header from the reader. It returns the source address from the header,
+// or nil if the header does not carry an address (v1 UNKNOWN or v2 LOCAL), in which case the address of the peer
+// should be used.
+func readHeader(reader *bufio.Reader) (net.Addr, error) {
+	start, err := reader.Peek(6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol header (%w)", err)
+	}
+	if string(start) == "PROXY " {
+		return readV1Header(reader)
+	}
+	if bytes.Equal(start, v2Signature[:6]) {
+		return readV2Header(reader)
+	}
+	return nil, fmt.Errorf("connection does not start with a PROXY protocol header")
+}
+
+func readV1Header(reader *bufio.Reader) (net.Addr, error) {
+	line := make([]byte, 0, v1MaxLength)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PROXY protocol v1 header (%w)", err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+		if len(line) >= v1MaxLength {
+			return nil, fmt.Errorf("PROXY protocol v1 header too long")
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, fmt.Errorf("PROXY protocol v1 header does not end with CRLF")
+	}
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid PROXY protocol v1 header")
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, fmt.Errorf("invalid source address in PROXY protocol v1 header: %s", fields[2])
+	}
+	switch fields[1] {
+	case "TCP4":
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("non-IPv4 source address in TCP4 PROXY protocol v1 header: %s", fields[2])
+		}
+	case "TCP6":
+	default:
+		return nil, fmt.Errorf("unsupported protocol in PROXY protocol v1 header: %s", fields[1])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source port in PROXY protocol v1 header: %s", fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readV2Header(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol v2 header (%w)", err)
+	}
+	if !bytes.Equal(header[:12], v2Signature) {
+		return nil, fmt.Errorf("invalid PROXY protocol v2 signature")
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version: %d", header[12]>>4)
+	}
+	command := header[12] & 0x0F
+	family := header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("failed to read PROXY protocol v2 addresses (%w)", err)
+	}
+	switch command {
+	case 0x0:
+		// LOCAL, e.g. a health check from the load balancer itself.
+		return nil, nil
+	case 0x1:
+	default:
+		return nil, fmt.Errorf("unsupported PROXY protocol v2 command: %d", command)
+	}
+	switch family {
+	case 0x11:
+		if len(payload) < 12 {
+			return nil, fmt.Errorf("PROXY protocol v2 IPv4 address block too short")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x21:
+		if len(payload) < 36 {
+			return nil, fmt.Errorf("PROXY protocol v2 IPv6 address block too short")
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	default:
+		// UNSPEC, UDP and Unix socket addresses carry no usable TCP source address.
+		return nil, nil
+	}
+}
@@ -0,0 +1,128 @@
+// Package proxyprotocol implements a listener that reads the PROXY protocol header sent by load balancers such as
+// HAProxy or the AWS Network Load Balancer, and exposes the original client address as the remote address of the
+// connection.
+package proxyprotocol
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+)
+
+// NewListener wraps the listener so connections from trusted proxies have their remote address replaced by the
+// address in the PROXY protocol header. If the PROXY protocol is disabled the listener is returned unchanged.
+func NewListener(listener net.Listener, cfg config.ProxyProtocolConfig) (net.Listener, error) {
+	if !cfg.Enable {
+		return listener, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	trustedProxies, err := cfg.ParseTrustedProxies()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyListener{
+		Listener:       listener,
+		trustedProxies: trustedProxies,
+		headerTimeout:  cfg.HeaderTimeout,
+	}, nil
+}
+
+type proxyListener struct {
+	net.Listener
+	trustedProxies []*net.IPNet
+	headerTimeout  time.Duration
+}
+
+// Accept accepts a connection. The PROXY protocol header is not read here so a slow client cannot block the accept
+// loop. Instead, it is read on the first call to RemoteAddr, Read or Handshake.
+func (p *proxyListener) Accept() (net.Conn, error) {
+	conn, err := p.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &Conn{
+		Conn:          conn,
+		reader:        bufio.NewReader(conn),
+		headerTimeout: p.headerTimeout,
+		remoteAddr:    conn.RemoteAddr(),
+	}, nil
+}
+
+func (p *proxyListener) isTrusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, trustedProxy := range p.trustedProxies {
+		if trustedProxy.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn is a connection from a trusted proxy that starts with a PROXY protocol header.
+type Conn struct {
+	net.Conn
+	reader        *bufio.Reader
+	headerTimeout time.Duration
+
+	once       sync.Once
+	err        error
+	remoteAddr net.Addr
+}
+
+// Handshake reads the PROXY protocol header if it has not been read yet and returns an error if the header is
+// missing or invalid.
+func (c *Conn) Handshake() error {
+	c.once.Do(func() {
+		if c.headerTimeout > 0 {
+			if err := c.Conn.SetReadDeadline(time.Now().Add(c.headerTimeout)); err != nil {
+				c.err = err
+				return
+			}
+		}
+		addr, err := readHeader(c.reader)
+		if err != nil {
+			c.err = err
+			return
+		}
+		if c.headerTimeout > 0 {
+			if err := c.Conn.SetReadDeadline(time.Time{}); err != nil {
+				c.err = err
+				return
+			}
+		}
+		if addr != nil {
+			c.remoteAddr = addr
+		}
+	})
+	return c.err
+}
+
+// RemoteAddr returns the client address from the PROXY protocol header. If the header could not be read or carries
+// no address, the address of the proxy is returned.
+func (c *Conn) RemoteAddr() net.Addr {
+	_ = c.Handshake()
+	return c.remoteAddr
+}
+
+// ProxyAddr returns the address of the proxy the connection came from.
+func (c *Conn) ProxyAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(b)
+}
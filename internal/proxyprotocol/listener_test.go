@@ -0,0 +1,99 @@
+package proxyprotocol_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/proxyprotocol"
+)
+
+func v2Header(command byte, family byte, addresses []byte) []byte {
+	header := []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A, 0x20 | command, family, 0, 0}
+	binary.BigEndian.PutUint16(header[14:16], uint16(len(addresses)))
+	return append(header, addresses...)
+}
+
+// acceptWithHeader sends the header and payload over a connection to a PROXY protocol listener and returns the
+// remote address seen by the listener and the data read after the header.
+func acceptWithHeader(t *testing.T, trustedProxies []string, header []byte) (net.Addr, string, error) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := proxyprotocol.NewListener(tcpListener, config.ProxyProtocolConfig{
+		Enable:         true,
+		TrustedProxies: trustedProxies,
+		HeaderTimeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	go func() {
+		client, err := net.Dial("tcp", tcpListener.Addr().String())
+		if err != nil {
+			return
+		}
+		_, _ = client.Write(append(header, []byte("SSH-2.0-Test")...))
+		_ = client.Close()
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	remoteAddr := conn.RemoteAddr()
+	data, err := io.ReadAll(conn)
+	return remoteAddr, string(data), err
+}
+
+func TestProxyProtocolV1(t *testing.T) {
+	addr, data, err := acceptWithHeader(
+		t,
+		[]string{"127.0.0.1"},
+		[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 2222\r\n"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:56324", addr.String())
+	assert.Equal(t, "SSH-2.0-Test", data)
+}
+
+func TestProxyProtocolV2(t *testing.T) {
+	addresses := []byte{192, 0, 2, 1, 198, 51, 100, 1, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(addresses[8:10], 56324)
+	binary.BigEndian.PutUint16(addresses[10:12], 2222)
+	addr, data, err := acceptWithHeader(t, []string{"127.0.0.0/8"}, v2Header(0x1, 0x11, addresses))
+	assert.NoError(t, err)
+	assert.Equal(t, "192.0.2.1:56324", addr.String())
+	assert.Equal(t, "SSH-2.0-Test", data)
+}
+
+func TestProxyProtocolV2Local(t *testing.T) {
+	addr, data, err := acceptWithHeader(t, []string{"127.0.0.1"}, v2Header(0x0, 0x00, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", addr.(*net.TCPAddr).IP.String())
+	assert.Equal(t, "SSH-2.0-Test", data)
+}
+
+func TestProxyProtocolMissingHeader(t *testing.T) {
+	_, _, err := acceptWithHeader(t, []string{"127.0.0.1"}, nil)
+	assert.Error(t, err)
+}
+
+func TestProxyProtocolUntrustedProxy(t *testing.T) {
+	addr, data, err := acceptWithHeader(
+		t,
+		[]string{"192.0.2.0/24"},
+		[]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 2222\r\n"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", addr.(*net.TCPAddr).IP.String())
+	assert.Equal(t, "PROXY TCP4 192.0.2.1 198.51.100.1 56324 2222\r\nSSH-2.0-Test", data)
+}
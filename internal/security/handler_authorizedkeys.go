@@ -0,0 +1,44 @@
+package security
+
+import (
+	config2 "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// applyAuthorizedKeysRestrictions tightens the security configuration according to the options of the authorized_keys
+// entry the user authenticated with. Like OpenSSH, a ForceCommand set in the configuration takes precedence over the
+// command option. If the user did not authenticate with an authorized_keys file the configuration is returned
+// unchanged.
+func applyAuthorizedKeysRestrictions(
+	cfg config2.SecurityConfig,
+	meta metadata.ConnectionAuthenticatedMetadata,
+) config2.SecurityConfig {
+	md := meta.GetMetadata()
+	if _, ok := md[auth.MetadataAuthorizedKeysFingerprint]; !ok {
+		return cfg
+	}
+	isSet := func(key string) bool {
+		_, ok := md[key]
+		return ok
+	}
+	if command, ok := md[auth.MetadataAuthorizedKeysCommand]; ok && cfg.ForceCommand == "" {
+		cfg.ForceCommand = command.Value
+	}
+	if isSet(auth.MetadataAuthorizedKeysNoPTY) {
+		cfg.TTY.Mode = config2.ExecutionPolicyDisable
+	}
+	if isSet(auth.MetadataAuthorizedKeysNoPortForwarding) {
+		cfg.Forwarding.ForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.ReverseForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.SocketForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.SocketListenMode = config2.ExecutionPolicyDisable
+	}
+	if isSet(auth.MetadataAuthorizedKeysNoX11Forwarding) {
+		cfg.Forwarding.X11ForwardingMode = config2.ExecutionPolicyDisable
+	}
+	if isSet(auth.MetadataAuthorizedKeysNoAgentForwarding) {
+		cfg.Forwarding.AgentForwardingMode = config2.ExecutionPolicyDisable
+	}
+	return cfg
+}
@@ -0,0 +1,44 @@
+package security
+
+import (
+	config2 "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/auth"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// applyCertificateRestrictions tightens the security configuration according to the critical options and extensions
+// of the OpenSSH certificate the user authenticated with. Like OpenSSH, a ForceCommand set in the configuration takes
+// precedence over the force-command option of the certificate. If the user did not authenticate with a certificate the
+// configuration is returned unchanged.
+func applyCertificateRestrictions(
+	cfg config2.SecurityConfig,
+	meta metadata.ConnectionAuthenticatedMetadata,
+) config2.SecurityConfig {
+	md := meta.GetMetadata()
+	if _, ok := md[auth.MetadataCertKeyID]; !ok {
+		return cfg
+	}
+	hasExtension := func(extension string) bool {
+		_, ok := md[auth.CertificateExtensionMetadataKey(extension)]
+		return ok
+	}
+	if forceCommand, ok := md[auth.MetadataCertForceCommand]; ok && cfg.ForceCommand == "" {
+		cfg.ForceCommand = forceCommand.Value
+	}
+	if !hasExtension("permit-pty") {
+		cfg.TTY.Mode = config2.ExecutionPolicyDisable
+	}
+	if !hasExtension("permit-port-forwarding") {
+		cfg.Forwarding.ForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.ReverseForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.SocketForwardingMode = config2.ExecutionPolicyDisable
+		cfg.Forwarding.SocketListenMode = config2.ExecutionPolicyDisable
+	}
+	if !hasExtension("permit-X11-forwarding") {
+		cfg.Forwarding.X11ForwardingMode = config2.ExecutionPolicyDisable
+	}
+	if !hasExtension("permit-agent-forwarding") {
+		cfg.Forwarding.AgentForwardingMode = config2.ExecutionPolicyDisable
+	}
+	return cfg
+}
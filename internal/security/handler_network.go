@@ -16,6 +16,7 @@ type networkHandler struct {
 	config  config2.SecurityConfig
 	backend sshserver.NetworkConnectionHandler
 	logger  log.Logger
+	timer   *sessionTimer
 }
 
 func (n *networkHandler) OnAuthKeyboardInteractive(
@@ -51,6 +52,10 @@ func (n *networkHandler) OnAuthPubKey(meta metadata.ConnectionAuthPendingMetadat
 	return n.backend.OnAuthPubKey(meta, pubKey)
 }
 
+func (n *networkHandler) OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return n.backend.OnBanner(meta, banner)
+}
+
 func (n *networkHandler) OnAuthGSSAPI(meta metadata.ConnectionMetadata) auth.GSSAPIServer {
 	return n.backend.OnAuthGSSAPI(meta)
 }
@@ -68,14 +73,21 @@ func (n *networkHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticate
 	if failureReason != nil {
 		return nil, meta, failureReason
 	}
+	cfg := applyCertificateRestrictions(n.config, meta)
+	cfg = applyAuthorizedKeysRestrictions(cfg, meta)
+	n.timer = newSessionTimer(cfg.Session, n.logger)
 	return &sshConnectionHandler{
-		config:  n.config,
+		config:  cfg,
 		backend: backend,
 		lock:    &sync.Mutex{},
 		logger:  n.logger,
+		timer:   n.timer,
 	}, meta, nil
 }
 
 func (n *networkHandler) OnDisconnect() {
+	if n.timer != nil {
+		n.timer.stop()
+	}
 	n.backend.OnDisconnect()
 }
@@ -109,6 +109,8 @@ func (d *dummySSHBackend) OnShutdown(_ context.Context) {
 	panic("implement me")
 }
 
+func (d *dummySSHBackend) OnConnection(_ sshserver.SSHConnection) {}
+
 func (d *dummySSHBackend) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {
 	panic("implement me")
 }
@@ -14,9 +14,15 @@ type sessionHandler struct {
 	backend       sshserver.SessionChannelHandler
 	sshConnection *sshConnectionHandler
 	logger        log.Logger
+	// activity tracks the input and output of the session for the idle timeout. It is nil if neither the idle
+	// timeout nor the maximum session duration is configured.
+	activity *activitySession
 }
 
 func (s *sessionHandler) OnClose() {
+	if s.activity != nil {
+		s.sshConnection.timer.remove(s.activity)
+	}
 	s.backend.OnClose()
 }
 
@@ -118,7 +124,13 @@ func (s *sessionHandler) OnPtyRequest(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnPtyRequest(requestID, term, columns, rows, width, height, modeList)
+		if err := s.backend.OnPtyRequest(requestID, term, columns, rows, width, height, modeList); err != nil {
+			return err
+		}
+		if s.activity != nil {
+			s.activity.setInteractive()
+		}
+		return nil
 	}
 }
 
@@ -299,6 +311,32 @@ func (s *sessionHandler) OnSignal(requestID uint64, signal string) error {
 	}
 }
 
+func (s *sessionHandler) OnBreak(requestID uint64, lengthMs uint32) error {
+	mode := s.getPolicy(s.config.Break.Mode)
+	switch mode {
+	case config2.ExecutionPolicyDisable:
+		err := message.UserMessage(
+			message.ESecurityBreakRejected,
+			"Sending a break is rejected.",
+			"Sending a break is rejected because it is disabled in the config.",
+		)
+		s.logger.Debug(err)
+		return err
+	case config2.ExecutionPolicyFilter:
+		err := message.UserMessage(
+			message.ESecurityBreakRejected,
+			"Sending a break is rejected.",
+			"Sending a break is rejected because it is set to filter and filtering break requests is not supported.",
+		)
+		s.logger.Debug(err)
+		return err
+	case config2.ExecutionPolicyEnable:
+		fallthrough
+	default:
+		return s.backend.OnBreak(requestID, lengthMs)
+	}
+}
+
 func (s *sessionHandler) OnWindow(requestID uint64, columns uint32, rows uint32, width uint32, height uint32) error {
 	return s.backend.OnWindow(requestID, columns, rows, width, height)
 }
@@ -332,6 +370,42 @@ func (s *sessionHandler) OnX11Request(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnX11Request(requestID, singleConnection, protocol, cookie, screen, reverseHandler)
+		return s.backend.OnX11Request(
+			requestID,
+			singleConnection,
+			protocol,
+			cookie,
+			screen,
+			s.sshConnection.trackReverseForward(reverseHandler),
+		)
+	}
+}
+
+func (s *sessionHandler) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	mode := s.getPolicy(s.config.Forwarding.AgentForwardingMode)
+	switch mode {
+	case config2.ExecutionPolicyDisable:
+		err := message.UserMessage(
+			message.ESecurityAgentForwardingRejected,
+			"SSH agent forwarding is rejected",
+			"SSH agent forwarding is rejected because it is disabled in the config",
+		)
+		s.logger.Debug(err)
+		return err
+	case config2.ExecutionPolicyFilter:
+		err := message.UserMessage(
+			message.ESecurityAgentForwardingRejected,
+			"SSH agent forwarding is rejected",
+			"SSH agent forwarding is rejected because it is set to filter and filtering agent requests is not supported",
+		)
+		s.logger.Debug(err)
+		return err
+	case config2.ExecutionPolicyEnable:
+		fallthrough
+	default:
+		return s.backend.OnAuthAgentRequest(requestID, s.sshConnection.trackReverseForward(reverseHandler))
 	}
 }
@@ -63,6 +63,49 @@ func TestPTYRequest(t *testing.T) {
 	assert.Error(t, session.OnPtyRequest(1, "XTERM", 80, 25, 800, 600, []byte{}))
 }
 
+func TestAuthAgentRequest(t *testing.T) {
+	session := &sessionHandler{
+		config:  config.SecurityConfig{},
+		backend: &dummyBackend{},
+		sshConnection: &sshConnectionHandler{
+			lock: &sync.Mutex{},
+		},
+		logger: log.NewTestLogger(t),
+	}
+
+	session.config.Forwarding.AgentForwardingMode = config.ExecutionPolicyEnable
+	assert.NoError(t, session.OnAuthAgentRequest(1, nil))
+
+	session.config.Forwarding.AgentForwardingMode = config.ExecutionPolicyFilter
+	assert.Error(t, session.OnAuthAgentRequest(2, nil))
+
+	session.config.Forwarding.AgentForwardingMode = config.ExecutionPolicyDisable
+	assert.Error(t, session.OnAuthAgentRequest(3, nil))
+}
+
+func TestBreakRequest(t *testing.T) {
+	session := &sessionHandler{
+		config:  config.SecurityConfig{},
+		backend: &dummyBackend{},
+		sshConnection: &sshConnectionHandler{
+			lock: &sync.Mutex{},
+		},
+		logger: log.NewTestLogger(t),
+	}
+
+	assert.NoError(t, session.OnBreak(1, 500))
+
+	session.config.Break.Mode = config.ExecutionPolicyFilter
+	assert.Error(t, session.OnBreak(2, 500))
+
+	session.config.Break.Mode = config.ExecutionPolicyDisable
+	assert.Error(t, session.OnBreak(3, 500))
+
+	session.config.Break.Mode = config.ExecutionPolicyUnconfigured
+	session.config.DefaultMode = config.ExecutionPolicyDisable
+	assert.Error(t, session.OnBreak(4, 500))
+}
+
 func TestCommand(t *testing.T) {
 	backend := &dummyBackend{}
 	session := &sessionHandler{
@@ -264,6 +307,10 @@ func (d *dummyBackend) OnSignal(_ uint64, _ string) error {
 	return nil
 }
 
+func (d *dummyBackend) OnBreak(_ uint64, _ uint32) error {
+	return nil
+}
+
 func (d *dummyBackend) OnWindow(_ uint64, _ uint32, _ uint32, _ uint32, _ uint32) error {
 	return nil
 }
@@ -279,4 +326,11 @@ func (s *dummyBackend) OnX11Request(
 	return fmt.Errorf("Unimplemented")
 }
 
+func (s *dummyBackend) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return nil
+}
+
 // endregion
@@ -18,12 +18,20 @@ type sshConnectionHandler struct {
 	sessionCount uint
 	lock         *sync.Mutex
 	logger       log.Logger
+	timer        *sessionTimer
 }
 
 func (s *sshConnectionHandler) OnShutdown(shutdownContext context.Context) {
 	s.backend.OnShutdown(shutdownContext)
 }
 
+func (s *sshConnectionHandler) OnConnection(connection sshserver.SSHConnection) {
+	if s.timer != nil {
+		s.timer.setConnection(connection)
+	}
+	s.backend.OnConnection(connection)
+}
+
 func (s *sshConnectionHandler) OnUnsupportedGlobalRequest(requestID uint64, requestType string, payload []byte) {
 	s.backend.OnUnsupportedGlobalRequest(requestID, requestType, payload)
 }
@@ -50,8 +58,16 @@ func (s *sshConnectionHandler) OnSessionChannel(
 		s.logger.Debug(err)
 		return nil, err
 	}
+	var activity *activitySession
+	if s.timer != nil {
+		activity = s.timer.wrap(session)
+		session = activity
+	}
 	backend, err := s.backend.OnSessionChannel(meta, extraData, session)
 	if err != nil {
+		if activity != nil {
+			s.timer.remove(activity)
+		}
 		return nil, err
 	}
 	s.sessionCount++
@@ -60,6 +76,7 @@ func (s *sshConnectionHandler) OnSessionChannel(
 		backend:       backend,
 		sshConnection: s,
 		logger:        s.logger,
+		activity:      activity,
 	}, nil
 }
 
@@ -103,7 +120,9 @@ func (s *sshConnectionHandler) OnTCPForwardChannel(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnTCPForwardChannel(channelID, hostToConnect, portToConnect, originatorHost, originatorPort)
+		return s.trackForward(
+			s.backend.OnTCPForwardChannel(channelID, hostToConnect, portToConnect, originatorHost, originatorPort),
+		)
 	}
 }
 
@@ -133,7 +152,7 @@ func (s *sshConnectionHandler) OnRequestTCPReverseForward(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnRequestTCPReverseForward(bindHost, bindPort, reverseHandler)
+		return s.backend.OnRequestTCPReverseForward(bindHost, bindPort, s.trackReverseForward(reverseHandler))
 	}
 }
 
@@ -174,7 +193,7 @@ func (s *sshConnectionHandler) OnDirectStreamLocal(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnDirectStreamLocal(channelID, path)
+		return s.trackForward(s.backend.OnDirectStreamLocal(channelID, path))
 	}
 }
 
@@ -203,7 +222,7 @@ func (s *sshConnectionHandler) OnRequestStreamLocal(
 	case config2.ExecutionPolicyEnable:
 		fallthrough
 	default:
-		return s.backend.OnRequestStreamLocal(path, reverseHandler)
+		return s.backend.OnRequestStreamLocal(path, s.trackReverseForward(reverseHandler))
 	}
 }
 
@@ -213,6 +232,26 @@ func (s *sshConnectionHandler) OnRequestCancelStreamLocal(
 	return s.backend.OnRequestCancelStreamLocal(path)
 }
 
+// trackForward records the traffic of a forwarding channel as activity for the idle timeout.
+func (s *sshConnectionHandler) trackForward(
+	channel sshserver.ForwardChannel,
+	failureReason sshserver.ChannelRejection,
+) (sshserver.ForwardChannel, sshserver.ChannelRejection) {
+	if failureReason != nil || s.timer == nil {
+		return channel, failureReason
+	}
+	return s.timer.wrapForward(channel), nil
+}
+
+// trackReverseForward records the traffic of the channels opened for reverse forwarding as activity for the idle
+// timeout.
+func (s *sshConnectionHandler) trackReverseForward(reverseHandler sshserver.ReverseForward) sshserver.ReverseForward {
+	if s.timer == nil {
+		return reverseHandler
+	}
+	return s.timer.wrapReverseForward(reverseHandler)
+}
+
 // ErrTooManySessions indicates that too many sessions were opened in the same connection.
 type ErrTooManySessions struct {
 	labels message.Labels
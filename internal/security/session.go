@@ -0,0 +1,333 @@
+package security
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config2 "go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// sessionCheckInterval is the interval at which the idle timeout and the maximum session duration are checked.
+var sessionCheckInterval = time.Second
+
+// sessionTimer closes the connection when it has been idle for too long or reached the maximum session duration. Input
+// and output on session and forwarding channels count as activity.
+type sessionTimer struct {
+	lock   *sync.Mutex
+	config config2.SecuritySessionConfig
+	logger log.Logger
+	start  time.Time
+	// lastActivity is the time of the last input or output on any channel in Unix nanoseconds.
+	lastActivity *atomic.Int64
+	sessions     map[*activitySession]struct{}
+	// connection is used to close the connection when a limit is reached.
+	connection        sshserver.SSHConnection
+	idleWarnedFor     time.Time
+	maxDurationWarned bool
+	done              chan struct{}
+	stopOnce          *sync.Once
+}
+
+func newSessionTimer(config config2.SecuritySessionConfig, logger log.Logger) *sessionTimer {
+	if config.IdleTimeout == 0 && config.MaxDuration == 0 {
+		return nil
+	}
+	now := time.Now()
+	lastActivity := &atomic.Int64{}
+	lastActivity.Store(now.UnixNano())
+	t := &sessionTimer{
+		lock:         &sync.Mutex{},
+		config:       config,
+		logger:       logger,
+		start:        now,
+		lastActivity: lastActivity,
+		sessions:     map[*activitySession]struct{}{},
+		done:         make(chan struct{}),
+		stopOnce:     &sync.Once{},
+	}
+	go t.run()
+	return t
+}
+
+// wrap returns a session that records its input and output as activity.
+func (t *sessionTimer) wrap(session sshserver.SessionChannel) *activitySession {
+	wrapped := &activitySession{
+		SessionChannel: session,
+		lastActivity:   t.lastActivity,
+		lock:           &sync.Mutex{},
+	}
+	t.lock.Lock()
+	t.sessions[wrapped] = struct{}{}
+	t.lock.Unlock()
+	return wrapped
+}
+
+// wrapForward returns a forwarding channel that records its traffic as activity.
+func (t *sessionTimer) wrapForward(channel sshserver.ForwardChannel) sshserver.ForwardChannel {
+	return &activityForwardChannel{
+		ForwardChannel: channel,
+		lastActivity:   t.lastActivity,
+	}
+}
+
+// wrapReverseForward returns a set of reverse forwarding callbacks that record the traffic of the channels they open
+// as activity.
+func (t *sessionTimer) wrapReverseForward(reverseForward sshserver.ReverseForward) sshserver.ReverseForward {
+	return &activityReverseForward{
+		ReverseForward: reverseForward,
+		timer:          t,
+	}
+}
+
+func (t *sessionTimer) setConnection(connection sshserver.SSHConnection) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.connection = connection
+}
+
+func (t *sessionTimer) remove(session *activitySession) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.sessions, session)
+}
+
+func (t *sessionTimer) stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+func (t *sessionTimer) run() {
+	ticker := time.NewTicker(sessionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case now := <-ticker.C:
+			if t.check(now) {
+				return
+			}
+		}
+	}
+}
+
+// check warns the user or closes the connection if a limit is near or has been reached. It returns true if the
+// connection has been closed.
+func (t *sessionTimer) check(now time.Time) bool {
+	if t.config.MaxDuration > 0 {
+		remaining := t.start.Add(t.config.MaxDuration).Sub(now)
+		if remaining <= 0 {
+			return t.terminate(
+				message.MSecuritySessionMaxDuration,
+				fmt.Sprintf("maximum session duration of %s reached", t.config.MaxDuration),
+			)
+		}
+		if !t.maxDurationWarned && remaining <= t.config.WarningBefore {
+			t.maxDurationWarned = true
+			t.warn(t.config.MaxDurationWarning, remaining)
+		}
+	}
+	if t.config.IdleTimeout > 0 {
+		lastActivity := time.Unix(0, t.lastActivity.Load())
+		remaining := lastActivity.Add(t.config.IdleTimeout).Sub(now)
+		if remaining <= 0 {
+			return t.terminate(
+				message.MSecuritySessionIdleTimeout,
+				fmt.Sprintf("idle timeout of %s reached", t.config.IdleTimeout),
+			)
+		}
+		// The warning is repeated if the user was active after the previous warning and became idle again.
+		if !t.idleWarnedFor.Equal(lastActivity) && remaining <= t.config.WarningBefore {
+			t.idleWarnedFor = lastActivity
+			t.warn(t.config.IdleWarning, remaining)
+		}
+	}
+	return false
+}
+
+// warn writes the message to all interactive sessions. Writing the warning does not count as activity.
+func (t *sessionTimer) warn(text string, remaining time.Duration) {
+	if text == "" {
+		return
+	}
+	notice := "\r\n" + strings.ReplaceAll(
+		text,
+		config2.SecuritySessionRemainingPlaceholder,
+		remaining.Round(time.Second).String(),
+	) + "\r\n"
+
+	t.lock.Lock()
+	sessions := make([]*activitySession, 0, len(t.sessions))
+	for session := range t.sessions {
+		sessions = append(sessions, session)
+	}
+	t.lock.Unlock()
+
+	for _, session := range sessions {
+		if !session.isInteractive() {
+			continue
+		}
+		if _, err := session.SessionChannel.Stdout().Write([]byte(notice)); err != nil {
+			t.logger.Debug(
+				message.Wrap(
+					err,
+					message.ESecuritySessionWarningFailed,
+					"Failed to write session warning to the terminal",
+				),
+			)
+		}
+	}
+}
+
+// terminate closes the connection. It returns false if the connection has not been handed over by the SSH server yet,
+// so the check is repeated on the next tick.
+func (t *sessionTimer) terminate(code string, reason string) bool {
+	t.lock.Lock()
+	connection := t.connection
+	t.lock.Unlock()
+	if connection == nil {
+		return false
+	}
+	t.logger.Info(message.NewMessage(code, "Closing connection: %s", reason))
+	if err := connection.Terminate(reason); err != nil {
+		t.logger.Debug(
+			message.Wrap(
+				err,
+				message.ESecuritySessionTerminateFailed,
+				"Failed to close connection",
+			),
+		)
+	}
+	return true
+}
+
+// activitySession records the time of the last input or output of a session.
+type activitySession struct {
+	sshserver.SessionChannel
+	lastActivity *atomic.Int64
+	lock         *sync.Mutex
+	interactive  bool
+}
+
+func (a *activitySession) setInteractive() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.interactive = true
+}
+
+func (a *activitySession) isInteractive() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.interactive
+}
+
+func (a *activitySession) Stdin() io.Reader {
+	return &activityReader{backend: a.SessionChannel.Stdin(), lastActivity: a.lastActivity}
+}
+
+func (a *activitySession) Stdout() io.Writer {
+	return &activityWriter{backend: a.SessionChannel.Stdout(), lastActivity: a.lastActivity}
+}
+
+func (a *activitySession) Stderr() io.Writer {
+	return &activityWriter{backend: a.SessionChannel.Stderr(), lastActivity: a.lastActivity}
+}
+
+// activityForwardChannel records the time of the last traffic on a forwarding channel.
+type activityForwardChannel struct {
+	sshserver.ForwardChannel
+	lastActivity *atomic.Int64
+}
+
+func (a *activityForwardChannel) Read(p []byte) (int, error) {
+	n, err := a.ForwardChannel.Read(p)
+	if n > 0 {
+		touch(a.lastActivity)
+	}
+	return n, err
+}
+
+func (a *activityForwardChannel) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		touch(a.lastActivity)
+	}
+	return a.ForwardChannel.Write(p)
+}
+
+// activityReverseForward records the traffic of the channels opened for reverse forwarding as activity.
+type activityReverseForward struct {
+	sshserver.ReverseForward
+	timer *sessionTimer
+}
+
+func (a *activityReverseForward) wrap(
+	channel sshserver.ForwardChannel,
+	channelID uint64,
+	err error,
+) (sshserver.ForwardChannel, uint64, error) {
+	if err != nil {
+		return channel, channelID, err
+	}
+	return a.timer.wrapForward(channel), channelID, nil
+}
+
+func (a *activityReverseForward) NewChannelTCP(
+	connectedAddress string,
+	connectedPort uint32,
+	originatorAddress string,
+	originatorPort uint32,
+) (sshserver.ForwardChannel, uint64, error) {
+	return a.wrap(a.ReverseForward.NewChannelTCP(connectedAddress, connectedPort, originatorAddress, originatorPort))
+}
+
+func (a *activityReverseForward) NewChannelUnix(path string) (sshserver.ForwardChannel, uint64, error) {
+	return a.wrap(a.ReverseForward.NewChannelUnix(path))
+}
+
+func (a *activityReverseForward) NewChannelX11(
+	originatorAddress string,
+	originatorPort uint32,
+) (sshserver.ForwardChannel, uint64, error) {
+	return a.wrap(a.ReverseForward.NewChannelX11(originatorAddress, originatorPort))
+}
+
+func (a *activityReverseForward) NewChannelAuthAgent() (sshserver.ForwardChannel, uint64, error) {
+	return a.wrap(a.ReverseForward.NewChannelAuthAgent())
+}
+
+func touch(lastActivity *atomic.Int64) {
+	lastActivity.Store(time.Now().UnixNano())
+}
+
+type activityReader struct {
+	backend      io.Reader
+	lastActivity *atomic.Int64
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.backend.Read(p)
+	if n > 0 {
+		touch(a.lastActivity)
+	}
+	return n, err
+}
+
+type activityWriter struct {
+	backend      io.Writer
+	lastActivity *atomic.Int64
+}
+
+func (a *activityWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		touch(a.lastActivity)
+	}
+	return a.backend.Write(p)
+}
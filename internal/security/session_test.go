@@ -0,0 +1,271 @@
+package security //nolint:testpackage
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+)
+
+func TestIdleTimeout(t *testing.T) {
+	setSessionCheckInterval(t, 10*time.Millisecond)
+	session := newRecordingSession()
+	connection := newTerminatingConnection()
+	timer := newSessionTimer(
+		config.SecuritySessionConfig{
+			IdleTimeout:   500 * time.Millisecond,
+			WarningBefore: 300 * time.Millisecond,
+			IdleWarning:   "Idle, closing in {remaining}.",
+		},
+		log.NewTestLogger(t),
+	)
+	defer timer.stop()
+	timer.setConnection(connection)
+	activity := timer.wrap(session)
+	activity.setInteractive()
+
+	// Activity keeps the connection open beyond the idle timeout.
+	for i := 0; i < 10; i++ {
+		_, err := activity.Stdout().Write([]byte("a"))
+		assert.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, "", connection.reason())
+
+	select {
+	case <-connection.terminated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection was not closed after the idle timeout")
+	}
+	assert.Contains(t, connection.reason(), "idle timeout")
+	assert.Contains(t, session.output(), "Idle, closing in ")
+	assert.Equal(t, 1, strings.Count(session.output(), "Idle"), "the warning should only be written once")
+}
+
+func TestMaxDuration(t *testing.T) {
+	setSessionCheckInterval(t, 10*time.Millisecond)
+	session := newRecordingSession()
+	connection := newTerminatingConnection()
+	timer := newSessionTimer(
+		config.SecuritySessionConfig{
+			MaxDuration:        500 * time.Millisecond,
+			WarningBefore:      300 * time.Millisecond,
+			MaxDurationWarning: "Closing in {remaining}.",
+		},
+		log.NewTestLogger(t),
+	)
+	defer timer.stop()
+	timer.setConnection(connection)
+	activity := timer.wrap(session)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(50 * time.Millisecond):
+				_, _ = activity.Stdout().Write([]byte("a"))
+			}
+		}
+	}()
+	defer close(done)
+
+	select {
+	case <-connection.terminated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection was not closed after the maximum session duration")
+	}
+	assert.Contains(t, connection.reason(), "maximum session duration")
+	assert.NotContains(t, session.output(), "Closing in", "non-interactive sessions should not receive warnings")
+}
+
+func TestMaxDurationForwardOnly(t *testing.T) {
+	setSessionCheckInterval(t, 10*time.Millisecond)
+	handler, connection, stop := newForwardingSSHConnectionHandler(
+		t,
+		config.SecuritySessionConfig{
+			MaxDuration: 500 * time.Millisecond,
+		},
+	)
+	defer stop()
+
+	channel, rejection := handler.OnTCPForwardChannel(1, "127.0.0.1", 80, "127.0.0.1", 1234)
+	if rejection != nil {
+		t.Fatal(rejection)
+	}
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(50 * time.Millisecond):
+				_, _ = channel.Write([]byte("a"))
+			}
+		}
+	}()
+	defer close(done)
+
+	select {
+	case <-connection.terminated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection without sessions was not closed after the maximum session duration")
+	}
+	assert.Contains(t, connection.reason(), "maximum session duration")
+}
+
+func TestIdleTimeoutForwardActivity(t *testing.T) {
+	setSessionCheckInterval(t, 10*time.Millisecond)
+	handler, connection, stop := newForwardingSSHConnectionHandler(
+		t,
+		config.SecuritySessionConfig{
+			IdleTimeout: 300 * time.Millisecond,
+		},
+	)
+	defer stop()
+
+	channel, rejection := handler.OnTCPForwardChannel(1, "127.0.0.1", 80, "127.0.0.1", 1234)
+	if rejection != nil {
+		t.Fatal(rejection)
+	}
+	// Traffic on the forwarding channel keeps the connection open beyond the idle timeout.
+	for i := 0; i < 10; i++ {
+		_, err := channel.Read(make([]byte, 1))
+		assert.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, "", connection.reason())
+
+	select {
+	case <-connection.terminated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection was not closed after the idle timeout")
+	}
+	assert.Contains(t, connection.reason(), "idle timeout")
+}
+
+func TestSessionTimerDisabled(t *testing.T) {
+	assert.Nil(t, newSessionTimer(config.SecuritySessionConfig{WarningBefore: time.Minute}, log.NewTestLogger(t)))
+}
+
+func setSessionCheckInterval(t *testing.T, interval time.Duration) {
+	previous := sessionCheckInterval
+	sessionCheckInterval = interval
+	t.Cleanup(func() {
+		sessionCheckInterval = previous
+	})
+}
+
+// newForwardingSSHConnectionHandler creates a security handler with the session timer in the same way as the network
+// handler does, with a backend that accepts all forwarding channels.
+func newForwardingSSHConnectionHandler(
+	t *testing.T,
+	cfg config.SecuritySessionConfig,
+) (*sshConnectionHandler, *terminatingConnection, func()) {
+	timer := newSessionTimer(cfg, log.NewTestLogger(t))
+	handler := &sshConnectionHandler{
+		config: config.SecurityConfig{
+			MaxSessions: -1,
+			Session:     cfg,
+		},
+		backend: &forwardingSSHBackend{},
+		lock:    &sync.Mutex{},
+		logger:  log.NewTestLogger(t),
+		timer:   timer,
+	}
+	connection := newTerminatingConnection()
+	handler.OnConnection(connection)
+	return handler, connection, timer.stop
+}
+
+type forwardingSSHBackend struct {
+	dummySSHBackend
+}
+
+func (f *forwardingSSHBackend) OnTCPForwardChannel(
+	_ uint64,
+	_ string,
+	_ uint32,
+	_ string,
+	_ uint32,
+) (channel sshserver.ForwardChannel, failureReason sshserver.ChannelRejection) {
+	return &discardForwardChannel{}, nil
+}
+
+type discardForwardChannel struct{}
+
+func (d *discardForwardChannel) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (d *discardForwardChannel) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (d *discardForwardChannel) Close() error {
+	return nil
+}
+
+type recordingSession struct {
+	sessionChannel
+	lock   *sync.Mutex
+	buffer *bytes.Buffer
+}
+
+func newRecordingSession() *recordingSession {
+	return &recordingSession{
+		lock:   &sync.Mutex{},
+		buffer: &bytes.Buffer{},
+	}
+}
+
+func (s *recordingSession) Stdout() io.Writer {
+	return s
+}
+
+func (s *recordingSession) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buffer.Write(p)
+}
+
+func (s *recordingSession) output() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.buffer.String()
+}
+
+type terminatingConnection struct {
+	lock             *sync.Mutex
+	terminated       chan struct{}
+	terminatedReason string
+}
+
+func newTerminatingConnection() *terminatingConnection {
+	return &terminatingConnection{
+		lock:       &sync.Mutex{},
+		terminated: make(chan struct{}),
+	}
+}
+
+func (c *terminatingConnection) reason() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.terminatedReason
+}
+
+func (c *terminatingConnection) Terminate(reason string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.terminatedReason = reason
+	close(c.terminated)
+	return nil
+}
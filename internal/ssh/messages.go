@@ -36,6 +36,12 @@ type PtyRequestPayload struct {
 type ShellRequestPayload struct {
 }
 
+// BreakRequestPayload is the payload of the break channel request as described in RFC 4335.
+type BreakRequestPayload struct {
+	// Length is the length of the break in milliseconds.
+	Length uint32
+}
+
 type SignalRequestPayload struct {
 	Signal string
 }
@@ -70,6 +76,10 @@ type X11RequestPayload struct {
 	Screen           uint32
 }
 
+// AuthAgentRequestPayload is the empty payload of the auth-agent-req@openssh.com channel request.
+type AuthAgentRequestPayload struct {
+}
+
 type X11ChanOpenRequestPayload struct {
 	OriginatorAddress string
 	OriginatorPort    uint32
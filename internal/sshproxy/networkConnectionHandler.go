@@ -67,6 +67,10 @@ func (s *networkConnectionHandler) OnAuthKeyboardInteractive(
 	)
 }
 
+func (s *networkConnectionHandler) OnBanner(_ metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return banner
+}
+
 func (s *networkConnectionHandler) OnAuthGSSAPI(_ metadata.ConnectionMetadata) auth.GSSAPIServer {
 	return nil
 }
@@ -333,6 +333,34 @@ func (s *sshChannelHandler) OnSignal(_ uint64, signal string) error {
 	return s.sendRequest("signal", payload)
 }
 
+func (s *sshChannelHandler) OnBreak(_ uint64, lengthMs uint32) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.started {
+		err := message.UserMessage(
+			message.ESSHProxyProgramNotStarted,
+			"Cannot send break before program has started.",
+			"Client tried to send a break before the program was started.",
+		)
+		s.logger.Debug(err)
+		return err
+	}
+	payload := ssh2.BreakRequestPayload{
+		Length: lengthMs,
+	}
+	if err := s.sendRequest(string(ssh2.RequestTypeBreak), payload); err != nil {
+		err := message.WrapUser(
+			err,
+			message.ESSHProxyBreakFailed,
+			"Cannot send break.",
+			"The backend rejected or failed to deliver the break request.",
+		)
+		s.logger.Debug(err)
+		return err
+	}
+	return nil
+}
+
 func (s *sshChannelHandler) OnWindow(_ uint64, columns uint32, rows uint32, width uint32, height uint32) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -393,6 +421,26 @@ func (s *sshChannelHandler) OnX11Request(
 	return nil
 }
 
+func (s *sshChannelHandler) OnAuthAgentRequest(
+	_ uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.sendRequest(string(ssh2.RequestTypeAuthAgent), nil); err != nil {
+		err := message.WrapUser(
+			err,
+			message.ESSHProxyAgentRequestFailed,
+			"Error requesting SSH agent forwarding",
+			"ContainerSSH cannot enable SSH agent forwarding because of an error on the backend connection",
+		)
+		s.logger.Debug(err)
+		return err
+	}
+	s.connectionHandler.setReverseHandler(reverseHandler)
+	return nil
+}
+
 func (s *sshChannelHandler) OnClose() {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -51,6 +51,8 @@ func (s *sshConnectionHandler) handleChannels(newChannels <-chan ssh.NewChannel)
 			s.handleStreamLocalChannel(newChannel)
 		case "forwarded-tcpip":
 			s.handleReverseForwardChannel(newChannel)
+		case "auth-agent@openssh.com":
+			s.handleAuthAgentChannel(newChannel)
 		default:
 			_ = newChannel.Reject(ssh.Prohibited, "Unsupported channel type")
 		}
@@ -192,6 +194,53 @@ func (s *sshConnectionHandler) handleX11Channel(newChannel ssh.NewChannel) {
 	go s.handleForward(&once, clientChannel, serverChannel)
 }
 
+func (s *sshConnectionHandler) handleAuthAgentChannel(newChannel ssh.NewChannel) {
+	s.forwardMu.Lock()
+	defer s.forwardMu.Unlock()
+	if s.reverseHandler == nil {
+		_ = newChannel.Reject(ssh.Prohibited, "SSH agent forwarding was not requested")
+		return
+	}
+	clientChannel, _, err := s.reverseHandler.NewChannelAuthAgent()
+	if err != nil {
+		m := message.Wrap(
+			err,
+			message.ESSHProxyBackendForwardFailed,
+			"Failed to open SSH agent channel to the client",
+		)
+		s.logger.Info(m)
+		_ = newChannel.Reject(ssh.ConnectionFailed, "Failed to open SSH agent channel to the client")
+		return
+	}
+	serverChannel, req, err := newChannel.Accept()
+	if err != nil {
+		m := message.Wrap(
+			err,
+			message.ESSHProxyBackendForwardFailed,
+			"Failed to accept SSH agent channel from server",
+		)
+		s.logger.Info(m)
+		_ = clientChannel.Close()
+		return
+	}
+	go s.rejectAllRequests(req)
+	once := sync.Once{}
+
+	go s.handleForward(&once, serverChannel, clientChannel)
+	go s.handleForward(&once, clientChannel, serverChannel)
+}
+
+// setReverseHandler stores the handler used to open channels to the client for connections initiated by the backend.
+func (s *sshConnectionHandler) setReverseHandler(reverseHandler sshserver.ReverseForward) {
+	s.forwardMu.Lock()
+	defer s.forwardMu.Unlock()
+	if s.reverseHandler == nil {
+		s.reverseHandler = reverseHandler
+	}
+}
+
+func (s *sshConnectionHandler) OnConnection(_ sshserver.SSHConnection) {}
+
 func (s *sshConnectionHandler) OnUnsupportedGlobalRequest(_ uint64, _ string, _ []byte) {
 }
 
@@ -13,6 +13,12 @@ import (
 type AbstractNetworkConnectionHandler struct {
 }
 
+// OnBanner is called before authentication to obtain the banner sent to the client. It returns the configured banner
+//          unchanged.
+func (a *AbstractNetworkConnectionHandler) OnBanner(_ metadata.ConnectionAuthPendingMetadata, banner string) string {
+	return banner
+}
+
 // OnAuthPassword is called when a user attempts a password authentication. The implementation must always supply
 //                AuthResponse and may supply error as a reason description.
 func (a *AbstractNetworkConnectionHandler) OnAuthPassword(
@@ -12,6 +12,9 @@ import (
 type AbstractSSHConnectionHandler struct {
 }
 
+// OnConnection is called once after the handshake, before any channels or global requests are handled.
+func (a *AbstractSSHConnectionHandler) OnConnection(_ SSHConnection) {}
+
 // OnUnsupportedGlobalRequest captures all global SSH requests and gives the implementation an opportunity to log
 //                            the request.
 //
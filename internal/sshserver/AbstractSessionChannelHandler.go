@@ -127,6 +127,18 @@ func (a *AbstractSessionChannelHandler) OnSignal(
 	return fmt.Errorf("not supported")
 }
 
+// OnBreak is called when the client requests a break to be sent to the terminal of the running process as described
+// in RFC 4335. The implementation can return an error to reject the request.
+//
+// requestID is an incrementing number uniquely identifying this request within the channel.
+// lengthMs is the requested length of the break in milliseconds.
+func (a *AbstractSessionChannelHandler) OnBreak(
+	_ uint64,
+	_ uint32,
+) error {
+	return fmt.Errorf("not supported")
+}
+
 // OnWindow is called when the client requests the window size to be changed. This method may be called
 //
 //	after a program is started. The implementation can return an error to reject the request.
@@ -166,3 +178,17 @@ func (s *AbstractSessionChannelHandler) OnX11Request(
 ) error {
 	return fmt.Errorf("not supported")
 }
+
+// OnAuthAgentRequest is called when the client requests the forwarding of its SSH agent to the container. The
+// implementation should expose the agent in the container, typically via SSH_AUTH_SOCK, and open a new channel via
+// reverseHandler for each connection to it. This method may be called after a program is started. The
+// implementation can return an error to reject the request.
+//
+// requestID is an incrementing number uniquely identifying the request within the channel.
+// reverseHandler is a callback interface to signal when new connections are made
+func (s *AbstractSessionChannelHandler) OnAuthAgentRequest(
+	_ uint64,
+	_ ReverseForward,
+) error {
+	return fmt.Errorf("not supported")
+}
@@ -4,15 +4,22 @@ import (
 	"sync"
 
     "go.containerssh.io/containerssh/config"
+    "go.containerssh.io/containerssh/internal/geoip/geoipprovider"
     "go.containerssh.io/containerssh/log"
 )
 
-// New creates a new SSH server ready to be run. It may return an error if the configuration is invalid.
-func New(cfg config.SSHConfig, handler Handler, logger log.Logger) (Server, error) {
+// New creates a new SSH server ready to be run. It may return an error if the configuration is invalid. The geoIP
+// lookup provides the country for the banner template and may be nil.
+func New(
+	cfg config.SSHConfig,
+	handler Handler,
+	logger log.Logger,
+	geoIP geoipprovider.LookupProvider,
+) (Server, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
-	hostKeys, err := cfg.LoadHostKeys()
+	listeners, err := newListeners(cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -22,8 +29,8 @@ func New(cfg config.SSHConfig, handler Handler, logger log.Logger) (Server, erro
 		logger:       logger,
 		wg:           &sync.WaitGroup{},
 		lock:         &sync.Mutex{},
-		listenSocket: nil,
-		hostKeys:     hostKeys,
+		listeners:    listeners,
+		geoIP:        geoIP,
 		shutdownHandlers: &shutdownRegistry{
 			lock:      &sync.Mutex{},
 			callbacks: map[string]shutdownHandler{},
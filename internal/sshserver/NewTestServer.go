@@ -24,7 +24,7 @@ func NewTestServer(t *testing.T, handler Handler, logger log.Logger, config *con
 	if err := config.GenerateHostKey(); err != nil {
 		panic(err)
 	}
-	svc, err := New(*config, handler, logger)
+	svc, err := New(*config, handler, logger, nil)
 	if err != nil {
 		panic(err)
 	}
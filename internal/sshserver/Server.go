@@ -1,6 +1,7 @@
 package sshserver
 
 import (
+    "go.containerssh.io/containerssh/config"
     "go.containerssh.io/containerssh/service"
 )
 
@@ -8,4 +9,14 @@ import (
 // with the Lifecycle interface from the service library.
 type Server interface {
 	service.Service
+
+	// Reload applies the host keys, banners, algorithms and keepalive settings from the new configuration to new
+	// connections. Existing connections keep their settings. Listen addresses, connection limits and PROXY protocol
+	// settings are only applied when the server is restarted.
+	Reload(cfg config.SSHConfig) error
+
+	// Drain stops accepting new connections and periodically writes the configured drain message to interactive
+	// sessions. The returned channel is closed when all connections have been closed or the drain timeout expired,
+	// after which the server should be stopped. Calling Drain again returns the same channel.
+	Drain() <-chan struct{}
 }
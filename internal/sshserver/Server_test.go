@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -39,7 +40,7 @@ func TestReadyRejection(t *testing.T) {
 	logger := log.NewTestLogger(t)
 	handler := &rejectHandler{}
 
-	server, err := sshserver.New(cfg, handler, logger)
+	server, err := sshserver.New(cfg, handler, logger, nil)
 	if err != nil {
 		assert.Fail(t, "failed to create server", err)
 		return
@@ -323,6 +324,402 @@ func TestPubKey(t *testing.T) {
 	assert.Equal(t, []byte("Hello world!"), reply)
 }
 
+func TestUnixSocketListener(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "ssh.sock")
+	server := newServerHelper(
+		t,
+		"",
+		map[string][]byte{
+			"foo": []byte("bar"),
+		},
+		map[string]string{},
+	)
+	server.listeners = []config.SSHListenerConfig{
+		{
+			Name:   "local",
+			Type:   config.SSHListenerTypeUnix,
+			Listen: socketPath,
+			Banner: "Local bastion",
+		},
+	}
+	hostKey, err := server.start(t)
+	if err != nil {
+		assert.Fail(t, "failed to start ssh server", err)
+		return
+	}
+	defer func() {
+		server.stop()
+		<-server.shutdownChannel
+	}()
+
+	banner := ""
+	sshConfig := &ssh.ClientConfig{
+		User: "foo",
+		Auth: []ssh.AuthMethod{ssh.Password("bar")},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if bytes.Equal(key.Marshal(), hostKey) {
+				return nil
+			}
+			return fmt.Errorf("invalid host")
+		},
+		BannerCallback: func(message string) error {
+			banner = message
+			return nil
+		},
+	}
+	sshConnection, err := ssh.Dial("unix", socketPath, sshConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_ = sshConnection.Close()
+	assert.Equal(t, "Local bastion", banner)
+}
+
+func TestBanner(t *testing.T) {
+	for name, tc := range map[string]struct {
+		bannerTemplate bool
+		banner         string
+		expected       string
+	}{
+		"legacy banner with braces": {false, "{{ Welcome }} {{.Username}}", "{{ Welcome }} {{.Username}}"},
+		"template":                  {true, "Welcome {{ .Username }} on {{ .Listener }}", "Welcome foo on local"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			socketPath := filepath.Join(t.TempDir(), "ssh.sock")
+			server := newServerHelper(
+				t,
+				"",
+				map[string][]byte{
+					"foo": []byte("bar"),
+				},
+				map[string]string{},
+			)
+			server.bannerTemplate = tc.bannerTemplate
+			server.listeners = []config.SSHListenerConfig{
+				{
+					Name:   "local",
+					Type:   config.SSHListenerTypeUnix,
+					Listen: socketPath,
+					Banner: tc.banner,
+				},
+			}
+			hostKey, err := server.start(t)
+			if err != nil {
+				assert.Fail(t, "failed to start ssh server", err)
+				return
+			}
+			defer func() {
+				server.stop()
+				<-server.shutdownChannel
+			}()
+
+			banner := ""
+			sshConfig := &ssh.ClientConfig{
+				User: "foo",
+				Auth: []ssh.AuthMethod{ssh.Password("bar")},
+				HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+					if bytes.Equal(key.Marshal(), hostKey) {
+						return nil
+					}
+					return fmt.Errorf("invalid host")
+				},
+				BannerCallback: func(message string) error {
+					banner = message
+					return nil
+				},
+			}
+			sshConnection, err := ssh.Dial("unix", socketPath, sshConfig)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_ = sshConnection.Close()
+			assert.Equal(t, tc.expected, banner)
+		})
+	}
+}
+
+func TestHostKeyRotation(t *testing.T) {
+	stagedCfg := config.SSHConfig{}
+	if err := stagedCfg.GenerateHostKey(); err != nil {
+		assert.Fail(t, "failed to generate staged host key", err)
+		return
+	}
+	stagedKey, err := ssh.ParsePrivateKey([]byte(stagedCfg.HostKeys[0]))
+	if err != nil {
+		assert.Fail(t, "failed to parse staged host key", err)
+		return
+	}
+	port := test.GetNextPort(t, "SSH")
+	server := newServerHelper(
+		t,
+		fmt.Sprintf("127.0.0.1:%d", port),
+		map[string][]byte{
+			"foo": []byte("bar"),
+		},
+		map[string]string{},
+	)
+	server.stagedHostKeys = stagedCfg.HostKeys
+	hostKey, err := server.start(t)
+	if err != nil {
+		assert.Fail(t, "failed to start ssh server", err)
+		return
+	}
+	defer func() {
+		server.stop()
+		<-server.shutdownChannel
+	}()
+
+	tcpConnection, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if !assert.NoError(t, err) {
+		return
+	}
+	sshConfig := &ssh.ClientConfig{
+		User: "foo",
+		Auth: []ssh.AuthMethod{ssh.Password("bar")},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if bytes.Equal(key.Marshal(), hostKey) {
+				return nil
+			}
+			return fmt.Errorf("invalid host")
+		},
+	}
+	sshConnection, channels, requests, err := ssh.NewClientConn(
+		tcpConnection,
+		fmt.Sprintf("127.0.0.1:%d", port),
+		sshConfig,
+	)
+	if !assert.NoError(t, err) {
+		_ = tcpConnection.Close()
+		return
+	}
+	defer func() {
+		_ = sshConnection.Close()
+	}()
+	advertisedHostKeys := make(chan []byte, 1)
+	go func() {
+		for request := range requests {
+			if request.Type == "hostkeys-00@openssh.com" {
+				advertisedHostKeys <- request.Payload
+			}
+			if request.WantReply {
+				_ = request.Reply(false, nil)
+			}
+		}
+	}()
+	go func() {
+		for newChannel := range channels {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "not supported")
+		}
+	}()
+
+	select {
+	case advertised := <-advertisedHostKeys:
+		assert.Equal(
+			t,
+			ssh.Marshal(struct{ Current, Staged []byte }{hostKey, stagedKey.PublicKey().Marshal()}),
+			advertised,
+		)
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "the server did not advertise its host keys")
+		return
+	}
+
+	payload := ssh.Marshal(struct{ Key []byte }{stagedKey.PublicKey().Marshal()})
+	ok, response, err := sshConnection.SendRequest("hostkeys-prove-00@openssh.com", true, payload)
+	if !assert.NoError(t, err) || !assert.True(t, ok) {
+		return
+	}
+	var proof struct{ Signature []byte }
+	if !assert.NoError(t, ssh.Unmarshal(response, &proof)) {
+		return
+	}
+	signature := &ssh.Signature{}
+	if !assert.NoError(t, ssh.Unmarshal(proof.Signature, signature)) {
+		return
+	}
+	signedData := ssh.Marshal(
+		struct {
+			RequestType string
+			SessionID   []byte
+			HostKey     []byte
+		}{
+			"hostkeys-prove-00@openssh.com",
+			sshConnection.SessionID(),
+			stagedKey.PublicKey().Marshal(),
+		},
+	)
+	assert.NoError(t, stagedKey.PublicKey().Verify(signedData, signature))
+
+	unknownCfg := config.SSHConfig{}
+	if err := unknownCfg.GenerateHostKey(); err != nil {
+		assert.Fail(t, "failed to generate host key", err)
+		return
+	}
+	unknownKey, err := ssh.ParsePrivateKey([]byte(unknownCfg.HostKeys[0]))
+	if err != nil {
+		assert.Fail(t, "failed to parse host key", err)
+		return
+	}
+	payload = ssh.Marshal(struct{ Key []byte }{unknownKey.PublicKey().Marshal()})
+	ok, _, err = sshConnection.SendRequest("hostkeys-prove-00@openssh.com", true, payload)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestReload(t *testing.T) {
+	port := test.GetNextPort(t, "SSH")
+	server := newServerHelper(
+		t,
+		fmt.Sprintf("127.0.0.1:%d", port),
+		map[string][]byte{
+			"foo": []byte("bar"),
+		},
+		map[string]string{},
+	)
+	hostKey, err := server.start(t)
+	if err != nil {
+		assert.Fail(t, "failed to start ssh server", err)
+		return
+	}
+	defer func() {
+		server.stop()
+		<-server.shutdownChannel
+	}()
+
+	connect := func() (*ssh.Client, string, error) {
+		banner := ""
+		sshConfig := &ssh.ClientConfig{
+			User: "foo",
+			Auth: []ssh.AuthMethod{ssh.Password("bar")},
+			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+				if bytes.Equal(key.Marshal(), hostKey) {
+					return nil
+				}
+				return fmt.Errorf("invalid host")
+			},
+			BannerCallback: func(message string) error {
+				banner = message
+				return nil
+			},
+		}
+		sshConnection, err := ssh.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), sshConfig)
+		return sshConnection, banner, err
+	}
+
+	existingConnection, banner, err := connect()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = existingConnection.Close()
+	}()
+	assert.Equal(t, "", banner)
+
+	reloadedConfig := server.cfg
+	reloadedConfig.Banner = "Reloaded banner"
+	reloadedConfig.Listen = "127.0.0.1:1"
+	if !assert.NoError(t, server.server.Reload(reloadedConfig)) {
+		return
+	}
+
+	newConnection, banner, err := connect()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_ = newConnection.Close()
+	assert.Equal(t, "Reloaded banner", banner)
+
+	// The existing connection must keep working after the reload.
+	session, err := existingConnection.NewSession()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_ = session.Close()
+}
+
+func TestDrain(t *testing.T) {
+	port := test.GetNextPort(t, "SSH")
+	server := newServerHelper(
+		t,
+		fmt.Sprintf("127.0.0.1:%d", port),
+		map[string][]byte{
+			"foo": []byte("bar"),
+		},
+		map[string]string{},
+	)
+	server.drain = &config.SSHDrainConfig{
+		Timeout:         time.Minute,
+		WarningInterval: 10 * time.Second,
+		Message:         "Shutting down in {remaining}",
+	}
+	hostKey, err := server.start(t)
+	if err != nil {
+		assert.Fail(t, "failed to start ssh server", err)
+		return
+	}
+	defer func() {
+		server.stop()
+		<-server.shutdownChannel
+	}()
+
+	sshConfig := &ssh.ClientConfig{
+		User: "foo",
+		Auth: []ssh.AuthMethod{ssh.Password("bar")},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if bytes.Equal(key.Marshal(), hostKey) {
+				return nil
+			}
+			return fmt.Errorf("invalid host")
+		},
+	}
+	sshConnection, err := ssh.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), sshConfig)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() {
+		_ = sshConnection.Close()
+	}()
+	session, err := sshConnection.NewSession()
+	if !assert.NoError(t, err) {
+		return
+	}
+	stderr, err := session.StderrPipe()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.NoError(t, session.RequestPty("xterm", 25, 80, ssh.TerminalModes{})) {
+		return
+	}
+	if !assert.NoError(t, session.Shell()) {
+		return
+	}
+
+	drained := server.server.Drain()
+
+	notice := make([]byte, 1024)
+	n, err := stderr.Read(notice)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Contains(t, string(notice[:n]), "Shutting down in 1m0s")
+
+	_, err = ssh.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port), sshConfig)
+	assert.Error(t, err, "new connections should be rejected while draining")
+
+	select {
+	case <-drained:
+		assert.Fail(t, "drain finished while a connection was still open")
+		return
+	default:
+	}
+	_ = sshConnection.Close()
+	select {
+	case <-drained:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "drain did not finish after all connections were closed")
+	}
+}
+
 func TestKeepAlive(t *testing.T) {
 	//t.Parallel()()
 
@@ -372,14 +769,27 @@ func TestKeepAlive(t *testing.T) {
 		_ = connection.Close()
 	}()
 
-	req := <-globalReq
+	// The host key advertisement is sent right after the handshake and is not a keepalive.
+	nextRequest := func() *ssh.Request {
+		for req := range globalReq {
+			if req.Type == "hostkeys-00@openssh.com" {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			return req
+		}
+		t.Fatal("Connection closed before receiving a keepalive request")
+		return nil
+	}
+
+	req := nextRequest()
 	err = req.Reply(false, nil)
 	if err != nil {
 		t.Fatal("Failed to respond to first request")
 	}
 	recv1 := time.Now()
 
-	req2 := <-globalReq
+	req2 := nextRequest()
 	recv2 := time.Now()
 	err = req.Reply(false, nil)
 	if err != nil {
@@ -527,6 +937,11 @@ type serverHelper struct {
 	passwords       map[string][]byte
 	pubKeys         map[string]string
 	listen          string
+	listeners       []config.SSHListenerConfig
+	bannerTemplate  bool
+	stagedHostKeys  []string
+	drain           *config.SSHDrainConfig
+	cfg             config.SSHConfig
 	shutdownChannel chan struct{}
 	receivedChannel chan struct{}
 }
@@ -538,6 +953,12 @@ func (h *serverHelper) start(t *testing.T) (hostKey []byte, err error) {
 	cfg := config.SSHConfig{}
 	structutils.Defaults(&cfg)
 	cfg.Listen = h.listen
+	cfg.Listeners = h.listeners
+	cfg.BannerTemplate = h.bannerTemplate
+	cfg.StagedHostKeys = h.stagedHostKeys
+	if h.drain != nil {
+		cfg.Drain = *h.drain
+	}
 	if err := cfg.GenerateHostKey(); err != nil {
 		return nil, err
 	}
@@ -546,6 +967,7 @@ func (h *serverHelper) start(t *testing.T) (hostKey []byte, err error) {
 		return nil, err
 	}
 	hostKey = private.PublicKey().Marshal()
+	h.cfg = cfg
 	logger := log.NewTestLogger(t)
 	readyChannel := make(chan struct{}, 1)
 	h.shutdownChannel = make(chan struct{}, 1)
@@ -556,7 +978,7 @@ func (h *serverHelper) start(t *testing.T) (hostKey []byte, err error) {
 		h.passwords,
 		h.pubKeys,
 	)
-	server, err := sshserver.New(cfg, handler, logger)
+	server, err := sshserver.New(cfg, handler, logger, nil)
 	if err != nil {
 		return hostKey, err
 	}
@@ -818,6 +1240,18 @@ func (f *fullSessionChannelHandler) OnShell(
 	return nil
 }
 
+func (f *fullSessionChannelHandler) OnPtyRequest(
+	_ uint64,
+	_ string,
+	_ uint32,
+	_ uint32,
+	_ uint32,
+	_ uint32,
+	_ []byte,
+) error {
+	return nil
+}
+
 func (f *fullSessionChannelHandler) OnSignal(_ uint64, _ string) error {
 	return nil
 }
@@ -837,6 +1271,13 @@ func (s *fullSessionChannelHandler) OnX11Request(
 	return nil
 }
 
+func (s *fullSessionChannelHandler) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return nil
+}
+
 func (f *fullSessionChannelHandler) OnShutdown(_ context.Context) {
 	_ = f.session.Close()
 }
@@ -0,0 +1,74 @@
+package sshserver
+
+import (
+	"bytes"
+	"text/template"
+
+	"go.containerssh.io/containerssh/log"
+	messageCodes "go.containerssh.io/containerssh/message"
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+// BannerData is the data available in the banner template.
+type BannerData struct {
+	// RemoteAddress is the IP address of the client, or "unix" for clients connected through a Unix socket.
+	RemoteAddress string
+	// Country is the ISO country code of the client from the GeoIP lookup, or XX if it is not known.
+	Country string
+	// ServerVersion is the SSH version string of the server.
+	ServerVersion string
+	// Listener is the name of the listener the client connected to.
+	Listener string
+	// ConnectionID is the unique identifier of the connection.
+	ConnectionID string
+	// Username is the username the client requested.
+	Username string
+}
+
+// parseBanner parses a banner template. An empty banner, or a banner that is not a template, results in a nil
+// template.
+func parseBanner(banner string, isTemplate bool) (*template.Template, error) {
+	if banner == "" || !isTemplate {
+		return nil, nil
+	}
+	return template.New("banner").Parse(banner)
+}
+
+// banner renders the banner template of the listener, if any, and passes the result to the handler, which may replace
+// it.
+func (s *serverImpl) banner(
+	serverVersion string,
+	l *listener,
+	meta metadata.ConnectionMetadata,
+	conn ssh.ConnMetadata,
+	handlerNetworkConnection NetworkConnectionHandler,
+	logger log.Logger,
+) string {
+	banner := l.banner
+	if l.bannerTemplate != nil {
+		banner = ""
+		country := "XX"
+		if s.geoIP != nil && !meta.RemoteAddress.IsUnix() {
+			country = s.geoIP.Lookup(meta.RemoteAddress.IP)
+		}
+		data := BannerData{
+			RemoteAddress: meta.RemoteAddress.Host(),
+			Country:       country,
+			ServerVersion: serverVersion,
+			Listener:      l.name,
+			ConnectionID:  meta.ConnectionID,
+			Username:      conn.User(),
+		}
+		buffer := &bytes.Buffer{}
+		if err := l.bannerTemplate.Execute(buffer, data); err != nil {
+			logger.Warning(messageCodes.Wrap(err, messageCodes.ESSHBannerFailed, "Failed to render banner template"))
+		} else {
+			banner = buffer.String()
+		}
+	}
+	return handlerNetworkConnection.OnBanner(
+		meta.StartAuthentication(string(conn.ClientVersion()), conn.User()),
+		banner,
+	)
+}
@@ -20,6 +20,8 @@ type channelWrapper struct {
 	exitSignalSent bool
 	closedWrite    bool
 	closed         bool
+	// interactive is set when the client requested a pty. Drain messages are only written to interactive sessions.
+	interactive bool
 }
 
 func (c *channelWrapper) Stdin() io.Reader {
@@ -141,3 +143,21 @@ func (c *channelWrapper) onClose() {
 	defer c.lock.Unlock()
 	c.closed = true
 }
+
+func (c *channelWrapper) setInteractive() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.interactive = true
+}
+
+// writeNotice writes a message to the stderr of an interactive session. Non-interactive sessions are left alone since
+// the output may be processed by a program.
+func (c *channelWrapper) writeNotice(message string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.interactive || c.closed || c.closedWrite || c.channel == nil {
+		return nil
+	}
+	_, err := c.channel.Stderr().Write([]byte(message))
+	return err
+}
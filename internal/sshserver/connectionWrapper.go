@@ -0,0 +1,23 @@
+package sshserver
+
+import (
+    "go.containerssh.io/containerssh/log"
+    messageCodes "go.containerssh.io/containerssh/message"
+	"golang.org/x/crypto/ssh"
+)
+
+type connectionWrapper struct {
+	sshConn *ssh.ServerConn
+	logger  log.Logger
+}
+
+func (c *connectionWrapper) Terminate(reason string) error {
+	c.logger.Debug(
+		messageCodes.NewMessage(
+			messageCodes.MSSHSessionTerminated,
+			"Closing connection: %s",
+			reason,
+		),
+	)
+	return c.sshConn.Close()
+}
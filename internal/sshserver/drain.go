@@ -0,0 +1,119 @@
+package sshserver
+
+import (
+	"strings"
+	"time"
+
+	"go.containerssh.io/containerssh/config"
+	messageCodes "go.containerssh.io/containerssh/message"
+)
+
+// drainCheckInterval is the interval at which the server checks if all connections have been closed while draining.
+const drainCheckInterval = time.Second
+
+func (s *serverImpl) Drain() <-chan struct{} {
+	s.lock.Lock()
+	if s.drained != nil {
+		drained := s.drained
+		s.lock.Unlock()
+		return drained
+	}
+	drained := make(chan struct{})
+	s.drained = drained
+	cfg := s.cfg.Drain
+	listenSockets := s.listenSockets
+	s.listenSockets = nil
+	s.lock.Unlock()
+
+	closeListeners(listenSockets)
+	s.logger.Notice(
+		messageCodes.NewMessage(
+			messageCodes.MSSHDrainStarted,
+			"SSH server is draining, new connections are no longer accepted and existing connections will be closed in %s",
+			cfg.Timeout,
+		),
+	)
+	go s.drain(cfg, drained)
+	return drained
+}
+
+func (s *serverImpl) isDraining() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.drained != nil
+}
+
+func (s *serverImpl) drain(cfg config.SSHDrainConfig, drained chan struct{}) {
+	defer close(drained)
+	deadline := time.Now().Add(cfg.Timeout)
+	timeout := time.NewTimer(cfg.Timeout)
+	defer timeout.Stop()
+	check := time.NewTicker(drainCheckInterval)
+	defer check.Stop()
+	var warning <-chan time.Time
+	if cfg.WarningInterval > 0 {
+		warningTicker := time.NewTicker(cfg.WarningInterval)
+		defer warningTicker.Stop()
+		warning = warningTicker.C
+	}
+
+	s.sendDrainNotice(cfg.Message, deadline)
+	for {
+		select {
+		case <-timeout.C:
+			s.logger.Notice(
+				messageCodes.NewMessage(
+					messageCodes.MSSHDrainFinished,
+					"Drain timeout expired, closing the remaining connections",
+				),
+			)
+			return
+		case <-check.C:
+			if s.connectionCount() == 0 {
+				s.logger.Notice(
+					messageCodes.NewMessage(messageCodes.MSSHDrainFinished, "All connections closed, drain finished"),
+				)
+				return
+			}
+		case <-warning:
+			s.sendDrainNotice(cfg.Message, deadline)
+		}
+	}
+}
+
+func (s *serverImpl) connectionCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.clientSockets)
+}
+
+// sendDrainNotice writes the drain message to all interactive sessions.
+func (s *serverImpl) sendDrainNotice(message string, deadline time.Time) {
+	if message == "" {
+		return
+	}
+	remaining := time.Until(deadline).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+	notice := "\r\n" + strings.ReplaceAll(message, config.SSHDrainRemainingPlaceholder, remaining.String()) + "\r\n"
+
+	s.lock.Lock()
+	sessions := make([]*channelWrapper, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.lock.Unlock()
+
+	for _, session := range sessions {
+		if err := session.writeNotice(notice); err != nil {
+			session.logger.Debug(
+				messageCodes.Wrap(
+					err,
+					messageCodes.ESSHDrainNoticeFailed,
+					"Failed to write drain message to session",
+				),
+			)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package sshserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 
@@ -44,8 +45,17 @@ const (
 	// AuthResponseUnavailable indicates that the authentication could not be performed because a backend system failed
 	//                         to respond.
 	AuthResponseUnavailable AuthResponse = 3
+
+	// AuthResponsePartialSuccess indicates that the credentials were valid, but the user must also pass further
+	//                            authentication methods before they are authenticated. The methods passed so far
+	//                            are listed in the AuthenticatedMethods field of the metadata of later attempts.
+	AuthResponsePartialSuccess AuthResponse = 4
 )
 
+// ErrAuthPartialSuccess can be returned by GSSAPIServer.AllowLogin to indicate that the user passed GSSAPI
+// authentication, but must also pass further authentication methods.
+var ErrAuthPartialSuccess = errors.New("partial success")
+
 // KeyboardInteractiveQuestion contains a question issued to a user as part of the keyboard-interactive exchange.
 type KeyboardInteractiveQuestion struct {
 	// ID is an optional opaque ID that can be used to identify a question in an answer. Can be left empty.
@@ -54,6 +64,9 @@ type KeyboardInteractiveQuestion struct {
 	Question string
 	// EchoResponse should be set to true to show the typed response to the user.
 	EchoResponse bool
+	// Sensitive should be set to true if the answer is a secret, such as a one-time password, which must not be
+	// recorded in the audit log.
+	Sensitive bool
 }
 
 func (k *KeyboardInteractiveQuestion) getID() string {
@@ -95,8 +108,14 @@ func (k *KeyboardInteractiveAnswers) GetByQuestionText(question string) (string,
 // NetworkConnectionHandler is an object that is used to represent the underlying network connection and the SSH
 // handshake.
 type NetworkConnectionHandler interface {
+	// OnBanner is called before authentication to obtain the banner sent to the client. The banner parameter contains
+	// the banner rendered from the configured template. The implementation may replace it, for example with a banner
+	// fetched from the authentication server, or return it unchanged. An empty banner is not sent to the client.
+	OnBanner(meta metadata.ConnectionAuthPendingMetadata, banner string) string
+
 	// OnAuthPassword is called when a user attempts a password authentication. The implementation must always supply
-	// AuthResponse and may supply error as a reason description.
+	// AuthResponse and may supply error as a reason description. If it returns AuthResponsePartialSuccess the user must
+	// pass another method next.
 	OnAuthPassword(meta metadata.ConnectionAuthPendingMetadata, password []byte) (
 		AuthResponse,
 		metadata.ConnectionAuthenticatedMetadata,
@@ -105,7 +124,8 @@ type NetworkConnectionHandler interface {
 
 	// OnAuthPubKey is called when a user attempts a pubkey authentication. The implementation must always supply
 	// AuthResponse and may supply error as a reason description. The pubKey parameter is an SSH key in
-	// the form of "ssh-rsa KEY HERE".
+	// the form of "ssh-rsa KEY HERE". If it returns AuthResponsePartialSuccess the user must pass another method next
+	// once the client has proven that it holds the private key.
 	OnAuthPubKey(meta metadata.ConnectionAuthPendingMetadata, pubKey auth.PublicKey) (
 		AuthResponse,
 		metadata.ConnectionAuthenticatedMetadata,
@@ -123,7 +143,8 @@ type NetworkConnectionHandler interface {
 		) (answers KeyboardInteractiveAnswers, err error),
 	) (AuthResponse, metadata.ConnectionAuthenticatedMetadata, error)
 
-	// OnAuthGSSAPI returns a GSSAPIServer which can perform a GSSAPI authentication.
+	// OnAuthGSSAPI returns a GSSAPIServer which can perform a GSSAPI authentication. Its AllowLogin method may return
+	// ErrAuthPartialSuccess if the user must pass another method next.
 	OnAuthGSSAPI(metadata metadata.ConnectionMetadata) auth2.GSSAPIServer
 
 	// OnHandshakeFailed is called when the SSH handshake failed. This method is also called after an authentication
@@ -180,6 +201,7 @@ const (
 	ChannelTypeDirectTCPIP          string = "direct-tcpip"
 	ChannelTypeReverseForward       string = "forwarded-tcpip"
 	ChannelTypeX11                  string = "x11"
+	ChannelTypeAuthAgent            string = "auth-agent@openssh.com"
 	ChannelTypeDirectStreamLocal    string = "direct-streamlocal@openssh.com"
 	ChannelTypeForwardedStreamLocal string = "forwarded-streamlocal@openssh.com"
 )
@@ -202,6 +224,8 @@ type ReverseForward interface {
 	// originatorAddress is the address that initiated the X11 request
 	// originatorPort is the port that originated the X11 request
 	NewChannelX11(originatorAddress string, originatorPort uint32) (ForwardChannel, uint64, error)
+	// NewChannelAuthAgent requests the opening of a channel to the SSH agent of the client
+	NewChannelAuthAgent() (ForwardChannel, uint64, error)
 }
 
 // ForwardChannel represents a network forwarding channel
@@ -213,8 +237,20 @@ type ForwardChannel interface {
 	Close() error
 }
 
+// SSHConnection contains a set of calls that can be used to manipulate an established SSH connection.
+type SSHConnection interface {
+	// Terminate closes the connection because of a session policy, such as an idle timeout. The reason is recorded in
+	// the audit log.
+	Terminate(reason string) error
+}
+
 // SSHConnectionHandler represents an established SSH connection that is ready to receive requests.
 type SSHConnectionHandler interface {
+	// OnConnection is called once after the handshake, before any channels or global requests are handled.
+	//
+	// connection contains a set of calls that can be used to manipulate the SSH connection.
+	OnConnection(connection SSHConnection)
+
 	// OnUnsupportedGlobalRequest captures all global SSH requests and gives the implementation an opportunity to log
 	//                            the request.
 	//
@@ -393,6 +429,18 @@ type SessionChannelHandler interface {
 		reverseHandler ReverseForward,
 	) error
 
+	// OnAuthAgentRequest is called when the client requests the forwarding of its SSH agent to the container. The
+	// implementation should expose the agent in the container, typically via SSH_AUTH_SOCK, and open a new channel via
+	// reverseHandler for each connection to it. This method may be called after a program is started. The
+	// implementation can return an error to reject the request.
+	//
+	// requestID is an incrementing number uniquely identifying the request within the channel.
+	// reverseHandler is a callback interface to signal when new connections are made
+	OnAuthAgentRequest(
+		requestID uint64,
+		reverseHandler ReverseForward,
+	) error
+
 	// endregion
 
 	// region Program execution
@@ -444,6 +492,16 @@ type SessionChannelHandler interface {
 		signal string,
 	) error
 
+	// OnBreak is called when the client requests a break to be sent to the terminal of the running process as
+	//         described in RFC 4335. The implementation can return an error to reject the request.
+	//
+	// requestID is an incrementing number uniquely identifying this request within the channel.
+	// lengthMs is the requested length of the break in milliseconds.
+	OnBreak(
+		requestID uint64,
+		lengthMs uint32,
+	) error
+
 	// OnWindow is called when the client requests the window size to be changed. This method may be called
 	//          after a program is started. The implementation can return an error to reject the request.
 	//
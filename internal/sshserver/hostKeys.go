@@ -0,0 +1,164 @@
+package sshserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"go.containerssh.io/containerssh/log"
+	messageCodes "go.containerssh.io/containerssh/message"
+	"golang.org/x/crypto/ssh"
+)
+
+// requestTypeHostKeys is the OpenSSH extension to advertise all host keys to the client after authentication. Clients
+// with UpdateHostKeys enabled use it to learn new keys before they are used for the handshake.
+const requestTypeHostKeys = "hostkeys-00@openssh.com"
+
+// requestTypeHostKeysProve is sent by the client to request proof that the server holds the private keys of the
+// advertised host keys it doesn't know yet.
+const requestTypeHostKeysProve = "hostkeys-prove-00@openssh.com"
+
+// advertisedHostKeys returns the plain host keys and the staged host keys of the listener. Certificates are not
+// advertised since OpenSSH only records plain keys in known_hosts.
+func (l *listener) advertisedHostKeys() []ssh.Signer {
+	var result []ssh.Signer
+	seen := map[string]bool{}
+	for _, signers := range [][]ssh.Signer{l.hostKeys, l.stagedHostKeys} {
+		for _, signer := range signers {
+			if _, ok := signer.PublicKey().(*ssh.Certificate); ok {
+				continue
+			}
+			key := string(signer.PublicKey().Marshal())
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, signer)
+		}
+	}
+	return result
+}
+
+// sendHostKeys advertises all host keys of the listener to the client. The request does not need a reply, clients
+// that don't support it will ignore it.
+func (s *serverImpl) sendHostKeys(sshConn *ssh.ServerConn, l *listener, logger log.Logger) {
+	var payload []byte
+	for _, signer := range l.advertisedHostKeys() {
+		payload = appendSSHString(payload, signer.PublicKey().Marshal())
+	}
+	if len(payload) == 0 {
+		return
+	}
+	if _, _, err := sshConn.SendRequest(requestTypeHostKeys, false, payload); err != nil {
+		logger.Debug(
+			messageCodes.Wrap(
+				err,
+				messageCodes.ESSHHostKeysAdvertiseFailed,
+				"failed to advertise host keys",
+			),
+		)
+	}
+}
+
+// handleHostKeysProveRequest signs the session ID with each requested host key so the client can verify that the
+// server holds the private keys before adding them to known_hosts.
+func (s *serverImpl) handleHostKeysProveRequest(
+	sshConn *ssh.ServerConn,
+	l *listener,
+	req *ssh.Request,
+	logger log.Logger,
+) {
+	response, err := proveHostKeys(l.advertisedHostKeys(), sshConn.SessionID(), req.Payload)
+	if err != nil {
+		logger.Debug(
+			messageCodes.Wrap(
+				err,
+				messageCodes.ESSHHostKeysProveFailed,
+				"failed to prove host key possession",
+			),
+		)
+	}
+	if !req.WantReply {
+		return
+	}
+	if err := req.Reply(err == nil, response); err != nil {
+		logger.Debug(
+			messageCodes.Wrap(
+				err,
+				messageCodes.ESSHReplyFailed,
+				"failed to send reply to global request type %s",
+				req.Type,
+			),
+		)
+	}
+}
+
+func proveHostKeys(signers []ssh.Signer, sessionID []byte, payload []byte) ([]byte, error) {
+	var response []byte
+	for len(payload) > 0 {
+		keyBlob, rest, ok := parseSSHString(payload)
+		if !ok {
+			return nil, fmt.Errorf("malformed host key prove request")
+		}
+		payload = rest
+		signer := findHostKey(signers, keyBlob)
+		if signer == nil {
+			return nil, fmt.Errorf("the client requested a proof for a host key that is not configured")
+		}
+		data := ssh.Marshal(
+			struct {
+				RequestType string
+				SessionID   []byte
+				HostKey     []byte
+			}{
+				RequestType: requestTypeHostKeysProve,
+				SessionID:   sessionID,
+				HostKey:     keyBlob,
+			},
+		)
+		signature, err := signHostKeyProof(signer, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign host key proof (%w)", err)
+		}
+		response = appendSSHString(response, ssh.Marshal(signature))
+	}
+	return response, nil
+}
+
+// signHostKeyProof signs the proof with the host key. RSA keys use rsa-sha2-512 since OpenSSH clients reject the
+// legacy SHA-1 signatures and prefer SHA-512 when negotiating the host key algorithm.
+func signHostKeyProof(signer ssh.Signer, data []byte) (*ssh.Signature, error) {
+	if signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok {
+			return algorithmSigner.SignWithAlgorithm(rand.Reader, data, ssh.KeyAlgoRSASHA512)
+		}
+	}
+	return signer.Sign(rand.Reader, data)
+}
+
+func findHostKey(signers []ssh.Signer, keyBlob []byte) ssh.Signer {
+	for _, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), keyBlob) {
+			return signer
+		}
+	}
+	return nil
+}
+
+func appendSSHString(target []byte, data []byte) []byte {
+	target = binary.BigEndian.AppendUint32(target, uint32(len(data)))
+	return append(target, data...)
+}
+
+func parseSSHString(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 4 {
+		return nil, nil, false
+	}
+	length := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint32(len(data)) < length {
+		return nil, nil, false
+	}
+	return data[:length], data[length:], true
+}
@@ -0,0 +1,151 @@
+package sshserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"text/template"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/proxyprotocol"
+	"golang.org/x/crypto/ssh"
+)
+
+// listener is a configured entry point of the SSH server with its own host keys and banner.
+type listener struct {
+	name       string
+	listenType config.SSHListenerType
+	listen     string
+	hostKeys   []ssh.Signer
+	// stagedHostKeys are only advertised to clients after authentication so they can be rotated in later.
+	stagedHostKeys []ssh.Signer
+	banner         string
+	// bannerTemplate is set instead of banner if banner templates are enabled.
+	bannerTemplate *template.Template
+}
+
+func (l *listener) String() string {
+	switch l.listenType {
+	case config.SSHListenerTypeUnix:
+		return "unix:" + l.listen
+	case config.SSHListenerTypeSystemd:
+		return "systemd:" + l.listen
+	default:
+		return l.listen
+	}
+}
+
+// newListeners creates the listeners from the listen option and the additional listeners in the configuration.
+func newListeners(cfg config.SSHConfig) ([]*listener, error) {
+	var listeners []*listener
+	if cfg.Listen != "" {
+		hostKeys, err := cfg.LoadHostKeys()
+		if err != nil {
+			return nil, err
+		}
+		stagedHostKeys, err := cfg.LoadStagedHostKeys()
+		if err != nil {
+			return nil, err
+		}
+		bannerTemplate, err := parseBanner(cfg.Banner, cfg.BannerTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse banner (%w)", err)
+		}
+		listeners = append(listeners, &listener{
+			name:           config.SSHDefaultListenerName,
+			listenType:     config.SSHListenerTypeTCP,
+			listen:         cfg.Listen,
+			hostKeys:       hostKeys,
+			stagedHostKeys: stagedHostKeys,
+			banner:         cfg.Banner,
+			bannerTemplate: bannerTemplate,
+		})
+	}
+	for _, listenerConfig := range cfg.Listeners {
+		hostKeys, err := listenerConfig.LoadHostKeys(cfg.HostKeys, cfg.HostCertificates)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load host keys for listener %s (%w)", listenerConfig.Name, err)
+		}
+		stagedHostKeys, err := listenerConfig.LoadStagedHostKeys(cfg.StagedHostKeys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load staged host keys for listener %s (%w)", listenerConfig.Name, err)
+		}
+		banner := listenerConfig.Banner
+		if banner == "" {
+			banner = cfg.Banner
+		}
+		bannerTemplate, err := parseBanner(banner, cfg.BannerTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse banner for listener %s (%w)", listenerConfig.Name, err)
+		}
+		listenType := listenerConfig.Type
+		if listenType == "" {
+			listenType = config.SSHListenerTypeTCP
+		}
+		listeners = append(listeners, &listener{
+			name:           listenerConfig.Name,
+			listenType:     listenType,
+			listen:         listenerConfig.Listen,
+			hostKeys:       hostKeys,
+			stagedHostKeys: stagedHostKeys,
+			banner:         banner,
+			bannerTemplate: bannerTemplate,
+		})
+	}
+	return listeners, nil
+}
+
+// openListener opens the sockets for a listener. Systemd listeners may result in more than one socket.
+func (s *serverImpl) openListener(ctx context.Context, l *listener) ([]net.Listener, error) {
+	var netListeners []net.Listener
+	switch l.listenType {
+	case config.SSHListenerTypeUnix:
+		if stat, err := os.Stat(l.listen); err == nil && stat.Mode().Type() == fs.ModeSocket {
+			// Remove the stale socket from a previous run.
+			if err := os.Remove(l.listen); err != nil {
+				return nil, err
+			}
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		listenConfig := net.ListenConfig{}
+		netListener, err := listenConfig.Listen(ctx, "unix", l.listen)
+		if err != nil {
+			return nil, err
+		}
+		netListeners = append(netListeners, netListener)
+	case config.SSHListenerTypeSystemd:
+		var err error
+		netListeners, err = systemdListeners(l.listen)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		listenConfig := net.ListenConfig{
+			Control: s.socketControl,
+		}
+		netListener, err := listenConfig.Listen(ctx, "tcp", l.listen)
+		if err != nil {
+			return nil, err
+		}
+		netListeners = append(netListeners, netListener)
+	}
+	for i, netListener := range netListeners {
+		proxyListener, err := proxyprotocol.NewListener(netListener, s.cfg.ProxyProtocol)
+		if err != nil {
+			closeListeners(netListeners)
+			return nil, err
+		}
+		netListeners[i] = proxyListener
+	}
+	return netListeners, nil
+}
+
+func closeListeners(netListeners []net.Listener) {
+	for _, netListener := range netListeners {
+		_ = netListener.Close()
+	}
+}
@@ -0,0 +1,82 @@
+package sshserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// systemdListenFDsStart is the first file descriptor passed by systemd socket activation.
+const systemdListenFDsStart = 3
+
+var systemdFilesOnce sync.Once
+var systemdFiles map[string][]*os.File
+var systemdFilesError error
+
+// loadSystemdFiles reads the sockets passed by systemd according to the sd_listen_fds(3) protocol. The environment
+// variables are only read once since the file descriptors belong to the process.
+func loadSystemdFiles() (map[string][]*os.File, error) {
+	systemdFilesOnce.Do(func() {
+		systemdFiles = map[string][]*os.File{}
+		pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+		if err != nil || pid != os.Getpid() {
+			systemdFilesError = fmt.Errorf("no sockets were passed by systemd (LISTEN_PID is not set to this process)")
+			return
+		}
+		fdCount, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil || fdCount <= 0 {
+			systemdFilesError = fmt.Errorf("no sockets were passed by systemd (invalid LISTEN_FDS)")
+			return
+		}
+		var names []string
+		if fdNames := os.Getenv("LISTEN_FDNAMES"); fdNames != "" {
+			names = strings.Split(fdNames, ":")
+		}
+		for i := 0; i < fdCount; i++ {
+			name := "unknown"
+			if i < len(names) {
+				name = names[i]
+			}
+			fd := systemdListenFDsStart + i
+			systemdFiles[name] = append(systemdFiles[name], os.NewFile(uintptr(fd), name))
+		}
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	})
+	return systemdFiles, systemdFilesError
+}
+
+// systemdListeners returns listeners for the sockets passed by systemd with the specified FileDescriptorName, or all
+// sockets if the name is empty. The listeners use a duplicate of the passed file descriptor so the server can be
+// restarted within the same process.
+func systemdListeners(name string) ([]net.Listener, error) {
+	files, err := loadSystemdFiles()
+	if err != nil {
+		return nil, err
+	}
+	var selected []*os.File
+	if name == "" {
+		for _, namedFiles := range files {
+			selected = append(selected, namedFiles...)
+		}
+	} else {
+		selected = files[name]
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no socket named %s was passed by systemd", name)
+	}
+	var netListeners []net.Listener
+	for _, file := range selected {
+		netListener, err := net.FileListener(file)
+		if err != nil {
+			closeListeners(netListeners)
+			return nil, fmt.Errorf("failed to use socket %s passed by systemd (%w)", file.Name(), err)
+		}
+		netListeners = append(netListeners, netListener)
+	}
+	return netListeners, nil
+}
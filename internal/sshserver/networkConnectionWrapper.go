@@ -12,6 +12,11 @@ type networkConnectionWrapper struct {
 
 	authenticatedMetadata metadata.ConnectionAuthenticatedMetadata
 	sshConnectionHandler  SSHConnectionHandler
+
+	// partialMetadata is the metadata returned by the last method that resulted in a partial success.
+	partialMetadata *metadata.ConnectionAuthenticatedMetadata
+	// authenticatedMethods lists the methods that resulted in a partial success.
+	authenticatedMethods []string
 }
 
 func (n *networkConnectionWrapper) OnShutdown(shutdownContext context.Context) {
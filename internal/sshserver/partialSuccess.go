@@ -0,0 +1,67 @@
+package sshserver
+
+import (
+	"maps"
+	"slices"
+
+	"go.containerssh.io/containerssh/metadata"
+	"golang.org/x/crypto/ssh"
+)
+
+// These are the names of the authentication methods in the SSH protocol.
+const (
+	methodPassword            = "password"
+	methodPublicKey           = "publickey"
+	methodKeyboardInteractive = "keyboard-interactive"
+	methodGSSAPI              = "gssapi-with-mic"
+)
+
+// extensionPartialSuccess marks the permissions of a public key that resulted in a partial success.
+const extensionPartialSuccess = "containerssh-partial-success"
+
+// startAuthentication creates the metadata for an authentication attempt. After a partial success the metadata
+// contains the metadata returned by the previous methods and the list of methods passed so far.
+func (n *networkConnectionWrapper) startAuthentication(
+	connectionMetadata metadata.ConnectionMetadata,
+	conn ssh.ConnMetadata,
+) metadata.ConnectionAuthPendingMetadata {
+	if n.partialMetadata == nil {
+		return connectionMetadata.StartAuthentication(string(conn.ClientVersion()), conn.User())
+	}
+	// The maps are copied so a failed attempt cannot change the metadata of the next one.
+	pending := n.partialMetadata.ConnectionAuthPendingMetadata
+	pending.Metadata = maps.Clone(pending.Metadata)
+	pending.Environment = maps.Clone(pending.Environment)
+	pending.Files = maps.Clone(pending.Files)
+	pending.AuthenticatedMethods = slices.Clone(n.authenticatedMethods)
+	return pending
+}
+
+// partialSuccess records that the method succeeded and returns the error telling the client to continue with one of
+// the methods it has not passed yet. The SSH library does not let the client change the username after a partial
+// success.
+func (n *networkConnectionWrapper) partialSuccess(
+	method string,
+	authenticatedMetadata metadata.ConnectionAuthenticatedMetadata,
+	callbacks *ssh.ServerAuthCallbacks,
+) error {
+	n.partialMetadata = &authenticatedMetadata
+	if !slices.Contains(n.authenticatedMethods, method) {
+		n.authenticatedMethods = append(n.authenticatedMethods, method)
+	}
+
+	next := ssh.ServerAuthCallbacks{}
+	if !slices.Contains(n.authenticatedMethods, methodPassword) {
+		next.PasswordCallback = callbacks.PasswordCallback
+	}
+	if !slices.Contains(n.authenticatedMethods, methodPublicKey) {
+		next.PublicKeyCallback = callbacks.PublicKeyCallback
+	}
+	if !slices.Contains(n.authenticatedMethods, methodKeyboardInteractive) {
+		next.KeyboardInteractiveCallback = callbacks.KeyboardInteractiveCallback
+	}
+	if !slices.Contains(n.authenticatedMethods, methodGSSAPI) {
+		next.GSSAPIWithMICConfig = callbacks.GSSAPIWithMICConfig
+	}
+	return &ssh.PartialSuccessError{Next: next}
+}
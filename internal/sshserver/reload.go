@@ -0,0 +1,59 @@
+package sshserver
+
+import (
+	"go.containerssh.io/containerssh/config"
+)
+
+func (s *serverImpl) Reload(cfg config.SSHConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	reloadedListeners, err := newListeners(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// The sockets, the connection limits and the PROXY protocol wrapper are set up when the server starts, so these
+	// settings are kept until the next restart.
+	cfg.Listen = s.cfg.Listen
+	cfg.Listeners = s.cfg.Listeners
+	cfg.Limits = s.cfg.Limits
+	cfg.ProxyProtocol = s.cfg.ProxyProtocol
+
+	listeners := make([]*listener, len(s.listeners))
+	for i, current := range s.listeners {
+		listeners[i] = current
+		for _, reloaded := range reloadedListeners {
+			if reloaded.name != current.name {
+				continue
+			}
+			listeners[i] = &listener{
+				name:           current.name,
+				listenType:     current.listenType,
+				listen:         current.listen,
+				hostKeys:       reloaded.hostKeys,
+				stagedHostKeys: reloaded.stagedHostKeys,
+				banner:         reloaded.banner,
+			}
+		}
+	}
+	s.cfg = cfg
+	s.listeners = listeners
+	return nil
+}
+
+// settings returns the current configuration and the current version of the listener a connection was accepted on.
+// Both may be replaced by Reload, so each connection takes a snapshot when it is accepted.
+func (s *serverImpl) settings(l *listener) (config.SSHConfig, *listener) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, current := range s.listeners {
+		if current.name == l.name {
+			return s.cfg, current
+		}
+	}
+	return s.cfg, l
+}
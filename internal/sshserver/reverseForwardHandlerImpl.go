@@ -50,6 +50,10 @@ func (r *ReverseForwardHandler) NewChannelX11(
 	return r.openChannel(ChannelTypeX11, mar)
 }
 
+func (r *ReverseForwardHandler) NewChannelAuthAgent() (ForwardChannel, uint64, error) {
+	return r.openChannel(ChannelTypeAuthAgent, nil)
+}
+
 func (r *ReverseForwardHandler) openChannel(
 	channelType string,
 	payload []byte,
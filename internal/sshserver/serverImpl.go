@@ -14,6 +14,8 @@ import (
 	protocol "go.containerssh.io/containerssh/agentprotocol"
 	"go.containerssh.io/containerssh/auth"
 	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/geoip/geoipprovider"
+	"go.containerssh.io/containerssh/internal/proxyprotocol"
 	ssh2 "go.containerssh.io/containerssh/internal/ssh"
 	"go.containerssh.io/containerssh/log"
 	messageCodes "go.containerssh.io/containerssh/message"
@@ -31,16 +33,19 @@ type serverImpl struct {
 	cfg                 config.SSHConfig
 	logger              log.Logger
 	handler             Handler
-	listenSocket        net.Listener
+	listeners           []*listener
+	listenSockets       []net.Listener
 	wg                  *sync.WaitGroup
 	lock                *sync.Mutex
 	clientSockets       map[*ssh.ServerConn]bool
 	connMap             map[string]connection
 	nextGlobalRequestID uint64
 	nextChannelID       uint64
-	hostKeys            []ssh.Signer
 	shutdownHandlers    *shutdownRegistry
 	shuttingDown        bool
+	sessions            map[string]*channelWrapper
+	drained             chan struct{}
+	geoIP               geoipprovider.LookupProvider
 }
 
 func (s *serverImpl) String() string {
@@ -50,11 +55,13 @@ func (s *serverImpl) String() string {
 func (s *serverImpl) RunWithLifecycle(lifecycle service.Lifecycle) error {
 	s.lock.Lock()
 	alreadyRunning := false
-	if s.listenSocket != nil {
+	if s.listenSockets != nil {
 		alreadyRunning = true
 	} else {
 		s.clientSockets = make(map[*ssh.ServerConn]bool)
 		s.connMap = make(map[string]connection)
+		s.sessions = make(map[string]*channelWrapper)
+		s.drained = nil
 	}
 	s.shuttingDown = false
 	if alreadyRunning {
@@ -62,41 +69,58 @@ func (s *serverImpl) RunWithLifecycle(lifecycle service.Lifecycle) error {
 		return messageCodes.NewMessage(messageCodes.ESSHAlreadyRunning, "SSH server is already running")
 	}
 
-	listenConfig := net.ListenConfig{
-		Control: s.socketControl,
-	}
-
-	netListener, err := listenConfig.Listen(lifecycle.Context(), "tcp", s.cfg.Listen)
-	if err != nil {
-		s.lock.Unlock()
-		return messageCodes.Wrap(err, messageCodes.ESSHStartFailed, "failed to start SSH server on %s", s.cfg.Listen)
+	var listenSockets []net.Listener
+	socketListeners := map[net.Listener]*listener{}
+	listeners := s.listeners
+	for _, l := range listeners {
+		netListeners, err := s.openListener(lifecycle.Context(), l)
+		if err != nil {
+			closeListeners(listenSockets)
+			s.lock.Unlock()
+			return messageCodes.Wrap(err, messageCodes.ESSHStartFailed, "failed to start SSH server on %s", l)
+		}
+		for _, netListener := range netListeners {
+			socketListeners[netListener] = l
+		}
+		listenSockets = append(listenSockets, netListeners...)
 	}
-	s.listenSocket = netListener
+	s.listenSockets = listenSockets
 	s.lock.Unlock()
 	if err := s.handler.OnReady(); err != nil {
-		if err := netListener.Close(); err != nil {
-			s.logger.Warning(
-				messageCodes.Wrap(
-					err,
-					messageCodes.ESSHListenCloseFailed,
-					"failed to close listen socket after failed startup",
-				),
-			)
+		s.lock.Lock()
+		s.listenSockets = nil
+		s.lock.Unlock()
+		for _, netListener := range listenSockets {
+			if err := netListener.Close(); err != nil {
+				s.logger.Warning(
+					messageCodes.Wrap(
+						err,
+						messageCodes.ESSHListenCloseFailed,
+						"failed to close listen socket after failed startup",
+					),
+				)
+			}
 		}
 		return err
 	}
 	lifecycle.Running()
-	s.logger.Info(messageCodes.NewMessage(messageCodes.MSSHServiceAvailable, "SSH server running on %s", s.cfg.Listen))
+	for _, l := range listeners {
+		s.logger.WithLabel("listener", l.name).Info(
+			messageCodes.NewMessage(messageCodes.MSSHServiceAvailable, "SSH server running on %s", l),
+		)
+	}
 
 	go s.handleListenSocketOnShutdown(lifecycle)
-	for {
-		tcpConn, err := netListener.Accept()
-		if err != nil {
-			// Assume listen socket closed
-			break
-		}
-		s.wg.Add(1)
-		go s.handleConnection(tcpConn)
+	acceptWg := &sync.WaitGroup{}
+	for _, netListener := range listenSockets {
+		acceptWg.Add(1)
+		go s.acceptConnections(netListener, socketListeners[netListener], acceptWg)
+	}
+	acceptWg.Wait()
+	if s.isDraining() {
+		// The listen sockets are closed when draining starts, but the connections are only closed when the server is
+		// stopped.
+		<-lifecycle.Context().Done()
 	}
 	lifecycle.Stopping()
 	s.shuttingDown = true
@@ -116,13 +140,28 @@ func (s *serverImpl) RunWithLifecycle(lifecycle service.Lifecycle) error {
 func (s *serverImpl) handleListenSocketOnShutdown(lifecycle service.Lifecycle) {
 	<-lifecycle.Context().Done()
 	s.lock.Lock()
-	if err := s.listenSocket.Close(); err != nil {
-		s.logger.Warning(messageCodes.Wrap(err, messageCodes.ESSHListenCloseFailed, "failed to close listen socket"))
+	for _, netListener := range s.listenSockets {
+		if err := netListener.Close(); err != nil {
+			s.logger.Warning(messageCodes.Wrap(err, messageCodes.ESSHListenCloseFailed, "failed to close listen socket"))
+		}
 	}
-	s.listenSocket = nil
+	s.listenSockets = nil
 	s.lock.Unlock()
 }
 
+func (s *serverImpl) acceptConnections(netListener net.Listener, l *listener, acceptWg *sync.WaitGroup) {
+	defer acceptWg.Done()
+	for {
+		conn, err := netListener.Accept()
+		if err != nil {
+			// Assume listen socket closed
+			return
+		}
+		s.wg.Add(1)
+		go s.handleConnection(conn, l)
+	}
+}
+
 func (s *serverImpl) disconnectClients(lifecycle service.Lifecycle, allClientsExited chan struct{}) {
 	select {
 	case <-allClientsExited:
@@ -140,7 +179,7 @@ func (s *serverImpl) disconnectClients(lifecycle service.Lifecycle, allClientsEx
 
 func (s *serverImpl) createPasswordAuthenticator(
 	connectionMetadata metadata.ConnectionMetadata,
-	handlerNetworkConnection NetworkConnectionHandler,
+	handlerNetworkConnection *networkConnectionWrapper,
 	logger log.Logger,
 ) func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, metadata.ConnectionAuthenticatedMetadata, error) {
 	return func(conn ssh.ConnMetadata, password []byte) (
@@ -148,7 +187,7 @@ func (s *serverImpl) createPasswordAuthenticator(
 		metadata.ConnectionAuthenticatedMetadata,
 		error,
 	) {
-		authenticatingMetadata := connectionMetadata.StartAuthentication(string(conn.ClientVersion()), conn.User())
+		authenticatingMetadata := handlerNetworkConnection.startAuthentication(connectionMetadata, conn)
 		authResponse, authenticatedMetadata, err := handlerNetworkConnection.OnAuthPassword(
 			authenticatingMetadata,
 			password,
@@ -158,6 +197,9 @@ func (s *serverImpl) createPasswordAuthenticator(
 		case AuthResponseSuccess:
 			s.logAuthSuccessful(logger, authenticatedMetadata, "Password")
 			return &ssh.Permissions{}, authenticatedMetadata, nil
+		case AuthResponsePartialSuccess:
+			s.logAuthPartialSuccess(logger, authenticatedMetadata, "Password")
+			return nil, authenticatedMetadata, ErrAuthPartialSuccess
 		case AuthResponseFailure:
 			err = s.wrapAndLogAuthFailure(logger, authenticatingMetadata, "Password", err)
 			return nil, authenticatedMetadata, err
@@ -239,9 +281,25 @@ func (s *serverImpl) logAuthSuccessful(
 	logger.Info(err)
 }
 
+func (s *serverImpl) logAuthPartialSuccess(
+	logger log.Logger,
+	conn metadata.ConnectionAuthenticatedMetadata,
+	authMethod string,
+) {
+	logger.Info(
+		messageCodes.UserMessage(
+			messageCodes.MSSHAuthPartialSuccess,
+			"Further authentication required.",
+			"%s authentication for user %s successful, further authentication is required.",
+			authMethod,
+			conn.Username,
+		).Label("username", conn.Username).Label("method", strings.ToLower(authMethod)),
+	)
+}
+
 func (s *serverImpl) createPubKeyAuthenticator(
 	connectionMetadata metadata.ConnectionMetadata,
-	handlerNetworkConnection NetworkConnectionHandler,
+	handlerNetworkConnection *networkConnectionWrapper,
 	logger log.Logger,
 ) func(conn ssh.ConnMetadata, pubKey ssh.PublicKey) (
 	*ssh.Permissions,
@@ -253,7 +311,7 @@ func (s *serverImpl) createPubKeyAuthenticator(
 		metadata.ConnectionAuthenticatedMetadata,
 		error,
 	) {
-		authenticatingMetadata := connectionMetadata.StartAuthentication(string(conn.ClientVersion()), conn.User())
+		authenticatingMetadata := handlerNetworkConnection.startAuthentication(connectionMetadata, conn)
 		authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey)))
 		authResponse, authenticatedMetadata, err := handlerNetworkConnection.OnAuthPubKey(
 			authenticatingMetadata,
@@ -264,6 +322,9 @@ func (s *serverImpl) createPubKeyAuthenticator(
 		case AuthResponseSuccess:
 			s.logAuthSuccessful(logger, authenticatedMetadata, "Public key")
 			return &ssh.Permissions{}, authenticatedMetadata, nil
+		case AuthResponsePartialSuccess:
+			s.logAuthPartialSuccess(logger, authenticatedMetadata, "Public key")
+			return nil, authenticatedMetadata, ErrAuthPartialSuccess
 		case AuthResponseFailure:
 			err = s.wrapAndLogAuthFailure(logger, authenticatingMetadata, "Public key", err)
 			return nil, authenticatedMetadata, err
@@ -289,7 +350,7 @@ func (s *serverImpl) createKeyboardInteractiveHandler(
 		conn ssh.ConnMetadata,
 		challenge ssh.KeyboardInteractiveChallenge,
 	) (*ssh.Permissions, metadata.ConnectionAuthenticatedMetadata, error) {
-		authenticatingMetadata := connectionMetadata.StartAuthentication(string(conn.ClientVersion()), conn.User())
+		authenticatingMetadata := handlerNetworkConnection.startAuthentication(connectionMetadata, conn)
 		challengeWrapper := func(
 			instruction string,
 			questions KeyboardInteractiveQuestions,
@@ -321,6 +382,9 @@ func (s *serverImpl) createKeyboardInteractiveHandler(
 		case AuthResponseSuccess:
 			s.logAuthSuccessful(logger, authenticatedMetadata, "Keyboard-interactive")
 			return &ssh.Permissions{}, authenticatedMetadata, nil
+		case AuthResponsePartialSuccess:
+			s.logAuthPartialSuccess(logger, authenticatedMetadata, "Keyboard-interactive")
+			return nil, authenticatedMetadata, ErrAuthPartialSuccess
 		case AuthResponseFailure:
 			err = s.wrapAndLogAuthFailure(logger, authenticatingMetadata, "Keyboard-interactive", err)
 			return nil, authenticatedMetadata, err
@@ -333,11 +397,13 @@ func (s *serverImpl) createKeyboardInteractiveHandler(
 }
 
 func (s *serverImpl) createConfiguration(
+	cfg config.SSHConfig,
+	l *listener,
 	meta metadata.ConnectionMetadata,
 	handlerNetworkConnection *networkConnectionWrapper,
 	logger log.Logger,
 ) *ssh.ServerConfig {
-	passwordCallback, pubkeyCallback, keyboardInteractiveCallback, gssConfig := s.createAuthenticators(
+	callbacks, verifiedPubKeyCallback := s.createAuthenticators(
 		meta,
 		handlerNetworkConnection,
 		logger,
@@ -345,45 +411,59 @@ func (s *serverImpl) createConfiguration(
 
 	serverConfig := &ssh.ServerConfig{
 		Config: ssh.Config{
-			KeyExchanges: s.cfg.KexAlgorithms.StringList(),
-			Ciphers:      s.cfg.Ciphers.StringList(),
-			MACs:         s.cfg.MACs.StringList(),
+			KeyExchanges: cfg.KexAlgorithms.StringList(),
+			Ciphers:      cfg.Ciphers.StringList(),
+			MACs:         cfg.MACs.StringList(),
 		},
 		NoClientAuth:                false,
 		MaxAuthTries:                6,
-		PasswordCallback:            passwordCallback,
-		PublicKeyCallback:           pubkeyCallback,
-		KeyboardInteractiveCallback: keyboardInteractiveCallback,
-		GSSAPIWithMICConfig:         gssConfig,
-		ServerVersion:               s.cfg.ServerVersion.String(),
-		BannerCallback:              func(conn ssh.ConnMetadata) string { return s.cfg.Banner },
-	}
-	for _, key := range s.hostKeys {
+		PasswordCallback:            callbacks.PasswordCallback,
+		PublicKeyCallback:           callbacks.PublicKeyCallback,
+		VerifiedPublicKeyCallback:   verifiedPubKeyCallback,
+		KeyboardInteractiveCallback: callbacks.KeyboardInteractiveCallback,
+		GSSAPIWithMICConfig:         callbacks.GSSAPIWithMICConfig,
+		ServerVersion:               cfg.ServerVersion.String(),
+		BannerCallback: func(conn ssh.ConnMetadata) string {
+			return s.banner(cfg.ServerVersion.String(), l, meta, conn, handlerNetworkConnection, logger)
+		},
+	}
+	for _, key := range l.hostKeys {
 		serverConfig.AddHostKey(key)
 	}
 	return serverConfig
 }
 
+// createAuthenticators creates the authentication callbacks. The callbacks reference the full set, so they can offer
+// the methods not passed yet after a partial success.
 func (s *serverImpl) createAuthenticators(
 	meta metadata.ConnectionMetadata,
 	handlerNetworkConnection *networkConnectionWrapper,
 	logger log.Logger,
 ) (
-	func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error),
-	func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error),
-	func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error),
-	*ssh.GSSAPIWithMICConfig,
+	*ssh.ServerAuthCallbacks,
+	func(conn ssh.ConnMetadata, key ssh.PublicKey, permissions *ssh.Permissions, signatureAlgorithm string) (
+		*ssh.Permissions,
+		error,
+	),
 ) {
-	passwordCallback := s.createPasswordCallback(meta, handlerNetworkConnection, logger)
-	pubkeyCallback := s.createPubKeyCallback(meta, handlerNetworkConnection, logger)
-	keyboardInteractiveCallback := s.createKeyboardInteractiveCallback(meta, handlerNetworkConnection, logger)
-	gssConfig := s.createGSSAPIConfig(meta, handlerNetworkConnection, logger)
-	return passwordCallback, pubkeyCallback, keyboardInteractiveCallback, gssConfig
+	callbacks := &ssh.ServerAuthCallbacks{}
+	callbacks.KeyboardInteractiveCallback = s.createKeyboardInteractiveCallback(
+		meta,
+		handlerNetworkConnection,
+		callbacks,
+		logger,
+	)
+	callbacks.PasswordCallback = s.createPasswordCallback(meta, handlerNetworkConnection, callbacks, logger)
+	callbacks.PublicKeyCallback = s.createPubKeyCallback(meta, handlerNetworkConnection, logger)
+	callbacks.GSSAPIWithMICConfig = s.createGSSAPIConfig(meta, handlerNetworkConnection, callbacks, logger)
+	verifiedPubKeyCallback := s.createVerifiedPubKeyCallback(handlerNetworkConnection, callbacks)
+	return callbacks, verifiedPubKeyCallback
 }
 
 func (s *serverImpl) createGSSAPIConfig(
 	connectionMetadata metadata.ConnectionMetadata,
 	handlerNetworkConnection *networkConnectionWrapper,
+	callbacks *ssh.ServerAuthCallbacks,
 	logger log.Logger,
 ) *ssh.GSSAPIWithMICConfig {
 	var gssConfig *ssh.GSSAPIWithMICConfig
@@ -402,8 +482,12 @@ func (s *serverImpl) createGSSAPIConfig(
 					return nil, gssServer.Error()
 				}
 
-				authenticating := connectionMetadata.StartAuthentication(string(conn.ClientVersion()), conn.User())
+				authenticating := handlerNetworkConnection.startAuthentication(connectionMetadata, conn)
 				authenticated, err := gssServer.AllowLogin(conn.User(), authenticating)
+				if errors.Is(err, ErrAuthPartialSuccess) {
+					s.logAuthPartialSuccess(logger, authenticated, "GSSAPI")
+					return nil, handlerNetworkConnection.partialSuccess(methodGSSAPI, authenticated, callbacks)
+				}
 				if err != nil {
 					return nil, s.wrapAndLogAuthFailure(logger, authenticating, "GSSAPI", err)
 				}
@@ -429,6 +513,7 @@ func (s *serverImpl) createGSSAPIConfig(
 func (s *serverImpl) createKeyboardInteractiveCallback(
 	connectionMetadata metadata.ConnectionMetadata,
 	handlerNetworkConnection *networkConnectionWrapper,
+	callbacks *ssh.ServerAuthCallbacks,
 	logger log.Logger,
 ) func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
 	keyboardInteractiveHandler := s.createKeyboardInteractiveHandler(
@@ -441,6 +526,9 @@ func (s *serverImpl) createKeyboardInteractiveCallback(
 		challenge ssh.KeyboardInteractiveChallenge,
 	) (*ssh.Permissions, error) {
 		_, authenticatedMetadata, err := keyboardInteractiveHandler(conn, challenge)
+		if errors.Is(err, ErrAuthPartialSuccess) {
+			return nil, handlerNetworkConnection.partialSuccess(methodKeyboardInteractive, authenticatedMetadata, callbacks)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -465,30 +553,62 @@ func (s *serverImpl) createPubKeyCallback(
 	pubKeyHandler := s.createPubKeyAuthenticator(meta, handlerNetworkConnection, logger)
 	pubkeyCallback := func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
 		_, authenticatedMetadata, err := pubKeyHandler(conn, key)
-		if err != nil {
+		partial := errors.Is(err, ErrAuthPartialSuccess)
+		if err != nil && !partial {
 			return nil, err
 		}
 		marshaledMetadata, err := json.Marshal(authenticatedMetadata)
 		if err != nil {
 			return nil, err
 		}
-		return &ssh.Permissions{
+		permissions := &ssh.Permissions{
 			Extensions: map[string]string{
 				"containerssh-metadata": string(marshaledMetadata),
 			},
-		}, err
+		}
+		if partial {
+			// This callback also runs when the client only asks if a key would be accepted, so the partial success
+			// is only recorded once the client has proven that it holds the key.
+			permissions.Extensions[extensionPartialSuccess] = "true"
+		}
+		return permissions, nil
 	}
 	return pubkeyCallback
 }
 
+// createVerifiedPubKeyCallback creates the callback that is called after the client has proven that it holds the
+// private key accepted by the public key callback.
+func (s *serverImpl) createVerifiedPubKeyCallback(
+	handlerNetworkConnection *networkConnectionWrapper,
+	callbacks *ssh.ServerAuthCallbacks,
+) func(conn ssh.ConnMetadata, key ssh.PublicKey, permissions *ssh.Permissions, signatureAlgorithm string) (
+	*ssh.Permissions,
+	error,
+) {
+	return func(_ ssh.ConnMetadata, _ ssh.PublicKey, permissions *ssh.Permissions, _ string) (*ssh.Permissions, error) {
+		if permissions.Extensions[extensionPartialSuccess] == "" {
+			return permissions, nil
+		}
+		var authenticatedMetadata metadata.ConnectionAuthenticatedMetadata
+		if err := json.Unmarshal([]byte(permissions.Extensions["containerssh-metadata"]), &authenticatedMetadata); err != nil {
+			return nil, err
+		}
+		return nil, handlerNetworkConnection.partialSuccess(methodPublicKey, authenticatedMetadata, callbacks)
+	}
+}
+
 func (s *serverImpl) createPasswordCallback(
 	meta metadata.ConnectionMetadata,
 	handlerNetworkConnection *networkConnectionWrapper,
+	callbacks *ssh.ServerAuthCallbacks,
 	logger log.Logger,
 ) func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
 	passwordHandler := s.createPasswordAuthenticator(meta, handlerNetworkConnection, logger)
 	passwordCallback := func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
 		_, authenticatedMetadata, err := passwordHandler(conn, password)
+		if errors.Is(err, ErrAuthPartialSuccess) {
+			return nil, handlerNetworkConnection.partialSuccess(methodPassword, authenticatedMetadata, callbacks)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -505,15 +625,36 @@ func (s *serverImpl) createPasswordCallback(
 	return passwordCallback
 }
 
-func (s *serverImpl) handleConnection(conn net.Conn) {
-	addr := conn.RemoteAddr().(*net.TCPAddr)
+func (s *serverImpl) handleConnection(conn net.Conn, l *listener) {
+	if proxyConn, ok := conn.(*proxyprotocol.Conn); ok {
+		if err := proxyConn.Handshake(); err != nil {
+			s.logger.WithLabel("proxyAddr", proxyConn.ProxyAddr().String()).Info(
+				messageCodes.Wrap(
+					err,
+					messageCodes.ESSHProxyProtocolFailed,
+					"Failed to read PROXY protocol header, closing connection",
+				),
+			)
+			_ = conn.Close()
+			s.wg.Done()
+			return
+		}
+	}
+	cfg, l := s.settings(l)
+	// Unix socket clients have no IP address, they must not be mistaken for local TCP connections.
+	remoteAddress := metadata.UnixRemoteAddress
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		remoteAddress = metadata.RemoteAddress(*addr)
+	}
 	connectionID := GenerateConnectionID()
 	logger := s.logger.
-		WithLabel("remoteAddr", addr.IP.String()).
-		WithLabel("connectionId", connectionID)
+		WithLabel("remoteAddr", remoteAddress.Host()).
+		WithLabel("connectionId", connectionID).
+		WithLabel("listener", l.name)
 	connectionMeta := metadata.ConnectionMetadata{
-		RemoteAddress: metadata.RemoteAddress(*addr),
+		RemoteAddress: remoteAddress,
 		ConnectionID:  connectionID,
+		Listener:      l.name,
 		Metadata:      map[string]metadata.Value{},
 		Environment:   map[string]metadata.Value{},
 		Files:         map[string]metadata.BinaryValue{},
@@ -542,7 +683,7 @@ func (s *serverImpl) handleConnection(conn net.Conn) {
 
 	sshConn, channels, globalRequests, err := ssh.NewServerConn(
 		conn,
-		s.createConfiguration(connectionMeta, &wrapper, logger),
+		s.createConfiguration(cfg, l, connectionMeta, &wrapper, logger),
 	)
 	abortCleanup := func() {
 		logger.Info(messageCodes.Wrap(err, messageCodes.ESSHHandshakeFailed, "SSH handshake failed"))
@@ -596,11 +737,11 @@ func (s *serverImpl) handleConnection(conn net.Conn) {
 	sshShutdownHandlerID := fmt.Sprintf("ssh-%s", connectionID)
 	s.lock.Unlock()
 
-	if s.cfg.ClientAliveInterval > 0 {
+	if cfg.ClientAliveInterval > 0 {
 		go func() {
 			missedAlives := 0
 			for {
-				time.Sleep(s.cfg.ClientAliveInterval)
+				time.Sleep(cfg.ClientAliveInterval)
 
 				_, _, err := sshConn.SendRequest("keepalive@openssh.com", true, []byte{})
 
@@ -614,7 +755,7 @@ func (s *serverImpl) handleConnection(conn net.Conn) {
 							"Keepalive error",
 						),
 					)
-					if missedAlives >= s.cfg.ClientAliveCountMax {
+					if missedAlives >= cfg.ClientAliveCountMax {
 						_ = sshConn.Close()
 						break
 					}
@@ -627,6 +768,10 @@ func (s *serverImpl) handleConnection(conn net.Conn) {
 
 	go func() {
 		_ = sshConn.Wait()
+		s.lock.Lock()
+		delete(s.clientSockets, sshConn)
+		delete(s.connMap, connectionID)
+		s.lock.Unlock()
 		logger.Debug(messageCodes.NewMessage(messageCodes.MSSHDisconnected, "Client disconnected"))
 		s.shutdownHandlers.Unregister(shutdownHandlerID)
 		s.shutdownHandlers.Unregister(sshShutdownHandlerID)
@@ -636,9 +781,11 @@ func (s *serverImpl) handleConnection(conn net.Conn) {
 	// HACK: check HACKS.md "OnHandshakeSuccess conformanceTestHandler"
 	handlerSSHConnection := wrapper.sshConnectionHandler
 	s.shutdownHandlers.Register(sshShutdownHandlerID, handlerSSHConnection)
+	handlerSSHConnection.OnConnection(&connectionWrapper{sshConn: sshConn, logger: logger})
 
 	go s.handleChannels(authenticatedMetadata, channels, handlerSSHConnection, logger)
-	go s.handleGlobalRequests(authenticatedMetadata, globalRequests, handlerSSHConnection, logger)
+	go s.handleGlobalRequests(authenticatedMetadata, sshConn, l, globalRequests, handlerSSHConnection, logger)
+	s.sendHostKeys(sshConn, l, logger)
 }
 
 func (s *serverImpl) handleKeepAliveRequest(req *ssh.Request, logger log.Logger) {
@@ -758,6 +905,8 @@ func (s *serverImpl) handleUnknownGlobalRequest(req *ssh.Request, requestID uint
 
 func (s *serverImpl) handleGlobalRequests(
 	authenticatedMetadata metadata.ConnectionAuthenticatedMetadata,
+	sshConn *ssh.ServerConn,
+	l *listener,
 	requests <-chan *ssh.Request,
 	connection SSHConnectionHandler,
 	logger log.Logger,
@@ -774,6 +923,8 @@ func (s *serverImpl) handleGlobalRequests(
 		switch request.Type {
 		case "keepalive@openssh.com":
 			s.handleKeepAliveRequest(request, logger)
+		case requestTypeHostKeysProve:
+			s.handleHostKeysProveRequest(sshConn, l, request, logger)
 		default:
 			logger.Debug("Handling global request %s", request.Type)
 			s.handleGlobalRequest(authenticatedMetadata, requestID, connection, request, logger)
@@ -859,18 +1010,27 @@ func (s *serverImpl) handleSessionChannel(
 	}
 	logger.Debug(messageCodes.NewMessage(messageCodes.MSSHNewChannel, "New SSH channel").Label("type", newChannel.ChannelType()))
 	channelCallbacks.channel = channel
+	s.lock.Lock()
+	s.sessions[shutdownHandlerID] = channelCallbacks
+	s.lock.Unlock()
 	nextRequestID := uint64(0)
 	for {
 		request, ok := <-requests
 		if !ok {
 			s.shutdownHandlers.Unregister(shutdownHandlerID)
+			s.lock.Lock()
+			delete(s.sessions, shutdownHandlerID)
+			s.lock.Unlock()
 			channelCallbacks.onClose()
 			handlerChannel.OnClose()
 			break
 		}
 		requestID := nextRequestID
 		nextRequestID++
-		s.handleChannelRequest(channelMetadata, requestID, request, handlerChannel, logger)
+		if s.handleChannelRequest(channelMetadata, requestID, request, handlerChannel, logger) &&
+			ssh2.RequestType(request.Type) == ssh2.RequestTypePty {
+			channelCallbacks.setInteractive()
+		}
 	}
 }
 
@@ -1034,6 +1194,10 @@ func (s *serverImpl) unmarshalSignal(request *ssh.Request) (payload ssh2.SignalR
 	return payload, ssh.Unmarshal(request.Payload, &payload)
 }
 
+func (s *serverImpl) unmarshalBreak(request *ssh.Request) (payload ssh2.BreakRequestPayload, err error) {
+	return payload, ssh.Unmarshal(request.Payload, &payload)
+}
+
 func (s *serverImpl) unmarshalTCPIPForward(request *ssh.Request) (payload ssh2.ForwardTCPIPRequestPayload, err error) {
 	return payload, ssh.Unmarshal(request.Payload, &payload)
 }
@@ -1047,6 +1211,10 @@ func (s *serverImpl) unmarshalX11(request *ssh.Request) (payload ssh2.X11Request
 	return payload, ssh.Unmarshal(request.Payload, &payload)
 }
 
+func (s *serverImpl) unmarshalAuthAgent(request *ssh.Request) (payload ssh2.AuthAgentRequestPayload, err error) {
+	return payload, ssh.Unmarshal(request.Payload, &payload)
+}
+
 func (s *serverImpl) unmarshalChannelRequestPayload(request *ssh.Request) (payload interface{}, err error) {
 	switch ssh2.RequestType(request.Type) {
 	case ssh2.RequestTypeEnv:
@@ -1063,8 +1231,12 @@ func (s *serverImpl) unmarshalChannelRequestPayload(request *ssh.Request) (paylo
 		return s.unmarshalWindow(request)
 	case ssh2.RequestTypeSignal:
 		return s.unmarshalSignal(request)
+	case ssh2.RequestTypeBreak:
+		return s.unmarshalBreak(request)
 	case ssh2.RequestTypeX11:
 		return s.unmarshalX11(request)
+	case ssh2.RequestTypeAuthAgent:
+		return s.unmarshalAuthAgent(request)
 	default:
 		return nil, nil
 	}
@@ -1085,6 +1257,8 @@ func (s *serverImpl) unmarshalGlobalRequestPayload(request *ssh.Request) (payloa
 	}
 }
 
+// handleChannelRequest handles a request on a session channel and returns true if the request was successful.
+//
 //nolint:dupl
 func (s *serverImpl) handleChannelRequest(
 	channelMetadata metadata.ChannelMetadata,
@@ -1092,13 +1266,13 @@ func (s *serverImpl) handleChannelRequest(
 	request *ssh.Request,
 	sessionChannel SessionChannelHandler,
 	logger log.Logger,
-) {
+) bool {
 	reply := s.createReply(request, logger)
 	payload, err := s.unmarshalChannelRequestPayload(request)
 	if payload == nil {
 		sessionChannel.OnUnsupportedChannelRequest(requestID, request.Type, request.Payload)
 		reply(false, fmt.Sprintf("unsupported request type: %s", request.Type), nil)
-		return
+		return false
 	}
 	if err != nil {
 		logger.Debug(
@@ -1111,7 +1285,7 @@ func (s *serverImpl) handleChannelRequest(
 		)
 		sessionChannel.OnFailedDecodeChannelRequest(requestID, request.Type, request.Payload, err)
 		reply(false, "failed to unmarshal payload", nil)
-		return
+		return false
 	}
 	logger.Debug(
 		messageCodes.NewMessage(
@@ -1135,7 +1309,7 @@ func (s *serverImpl) handleChannelRequest(
 			).Label("RequestType", request.Type),
 		)
 		reply(false, err.Error(), err)
-		return
+		return false
 	}
 	logger.Debug(
 		messageCodes.NewMessage(
@@ -1145,6 +1319,7 @@ func (s *serverImpl) handleChannelRequest(
 		).Label("RequestType", request.Type),
 	)
 	reply(true, "", nil)
+	return true
 }
 
 func (s *serverImpl) createReply(request *ssh.Request, logger log.Logger) func(
@@ -1193,8 +1368,12 @@ func (s *serverImpl) handleDecodedChannelRequest(
 		return s.onChannel(requestID, sessionChannel, payload)
 	case ssh2.RequestTypeSignal:
 		return s.onSignal(requestID, sessionChannel, payload)
+	case ssh2.RequestTypeBreak:
+		return s.onBreak(requestID, sessionChannel, payload)
 	case ssh2.RequestTypeX11:
 		return s.onX11(channelMetadata.Connection.ConnectionID, requestID, sessionChannel, payload)
+	case ssh2.RequestTypeAuthAgent:
+		return s.onAuthAgent(channelMetadata.Connection.ConnectionID, requestID, sessionChannel)
 	}
 	return nil
 }
@@ -1246,6 +1425,13 @@ func (s *serverImpl) onSignal(requestID uint64, sessionChannel SessionChannelHan
 	)
 }
 
+func (s *serverImpl) onBreak(requestID uint64, sessionChannel SessionChannelHandler, payload interface{}) error {
+	return sessionChannel.OnBreak(
+		requestID,
+		payload.(ssh2.BreakRequestPayload).Length,
+	)
+}
+
 func (s *serverImpl) onForwardTCPIP(authenticatedMetadata metadata.ConnectionAuthenticatedMetadata, requestID uint64, payload interface{}, connection SSHConnectionHandler) error {
 	fwdAddress := payload.(ssh2.ForwardTCPIPRequestPayload).Address
 	fwdPort := payload.(ssh2.ForwardTCPIPRequestPayload).Port
@@ -1326,6 +1512,27 @@ func (s *serverImpl) onX11(connectionID string, requestID uint64, sessionChannel
 	return err
 }
 
+func (s *serverImpl) onAuthAgent(connectionID string, requestID uint64, sessionChannel SessionChannelHandler) error {
+	// The lock is only held for the lookup since the backend may take a while to set up the agent socket.
+	s.lock.Lock()
+	conn, ok := s.connMap[connectionID]
+	s.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("connection %s not found", connectionID)
+	}
+
+	reverseForwardHandler := ReverseForwardHandler{
+		sshConn: conn.sshConn,
+		server:  s,
+		logger:  s.logger,
+	}
+	err := sessionChannel.OnAuthAgentRequest(requestID, &reverseForwardHandler)
+	if err != nil {
+		s.logger.Warning("Failed to start SSH agent forwarding %+v", err)
+	}
+	return err
+}
+
 func (s *serverImpl) onSubsystem(
 	requestID uint64,
 	sessionChannel SessionChannelHandler,
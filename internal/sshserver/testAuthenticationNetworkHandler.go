@@ -15,6 +15,13 @@ type testAuthenticationNetworkHandler struct {
 	backend     NetworkConnectionHandler
 }
 
+func (t *testAuthenticationNetworkHandler) OnBanner(
+	meta metadata.ConnectionAuthPendingMetadata,
+	banner string,
+) string {
+	return t.backend.OnBanner(meta, banner)
+}
+
 func (t *testAuthenticationNetworkHandler) OnAuthKeyboardInteractive(
 	meta metadata.ConnectionAuthPendingMetadata,
 	challenge func(
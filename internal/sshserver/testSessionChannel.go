@@ -239,6 +239,13 @@ func (t *testSessionChannel) OnWindow(
 	return nil
 }
 
+func (t *testSessionChannel) OnBreak(
+	_ uint64,
+	_ uint32,
+) error {
+	return fmt.Errorf("not supported")
+}
+
 func (s *testSessionChannel) OnX11Request(
 	requestID uint64,
 	singleConnection bool,
@@ -250,6 +257,13 @@ func (s *testSessionChannel) OnX11Request(
 	return fmt.Errorf("Unimplemented")
 }
 
+func (s *testSessionChannel) OnAuthAgentRequest(
+	requestID uint64,
+	reverseHandler ReverseForward,
+) error {
+	return fmt.Errorf("Unimplemented")
+}
+
 func (t *testSessionChannel) OnShutdown(_ context.Context) {
 	if t.running {
 		_ = t.session.Close()
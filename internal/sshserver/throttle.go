@@ -0,0 +1,204 @@
+package sshserver
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Throttle limits the rate of bytes passing through the readers and writers sharing it using a token bucket that holds
+// one second worth of bytes. A nil Throttle does not limit the rate.
+type Throttle struct {
+	lock    *sync.Mutex
+	rate    float64
+	burst   int
+	tokens  float64
+	last    time.Time
+	onDelay func(bytes int, delay time.Duration)
+}
+
+// NewThrottle creates a throttle limiting the rate to bytesPerSecond. If bytesPerSecond is 0 or lower it returns nil.
+// The onDelay function, if set, is called every time data is delayed.
+func NewThrottle(bytesPerSecond int64, onDelay func(bytes int, delay time.Duration)) *Throttle {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &Throttle{
+		lock:    &sync.Mutex{},
+		rate:    float64(bytesPerSecond),
+		burst:   int(bytesPerSecond),
+		tokens:  float64(bytesPerSecond),
+		last:    time.Now(),
+		onDelay: onDelay,
+	}
+}
+
+// wait takes the specified number of bytes from the bucket and sleeps until the bucket would have held them. The bucket
+// may go into debt so concurrent users sharing a throttle are delayed in the order they arrived.
+func (t *Throttle) wait(bytes int) {
+	t.lock.Lock()
+	now := time.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > float64(t.burst) {
+		t.tokens = float64(t.burst)
+	}
+	t.last = now
+	t.tokens -= float64(bytes)
+	var delay time.Duration
+	if t.tokens < 0 {
+		delay = time.Duration(-t.tokens / t.rate * float64(time.Second))
+	}
+	t.lock.Unlock()
+
+	if delay <= 0 {
+		return
+	}
+	time.Sleep(delay)
+	if t.onDelay != nil {
+		t.onDelay(bytes, delay)
+	}
+}
+
+// throttles is a set of throttles that all apply to the same data.
+type throttles []*Throttle
+
+func newThrottles(list []*Throttle) throttles {
+	var result throttles
+	for _, t := range list {
+		if t != nil {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// chunk returns the largest number of bytes that should be transferred at once so a single transfer does not exceed
+// the burst of any throttle.
+func (t throttles) chunk(size int) int {
+	for _, throttle := range t {
+		if throttle.burst < size {
+			size = throttle.burst
+		}
+	}
+	return size
+}
+
+func (t throttles) wait(bytes int) {
+	for _, throttle := range t {
+		throttle.wait(bytes)
+	}
+}
+
+// ThrottleReader returns a reader that limits the rate of reading from reader to the lowest of the passed throttles.
+// If no non-nil throttles are passed the reader is returned unchanged.
+func ThrottleReader(reader io.Reader, list ...*Throttle) io.Reader {
+	t := newThrottles(list)
+	if len(t) == 0 {
+		return reader
+	}
+	return &throttledReader{reader: reader, throttles: t}
+}
+
+// ThrottleWriter returns a writer that limits the rate of writing to writer to the lowest of the passed throttles.
+// If no non-nil throttles are passed the writer is returned unchanged.
+func ThrottleWriter(writer io.Writer, list ...*Throttle) io.Writer {
+	t := newThrottles(list)
+	if len(t) == 0 {
+		return writer
+	}
+	return &throttledWriter{writer: writer, throttles: t}
+}
+
+type throttledReader struct {
+	reader    io.Reader
+	throttles throttles
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p[:t.throttles.chunk(len(p))])
+	if n > 0 {
+		t.throttles.wait(n)
+	}
+	return n, err
+}
+
+type throttledWriter struct {
+	writer    io.Writer
+	throttles throttles
+}
+
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		size := t.throttles.chunk(len(p) - written)
+		t.throttles.wait(size)
+		n, err := t.writer.Write(p[written : written+size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// ThrottleSessionChannel returns a session channel that limits the rate of the standard input to the stdin throttles
+// and the rate of the standard output and standard error to the output throttles.
+func ThrottleSessionChannel(session SessionChannel, stdin []*Throttle, output []*Throttle) SessionChannel {
+	if len(newThrottles(stdin)) == 0 && len(newThrottles(output)) == 0 {
+		return session
+	}
+	return &throttledSessionChannel{
+		SessionChannel: session,
+		stdin:          stdin,
+		output:         output,
+	}
+}
+
+// throttledSessionChannel wraps the streams when they are requested since they are only available once the channel
+// is open.
+type throttledSessionChannel struct {
+	SessionChannel
+
+	stdin  []*Throttle
+	output []*Throttle
+}
+
+func (t *throttledSessionChannel) Stdin() io.Reader {
+	return ThrottleReader(t.SessionChannel.Stdin(), t.stdin...)
+}
+
+func (t *throttledSessionChannel) Stdout() io.Writer {
+	return ThrottleWriter(t.SessionChannel.Stdout(), t.output...)
+}
+
+func (t *throttledSessionChannel) Stderr() io.Writer {
+	return ThrottleWriter(t.SessionChannel.Stderr(), t.output...)
+}
+
+// ThrottleForwardChannel returns a forwarding channel that limits the rate of reading from the channel to the read
+// throttles and the rate of writing to the channel to the write throttles.
+func ThrottleForwardChannel(channel ForwardChannel, read []*Throttle, write []*Throttle) ForwardChannel {
+	if channel == nil || (len(newThrottles(read)) == 0 && len(newThrottles(write)) == 0) {
+		return channel
+	}
+	return &throttledForwardChannel{
+		ForwardChannel: channel,
+		reader:         ThrottleReader(channel, read...),
+		writer:         ThrottleWriter(channel, write...),
+	}
+}
+
+type throttledForwardChannel struct {
+	ForwardChannel
+
+	reader io.Reader
+	writer io.Writer
+}
+
+func (t *throttledForwardChannel) Read(p []byte) (int, error) {
+	return t.reader.Read(p)
+}
+
+func (t *throttledForwardChannel) Write(p []byte) (int, error) {
+	return t.writer.Write(p)
+}
@@ -0,0 +1,60 @@
+package sshserver_test
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/internal/sshserver"
+)
+
+func TestThrottleDisabled(t *testing.T) {
+	assert.Nil(t, sshserver.NewThrottle(0, nil))
+	reader := &bytes.Buffer{}
+	assert.Same(t, reader, sshserver.ThrottleReader(reader, nil, sshserver.NewThrottle(0, nil)))
+}
+
+func TestThrottleWriter(t *testing.T) {
+	var delayed int
+	lock := &sync.Mutex{}
+	throttle := sshserver.NewThrottle(100000, func(bytes int, _ time.Duration) {
+		lock.Lock()
+		defer lock.Unlock()
+		delayed += bytes
+	})
+	target := &bytes.Buffer{}
+	writer := sshserver.ThrottleWriter(target, throttle)
+
+	start := time.Now()
+	n, err := writer.Write(make([]byte, 250000))
+	assert.NoError(t, err)
+	assert.Equal(t, 250000, n)
+	assert.Equal(t, 250000, target.Len())
+	// The first 100000 bytes fit into the burst, the rest takes 1.5 seconds.
+	assert.GreaterOrEqual(t, time.Since(start), 1400*time.Millisecond)
+	lock.Lock()
+	assert.Greater(t, delayed, 0)
+	lock.Unlock()
+}
+
+func TestThrottleShared(t *testing.T) {
+	throttle := sshserver.NewThrottle(100000, nil)
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader := sshserver.ThrottleReader(bytes.NewReader(make([]byte, 125000)), throttle)
+			n, err := io.Copy(io.Discard, reader)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(125000), n)
+		}()
+	}
+	wg.Wait()
+	// Both readers share one bucket, so reading 250000 bytes takes 1.5 seconds in total.
+	assert.GreaterOrEqual(t, time.Since(start), 1400*time.Millisecond)
+}
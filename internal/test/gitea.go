@@ -0,0 +1,135 @@
+package test
+
+import (
+	goHttp "net/http"
+	"strconv"
+	"testing"
+)
+
+// GiteaServer starts an in-process stand-in for the Gitea OAuth2 and user APIs. It supports the authorization code
+// flow, the /api/v1/user endpoint and the paginated /api/v1/user/orgs endpoint. The server is stopped when the test
+// ends.
+func GiteaServer(t *testing.T) GiteaServerInstance {
+	handler := &giteaHandler{
+		oauth2Stub: newOAuth2Stub(t, "Gitea server"),
+	}
+	handler.start(t, "Gitea server", handler)
+	return handler
+}
+
+// GiteaServerInstance is a running Gitea stand-in.
+type GiteaServerInstance interface {
+	// BaseURL returns the URL to configure as the Gitea URL.
+	BaseURL() string
+	// ClientID returns the OAuth2 client ID accepted by the server.
+	ClientID() string
+	// ClientSecret returns the OAuth2 client secret accepted by the server.
+	ClientSecret() string
+	// AddUser adds a user who is a member of the specified organizations.
+	AddUser(username string, orgs ...string)
+	// Login simulates the user logging in on the authorization URL with their browser and returns the state and
+	// authorization code the redirect page would display.
+	Login(authorizationURL string, username string) (state string, code string, err error)
+}
+
+type giteaHandler struct {
+	*oauth2Stub
+}
+
+func (g *giteaHandler) BaseURL() string {
+	return g.baseURL
+}
+
+func (g *giteaHandler) ClientID() string {
+	return g.clientID
+}
+
+func (g *giteaHandler) ClientSecret() string {
+	return g.clientSecret
+}
+
+func (g *giteaHandler) AddUser(username string, orgs ...string) {
+	g.addUser(username, orgs)
+}
+
+func (g *giteaHandler) Login(authorizationURL string, username string) (string, string, error) {
+	return g.login(authorizationURL, "/login/oauth/authorize", username)
+}
+
+func (g *giteaHandler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	switch request.URL.Path {
+	case "/login/oauth/access_token":
+		g.token(writer, request)
+	case "/api/v1/user":
+		g.user(writer, request)
+	case "/api/v1/user/orgs":
+		g.orgs(writer, request)
+	default:
+		writer.WriteHeader(404)
+	}
+}
+
+func (g *giteaHandler) token(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if err := request.ParseForm(); err != nil || request.Method != goHttp.MethodPost {
+		g.sendError(writer, "invalid_request")
+		return
+	}
+	if request.PostForm.Get("grant_type") != "authorization_code" {
+		g.sendError(writer, "unsupported_grant_type")
+		return
+	}
+	token, errorCode := g.exchangeCode(request.PostForm)
+	if errorCode != "" {
+		g.sendError(writer, errorCode)
+		return
+	}
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"access_token":  token,
+		"token_type":    "bearer",
+		"expires_in":    3600,
+		"refresh_token": randomHex(16),
+	})
+}
+
+func (g *giteaHandler) user(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	username, _, ok := g.authenticatedUser(request)
+	if !ok {
+		g.writeJSON(writer, 401, map[string]string{"message": "token is required"})
+		return
+	}
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"id":         1,
+		"login":      username,
+		"full_name":  username,
+		"email":      username + "@example.com",
+		"avatar_url": g.baseURL + "/avatars/1",
+		"is_admin":   false,
+	})
+}
+
+func (g *giteaHandler) orgs(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	_, orgs, ok := g.authenticatedUser(request)
+	if !ok {
+		g.writeJSON(writer, 401, map[string]string{"message": "token is required"})
+		return
+	}
+	query := request.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 30
+	}
+	result := []map[string]interface{}{}
+	for i := (page - 1) * limit; i < len(orgs) && i < page*limit; i++ {
+		result = append(result, map[string]interface{}{
+			"id":        i + 1,
+			"name":      orgs[i],
+			"username":  orgs[i],
+			"full_name": "",
+		})
+	}
+	g.writeJSON(writer, 200, result)
+}
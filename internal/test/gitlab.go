@@ -0,0 +1,223 @@
+package test
+
+import (
+	"fmt"
+	goHttp "net/http"
+	"testing"
+)
+
+// GitLabServer starts an in-process stand-in for the GitLab OAuth2 and user APIs. It supports the authorization code
+// and the device flow, token revocation, the /api/v4/user endpoint and the groups claim of the /oauth/userinfo
+// endpoint. The server is stopped when the test ends.
+func GitLabServer(t *testing.T) GitLabServerInstance {
+	handler := &gitLabHandler{
+		oauth2Stub:  newOAuth2Stub(t, "GitLab server"),
+		deviceCodes: map[string]*gitLabDeviceCode{},
+	}
+	handler.start(t, "GitLab server", handler)
+	return handler
+}
+
+// GitLabServerInstance is a running GitLab stand-in.
+type GitLabServerInstance interface {
+	// BaseURL returns the URL to configure as the GitLab URL.
+	BaseURL() string
+	// ClientID returns the OAuth2 client ID accepted by the server.
+	ClientID() string
+	// ClientSecret returns the OAuth2 client secret accepted by the server.
+	ClientSecret() string
+	// AddUser adds a user who is a member of the specified groups. Groups are identified by their full path.
+	AddUser(username string, groups ...string)
+	// Login simulates the user logging in on the authorization URL with their browser and returns the state and
+	// authorization code the redirect page would display.
+	Login(authorizationURL string, username string) (state string, code string, err error)
+	// ApproveDevice simulates the user entering the user code of a device flow and approving the request.
+	ApproveDevice(userCode string, username string) error
+	// ActiveTokens returns the number of access tokens that have not been revoked.
+	ActiveTokens() int
+}
+
+type gitLabDeviceCode struct {
+	userCode string
+	username string
+}
+
+type gitLabHandler struct {
+	*oauth2Stub
+
+	deviceCodes map[string]*gitLabDeviceCode
+}
+
+func (g *gitLabHandler) BaseURL() string {
+	return g.baseURL
+}
+
+func (g *gitLabHandler) ClientID() string {
+	return g.clientID
+}
+
+func (g *gitLabHandler) ClientSecret() string {
+	return g.clientSecret
+}
+
+func (g *gitLabHandler) AddUser(username string, groups ...string) {
+	g.addUser(username, groups)
+}
+
+func (g *gitLabHandler) Login(authorizationURL string, username string) (string, string, error) {
+	return g.login(authorizationURL, "/oauth/authorize", username)
+}
+
+func (g *gitLabHandler) ApproveDevice(userCode string, username string) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if _, ok := g.users[username]; !ok {
+		return fmt.Errorf("no such user: %s", username)
+	}
+	for _, deviceCode := range g.deviceCodes {
+		if deviceCode.userCode == userCode {
+			deviceCode.username = username
+			return nil
+		}
+	}
+	return fmt.Errorf("no such user code: %s", userCode)
+}
+
+func (g *gitLabHandler) ActiveTokens() int {
+	return g.tokenCount()
+}
+
+func (g *gitLabHandler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	switch request.URL.Path {
+	case "/oauth/authorize_device":
+		g.device(writer, request)
+	case "/oauth/token":
+		g.token(writer, request)
+	case "/oauth/revoke":
+		g.revoke(writer, request)
+	case "/oauth/userinfo":
+		g.userinfo(writer, request)
+	case "/api/v4/user":
+		g.user(writer, request)
+	default:
+		writer.WriteHeader(404)
+	}
+}
+
+func (g *gitLabHandler) device(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if err := request.ParseForm(); err != nil || request.Method != goHttp.MethodPost {
+		g.sendError(writer, "invalid_request")
+		return
+	}
+	if request.PostForm.Get("client_id") != g.clientID {
+		g.sendError(writer, "invalid_client")
+		return
+	}
+	deviceCode := randomHex(16)
+	userCode := randomHex(4)
+	g.lock.Lock()
+	g.deviceCodes[deviceCode] = &gitLabDeviceCode{userCode: userCode}
+	g.lock.Unlock()
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"device_code":               deviceCode,
+		"user_code":                 userCode,
+		"verification_uri":          g.baseURL + "/oauth/device",
+		"verification_uri_complete": g.baseURL + "/oauth/device?user_code=" + userCode,
+		"expires_in":                300,
+		"interval":                  1,
+	})
+}
+
+func (g *gitLabHandler) token(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if err := request.ParseForm(); err != nil || request.Method != goHttp.MethodPost {
+		g.sendError(writer, "invalid_request")
+		return
+	}
+	form := request.PostForm
+	var token string
+	switch form.Get("grant_type") {
+	case "authorization_code":
+		var errorCode string
+		token, errorCode = g.exchangeCode(form)
+		if errorCode != "" {
+			g.sendError(writer, errorCode)
+			return
+		}
+	case "urn:ietf:params:oauth:grant-type:device_code":
+		if form.Get("client_id") != g.clientID {
+			g.sendError(writer, "invalid_client")
+			return
+		}
+		g.lock.Lock()
+		deviceCode, ok := g.deviceCodes[form.Get("device_code")]
+		if !ok {
+			g.lock.Unlock()
+			g.sendError(writer, "invalid_grant")
+			return
+		}
+		if deviceCode.username == "" {
+			g.lock.Unlock()
+			g.sendError(writer, "authorization_pending")
+			return
+		}
+		delete(g.deviceCodes, form.Get("device_code"))
+		token = g.issueToken(deviceCode.username)
+		g.lock.Unlock()
+	default:
+		g.sendError(writer, "unsupported_grant_type")
+		return
+	}
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   7200,
+		"scope":        "openid read_user",
+		"created_at":   0,
+	})
+}
+
+func (g *gitLabHandler) revoke(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if err := request.ParseForm(); err != nil || request.Method != goHttp.MethodPost {
+		g.sendError(writer, "invalid_request")
+		return
+	}
+	if request.PostForm.Get("client_id") != g.clientID || request.PostForm.Get("client_secret") != g.clientSecret {
+		g.sendError(writer, "invalid_client")
+		return
+	}
+	g.revokeToken(request.PostForm.Get("token"))
+	g.writeJSON(writer, 200, map[string]interface{}{})
+}
+
+func (g *gitLabHandler) user(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	username, _, ok := g.authenticatedUser(request)
+	if !ok {
+		g.writeJSON(writer, 401, map[string]string{"message": "401 Unauthorized"})
+		return
+	}
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"id":         1,
+		"username":   username,
+		"name":       username,
+		"email":      username + "@example.com",
+		"state":      "active",
+		"avatar_url": g.baseURL + "/uploads/avatar.png",
+		"web_url":    g.baseURL + "/" + username,
+	})
+}
+
+func (g *gitLabHandler) userinfo(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	username, groups, ok := g.authenticatedUser(request)
+	if !ok {
+		g.writeJSON(writer, 401, map[string]string{"error": "invalid_token"})
+		return
+	}
+	if groups == nil {
+		groups = []string{}
+	}
+	g.writeJSON(writer, 200, map[string]interface{}{
+		"sub":      "1",
+		"nickname": username,
+		"groups":   groups,
+	})
+}
@@ -0,0 +1,379 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+// LDAPEntry is an entry served by the LDAP test server.
+type LDAPEntry struct {
+	// DN is the distinguished name of the entry.
+	DN string
+	// Password is the password for a simple bind as DN. If empty, binding as this entry is not possible.
+	Password string
+	// Attributes are the attributes of the entry. The objectClass attribute is not added automatically.
+	Attributes map[string][]string
+}
+
+// LDAPServer starts an in-process LDAP server serving the specified entries. It supports simple binds, searches with
+// and, or, not, equality, presence and substring filters, and StartTLS. If ldaps is true the server only accepts TLS
+// connections. The server is stopped when the test ends.
+//
+// The server does not enforce access control, anonymous clients can search all entries.
+func LDAPServer(t *testing.T, ldaps bool, entries ...LDAPEntry) LDAPServerInstance {
+	caCert, tlsConfig := createLDAPCertificate(t)
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", GetNextPort(t, "LDAP server")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := "ldap"
+	if ldaps {
+		scheme = "ldaps"
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	srv := &ldapServer{
+		t:         t,
+		url:       fmt.Sprintf("%s://%s", scheme, listener.Addr().String()),
+		caCert:    caCert,
+		tlsConfig: tlsConfig,
+		entries:   entries,
+		listener:  listener,
+		wg:        &sync.WaitGroup{},
+	}
+	srv.wg.Add(1)
+	go srv.serve()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		srv.wg.Wait()
+	})
+	return srv
+}
+
+// LDAPServerInstance is a running LDAP test server.
+type LDAPServerInstance interface {
+	// URL returns the ldap:// or ldaps:// URL of the server.
+	URL() string
+	// CACert returns the PEM-encoded CA certificate the TLS certificate of the server is signed with.
+	CACert() string
+}
+
+type ldapServer struct {
+	t         *testing.T
+	url       string
+	caCert    string
+	tlsConfig *tls.Config
+	entries   []LDAPEntry
+	listener  net.Listener
+	wg        *sync.WaitGroup
+}
+
+func (l *ldapServer) URL() string {
+	return l.url
+}
+
+func (l *ldapServer) CACert() string {
+	return l.caCert
+}
+
+const (
+	ldapResultSuccess            = 0
+	ldapResultSizeLimitExceeded  = 4
+	ldapResultProtocolError      = 2
+	ldapResultInvalidCredentials = 49
+	ldapResultUnwillingToPerform = 53
+	ldapApplicationBindRequest   = 0
+	ldapApplicationBindResponse  = 1
+	ldapApplicationUnbindRequest = 2
+	ldapApplicationSearchRequest = 3
+	ldapApplicationSearchEntry   = 4
+	ldapApplicationSearchDone    = 5
+	ldapApplicationExtendedReq   = 23
+	ldapApplicationExtendedResp  = 24
+	ldapStartTLSOID              = "1.3.6.1.4.1.1466.20037"
+	ldapFilterAnd                = 0
+	ldapFilterOr                 = 1
+	ldapFilterNot                = 2
+	ldapFilterEqualityMatch      = 3
+	ldapFilterSubstrings         = 4
+	ldapFilterPresent            = 7
+	ldapFilterSubstringsInitial  = 0
+	ldapFilterSubstringsAny      = 1
+	ldapFilterSubstringsFinal    = 2
+)
+
+func (l *ldapServer) serve() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			l.handle(conn)
+		}()
+	}
+}
+
+func (l *ldapServer) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID, _ := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+		switch request.Tag {
+		case ldapApplicationBindRequest:
+			l.write(conn, ldapResult(messageID, ldapApplicationBindResponse, l.bind(request)))
+		case ldapApplicationSearchRequest:
+			for _, response := range l.search(messageID, request) {
+				l.write(conn, response)
+			}
+		case ldapApplicationExtendedReq:
+			if len(request.Children) == 0 || request.Children[0].Data.String() != ldapStartTLSOID {
+				l.write(conn, ldapResult(messageID, ldapApplicationExtendedResp, ldapResultProtocolError))
+				continue
+			}
+			if _, isTLS := conn.(*tls.Conn); isTLS {
+				l.write(conn, ldapResult(messageID, ldapApplicationExtendedResp, ldapResultUnwillingToPerform))
+				continue
+			}
+			l.write(conn, ldapResult(messageID, ldapApplicationExtendedResp, ldapResultSuccess))
+			tlsConn := tls.Server(conn, l.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		case ldapApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (l *ldapServer) write(conn net.Conn, packet *ber.Packet) {
+	if _, err := conn.Write(packet.Bytes()); err != nil {
+		l.t.Logf("failed to write LDAP response (%v)", err)
+	}
+}
+
+func (l *ldapServer) bind(request *ber.Packet) int {
+	if len(request.Children) < 3 {
+		return ldapResultProtocolError
+	}
+	dn := request.Children[1].Data.String()
+	password := request.Children[2].Data.String()
+	if dn == "" && password == "" {
+		return ldapResultSuccess
+	}
+	for _, entry := range l.entries {
+		if strings.EqualFold(entry.DN, dn) && entry.Password != "" && entry.Password == password {
+			return ldapResultSuccess
+		}
+	}
+	return ldapResultInvalidCredentials
+}
+
+func (l *ldapServer) search(messageID int64, request *ber.Packet) []*ber.Packet {
+	if len(request.Children) < 8 {
+		return []*ber.Packet{ldapResult(messageID, ldapApplicationSearchDone, ldapResultProtocolError)}
+	}
+	baseDN := strings.ToLower(request.Children[0].Data.String())
+	sizeLimit, _ := request.Children[3].Value.(int64)
+	filter := request.Children[6]
+	var attributes []string
+	for _, attribute := range request.Children[7].Children {
+		attributes = append(attributes, attribute.Data.String())
+	}
+
+	var result []*ber.Packet
+	for _, entry := range l.entries {
+		if !strings.HasSuffix(strings.ToLower(entry.DN), baseDN) || !ldapMatch(entry, filter) {
+			continue
+		}
+		if sizeLimit > 0 && int64(len(result)) >= sizeLimit {
+			return append(result, ldapResult(messageID, ldapApplicationSearchDone, ldapResultSizeLimitExceeded))
+		}
+		result = append(result, ldapSearchEntry(messageID, entry, attributes))
+	}
+	return append(result, ldapResult(messageID, ldapApplicationSearchDone, ldapResultSuccess))
+}
+
+func ldapAttributeValues(entry LDAPEntry, name string) []string {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func ldapMatch(entry LDAPEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldapFilterAnd:
+		for _, child := range filter.Children {
+			if !ldapMatch(entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldapFilterOr:
+		for _, child := range filter.Children {
+			if ldapMatch(entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldapFilterNot:
+		return len(filter.Children) == 1 && !ldapMatch(entry, filter.Children[0])
+	case ldapFilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		expected := filter.Children[1].Data.String()
+		for _, value := range ldapAttributeValues(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, expected) {
+				return true
+			}
+		}
+		return false
+	case ldapFilterPresent:
+		return len(ldapAttributeValues(entry, filter.Data.String())) > 0
+	case ldapFilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, value := range ldapAttributeValues(entry, filter.Children[0].Data.String()) {
+			if ldapMatchSubstrings(strings.ToLower(value), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func ldapMatchSubstrings(value string, substrings []*ber.Packet) bool {
+	for _, substring := range substrings {
+		part := strings.ToLower(substring.Data.String())
+		switch substring.Tag {
+		case ldapFilterSubstringsInitial:
+			if !strings.HasPrefix(value, part) {
+				return false
+			}
+			value = value[len(part):]
+		case ldapFilterSubstringsAny:
+			index := strings.Index(value, part)
+			if index < 0 {
+				return false
+			}
+			value = value[index+len(part):]
+		case ldapFilterSubstringsFinal:
+			if !strings.HasSuffix(value, part) {
+				return false
+			}
+			value = ""
+		}
+	}
+	return true
+}
+
+func ldapEnvelope(messageID int64, op *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	packet.AppendChild(op)
+	return packet
+}
+
+func ldapResult(messageID int64, tag ber.Tag, resultCode int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return ldapEnvelope(messageID, op)
+}
+
+func ldapSearchEntry(messageID int64, entry LDAPEntry, attributes []string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapApplicationSearchEntry, nil, "SearchResultEntry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "objectName"))
+	attributeList := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range entry.Attributes {
+		if !ldapAttributeRequested(name, attributes) {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		valueSet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			valueSet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(valueSet)
+		attributeList.AppendChild(attribute)
+	}
+	op.AppendChild(attributeList)
+	return ldapEnvelope(messageID, op)
+}
+
+func ldapAttributeRequested(name string, attributes []string) bool {
+	if len(attributes) == 0 {
+		return true
+	}
+	for _, attribute := range attributes {
+		if attribute == "*" || strings.EqualFold(attribute, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// createLDAPCertificate creates a self-signed certificate for 127.0.0.1 and returns it in PEM format along with a TLS
+// server configuration using it.
+func createLDAPCertificate(t *testing.T) (string, *tls.Config) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "LDAP test server"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	certData, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certData})), &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		Certificates: []tls.Certificate{
+			{
+				Certificate: [][]byte{certData},
+				PrivateKey:  privateKey,
+			},
+		},
+	}
+}
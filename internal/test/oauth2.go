@@ -0,0 +1,184 @@
+package test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	goHttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/http"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/service"
+)
+
+// oauth2Stub holds the state shared by the OAuth2 test servers: the client credentials, the users, and the issued
+// authorization codes and access tokens.
+type oauth2Stub struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+
+	lock      sync.Mutex
+	users     map[string][]string
+	authCodes map[string]oauth2StubCode
+	tokens    map[string]string
+}
+
+type oauth2StubCode struct {
+	username    string
+	redirectURI string
+}
+
+func newOAuth2Stub(t *testing.T, name string) *oauth2Stub {
+	return &oauth2Stub{
+		baseURL:      fmt.Sprintf("http://127.0.0.1:%d", GetNextPort(t, name)),
+		clientID:     randomHex(8),
+		clientSecret: randomHex(16),
+		users:        map[string][]string{},
+		authCodes:    map[string]oauth2StubCode{},
+		tokens:       map[string]string{},
+	}
+}
+
+func randomHex(length int) string {
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(data)
+}
+
+// start runs an HTTP server with the handler on the base URL until the test ends.
+func (o *oauth2Stub) start(t *testing.T, name string, handler goHttp.Handler) {
+	srv, err := http.NewServer(
+		name,
+		config.HTTPServerConfiguration{
+			Listen: strings.TrimPrefix(o.baseURL, "http://"),
+		},
+		handler,
+		log.NewTestLogger(t),
+		func(_ string) {},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := service.NewLifecycle(srv)
+	ready := make(chan struct{})
+	lifecycle.OnRunning(func(_ service.Service, _ service.Lifecycle) {
+		close(ready)
+	})
+	go func() {
+		_ = lifecycle.Run()
+	}()
+	<-ready
+	t.Cleanup(func() {
+		lifecycle.Stop(context.Background())
+	})
+}
+
+func (o *oauth2Stub) addUser(username string, groups []string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.users[username] = groups
+}
+
+// login simulates the user logging in with their browser on the authorization URL. It returns the state and the
+// authorization code the redirect server would receive.
+func (o *oauth2Stub) login(authorizationURL string, authorizePath string, username string) (string, string, error) {
+	link, err := url.Parse(authorizationURL)
+	if err != nil {
+		return "", "", err
+	}
+	if link.Scheme+"://"+link.Host != o.baseURL || link.Path != authorizePath {
+		return "", "", fmt.Errorf("invalid authorization URL: %s", authorizationURL)
+	}
+	query := link.Query()
+	if query.Get("client_id") != o.clientID {
+		return "", "", fmt.Errorf("invalid client ID: %s", query.Get("client_id"))
+	}
+	if query.Get("response_type") != "code" {
+		return "", "", fmt.Errorf("invalid response type: %s", query.Get("response_type"))
+	}
+	if query.Get("redirect_uri") == "" {
+		return "", "", fmt.Errorf("no redirect URI")
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	if _, ok := o.users[username]; !ok {
+		return "", "", fmt.Errorf("no such user: %s", username)
+	}
+	code := randomHex(16)
+	o.authCodes[code] = oauth2StubCode{username, query.Get("redirect_uri")}
+	return query.Get("state"), code, nil
+}
+
+// exchangeCode returns a new access token for the authorization code, or an OAuth2 error code.
+func (o *oauth2Stub) exchangeCode(form url.Values) (string, string) {
+	if form.Get("client_id") != o.clientID || form.Get("client_secret") != o.clientSecret {
+		return "", "invalid_client"
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	code, ok := o.authCodes[form.Get("code")]
+	if !ok {
+		return "", "invalid_grant"
+	}
+	delete(o.authCodes, form.Get("code"))
+	if code.redirectURI != form.Get("redirect_uri") {
+		return "", "invalid_grant"
+	}
+	return o.issueToken(code.username), ""
+}
+
+// issueToken must be called with the lock held.
+func (o *oauth2Stub) issueToken(username string) string {
+	token := randomHex(16)
+	o.tokens[token] = username
+	return token
+}
+
+func (o *oauth2Stub) revokeToken(token string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	delete(o.tokens, token)
+}
+
+// authenticatedUser returns the user the bearer token of the request belongs to.
+func (o *oauth2Stub) authenticatedUser(request *goHttp.Request) (string, []string, bool) {
+	authorization := strings.SplitN(request.Header.Get("Authorization"), " ", 2)
+	if len(authorization) != 2 || !strings.EqualFold(authorization[0], "bearer") {
+		return "", nil, false
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	username, ok := o.tokens[authorization[1]]
+	if !ok {
+		return "", nil, false
+	}
+	return username, o.users[username], true
+}
+
+func (o *oauth2Stub) tokenCount() int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return len(o.tokens)
+}
+
+func (o *oauth2Stub) writeJSON(writer goHttp.ResponseWriter, status int, result interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(result); err != nil {
+		panic(err)
+	}
+}
+
+func (o *oauth2Stub) sendError(writer goHttp.ResponseWriter, err string) {
+	o.writeJSON(writer, 400, map[string]string{"error": err})
+}
@@ -0,0 +1,183 @@
+package test
+
+import (
+	goHttp "net/http"
+	"testing"
+	"time"
+)
+
+// OIDCProviderServer starts an in-process OIDC provider supporting discovery, the authorization code flow, ID tokens,
+// the userinfo endpoint and token revocation. Unlike OIDCServer, the claims of each user can be set by the test. The
+// server is stopped when the test ends.
+func OIDCProviderServer(t *testing.T) OIDCProviderServerInstance {
+	handler := &oidcProviderHandler{
+		oauth2Stub:     newOAuth2Stub(t, "OIDC provider"),
+		idTokenClaims:  map[string]map[string]interface{}{},
+		userinfoClaims: map[string]map[string]interface{}{},
+	}
+	handler.start(t, "OIDC provider", handler)
+	return handler
+}
+
+// OIDCProviderServerInstance is a running OIDC provider.
+type OIDCProviderServerInstance interface {
+	// BaseURL returns the issuer URL. The OpenID configuration is served under /.well-known/openid-configuration.
+	BaseURL() string
+	// ClientID returns the OAuth2 client ID accepted by the server.
+	ClientID() string
+	// ClientSecret returns the OAuth2 client secret accepted by the server.
+	ClientSecret() string
+	// AddUser adds a user with the claims returned in the ID token and the claims returned from the userinfo
+	// endpoint. The sub claim is set to the username in both if not present.
+	AddUser(username string, idTokenClaims map[string]interface{}, userinfoClaims map[string]interface{})
+	// Login simulates the user logging in on the authorization URL with their browser and returns the state and
+	// authorization code the redirect page would display.
+	Login(authorizationURL string, username string) (state string, code string, err error)
+	// ActiveTokens returns the number of access tokens that have not been revoked.
+	ActiveTokens() int
+}
+
+type oidcProviderHandler struct {
+	*oauth2Stub
+
+	idTokenClaims  map[string]map[string]interface{}
+	userinfoClaims map[string]map[string]interface{}
+}
+
+func (o *oidcProviderHandler) BaseURL() string {
+	return o.baseURL
+}
+
+func (o *oidcProviderHandler) ClientID() string {
+	return o.clientID
+}
+
+func (o *oidcProviderHandler) ClientSecret() string {
+	return o.clientSecret
+}
+
+func (o *oidcProviderHandler) AddUser(
+	username string,
+	idTokenClaims map[string]interface{},
+	userinfoClaims map[string]interface{},
+) {
+	o.addUser(username, nil)
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.idTokenClaims[username] = withSubject(idTokenClaims, username)
+	o.userinfoClaims[username] = withSubject(userinfoClaims, username)
+}
+
+func withSubject(claims map[string]interface{}, username string) map[string]interface{} {
+	result := map[string]interface{}{"sub": username}
+	for claim, value := range claims {
+		result[claim] = value
+	}
+	return result
+}
+
+func (o *oidcProviderHandler) Login(authorizationURL string, username string) (string, string, error) {
+	return o.login(authorizationURL, "/authorize", username)
+}
+
+func (o *oidcProviderHandler) ActiveTokens() int {
+	return o.tokenCount()
+}
+
+func (o *oidcProviderHandler) ServeHTTP(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	switch request.URL.Path {
+	case "/.well-known/openid-configuration":
+		o.configuration(writer)
+	case "/token":
+		o.token(writer, request)
+	case "/userinfo":
+		o.userinfo(writer, request)
+	case "/revoke":
+		o.revoke(writer, request)
+	default:
+		writer.WriteHeader(404)
+	}
+}
+
+func (o *oidcProviderHandler) configuration(writer goHttp.ResponseWriter) {
+	o.writeJSON(writer, 200, map[string]interface{}{
+		"issuer":                                o.baseURL,
+		"authorization_endpoint":                o.baseURL + "/authorize",
+		"token_endpoint":                        o.baseURL + "/token",
+		"userinfo_endpoint":                     o.baseURL + "/userinfo",
+		"revocation_endpoint":                   o.baseURL + "/revoke",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code"},
+		"id_token_signing_alg_values_supported": []string{"HS256"},
+	})
+}
+
+// parseClientForm parses the request form and adds the client credentials from the basic authorization header.
+func (o *oidcProviderHandler) parseClientForm(request *goHttp.Request) bool {
+	if err := request.ParseForm(); err != nil || request.Method != goHttp.MethodPost {
+		return false
+	}
+	if clientID, clientSecret, ok := request.BasicAuth(); ok {
+		request.PostForm.Set("client_id", clientID)
+		request.PostForm.Set("client_secret", clientSecret)
+	}
+	return true
+}
+
+func (o *oidcProviderHandler) token(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if !o.parseClientForm(request) {
+		o.sendError(writer, "invalid_request")
+		return
+	}
+	if request.PostForm.Get("grant_type") != "authorization_code" {
+		o.sendError(writer, "unsupported_grant_type")
+		return
+	}
+	token, errorCode := o.exchangeCode(request.PostForm)
+	if errorCode != "" {
+		o.sendError(writer, errorCode)
+		return
+	}
+	o.lock.Lock()
+	idToken := map[string]interface{}{
+		"iss": o.baseURL,
+		"aud": o.clientID,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for claim, value := range o.idTokenClaims[o.tokens[token]] {
+		idToken[claim] = value
+	}
+	o.lock.Unlock()
+	o.writeJSON(writer, 200, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     createJWT(idToken, []byte(o.clientSecret)),
+	})
+}
+
+func (o *oidcProviderHandler) userinfo(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	username, _, ok := o.authenticatedUser(request)
+	if !ok {
+		o.writeJSON(writer, 401, map[string]string{"error": "invalid_token"})
+		return
+	}
+	o.lock.Lock()
+	claims := o.userinfoClaims[username]
+	o.lock.Unlock()
+	o.writeJSON(writer, 200, claims)
+}
+
+func (o *oidcProviderHandler) revoke(writer goHttp.ResponseWriter, request *goHttp.Request) {
+	if !o.parseClientForm(request) {
+		o.sendError(writer, "invalid_request")
+		return
+	}
+	if request.PostForm.Get("client_id") != o.clientID || request.PostForm.Get("client_secret") != o.clientSecret {
+		o.sendError(writer, "invalid_client")
+		return
+	}
+	o.revokeToken(request.PostForm.Get("token"))
+	writer.WriteHeader(200)
+}
@@ -0,0 +1,208 @@
+package throttle
+
+import (
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// networkHandler holds the throttles shared by all channels of a connection.
+type networkHandler struct {
+	sshserver.NetworkConnectionHandler
+
+	config   config.ThrottleConfig
+	metrics  *Metrics
+	upload   *sshserver.Throttle
+	download *sshserver.Throttle
+}
+
+func (n *networkHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticatedMetadata) (
+	connection sshserver.SSHConnectionHandler,
+	metadata metadata.ConnectionAuthenticatedMetadata,
+	failureReason error,
+) {
+	connection, metadata, failureReason = n.NetworkConnectionHandler.OnHandshakeSuccess(meta)
+	if failureReason != nil {
+		return connection, metadata, failureReason
+	}
+	return &sshConnectionHandler{
+		SSHConnectionHandler: connection,
+		network:              n,
+	}, metadata, nil
+}
+
+// forwardThrottles creates the upload and download throttles of a new forwarding channel, including the connection
+// throttles.
+func (n *networkHandler) forwardThrottles() (upload []*sshserver.Throttle, download []*sshserver.Throttle) {
+	upload = []*sshserver.Throttle{
+		n.metrics.newThrottle(n.config.Forward.Upload, scopeForward, directionUpload),
+		n.upload,
+	}
+	download = []*sshserver.Throttle{
+		n.metrics.newThrottle(n.config.Forward.Download, scopeForward, directionDownload),
+		n.download,
+	}
+	return upload, download
+}
+
+type sshConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+
+	network *networkHandler
+}
+
+func (s *sshConnectionHandler) OnSessionChannel(
+	channelMetadata metadata.ChannelMetadata,
+	extraData []byte,
+	session sshserver.SessionChannel,
+) (channel sshserver.SessionChannelHandler, failureReason sshserver.ChannelRejection) {
+	n := s.network
+	session = sshserver.ThrottleSessionChannel(
+		session,
+		[]*sshserver.Throttle{
+			n.metrics.newThrottle(n.config.Session.Upload, scopeSession, directionUpload),
+			n.upload,
+		},
+		[]*sshserver.Throttle{
+			n.metrics.newThrottle(n.config.Session.Download, scopeSession, directionDownload),
+			n.download,
+		},
+	)
+	channel, failureReason = s.SSHConnectionHandler.OnSessionChannel(channelMetadata, extraData, session)
+	if failureReason != nil {
+		return channel, failureReason
+	}
+	return &sessionChannelHandler{SessionChannelHandler: channel, network: n}, nil
+}
+
+func (s *sshConnectionHandler) OnTCPForwardChannel(
+	channelID uint64,
+	hostToConnect string,
+	portToConnect uint32,
+	originatorHost string,
+	originatorPort uint32,
+) (channel sshserver.ForwardChannel, failureReason sshserver.ChannelRejection) {
+	channel, failureReason = s.SSHConnectionHandler.OnTCPForwardChannel(
+		channelID,
+		hostToConnect,
+		portToConnect,
+		originatorHost,
+		originatorPort,
+	)
+	if failureReason != nil {
+		return channel, failureReason
+	}
+	// The SSH server reads the data sent to the client from the channel and writes the data sent by the client to it.
+	upload, download := s.network.forwardThrottles()
+	return sshserver.ThrottleForwardChannel(channel, download, upload), nil
+}
+
+func (s *sshConnectionHandler) OnDirectStreamLocal(
+	channelID uint64,
+	path string,
+) (channel sshserver.ForwardChannel, failureReason sshserver.ChannelRejection) {
+	channel, failureReason = s.SSHConnectionHandler.OnDirectStreamLocal(channelID, path)
+	if failureReason != nil {
+		return channel, failureReason
+	}
+	upload, download := s.network.forwardThrottles()
+	return sshserver.ThrottleForwardChannel(channel, download, upload), nil
+}
+
+func (s *sshConnectionHandler) OnRequestTCPReverseForward(
+	bindHost string,
+	bindPort uint32,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return s.SSHConnectionHandler.OnRequestTCPReverseForward(
+		bindHost,
+		bindPort,
+		&reverseForward{ReverseForward: reverseHandler, network: s.network},
+	)
+}
+
+func (s *sshConnectionHandler) OnRequestStreamLocal(path string, reverseHandler sshserver.ReverseForward) error {
+	return s.SSHConnectionHandler.OnRequestStreamLocal(
+		path,
+		&reverseForward{ReverseForward: reverseHandler, network: s.network},
+	)
+}
+
+// sessionChannelHandler throttles the X11 and SSH agent channels requested on a session channel.
+type sessionChannelHandler struct {
+	sshserver.SessionChannelHandler
+
+	network *networkHandler
+}
+
+func (s *sessionChannelHandler) OnX11Request(
+	requestID uint64,
+	singleConnection bool,
+	protocol string,
+	cookie string,
+	screen uint32,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	return s.SessionChannelHandler.OnX11Request(
+		requestID,
+		singleConnection,
+		protocol,
+		cookie,
+		screen,
+		&reverseForward{ReverseForward: reverseHandler, network: s.network},
+	)
+}
+
+func (s *sessionChannelHandler) OnAuthAgentRequest(requestID uint64, reverseHandler sshserver.ReverseForward) error {
+	return s.SessionChannelHandler.OnAuthAgentRequest(
+		requestID,
+		&reverseForward{ReverseForward: reverseHandler, network: s.network},
+	)
+}
+
+// reverseForward throttles the channels the backend opens towards the client. The backend writes the data sent to the
+// client to these channels and reads the data sent by the client from them.
+type reverseForward struct {
+	sshserver.ReverseForward
+
+	network *networkHandler
+}
+
+func (r *reverseForward) throttle(
+	channel sshserver.ForwardChannel,
+	channelID uint64,
+	err error,
+) (sshserver.ForwardChannel, uint64, error) {
+	if err != nil {
+		return channel, channelID, err
+	}
+	upload, download := r.network.forwardThrottles()
+	return sshserver.ThrottleForwardChannel(channel, upload, download), channelID, nil
+}
+
+func (r *reverseForward) NewChannelTCP(
+	connectedAddress string,
+	connectedPort uint32,
+	originatorAddress string,
+	originatorPort uint32,
+) (sshserver.ForwardChannel, uint64, error) {
+	return r.throttle(
+		r.ReverseForward.NewChannelTCP(connectedAddress, connectedPort, originatorAddress, originatorPort),
+	)
+}
+
+func (r *reverseForward) NewChannelUnix(path string) (sshserver.ForwardChannel, uint64, error) {
+	return r.throttle(r.ReverseForward.NewChannelUnix(path))
+}
+
+func (r *reverseForward) NewChannelX11(originatorAddress string, originatorPort uint32) (
+	sshserver.ForwardChannel,
+	uint64,
+	error,
+) {
+	return r.throttle(r.ReverseForward.NewChannelX11(originatorAddress, originatorPort))
+}
+
+func (r *reverseForward) NewChannelAuthAgent() (sshserver.ForwardChannel, uint64, error) {
+	return r.throttle(r.ReverseForward.NewChannelAuthAgent())
+}
@@ -0,0 +1,30 @@
+package throttle
+
+import (
+	"fmt"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/sshserver"
+)
+
+// New wraps the backend of a single connection with the bandwidth limits in cfg. If no limits are configured the
+// backend is returned unchanged. The metrics may be nil.
+func New(
+	cfg config.ThrottleConfig,
+	backend sshserver.NetworkConnectionHandler,
+	metrics *Metrics,
+) (sshserver.NetworkConnectionHandler, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid throttle configuration (%w)", err)
+	}
+	if cfg == (config.ThrottleConfig{}) {
+		return backend, nil
+	}
+	return &networkHandler{
+		NetworkConnectionHandler: backend,
+		config:                   cfg,
+		metrics:                  metrics,
+		upload:                   metrics.newThrottle(cfg.Connection.Upload, scopeConnection, directionUpload),
+		download:                 metrics.newThrottle(cfg.Connection.Download, scopeConnection, directionDownload),
+	}, nil
+}
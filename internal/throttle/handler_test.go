@@ -0,0 +1,392 @@
+package throttle_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/geoip/dummy"
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/internal/throttle"
+	"go.containerssh.io/containerssh/metadata"
+)
+
+// testRate is the rate of the throttles in the tests. The burst is one second worth of data, so transferring 12000
+// bytes is delayed by 0.2 seconds.
+const testRate = 10000
+
+// transfer moves the specified number of bytes through a channel.
+type transfer func(bytes int64)
+
+// channelDirections opens a channel of each type through the throttled connection and returns functions that
+// transfer data from the client (upload) and to the client (download) through it.
+var channelDirections = map[string]func(t *testing.T, connection *testConnection) (upload transfer, download transfer){
+	"session": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		session := connection.openSession(t)
+		return func(bytes int64) {
+				_, err := io.CopyN(io.Discard, session.Stdin(), bytes)
+				assert.NoError(t, err)
+			}, func(bytes int64) {
+				_, err := session.Stdout().Write(make([]byte, bytes))
+				assert.NoError(t, err)
+			}
+	},
+	"direct tcp": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		channel, rejection := connection.handler.OnTCPForwardChannel(1, "127.0.0.1", 80, "127.0.0.1", 1234)
+		if rejection != nil {
+			t.Fatal(rejection)
+		}
+		// The SSH server writes the data sent by the client to the channel and reads the data sent to the client.
+		return writeTo(t, channel), readFrom(t, channel)
+	},
+	"direct streamlocal": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		channel, rejection := connection.handler.OnDirectStreamLocal(1, "/tmp/test.sock")
+		if rejection != nil {
+			t.Fatal(rejection)
+		}
+		return writeTo(t, channel), readFrom(t, channel)
+	},
+	"reverse tcp": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		reverse := connection.reverseForward(t, func(r sshserver.ReverseForward) error {
+			return connection.handler.OnRequestTCPReverseForward("127.0.0.1", 8080, r)
+		})
+		channel, _, err := reverse.NewChannelTCP("127.0.0.1", 8080, "127.0.0.1", 1234)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The backend reads the data sent by the client from the channel and writes the data sent to the client.
+		return readFrom(t, channel), writeTo(t, channel)
+	},
+	"reverse streamlocal": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		reverse := connection.reverseForward(t, func(r sshserver.ReverseForward) error {
+			return connection.handler.OnRequestStreamLocal("/tmp/test.sock", r)
+		})
+		channel, _, err := reverse.NewChannelUnix("/tmp/test.sock")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readFrom(t, channel), writeTo(t, channel)
+	},
+	"x11": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		session := connection.openSessionHandler(t)
+		reverse := connection.reverseForward(t, func(r sshserver.ReverseForward) error {
+			return session.OnX11Request(1, false, "MIT-MAGIC-COOKIE-1", "cookie", 0, r)
+		})
+		channel, _, err := reverse.NewChannelX11("127.0.0.1", 1234)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readFrom(t, channel), writeTo(t, channel)
+	},
+	"auth agent": func(t *testing.T, connection *testConnection) (transfer, transfer) {
+		session := connection.openSessionHandler(t)
+		reverse := connection.reverseForward(t, func(r sshserver.ReverseForward) error {
+			return session.OnAuthAgentRequest(1, r)
+		})
+		channel, _, err := reverse.NewChannelAuthAgent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readFrom(t, channel), writeTo(t, channel)
+	},
+}
+
+func TestChannelDirections(t *testing.T) {
+	for name, open := range channelDirections {
+		open := open
+		scope := "forward"
+		if name == "session" {
+			scope = "session"
+		}
+		t.Run(name, func(t *testing.T) {
+			for _, direction := range []string{"upload", "download"} {
+				t.Run(direction, func(t *testing.T) {
+					rate := config.ThrottleRateConfig{}
+					if direction == "upload" {
+						rate.Upload = testRate
+					} else {
+						rate.Download = testRate
+					}
+					cfg := config.ThrottleConfig{Forward: rate}
+					if scope == "session" {
+						cfg = config.ThrottleConfig{Session: rate}
+					}
+					connection := newTestConnection(t, cfg)
+					upload, download := open(t, connection)
+
+					upload(12000)
+					download(12000)
+
+					other := "download"
+					if direction == "download" {
+						other = "upload"
+					}
+					assert.Greater(t, connection.delayedBytes(scope, direction), float64(0))
+					assert.Equal(t, float64(0), connection.delayedBytes(scope, other))
+				})
+			}
+		})
+	}
+}
+
+func TestConnectionThrottleShared(t *testing.T) {
+	collector := metrics.New(dummy.New())
+	cfg := config.ThrottleConfig{Connection: config.ThrottleRateConfig{Upload: testRate, Download: testRate}}
+	connection := newTestConnectionWithCollector(t, cfg, collector)
+
+	// Another connection has its own bucket, so it is not delayed by the traffic of the first one.
+	other := newTestConnectionWithCollector(t, cfg, collector)
+	otherUpload, _ := channelDirections["direct tcp"](t, other)
+
+	// Each channel stays within the burst, but together they exceed it.
+	for _, name := range []string{"session", "direct tcp", "reverse tcp"} {
+		upload, _ := channelDirections[name](t, connection)
+		upload(4000)
+	}
+	uploadDelayed := connection.delayedBytes("connection", "upload")
+	assert.Greater(t, uploadDelayed, float64(0))
+	assert.Equal(t, float64(0), connection.delayedBytes("connection", "download"))
+
+	otherUpload(4000)
+	assert.Equal(t, uploadDelayed, connection.delayedBytes("connection", "upload"))
+}
+
+func TestDisabled(t *testing.T) {
+	backend := &testNetworkHandler{}
+	handler, err := throttle.New(config.ThrottleConfig{}, backend, nil)
+	assert.NoError(t, err)
+	assert.Same(t, backend, handler)
+}
+
+func writeTo(t *testing.T, channel sshserver.ForwardChannel) transfer {
+	return func(bytes int64) {
+		_, err := channel.Write(make([]byte, bytes))
+		assert.NoError(t, err)
+	}
+}
+
+func readFrom(t *testing.T, channel sshserver.ForwardChannel) transfer {
+	return func(bytes int64) {
+		_, err := io.CopyN(io.Discard, channel, bytes)
+		assert.NoError(t, err)
+	}
+}
+
+type testConnection struct {
+	handler   sshserver.SSHConnectionHandler
+	backend   *testSSHConnectionHandler
+	collector metrics.Collector
+}
+
+func newTestConnection(t *testing.T, cfg config.ThrottleConfig) *testConnection {
+	return newTestConnectionWithCollector(t, cfg, metrics.New(dummy.New()))
+}
+
+func newTestConnectionWithCollector(
+	t *testing.T,
+	cfg config.ThrottleConfig,
+	collector metrics.Collector,
+) *testConnection {
+	backend := &testSSHConnectionHandler{}
+	networkHandler, err := throttle.New(
+		cfg,
+		&testNetworkHandler{connection: backend},
+		throttle.NewMetrics(collector),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, _, err := networkHandler.OnHandshakeSuccess(metadata.NewTestAuthenticatingMetadata("foo").Authenticated("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testConnection{handler: handler, backend: backend, collector: collector}
+}
+
+// openSession opens a session channel and returns the session channel the backend received.
+func (c *testConnection) openSession(t *testing.T) sshserver.SessionChannel {
+	c.openSessionHandler(t)
+	return c.backend.session
+}
+
+func (c *testConnection) openSessionHandler(t *testing.T) sshserver.SessionChannelHandler {
+	handler, rejection := c.handler.OnSessionChannel(metadata.ChannelMetadata{}, nil, &testSessionChannel{})
+	if rejection != nil {
+		t.Fatal(rejection)
+	}
+	return handler
+}
+
+// reverseForward requests a reverse forwarding and returns the reverse handler the backend received.
+func (c *testConnection) reverseForward(
+	t *testing.T,
+	request func(reverseHandler sshserver.ReverseForward) error,
+) sshserver.ReverseForward {
+	if err := request(&testReverseForward{}); err != nil {
+		t.Fatal(err)
+	}
+	return c.backend.reverseHandler
+}
+
+func (c *testConnection) delayedBytes(scope string, direction string) float64 {
+	var result float64
+	for _, value := range c.collector.GetMetric(throttle.MetricNameDelayedBytes) {
+		if value.Labels["scope"] == scope && value.Labels["direction"] == direction {
+			result += value.Value
+		}
+	}
+	return result
+}
+
+type testNetworkHandler struct {
+	sshserver.AbstractNetworkConnectionHandler
+
+	connection *testSSHConnectionHandler
+}
+
+func (n *testNetworkHandler) OnHandshakeSuccess(meta metadata.ConnectionAuthenticatedMetadata) (
+	sshserver.SSHConnectionHandler,
+	metadata.ConnectionAuthenticatedMetadata,
+	error,
+) {
+	return n.connection, meta, nil
+}
+
+// testSSHConnectionHandler is the backend. It accepts all channels and records the session and reverse handler it
+// receives so the test can use them the way the backend would.
+type testSSHConnectionHandler struct {
+	sshserver.SSHConnectionHandler
+
+	session        sshserver.SessionChannel
+	reverseHandler sshserver.ReverseForward
+}
+
+func (s *testSSHConnectionHandler) OnSessionChannel(
+	_ metadata.ChannelMetadata,
+	_ []byte,
+	session sshserver.SessionChannel,
+) (sshserver.SessionChannelHandler, sshserver.ChannelRejection) {
+	s.session = session
+	return &testSessionChannelHandler{backend: s}, nil
+}
+
+func (s *testSSHConnectionHandler) OnTCPForwardChannel(
+	_ uint64,
+	_ string,
+	_ uint32,
+	_ string,
+	_ uint32,
+) (sshserver.ForwardChannel, sshserver.ChannelRejection) {
+	return &testForwardChannel{}, nil
+}
+
+func (s *testSSHConnectionHandler) OnDirectStreamLocal(
+	_ uint64,
+	_ string,
+) (sshserver.ForwardChannel, sshserver.ChannelRejection) {
+	return &testForwardChannel{}, nil
+}
+
+func (s *testSSHConnectionHandler) OnRequestTCPReverseForward(
+	_ string,
+	_ uint32,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	s.reverseHandler = reverseHandler
+	return nil
+}
+
+func (s *testSSHConnectionHandler) OnRequestStreamLocal(_ string, reverseHandler sshserver.ReverseForward) error {
+	s.reverseHandler = reverseHandler
+	return nil
+}
+
+type testSessionChannelHandler struct {
+	sshserver.AbstractSessionChannelHandler
+
+	backend *testSSHConnectionHandler
+}
+
+func (s *testSessionChannelHandler) OnX11Request(
+	_ uint64,
+	_ bool,
+	_ string,
+	_ string,
+	_ uint32,
+	reverseHandler sshserver.ReverseForward,
+) error {
+	s.backend.reverseHandler = reverseHandler
+	return nil
+}
+
+func (s *testSessionChannelHandler) OnAuthAgentRequest(_ uint64, reverseHandler sshserver.ReverseForward) error {
+	s.backend.reverseHandler = reverseHandler
+	return nil
+}
+
+// testReverseForward stands in for the SSH client when the backend opens channels towards it.
+type testReverseForward struct{}
+
+func (r *testReverseForward) NewChannelTCP(_ string, _ uint32, _ string, _ uint32) (
+	sshserver.ForwardChannel,
+	uint64,
+	error,
+) {
+	return &testForwardChannel{}, 1, nil
+}
+
+func (r *testReverseForward) NewChannelUnix(_ string) (sshserver.ForwardChannel, uint64, error) {
+	return &testForwardChannel{}, 1, nil
+}
+
+func (r *testReverseForward) NewChannelX11(_ string, _ uint32) (sshserver.ForwardChannel, uint64, error) {
+	return &testForwardChannel{}, 1, nil
+}
+
+func (r *testReverseForward) NewChannelAuthAgent() (sshserver.ForwardChannel, uint64, error) {
+	return &testForwardChannel{}, 1, nil
+}
+
+// testForwardChannel returns zeroes on read and discards all writes.
+type testForwardChannel struct{}
+
+func (c *testForwardChannel) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (c *testForwardChannel) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (c *testForwardChannel) Close() error {
+	return nil
+}
+
+type testSessionChannel struct {
+	testForwardChannel
+}
+
+func (s *testSessionChannel) Stdin() io.Reader {
+	return &s.testForwardChannel
+}
+
+func (s *testSessionChannel) Stdout() io.Writer {
+	return &s.testForwardChannel
+}
+
+func (s *testSessionChannel) Stderr() io.Writer {
+	return &s.testForwardChannel
+}
+
+func (s *testSessionChannel) ExitStatus(_ uint32) {
+}
+
+func (s *testSessionChannel) ExitSignal(_ string, _ bool, _ string, _ string) {
+}
+
+func (s *testSessionChannel) CloseWrite() error {
+	return fmt.Errorf("not implemented")
+}
@@ -0,0 +1,58 @@
+package throttle
+
+import (
+	"time"
+
+	"go.containerssh.io/containerssh/internal/metrics"
+	"go.containerssh.io/containerssh/internal/sshserver"
+)
+
+// MetricNameDelay is the total time data was delayed due to bandwidth limits. The scope label is session, forward or
+// connection, the direction label is upload or download.
+const MetricNameDelay = "containerssh_throttle_delay_seconds_total"
+
+// MetricHelpDelay is the help text for the total time data was delayed due to bandwidth limits.
+const MetricHelpDelay = "Time data was delayed due to bandwidth limits since start"
+
+// MetricNameDelayedBytes is the number of bytes that were delayed due to bandwidth limits. The scope label is session,
+// forward or connection, the direction label is upload or download.
+const MetricNameDelayedBytes = "containerssh_throttle_delayed_bytes_total"
+
+// MetricHelpDelayedBytes is the help text for the number of bytes that were delayed due to bandwidth limits.
+const MetricHelpDelayedBytes = "Bytes delayed due to bandwidth limits since start"
+
+const (
+	scopeSession    = "session"
+	scopeForward    = "forward"
+	scopeConnection = "connection"
+
+	directionUpload   = "upload"
+	directionDownload = "download"
+)
+
+// Metrics holds the throttling metrics. It is created once and shared between all connections.
+type Metrics struct {
+	delay        metrics.Counter
+	delayedBytes metrics.Counter
+}
+
+// NewMetrics creates the throttling metrics in the specified collector.
+func NewMetrics(collector metrics.Collector) *Metrics {
+	return &Metrics{
+		delay:        collector.MustCreateCounter(MetricNameDelay, "seconds_total", MetricHelpDelay),
+		delayedBytes: collector.MustCreateCounter(MetricNameDelayedBytes, "bytes_total", MetricHelpDelayedBytes),
+	}
+}
+
+// newThrottle creates a throttle that records its delays with the scope and direction labels. If the metrics are nil
+// delays are not recorded.
+func (m *Metrics) newThrottle(bytesPerSecond int64, scope string, direction string) *sshserver.Throttle {
+	if m == nil {
+		return sshserver.NewThrottle(bytesPerSecond, nil)
+	}
+	labels := []metrics.MetricLabel{metrics.Label("scope", scope), metrics.Label("direction", direction)}
+	return sshserver.NewThrottle(bytesPerSecond, func(bytes int, delay time.Duration) {
+		_ = m.delay.IncrementBy(delay.Seconds(), labels...)
+		_ = m.delayedBytes.IncrementBy(float64(bytes), labels...)
+	})
+}
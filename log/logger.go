@@ -47,6 +47,13 @@ type Logger interface {
 	Close() error
 }
 
+// LevelSetter is implemented by loggers that can change their log level at runtime.
+type LevelSetter interface {
+	// SetLevel changes the log level of this logger and all loggers created from it using WithLabel. Loggers created
+	// using WithLevel keep their own level.
+	SetLevel(level config.LogLevel)
+}
+
 // LoggerFactory is a factory to create a logger on demand
 type LoggerFactory interface {
 	// Make creates a new logger with the specified configuration and module.
@@ -73,3 +73,25 @@ func testLevel(t *testing.T, logLevel config.LogLevel, writeLogLevel config.LogL
 		assert.Equal(t, "test", data["message"])
 	}
 }
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	p := log.MustNewLogger(config.LogConfig{
+		Level:       config.LogLevelWarning,
+		Format:      config.LogFormatLJSON,
+		Destination: config.LogDestinationStdout,
+		Stdout:      &buf,
+	})
+	labeled := p.WithLabel("module", "test")
+	fixed := p.WithLevel(config.LogLevelWarning)
+	msg := message.UserMessage("E_TEST", "test", "test")
+
+	labeled.Debug(msg)
+	assert.Equal(t, 0, buf.Len())
+
+	p.(log.LevelSetter).SetLevel(config.LogLevelDebug)
+	fixed.Debug(msg)
+	assert.Equal(t, 0, buf.Len())
+	labeled.Debug(msg)
+	assert.NotEqual(t, 0, buf.Len())
+}
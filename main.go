@@ -37,7 +37,7 @@ func Main() {
 
 	logger = logger.WithLabel("module", "core")
 
-	configFile, actionDumpConfig, actionLicenses, actionHealthCheck := getArguments()
+	configFile, actionDumpConfig, actionLicenses, actionHealthCheck, watchConfig := getArguments()
 
 	if configFile == "" {
 		configFile = "config.yaml"
@@ -82,7 +82,7 @@ func Main() {
 	case actionHealthCheck:
 		runHealthCheck(cfg, configuredLogger)
 	default:
-		runContainerSSH(loggerFactory, configuredLogger, cfg, configFile)
+		runContainerSSH(loggerFactory, configuredLogger, cfg, configFile, watchConfig)
 	}
 }
 
@@ -116,6 +116,7 @@ func runContainerSSH(
 	logger log.Logger,
 	cfg config.AppConfig,
 	configFile string,
+	watchConfig bool,
 ) {
 	if len(cfg.SSH.HostKeys) == 0 {
 		logger.Warning(
@@ -135,18 +136,19 @@ func runContainerSSH(
 		}
 	}
 
-	if err := startServices(cfg, loggerFactory); err != nil {
+	if err := startServices(cfg, loggerFactory, configFile, watchConfig); err != nil {
 		logger.Critical(err)
 		os.Exit(1)
 	}
 	os.Exit(0)
 }
 
-func getArguments() (string, bool, bool, bool) {
+func getArguments() (string, bool, bool, bool, bool) {
 	configFile := ""
 	actionDumpConfig := false
 	actionLicenses := false
 	healthCheck := false
+	watchConfig := false
 	flag.StringVar(
 		&configFile,
 		"config",
@@ -171,20 +173,114 @@ func getArguments() (string, bool, bool, bool) {
 		false,
 		"Run health check",
 	)
+	flag.BoolVar(
+		&watchConfig,
+		"watch-config",
+		false,
+		"Reload the configuration file when it changes",
+	)
 	flag.Parse()
-	return configFile, actionDumpConfig, actionLicenses, healthCheck
+	return configFile, actionDumpConfig, actionLicenses, healthCheck, watchConfig
 }
 
-func startServices(cfg config.AppConfig, loggerFactory log.LoggerFactory) error {
+func startServices(
+	cfg config.AppConfig,
+	loggerFactory log.LoggerFactory,
+	configFile string,
+	watchConfig bool,
+) error {
 	pool, lifecycle, err := New(cfg, loggerFactory)
 	if err != nil {
 		return err
 	}
 
-	return startPool(pool, lifecycle)
+	logger, err := loggerFactory.Make(cfg.Log)
+	if err != nil {
+		return err
+	}
+	logger = logger.WithLabel("module", "core")
+	reload := func() {
+		reloadConfigFile(configFile, loggerFactory, pool, logger)
+	}
+	if watchConfig {
+		done := make(chan struct{})
+		defer close(done)
+		go watchConfigFile(configFile, reload, done, logger)
+	}
+
+	return startPool(pool, lifecycle, reload)
 }
 
-func startPool(pool Service, lifecycle service.Lifecycle) error {
+// configWatchInterval is the interval at which the configuration file is checked for changes if -watch-config is set.
+const configWatchInterval = 5 * time.Second
+
+// watchConfigFile polls the configuration file and calls reload when its modification time or size changes.
+func watchConfigFile(configFile string, reload func(), done <-chan struct{}, logger log.Logger) {
+	lastStat, err := os.Stat(configFile)
+	if err != nil {
+		logger.Warning(
+			message.Wrap(
+				err,
+				message.ECoreConfigReloadFailed,
+				"Cannot watch configuration file %s for changes",
+				configFile,
+			),
+		)
+		return
+	}
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		stat, err := os.Stat(configFile)
+		if err != nil {
+			// The file may be replaced by an editor or a configuration management tool, try again later.
+			continue
+		}
+		if stat.ModTime().Equal(lastStat.ModTime()) && stat.Size() == lastStat.Size() {
+			continue
+		}
+		lastStat = stat
+		reload()
+	}
+}
+
+// reloadConfigFile reads and validates the configuration file and applies it to new connections. If the file is
+// invalid the current configuration is kept.
+func reloadConfigFile(configFile string, loggerFactory log.LoggerFactory, pool Service, logger log.Logger) {
+	cfg := config.AppConfig{}
+	cfg.Default()
+	if err := readConfigFile(configFile, loggerFactory, &cfg); err != nil {
+		logger.Error(
+			message.Wrap(
+				err,
+				message.ECoreConfigReloadFailed,
+				"Invalid configuration in file %s, keeping the current configuration",
+				configFile,
+			),
+		)
+		return
+	}
+	if len(cfg.SSH.HostKeys) == 0 {
+		logger.Error(
+			message.NewMessage(
+				message.ECoreConfigReloadFailed,
+				"No host keys found in configuration file %s, keeping the current configuration",
+				configFile,
+			),
+		)
+		return
+	}
+	if err := pool.Reload(cfg); err != nil {
+		logger.Error(err)
+	}
+}
+
+func startPool(pool Service, lifecycle service.Lifecycle, reload func()) error {
 	starting := make(chan struct{})
 	lifecycle.OnStarting(
 		func(s service.Service, l service.Lifecycle) {
@@ -201,8 +297,12 @@ func startPool(pool Service, lifecycle service.Lifecycle) error {
 	rotateSignalList := []os.Signal{syscall.SIGHUP}
 	exitSignals := make(chan os.Signal, 1)
 	rotateSignals := make(chan os.Signal, 1)
+	drainSignalChannel := make(chan os.Signal, 1)
 	signal.Notify(exitSignals, exitSignalList...)
 	signal.Notify(rotateSignals, rotateSignalList...)
+	if len(drainSignals) > 0 {
+		signal.Notify(drainSignalChannel, drainSignals...)
+	}
 	go func() {
 		if _, ok := <-exitSignals; ok {
 			// ok means the channel wasn't closed
@@ -223,6 +323,16 @@ func startPool(pool Service, lifecycle service.Lifecycle) error {
 				if err != nil {
 					panic(err)
 				}
+				reload()
+			} else {
+				break
+			}
+		}
+	}()
+	go func() {
+		for {
+			if _, ok := <-drainSignalChannel; ok {
+				pool.Drain()
 			} else {
 				break
 			}
@@ -231,7 +341,11 @@ func startPool(pool Service, lifecycle service.Lifecycle) error {
 	err := lifecycle.Wait()
 	signal.Ignore(rotateSignalList...)
 	signal.Ignore(exitSignalList...)
+	if len(drainSignals) > 0 {
+		signal.Ignore(drainSignals...)
+	}
 	close(exitSignals)
+	close(drainSignalChannel)
 	return err
 }
 
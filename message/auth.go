@@ -146,6 +146,23 @@ const EAuthOIDCHTTPClientCreateFailed = "OIDC_HTTP_CLIENT_CREATE_FAILED"
 // authentication. This happens when enforceScopes is enabled and the user denies one or more requested scopes.
 const EAuthOIDCRequiredScopeNotGranted = "OIDC_REQUIRED_SCOPE_NOT_GRANTED"
 
+// EAuthOIDCIDTokenDecodeFailed indicates that the ID token returned by the OIDC server could not be decoded. The
+// authentication fails since the claims of the token cannot be validated.
+const EAuthOIDCIDTokenDecodeFailed = "OIDC_ID_TOKEN_DECODE_FAILED"
+
+// EAuthOIDCIDTokenInvalid indicates that the ID token returned by the OIDC server was rejected because it was issued
+// by a different issuer, for a different client ID, or has expired. Check if the OIDC URL and client ID in the
+// ContainerSSH configuration match the OIDC server.
+const EAuthOIDCIDTokenInvalid = "OIDC_ID_TOKEN_INVALID"
+
+// EAuthOIDCUserInfoSubjectMismatch indicates that the sub claim returned from the userinfo endpoint did not match the
+// sub claim of the ID token, so the userinfo response was rejected.
+const EAuthOIDCUserInfoSubjectMismatch = "OIDC_USER_INFO_SUBJECT_MISMATCH"
+
+// EAuthOIDCRequiredClaimFailed indicates that the claims of the user did not satisfy one of the required claim
+// expressions, for example because the user is not a member of a required group.
+const EAuthOIDCRequiredClaimFailed = "OIDC_REQUIRED_CLAIM_FAILED"
+
 // EAuthGenericTimeout indicates that the generic oAuth authentication process resulted in a timeout.
 const EAuthGenericTimeout = "GENERIC_TIMEOUT"
 
@@ -157,6 +174,48 @@ const EAuthOAuth2AccessTokenFetchFailed = "GENERIC_ACCESS_TOKEN_FETCH_FAILED"
 // oAuth2 authentication. This is most likely due to a misconfiguration.
 const EAuthOAuth2HTTPClientCreateFailed = "GENERIC_HTTP_CLIENT_CREATE_FAILED"
 
+// EAuthGitLabAccessTokenFetchFailed indicates that ContainerSSH failed to fetch the access token from GitLab. This
+// is usually the result of a user entering the incorrect code.
+const EAuthGitLabAccessTokenFetchFailed = "GITLAB_ACCESS_TOKEN_FETCH_FAILED" //nolint:gosec
+
+// EAuthGitLabUserRequestFailed indicates that fetching the user information or the group memberships from GitLab
+// failed.
+const EAuthGitLabUserRequestFailed = "GITLAB_USER_REQUEST_FAILED"
+
+// EAuthGitLabUsernameDoesNotMatch indicates that the user specified a username other than their GitLab login and
+// enforceUsername was set to on.
+const EAuthGitLabUsernameDoesNotMatch = "GITLAB_USERNAME_DOES_NOT_MATCH"
+
+// EAuthGitLabNotGroupMember indicates that the user is not a member of any of the groups required to log in.
+const EAuthGitLabNotGroupMember = "GITLAB_NOT_GROUP_MEMBER"
+
+// EAuthGitLabHTTPClientCreateFailed indicates that ContainerSSH failed to create an HTTP client for GitLab. This is
+// most likely due to a misconfiguration.
+const EAuthGitLabHTTPClientCreateFailed = "GITLAB_HTTP_CLIENT_CREATE_FAILED"
+
+// EAuthGitLabDeauthorizeFailed indicates that ContainerSSH failed to revoke the GitLab access token when the user
+// disconnected.
+const EAuthGitLabDeauthorizeFailed = "GITLAB_DEAUTHORIZE_FAILED"
+
+// EAuthGiteaAccessTokenFetchFailed indicates that ContainerSSH failed to fetch the access token from Gitea. This is
+// usually the result of a user entering the incorrect code.
+const EAuthGiteaAccessTokenFetchFailed = "GITEA_ACCESS_TOKEN_FETCH_FAILED" //nolint:gosec
+
+// EAuthGiteaUserRequestFailed indicates that fetching the user information or the organization memberships from
+// Gitea failed.
+const EAuthGiteaUserRequestFailed = "GITEA_USER_REQUEST_FAILED"
+
+// EAuthGiteaUsernameDoesNotMatch indicates that the user specified a username other than their Gitea login and
+// enforceUsername was set to on.
+const EAuthGiteaUsernameDoesNotMatch = "GITEA_USERNAME_DOES_NOT_MATCH"
+
+// EAuthGiteaNotOrgMember indicates that the user is not a member of any of the organizations required to log in.
+const EAuthGiteaNotOrgMember = "GITEA_NOT_ORG_MEMBER"
+
+// EAuthGiteaHTTPClientCreateFailed indicates that ContainerSSH failed to create an HTTP client for Gitea. This is
+// most likely due to a misconfiguration.
+const EAuthGiteaHTTPClientCreateFailed = "GITEA_HTTP_CLIENT_CREATE_FAILED"
+
 // EAuthKerberosUsernameDoesNotMatch indicates that the user tried to a user other than their own and enforceUsername was set to on
 const EAuthKerberosUsernameDoesNotMatch = "KRB_USERNAME_DOES_NOT_MATCH"
 
@@ -165,3 +224,139 @@ const EAuthKerberosBackendError = "KRB_BACKEND_ERROR"
 
 // EAuthzFailed indicates that the authorization server rejected the user
 const EAuthzFailed = "AUTHZ_FAILED"
+
+// EAuthCertificateInvalid indicates that the user presented an SSH certificate that is not a valid user certificate,
+// is expired or not yet valid, or does not list the requested username as a principal.
+const EAuthCertificateInvalid = "AUTH_CERT_INVALID"
+
+// EAuthCertificateUntrustedCA indicates that the user presented an SSH certificate that was not signed by any of the
+// configured CA keys, or a plain public key when only certificates are accepted.
+const EAuthCertificateUntrustedCA = "AUTH_CERT_UNTRUSTED_CA"
+
+// EAuthCertificateRevoked indicates that the user presented an SSH certificate that is listed in the revocation list.
+const EAuthCertificateRevoked = "AUTH_CERT_REVOKED"
+
+// EAuthCertificateSourceAddressMismatch indicates that the user presented an SSH certificate with a source-address
+// critical option that does not match the address the user is connecting from.
+const EAuthCertificateSourceAddressMismatch = "AUTH_CERT_SOURCE_ADDRESS_MISMATCH"
+
+// EAuthCertificateRevocationListFailed indicates that ContainerSSH failed to reload the certificate revocation list.
+// Certificate authentication is rejected until the list can be read again.
+const EAuthCertificateRevocationListFailed = "AUTH_CERT_REVOCATION_LIST_FAILED"
+
+// EAuthAuthorizedKeysNoMatch indicates that the public key the user presented is not listed in their authorized_keys
+// file, or the file does not exist.
+const EAuthAuthorizedKeysNoMatch = "AUTH_AUTHORIZED_KEYS_NO_MATCH"
+
+// EAuthAuthorizedKeysInvalidUsername indicates that the username contains characters that cannot be used in the path
+// of an authorized_keys file, for example a path separator.
+const EAuthAuthorizedKeysInvalidUsername = "AUTH_AUTHORIZED_KEYS_INVALID_USERNAME"
+
+// EAuthAuthorizedKeysSourceAddressMismatch indicates that the matching authorized_keys entry has a from option that
+// does not match the address the user is connecting from.
+const EAuthAuthorizedKeysSourceAddressMismatch = "AUTH_AUTHORIZED_KEYS_SOURCE_ADDRESS_MISMATCH"
+
+// EAuthAuthorizedKeysExpired indicates that the matching authorized_keys entry has an expiry-time option in the past.
+const EAuthAuthorizedKeysExpired = "AUTH_AUTHORIZED_KEYS_EXPIRED"
+
+// EAuthAuthorizedKeysInvalidEntry indicates that an entry in an authorized_keys file could not be parsed or contains
+// an unsupported option. The entry is ignored.
+const EAuthAuthorizedKeysInvalidEntry = "AUTH_AUTHORIZED_KEYS_INVALID_ENTRY"
+
+// EAuthAuthorizedKeysReadFailed indicates that ContainerSSH failed to read an authorized_keys file. Public key
+// authentication for the user is rejected until the file can be read.
+const EAuthAuthorizedKeysReadFailed = "AUTH_AUTHORIZED_KEYS_READ_FAILED"
+
+// EAuthPasswordFileMismatch indicates that the user is not listed in the password file or entered a password that
+// does not match the stored hash.
+const EAuthPasswordFileMismatch = "AUTH_PASSWORD_FILE_MISMATCH"
+
+// EAuthPasswordFileInvalidEntry indicates that an entry in the password file could not be parsed or uses an
+// unsupported hash. The entry is ignored.
+const EAuthPasswordFileInvalidEntry = "AUTH_PASSWORD_FILE_INVALID_ENTRY"
+
+// EAuthPasswordFileReadFailed indicates that ContainerSSH failed to read the password file. Password authentication is
+// rejected until the file can be read again.
+const EAuthPasswordFileReadFailed = "AUTH_PASSWORD_FILE_READ_FAILED"
+
+// EAuthLDAPFailed indicates that ContainerSSH failed to connect to the LDAP server or to search the directory. The
+// authentication is rejected as the directory is unavailable.
+const EAuthLDAPFailed = "AUTH_LDAP_FAILED"
+
+// EAuthLDAPUserNotFound indicates that the LDAP user filter did not match exactly one entry for the username.
+const EAuthLDAPUserNotFound = "AUTH_LDAP_USER_NOT_FOUND"
+
+// EAuthLDAPInvalidCredentials indicates that the LDAP server rejected the password of the user, or the public key of
+// the user is not stored in their LDAP entry.
+const EAuthLDAPInvalidCredentials = "AUTH_LDAP_INVALID_CREDENTIALS"
+
+// EAuthLDAPGroupMismatch indicates that the user is not a member of any of the groups required by the LDAP
+// configuration.
+const EAuthLDAPGroupMismatch = "AUTH_LDAP_GROUP_MISMATCH"
+
+// EAuthLockedOut indicates that an authentication attempt was rejected without contacting the authentication backend
+// because the username or the source address is banned after too many failed attempts.
+const EAuthLockedOut = "AUTH_LOCKED_OUT"
+
+// MAuthLockoutBanned indicates that a username, a source address or their combination has been banned after reaching
+// the configured number of failed authentication attempts.
+const MAuthLockoutBanned = "AUTH_LOCKOUT_BANNED"
+
+// MAuthLockoutServerAvailable indicates that the HTTP server listing the current authentication bans is available.
+const MAuthLockoutServerAvailable = "AUTH_LOCKOUT_SERVER_AVAILABLE"
+
+// EAuthBannerFailed indicates that ContainerSSH could not fetch the banner from the authentication server. The banner
+// from the configuration is sent to the client instead.
+const EAuthBannerFailed = "AUTH_BANNER_FAILED"
+
+// EAuthTOTPFailed indicates that the user entered an invalid or expired time-based one-time password.
+const EAuthTOTPFailed = "AUTH_TOTP_FAILED"
+
+// EAuthTOTPReplay indicates that the user entered a time-based one-time password that has already been used. Codes
+// are only accepted once to prevent replaying an intercepted code.
+const EAuthTOTPReplay = "AUTH_TOTP_REPLAY"
+
+// EAuthTOTPNoSecret indicates that no TOTP secret is configured for the user, neither in the metadata returned by the
+// first authentication method nor in the secrets file.
+const EAuthTOTPNoSecret = "AUTH_TOTP_NO_SECRET"
+
+// EAuthTOTPInvalidSecret indicates that the TOTP secret of the user is not valid base32. The user cannot log in until
+// the secret is fixed.
+const EAuthTOTPInvalidSecret = "AUTH_TOTP_INVALID_SECRET"
+
+// EAuthTOTPReadFailed indicates that ContainerSSH failed to read the TOTP secrets file. Users without a secret in the
+// metadata cannot log in until the file can be read again.
+const EAuthTOTPReadFailed = "AUTH_TOTP_READ_FAILED"
+
+// EAuthMethodNotAllowed indicates that the client attempted an authentication method that cannot be used at this
+// point because of the required authentication methods, for example keyboard-interactive before public key
+// authentication. The client will continue with the next method.
+const EAuthMethodNotAllowed = "AUTH_METHOD_NOT_ALLOWED"
+
+// MAuthCacheHit indicates that a public key or authorization decision was served from the webhook response cache
+// instead of contacting the authentication server.
+const MAuthCacheHit = "AUTH_CACHE_HIT"
+
+// EAuthCircuitOpen indicates that an authentication server failed too many times in a row and is skipped until the
+// reset timeout of the circuit breaker passes. Requests are sent to the fallback URLs instead, if any.
+const EAuthCircuitOpen = "AUTH_CIRCUIT_OPEN"
+
+// MAuthCircuitHalfOpen indicates that the reset timeout of the circuit breaker passed and a single request is sent to
+// the authentication server to check if it has recovered.
+const MAuthCircuitHalfOpen = "AUTH_CIRCUIT_HALF_OPEN"
+
+// MAuthCircuitClosed indicates that an authentication server that was previously skipped responded successfully and
+// is used again.
+const MAuthCircuitClosed = "AUTH_CIRCUIT_CLOSED"
+
+// EAuthNoServerAvailable indicates that the circuit breakers of all configured authentication servers are open, so
+// the request failed without contacting any of them.
+const EAuthNoServerAvailable = "AUTH_NO_SERVER_AVAILABLE"
+
+// EAuthJWTInvalid indicates that the password presented for JWT authentication is not a valid token. The token may be
+// expired, signed by an unknown key, issued for a different audience, or missing a required claim.
+const EAuthJWTInvalid = "AUTH_JWT_INVALID"
+
+// EAuthJWKSFailed indicates that ContainerSSH could not load the JSON Web Key Set to verify tokens with. If keys were
+// loaded before, they are still used until the JWKS can be loaded again.
+const EAuthJWKSFailed = "AUTH_JWKS_FAILED"
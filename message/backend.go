@@ -2,3 +2,7 @@ package message
 
 // EBackendConfig indicates that there is an error in the backend configuration.
 const EBackendConfig = "BACKEND_CONFIG_ERROR"
+
+// EBackendMOTDFailed indicates that the message of the day could not be rendered or written to the client when a shell
+// started. The shell is started without it.
+const EBackendMOTDFailed = "BACKEND_MOTD_FAILED"
@@ -20,3 +20,15 @@ const ECoreHealthCheckFailed = "CORE_HEALTH_CHECK_FAILED"
 
 // MCoreHealthCheckSuccessful indicates that The health check was successful.
 const MCoreHealthCheckSuccessful = "CORE_HEALTH_CHECK_SUCCESSFUL"
+
+// MCoreConfigReloaded indicates that ContainerSSH has reloaded the configuration file and applied the changes to new
+// connections. Existing connections keep the configuration they were established with.
+const MCoreConfigReloaded = "CORE_CONFIG_RELOADED"
+
+// ECoreConfigReloadFailed indicates that ContainerSSH could not reload the configuration file, or could not apply some
+// of the changes. The settings that failed keep their previous values.
+const ECoreConfigReloadFailed = "CORE_CONFIG_RELOAD_FAILED"
+
+// ECoreConfigRestartRequired indicates that the configuration file contains changes that cannot be applied while
+// ContainerSSH is running. These changes take effect when ContainerSSH is restarted.
+const ECoreConfigRestartRequired = "CORE_CONFIG_RESTART_REQUIRED"
@@ -104,6 +104,14 @@ const EDockerFailedExecSignal = "DOCKER_EXEC_SIGNAL_FAILED"
 // ContainerSSH Guest Agent support is disabled.
 const EDockerCannotSendSignalNoAgent = "DOCKER_EXEC_SIGNAL_FAILED_NO_AGENT"
 
+// EDockerFailedExecBreak indicates that the ContainerSSH Docker module failed to deliver a break to the terminal of
+// the running program.
+const EDockerFailedExecBreak = "DOCKER_EXEC_BREAK_FAILED"
+
+// EDockerCannotSendBreakNoAgent indicates that the ContainerSSH Docker module failed to deliver a break because the
+// guest agent is disabled.
+const EDockerCannotSendBreakNoAgent = "DOCKER_EXEC_BREAK_FAILED_NO_AGENT"
+
 // MDockerExecSignalSuccessful indicates that the ContainerSSH Docker module successfully delivered the requested
 // signal.
 const MDockerExecSignalSuccessful = "DOCKER_EXEC_SIGNAL_SUCCESSFUL"
@@ -5,3 +5,6 @@ const MHealthServiceAvailable = "HEALTH_AVAILABLE"
 
 // EHealthRequestFailed indicates that a request to the health check endpoint failed.
 const EHealthRequestFailed = "HEALTH_REQUEST_FAILED"
+
+// MHealthDrainRequested indicates that drain mode was requested through the health check endpoint.
+const MHealthDrainRequested = "HEALTH_DRAIN_REQUESTED"
@@ -32,3 +32,12 @@ const MHTTPServerResponseWriteFailed = "HTTP_SERVER_RESPONSE_WRITE_FAILED"
 // MHTTPServerEncodeFailed indicates that the HTTP server failed to encode the response object. This can happen
 // if the incorrect response object was returned in a webhook.
 const MHTTPServerEncodeFailed = "HTTP_SERVER_ENCODE_FAILED"
+
+// EHTTPSignatureInvalid indicates that a webhook server rejected a request because it was not signed, the signature
+// did not match any of the configured keys, or the signature timestamp was too old. Check that the client and the
+// server are configured with the same secret and that their clocks are in sync.
+const EHTTPSignatureInvalid = "HTTP_SIGNATURE_INVALID"
+
+// EHTTPSignatureReplay indicates that a webhook server rejected a signed request because its nonce was already used.
+// This may indicate that someone is replaying captured requests.
+const EHTTPSignatureReplay = "HTTP_SIGNATURE_REPLAY"
@@ -66,6 +66,14 @@ const EKubernetesFailedExecSignal = "KUBERNETES_EXEC_SIGNAL_FAILED"
 // agent support is disabled.
 const EKubernetesCannotSendSignalNoAgent = "KUBERNETES_EXEC_SIGNAL_FAILED_NO_AGENT"
 
+// EKubernetesFailedExecBreak indicates that the ContainerSSH Kubernetes module failed to deliver a break to the
+// terminal of the running program.
+const EKubernetesFailedExecBreak = "KUBERNETES_EXEC_BREAK_FAILED"
+
+// EKubernetesCannotSendBreakNoAgent indicates that the ContainerSSH Kubernetes module failed to deliver a break
+// because the guest agent is disabled.
+const EKubernetesCannotSendBreakNoAgent = "KUBERNETES_EXEC_BREAK_FAILED_NO_AGENT"
+
 // MKubernetesExecSignalSuccessful indicates that the ContainerSSH Kubernetes module successfully delivered the requested signal.
 const MKubernetesExecSignalSuccessful = "KUBERNETES_EXEC_SIGNAL_SUCCESSFUL"
 
@@ -33,10 +33,32 @@ const ESecuritySignalRejected = "SECURITY_SIGNAL_REJECTED"
 
 const ESecurityX11ForwardingRejected = "SECURITY_X11_FORWARDING_REJECTED"
 
+// ESecurityAgentForwardingRejected indicates that ContainerSSH rejected the SSH agent forwarding request because of the
+// security settings.
+const ESecurityAgentForwardingRejected = "SECURITY_AGENT_FORWARDING_REJECTED"
+
+// ESecurityBreakRejected indicates that ContainerSSH rejected a break request because of the security settings.
+const ESecurityBreakRejected = "SECURITY_BREAK_REJECTED"
+
 // ESecurityMaxSessions indicates that the client has reached the maximum number of configured sessions, the new session
 // request is therefore rejected.
 const ESecurityMaxSessions = "SECURITY_MAX_SESSIONS"
 
 const ESecurityForwardingRejected = "SECURITY_FORWARDING_REJECTED"
 
-const ESecurityReverseForwardingRejected = "SECURITY_REVERSE_FORWARDING_REJECTED"
\ No newline at end of file
+const ESecurityReverseForwardingRejected = "SECURITY_REVERSE_FORWARDING_REJECTED"
+// MSecuritySessionIdleTimeout indicates that ContainerSSH closed a connection because there was no input or output on
+// any session for the configured idle timeout.
+const MSecuritySessionIdleTimeout = "SECURITY_SESSION_IDLE_TIMEOUT"
+
+// MSecuritySessionMaxDuration indicates that ContainerSSH closed a connection because it reached the configured
+// maximum session duration.
+const MSecuritySessionMaxDuration = "SECURITY_SESSION_MAX_DURATION"
+
+// ESecuritySessionWarningFailed indicates that ContainerSSH could not write the warning about the upcoming session
+// termination to the terminal of the user.
+const ESecuritySessionWarningFailed = "SECURITY_SESSION_WARNING_FAILED"
+
+// ESecuritySessionTerminateFailed indicates that ContainerSSH could not close a connection that exceeded the idle
+// timeout or the maximum session duration.
+const ESecuritySessionTerminateFailed = "SECURITY_SESSION_TERMINATE_FAILED"
@@ -57,6 +57,10 @@ const ESSHAuthFailed = "SSH_AUTH_FAILED"
 // ESSHAuthSuccessful indicates that the user has provided valid credentials and is now authenticated.
 const ESSHAuthSuccessful = "SSH_AUTH_SUCCESSFUL"
 
+// MSSHAuthPartialSuccess indicates that the user has provided valid credentials, but must pass keyboard-interactive
+// authentication before they are authenticated.
+const MSSHAuthPartialSuccess = "SSH_AUTH_PARTIAL_SUCCESS"
+
 // ESSHExitCodeFailed indicates that ContainerSSH failed to obtain and send the exit code of the program to the user.
 const ESSHExitCodeFailed = "SSH_EXIT_CODE_FAILED"
 
@@ -94,3 +98,66 @@ const ESSHSOReuseFailed = "SSH_SOCKET_REUSE_FAILED"
 
 // ESSHNotImplemented indicates that a feature is not implemented in the backend.
 const ESSHNotImplemented = "SSH_NOT_IMPLEMENTED"
+
+// ESSHConnectionLimitReached indicates that a connection was rejected because the maximum number of concurrent
+// connections configured in ssh.limits.maxConnections has been reached.
+const ESSHConnectionLimitReached = "SSH_CONNECTION_LIMIT_REACHED"
+
+// ESSHSourceConnectionLimitReached indicates that a connection was rejected because the source network already has
+// the maximum number of concurrent connections configured in ssh.limits.maxConnectionsPerSource.
+const ESSHSourceConnectionLimitReached = "SSH_SOURCE_CONNECTION_LIMIT_REACHED"
+
+// ESSHConnectionRateLimited indicates that a connection was rejected because the source network opened new
+// connections faster than ssh.limits.connectionRate allows.
+const ESSHConnectionRateLimited = "SSH_CONNECTION_RATE_LIMITED"
+
+// ESSHMaxStartupsReached indicates that a connection was rejected because there were too many unauthenticated
+// connections as configured in ssh.limits.maxStartups.
+const ESSHMaxStartupsReached = "SSH_MAX_STARTUPS_REACHED"
+
+// ESSHUserConnectionLimitReached indicates that an authenticated connection was rejected because the user already
+// has the maximum number of concurrent connections configured in ssh.limits.maxConnectionsPerUser.
+const ESSHUserConnectionLimitReached = "SSH_USER_CONNECTION_LIMIT_REACHED"
+
+// ESSHUserContainerLimitReached indicates that a connection or session was rejected because the user already has the
+// maximum number of concurrent backend containers configured in ssh.limits.maxContainersPerUser.
+const ESSHUserContainerLimitReached = "SSH_USER_CONTAINER_LIMIT_REACHED"
+
+// ESSHGroupConnectionLimitReached indicates that an authenticated connection was rejected because the group of the
+// user already has the maximum number of concurrent connections configured in ssh.limits.maxConnectionsPerGroup.
+const ESSHGroupConnectionLimitReached = "SSH_GROUP_CONNECTION_LIMIT_REACHED"
+
+// ESSHGroupContainerLimitReached indicates that a connection or session was rejected because the group of the user
+// already has the maximum number of concurrent backend containers configured in ssh.limits.maxContainersPerGroup.
+const ESSHGroupContainerLimitReached = "SSH_GROUP_CONTAINER_LIMIT_REACHED"
+
+// ESSHBannerFailed indicates that the banner template could not be rendered for a connection. No banner is sent to the
+// client.
+const ESSHBannerFailed = "SSH_BANNER_FAILED"
+
+// ESSHProxyProtocolFailed indicates that a connection from a trusted proxy did not start with a valid PROXY protocol
+// header and was closed.
+const ESSHProxyProtocolFailed = "SSH_PROXY_PROTOCOL_FAILED"
+
+// ESSHHostKeysAdvertiseFailed indicates that ContainerSSH failed to send the list of host keys to the client after
+// authentication. The client will not learn about staged host keys on this connection.
+const ESSHHostKeysAdvertiseFailed = "SSH_HOSTKEYS_ADVERTISE_FAILED"
+
+// ESSHHostKeysProveFailed indicates that the client requested a proof of host key possession and ContainerSSH could
+// not provide it. This is either because the client asked for a key that is not configured, or because signing failed.
+const ESSHHostKeysProveFailed = "SSH_HOSTKEYS_PROVE_FAILED"
+
+// MSSHDrainStarted indicates that the SSH server stopped accepting new connections and is waiting for the existing
+// connections to close before shutting down.
+const MSSHDrainStarted = "SSH_DRAIN_STARTED"
+
+// MSSHDrainFinished indicates that all connections have been closed or the drain timeout expired and the SSH server
+// is ready to be shut down.
+const MSSHDrainFinished = "SSH_DRAIN_FINISHED"
+
+// ESSHDrainNoticeFailed indicates that ContainerSSH could not write the drain message to an interactive session.
+const ESSHDrainNoticeFailed = "SSH_DRAIN_NOTICE_FAILED"
+
+// MSSHSessionTerminated indicates that ContainerSSH closed a connection because a session policy, such as an idle
+// timeout, requested it.
+const MSSHSessionTerminated = "SSH_SESSION_TERMINATED"
@@ -49,6 +49,10 @@ const ESSHProxyWindowChangeFailed = "SSHPROXY_BACKEND_WINDOW_CHANGE_FAILED"
 
 const ESSHProxyX11RequestFailed = "SSHPROXY_X11_FAILED"
 
+// ESSHProxyAgentRequestFailed indicates that ContainerSSH failed to request SSH agent forwarding on the backend
+// connection.
+const ESSHProxyAgentRequestFailed = "SSHPROXY_AGENT_FAILED"
+
 // MSSHProxyShutdown indicates that ContainerSSH is shutting down and is sending TERM and KILL signals on the backend
 // connection.
 const MSSHProxyShutdown = "SSHPROXY_SHUTDOWN"
@@ -86,6 +90,10 @@ const MSSHProxySessionClosed = "SSHPROXY_SESSION_CLOSED"
 // be because of an underlying network issue or a problem with the backend server.
 const ESSHProxySessionCloseFailed = "SSHPROXY_SESSION_CLOSE_FAILED"
 
+// ESSHProxyBreakFailed indicates that ContainerSSH failed to deliver a break request on the backend channel. This may
+// be because of an underlying network issue or because the backend does not support break requests.
+const ESSHProxyBreakFailed = "SSHPROXY_BACKEND_BREAK_FAILED"
+
 // MSSHProxyExitSignal indicates that the ContainerSSH SSH proxy backend has received an exit-signal message.
 const MSSHProxyExitSignal = "SSHPROXY_EXIT_SIGNAL"
 
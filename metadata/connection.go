@@ -10,19 +10,46 @@ import (
 	"strings"
 )
 
-// RemoteAddress is an overlay for net.TCPAddr to provide JSON marshalling and unmarshalling.
+// RemoteAddress is an overlay for net.TCPAddr to provide JSON marshalling and unmarshalling. Clients connecting
+// through a Unix socket listener have no IP address, see UnixRemoteAddress.
 //
 //swagger:type string
 type RemoteAddress net.TCPAddr
 
+// unixRemoteAddress is the text representation of UnixRemoteAddress.
+const unixRemoteAddress = "unix"
+
+// UnixRemoteAddress is the address of clients connecting through a Unix socket listener. It has no IP address, so
+// IP-based limits and lockouts skip it and IP allow lists such as from= never match it. It is marshalled as "unix".
+var UnixRemoteAddress = RemoteAddress{}
+
+// IsUnix returns true if the address belongs to a client connected through a Unix socket and has no IP address.
+func (r RemoteAddress) IsUnix() bool {
+	return len(r.IP) == 0
+}
+
+// Host returns the IP address as a string, or "unix" for Unix socket clients.
+func (r RemoteAddress) Host() string {
+	if r.IsUnix() {
+		return unixRemoteAddress
+	}
+	return r.IP.String()
+}
+
 // String returns a string representation of this address.
 func (r RemoteAddress) String() string {
+	if r.IsUnix() {
+		return unixRemoteAddress
+	}
 	backend := net.TCPAddr(r)
 	return backend.String()
 }
 
-// Network returns the network type ("tcp") of this address.
+// Network returns the network type ("tcp" or "unix") of this address.
 func (r RemoteAddress) Network() string {
+	if r.IsUnix() {
+		return unixRemoteAddress
+	}
 	backend := net.TCPAddr(r)
 	return backend.Network()
 }
@@ -44,6 +71,9 @@ func (r RemoteAddress) MarshalJSON() ([]byte, error) {
 
 // MarshalText provides custom marshalling to a string.
 func (r RemoteAddress) MarshalText() ([]byte, error) {
+	if r.IsUnix() {
+		return []byte(unixRemoteAddress), nil
+	}
 	data := net.JoinHostPort(r.IP.String(), strconv.Itoa(r.Port))
 	return []byte(data), nil
 }
@@ -59,6 +89,10 @@ func (r *RemoteAddress) UnmarshalJSON(input []byte) error {
 
 // UnmarshalText provides custom unmarshalling from a string.
 func (r *RemoteAddress) UnmarshalText(input []byte) error {
+	if string(input) == unixRemoteAddress {
+		*r = UnixRemoteAddress
+		return nil
+	}
 	parts := strings.Split(string(input), ":")
 	if len(parts) < 2 {
 		return fmt.Errorf("invalid IP:port combination: %s", input)
@@ -85,7 +119,8 @@ func (r *RemoteAddress) UnmarshalText(input []byte) error {
 //
 // swagger:model ConnectionMetadata
 type ConnectionMetadata struct {
-	// RemoteAddress is the IP address and port of the user trying to authenticate.
+	// RemoteAddress is the IP address and port of the user trying to authenticate, or "unix" if the user connected
+	// through a Unix socket listener.
 	//
 	// required: true
 	// in: body
@@ -97,6 +132,13 @@ type ConnectionMetadata struct {
 	// in: body
 	ConnectionID string `json:"connectionId"`
 
+	// Listener is the name of the SSH server listener the user connected through. The listener configured in the
+	// listen option is called "default".
+	//
+	// required: false
+	// in: body
+	Listener string `json:"listener,omitempty"`
+
 	// Metadata is a set of key-value pairs that carry additional information from the authentication and configuration
 	// system to the backends. Backends can expose this information as container labels, environment variables, or
 	// other places.
@@ -129,6 +171,7 @@ func (meta ConnectionMetadata) StartAuthentication(
 
 		clientVersion,
 		username,
+		nil,
 	}
 }
 
@@ -168,6 +211,13 @@ type ConnectionAuthPendingMetadata struct {
 	// required: true
 	// in: body
 	Username string `json:"username"`
+
+	// AuthenticatedMethods lists the SSH authentication methods (e.g. publickey) the client has already passed on
+	// this connection if the configuration requires more than one method.
+	//
+	// required: false
+	// in: body
+	AuthenticatedMethods []string `json:"authenticatedMethods,omitempty"`
 }
 
 func NewTestAuthenticatingMetadata(username string) ConnectionAuthPendingMetadata {
@@ -175,6 +225,7 @@ func NewTestAuthenticatingMetadata(username string) ConnectionAuthPendingMetadat
 		NewTestMetadata(),
 		"SSH-2.0-FooSSH",
 		username,
+		nil,
 	}
 }
 
@@ -44,6 +44,23 @@ func TestMarshalRemoteAddress(t *testing.T) {
 	}
 }
 
+func TestMarshalUnixRemoteAddress(t *testing.T) {
+	marshalled, err := json.Marshal(metadata.UnixRemoteAddress)
+	if err != nil {
+		t.Fatalf("Failed to JSON marshal Unix socket address (%v).", err)
+	}
+	if string(marshalled) != `"unix"` {
+		t.Fatalf("Unexpected Unix socket address: %s", marshalled)
+	}
+	var unmarshalled metadata.RemoteAddress
+	if err := json.Unmarshal(marshalled, &unmarshalled); err != nil {
+		t.Fatalf("Failed to JSON unmarshal %s (%v).", marshalled, err)
+	}
+	if !unmarshalled.IsUnix() {
+		t.Fatalf("Unmarshalled address %s is not a Unix socket address.", unmarshalled)
+	}
+}
+
 func TestMarshalConnectionMetadata(t *testing.T) {
 	meta := metadata.ConnectionMetadata{
 		RemoteAddress: metadata.RemoteAddress{
@@ -0,0 +1,222 @@
+package containerssh
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/internal/authintegration"
+	"go.containerssh.io/containerssh/internal/backend"
+	"go.containerssh.io/containerssh/internal/sshserver"
+	"go.containerssh.io/containerssh/log"
+	"go.containerssh.io/containerssh/message"
+)
+
+// reloadGroup describes which component applies a setting when the configuration is reloaded.
+type reloadGroup int
+
+const (
+	reloadGroupRestart reloadGroup = iota
+	reloadGroupLog
+	reloadGroupSSH
+	reloadGroupAuth
+	reloadGroupBackend
+)
+
+// reloadGroups maps settings and sections to the component that applies them. Settings not listed here require a
+// restart. More specific settings take precedence over the section they are in.
+var reloadGroups = map[string]reloadGroup{
+	"log.level":         reloadGroupLog,
+	"ssh":               reloadGroupSSH,
+	"ssh.listen":        reloadGroupRestart,
+	"ssh.limits":        reloadGroupRestart,
+	"ssh.proxyProtocol": reloadGroupRestart,
+	"ssh.motd":          reloadGroupBackend,
+	"auth":              reloadGroupAuth,
+	"configserver":      reloadGroupBackend,
+	"security":          reloadGroupBackend,
+	"throttle":          reloadGroupBackend,
+	"backend":           reloadGroupBackend,
+	"docker":            reloadGroupBackend,
+	"kubernetes":        reloadGroupBackend,
+	"sshproxy":          reloadGroupBackend,
+}
+
+// nestedConfigSections are compared setting by setting since only some of their settings can be reloaded.
+var nestedConfigSections = map[string]bool{
+	"ssh": true,
+	"log": true,
+}
+
+func reloadGroupOf(name string) reloadGroup {
+	if group, ok := reloadGroups[name]; ok {
+		return group
+	}
+	if group, ok := reloadGroups[strings.SplitN(name, ".", 2)[0]]; ok {
+		return group
+	}
+	return reloadGroupRestart
+}
+
+// reloader applies a new configuration to the components that support changing their configuration while running.
+// The changes only affect new connections.
+type reloader struct {
+	lock           *sync.Mutex
+	initial        config.AppConfig
+	cfg            config.AppConfig
+	logger         log.Logger
+	sshServer      sshserver.Server
+	authHandler    authintegration.Handler
+	backendHandler backend.Handler
+}
+
+func (r *reloader) reload(cfg config.AppConfig) error {
+	if err := cfg.Validate(false); err != nil {
+		return message.Wrap(
+			err,
+			message.ECoreConfigReloadFailed,
+			"invalid configuration, keeping the current configuration",
+		)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	currentSettings := configSettings(&r.cfg)
+	initialSettings := configSettings(&r.initial)
+	newSettings := configSettings(&cfg)
+	changes := map[reloadGroup][]string{}
+	for _, name := range sortedSettingNames(newSettings) {
+		group := reloadGroupOf(name)
+		// Settings that require a restart are compared to the configuration ContainerSSH was started with, so they are
+		// reported until the next restart.
+		previous := currentSettings[name]
+		if group == reloadGroupRestart {
+			previous = initialSettings[name]
+		}
+		if !reflect.DeepEqual(previous.Interface(), newSettings[name].Interface()) {
+			changes[group] = append(changes[group], name)
+		}
+	}
+	if !reflect.DeepEqual(listenerAddresses(r.initial.SSH), listenerAddresses(cfg.SSH)) {
+		changes[reloadGroupRestart] = append(changes[reloadGroupRestart], "ssh.listeners.listen")
+	}
+
+	appliers := []struct {
+		group reloadGroup
+		apply func() error
+	}{
+		{reloadGroupLog, func() error {
+			levelSetter, ok := r.logger.(log.LevelSetter)
+			if !ok {
+				return errors.New("the logger does not support changing the log level")
+			}
+			levelSetter.SetLevel(cfg.Log.Level)
+			return nil
+		}},
+		{reloadGroupSSH, func() error { return r.sshServer.Reload(cfg.SSH) }},
+		{reloadGroupAuth, func() error { return r.authHandler.Reload(cfg.Auth) }},
+		{reloadGroupBackend, func() error { return r.backendHandler.Reload(cfg) }},
+	}
+	var applied []string
+	var failed []string
+	var errs []error
+	for _, applier := range appliers {
+		names := changes[applier.group]
+		if len(names) == 0 {
+			continue
+		}
+		if err := applier.apply(); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, names...)
+			// Keep the previous values so the change is attempted again on the next reload.
+			for _, name := range names {
+				newSettings[name].Set(currentSettings[name])
+			}
+			continue
+		}
+		applied = append(applied, names...)
+	}
+	r.cfg = cfg
+
+	if len(applied) > 0 {
+		r.logger.Info(
+			message.NewMessage(
+				message.MCoreConfigReloaded,
+				"Configuration reloaded, changes applied to new connections: %s",
+				strings.Join(applied, ", "),
+			),
+		)
+	} else if len(failed) == 0 {
+		r.logger.Info(message.NewMessage(message.MCoreConfigReloaded, "Configuration reloaded, no changes applied"))
+	}
+	if restartRequired := changes[reloadGroupRestart]; len(restartRequired) > 0 {
+		r.logger.Warning(
+			message.NewMessage(
+				message.ECoreConfigRestartRequired,
+				"Configuration changes require a restart to take effect: %s",
+				strings.Join(restartRequired, ", "),
+			),
+		)
+	}
+	if len(errs) > 0 {
+		return message.Wrap(
+			errors.Join(errs...),
+			message.ECoreConfigReloadFailed,
+			"failed to apply configuration changes, keeping the previous values: %s",
+			strings.Join(failed, ", "),
+		)
+	}
+	return nil
+}
+
+// configSettings returns the settings of the configuration by their name in the configuration file. The values can
+// be set since they point into the passed configuration.
+func configSettings(cfg *config.AppConfig) map[string]reflect.Value {
+	result := map[string]reflect.Value{}
+	collectConfigSettings(reflect.ValueOf(cfg).Elem(), "", result)
+	return result
+}
+
+func collectConfigSettings(value reflect.Value, prefix string, result map[string]reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.Split(value.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		name = prefix + name
+		if prefix == "" && nestedConfigSections[name] {
+			collectConfigSettings(value.Field(i), name+".", result)
+			continue
+		}
+		result[name] = value.Field(i)
+	}
+}
+
+func sortedSettingNames(settings map[string]reflect.Value) []string {
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listenerAddresses returns the sockets the SSH server opens. These can only be changed with a restart.
+func listenerAddresses(cfg config.SSHConfig) []string {
+	var result []string
+	if cfg.Listen != "" {
+		result = append(result, config.SSHDefaultListenerName+" "+cfg.Listen)
+	}
+	for _, listener := range cfg.Listeners {
+		listenType := listener.Type
+		if listenType == "" {
+			listenType = config.SSHListenerTypeTCP
+		}
+		result = append(result, listener.Name+" "+string(listenType)+" "+listener.Listen)
+	}
+	return result
+}
@@ -0,0 +1,41 @@
+package containerssh_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	containerssh "go.containerssh.io/containerssh"
+	"go.containerssh.io/containerssh/config"
+	"go.containerssh.io/containerssh/log"
+)
+
+func TestReload(t *testing.T) {
+	cfg := config.AppConfig{}
+	cfg.Default()
+	cfg.Log.Destination = config.LogDestinationTest
+	cfg.Log.T = t
+	cfg.Auth.PasswordAuth.Method = config.PasswordAuthMethodWebhook
+	cfg.Auth.PasswordAuth.Webhook.URL = "http://127.0.0.1:8080"
+	if err := cfg.SSH.GenerateHostKey(); err != nil {
+		t.Fatal(err)
+	}
+	pool, _, err := containerssh.New(cfg, log.NewLoggerFactory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := cfg
+	reloaded.SSH.Banner = "Welcome"
+	reloaded.Log.Level = config.LogLevelDebug
+	reloaded.Auth.PasswordAuth.Webhook.URL = "http://127.0.0.1:8081"
+	reloaded.Metrics.Enable = true
+	assert.NoError(t, pool.Reload(reloaded))
+
+	invalid := reloaded
+	invalid.Backend = "nonexistent"
+	assert.Error(t, pool.Reload(invalid))
+
+	invalid = reloaded
+	invalid.SSH.HostKeys = []string{"not a host key"}
+	assert.Error(t, pool.Reload(invalid))
+}
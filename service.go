@@ -1,6 +1,7 @@
 package containerssh
 
 import (
+    "go.containerssh.io/containerssh/config"
     "go.containerssh.io/containerssh/service"
 )
 
@@ -10,4 +11,12 @@ type Service interface {
 
 	// RotateLogs closes the currently open logs and reopens them to allow for log rotation.
 	RotateLogs() error
+
+	// Reload applies a new configuration to new connections. Existing connections keep their configuration. Settings
+	// that cannot be changed while running are logged and take effect on the next restart.
+	Reload(cfg config.AppConfig) error
+
+	// Drain stops accepting new connections, marks the service as not ready and warns the users of interactive
+	// sessions. ContainerSSH stops when all connections are closed or the drain timeout expires.
+	Drain()
 }